@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -15,6 +16,9 @@ import (
 	"github.com/PouryDev/oneclick/internal/api/handlers"
 	"github.com/PouryDev/oneclick/internal/api/middleware"
 	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/registry"
+	"github.com/PouryDev/oneclick/internal/app/rollout"
 	"github.com/PouryDev/oneclick/internal/app/services"
 	"github.com/PouryDev/oneclick/internal/app/worker"
 	"github.com/PouryDev/oneclick/internal/config"
@@ -59,12 +63,22 @@ func main() {
 	orgRepo := repo.NewOrganizationRepository(db)
 	clusterRepo := repo.NewClusterRepository(db)
 	repositoryRepo := repo.NewRepositoryRepository(db)
+	webhookDeliveryRepo := repo.NewWebhookDeliveryRepository(db)
 	appRepo := repo.NewApplicationRepository(db)
 	releaseRepo := repo.NewReleaseRepository(db)
+	appSpecRepo := repo.NewApplicationSpecRepository(db)
+	appSecretRepo := repo.NewAppSecretRepository(db)
 	gitServerRepo := repo.NewGitServerRepository(db)
 	runnerRepo := repo.NewRunnerRepository(db)
 	jobRepo := repo.NewJobRepository(db)
 	domainRepo := repo.NewDomainRepository(db)
+	envRepo := repo.NewEnvironmentRepository(db)
+	releaseTaskRepo := repo.NewReleaseTaskRepository(db)
+	serviceRepo := repo.NewServiceRepository(db)
+	registryCredRepo := repo.NewRegistryCredentialRepository(db)
+	namespacePolicyRepo := repo.NewNamespacePolicyRepository(db)
+	releaseDriftRepo := repo.NewReleaseDriftRepository(db)
+	releaseSnapshotRepo := repo.NewReleaseSnapshotRepository(db)
 	pipelineRepo := repo.NewPipelineRepository(sqlxDB)
 	pipelineStepRepo := repo.NewPipelineStepRepository(sqlxDB)
 
@@ -79,17 +93,30 @@ func main() {
 		logger.Fatal("Failed to initialize crypto service", zap.Error(err))
 	}
 
+	// Rollout progress is published by the deployment worker and streamed by the API
+	progressBroker := deployment.NewProgressBroker()
+
+	// Releases are pinned to the image digest their tag points to when they are deployed
+	registryResolver := registry.NewResolver(registry.NewClient(&http.Client{Timeout: 30 * time.Second}), registryCredRepo, cryptoService)
+	desiredState := rollout.NewResolver(appSpecRepo, appSecretRepo, domainRepo, namespacePolicyRepo, repositoryRepo, releaseSnapshotRepo, registryResolver, cryptoService)
+
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg.JWT.Secret)
 	orgService := services.NewOrganizationService(orgRepo, userRepo)
 	clusterService := services.NewClusterService(clusterRepo, orgRepo, cryptoService)
-	repositoryService := services.NewRepositoryService(repositoryRepo, orgRepo, cryptoService)
-	applicationService := services.NewApplicationService(appRepo, releaseRepo, clusterRepo, repositoryRepo, orgRepo)
+	applicationService := services.NewApplicationService(appRepo, releaseRepo, clusterRepo, repositoryRepo, orgRepo, jobRepo, appSpecRepo, envRepo, releaseDriftRepo, progressBroker, cryptoService, nil)
+	appSecretService := services.NewAppSecretService(appSecretRepo, appRepo, orgRepo, cryptoService)
+	environmentService := services.NewEnvironmentService(envRepo, appRepo, releaseRepo, clusterRepo, orgRepo, jobRepo)
+	deployPreviewService := services.NewDeployPreviewService(appRepo, releaseRepo, clusterRepo, orgRepo, appSpecRepo, envRepo, desiredState, cryptoService)
+	registryCredentialService := services.NewRegistryCredentialService(registryCredRepo, orgRepo, cryptoService)
+	namespacePolicyService := services.NewNamespacePolicyService(namespacePolicyRepo, orgRepo)
+	releaseTaskService := services.NewReleaseTaskService(releaseTaskRepo, appRepo, releaseRepo, envRepo, orgRepo, jobRepo)
 	gitServerService := services.NewGitServerService(gitServerRepo, jobRepo, orgRepo, cryptoService, logger)
 	runnerService := services.NewRunnerService(runnerRepo, jobRepo, orgRepo, cryptoService, logger)
 	jobService := services.NewJobService(jobRepo, orgRepo, logger)
-	domainService := services.NewDomainService(domainRepo, appRepo, jobRepo, orgRepo, cryptoService, logger)
+	domainService := services.NewDomainService(domainRepo, appRepo, envRepo, jobRepo, orgRepo, cryptoService, logger)
 	pipelineService := services.NewPipelineService(pipelineRepo, pipelineStepRepo, appRepo, repositoryRepo, orgRepo, jobRepo, logger)
+	repositoryService := services.NewRepositoryService(repositoryRepo, appRepo, orgRepo, webhookDeliveryRepo, pipelineService, cryptoService)
 	// For now, we'll pass nil for the Kubernetes client
 	// In a real implementation, you would create a Kubernetes client factory
 	// that creates clients per request based on the cluster's kubeconfig
@@ -109,6 +136,12 @@ func main() {
 	repositoryHandler := handlers.NewRepositoryHandler(repositoryService)
 	webhookHandler := handlers.NewWebhookHandler(repositoryService, logger)
 	applicationHandler := handlers.NewApplicationHandler(applicationService)
+	appSecretHandler := handlers.NewAppSecretHandler(appSecretService)
+	environmentHandler := handlers.NewEnvironmentHandler(environmentService)
+	deployPreviewHandler := handlers.NewDeployPreviewHandler(deployPreviewService)
+	registryCredentialHandler := handlers.NewRegistryCredentialHandler(registryCredentialService)
+	namespacePolicyHandler := handlers.NewNamespacePolicyHandler(namespacePolicyService)
+	releaseTaskHandler := handlers.NewReleaseTaskHandler(releaseTaskService)
 	gitServerHandler := handlers.NewGitServerHandler(gitServerService, logger)
 	runnerHandler := handlers.NewRunnerHandler(runnerService, logger)
 	jobHandler := handlers.NewJobHandler(jobService, logger)
@@ -198,11 +231,30 @@ func main() {
 				runners.GET("", runnerHandler.GetRunnersByOrg)
 			}
 
+			// Container registry credential routes
+			registries := orgSpecific.Group("/registries")
+			registries.Use(middleware.RequireAdminOrOwnerMiddleware())
+			{
+				registries.GET("", registryCredentialHandler.GetRegistryCredentials)
+				registries.POST("", registryCredentialHandler.CreateRegistryCredential)
+				registries.PUT("/:registryId", registryCredentialHandler.UpdateRegistryCredential)
+				registries.DELETE("/:registryId", registryCredentialHandler.DeleteRegistryCredential)
+			}
+
+			// Namespace isolation policy routes
+			namespacePolicy := orgSpecific.Group("/namespace-policy")
+			namespacePolicy.Use(middleware.RequireMemberMiddleware())
+			{
+				namespacePolicy.GET("", namespacePolicyHandler.GetNamespacePolicy)
+				namespacePolicy.PUT("", middleware.RequireAdminOrOwnerMiddleware(), namespacePolicyHandler.UpdateNamespacePolicy)
+			}
+
 			// Job management routes
 			jobs := orgSpecific.Group("/jobs")
 			jobs.Use(middleware.RequireMemberMiddleware())
 			{
 				jobs.GET("", jobHandler.GetJobsByOrg)
+				jobs.GET("/:jobId", jobHandler.GetJob)
 			}
 		}
 	}
@@ -226,6 +278,10 @@ func main() {
 	{
 		repos.GET("/:repoId", repositoryHandler.GetRepository)
 		repos.DELETE("/:repoId", middleware.RequireAdminOrOwnerMiddleware(), repositoryHandler.DeleteRepository)
+		repos.POST("/:repoId/webhook-secret", repositoryHandler.RotateWebhookSecret)
+		repos.GET("/:repoId/webhook-deliveries", repositoryHandler.GetWebhookDeliveries)
+		repos.GET("/:repoId/webhook-deliveries/:deliveryId", repositoryHandler.GetWebhookDelivery)
+		repos.POST("/:repoId/webhook-deliveries/:deliveryId/redeliver", repositoryHandler.RedeliverWebhook)
 	}
 
 	// Global application routes (require authentication)
@@ -235,8 +291,33 @@ func main() {
 		apps.GET("/:appId", applicationHandler.GetApplication)
 		apps.DELETE("/:appId", middleware.RequireAdminOrOwnerMiddleware(), applicationHandler.DeleteApplication)
 		apps.POST("/:appId/deploy", applicationHandler.DeployApplication)
+		apps.POST("/:appId/deploy/preview", deployPreviewHandler.PreviewDeploy)
 		apps.GET("/:appId/releases", applicationHandler.GetReleasesByApplication)
+		apps.GET("/:appId/releases/:releaseId/stream", applicationHandler.StreamRelease)
+		apps.GET("/:appId/releases/:releaseId/manifests", deployPreviewHandler.GetReleaseManifests)
 		apps.POST("/:appId/releases/:releaseId/rollback", applicationHandler.RollbackApplication)
+		apps.POST("/:appId/releases/:releaseId/promote", applicationHandler.PromoteRelease)
+		apps.POST("/:appId/releases/:releaseId/abort", applicationHandler.AbortRelease)
+		apps.GET("/:appId/spec", applicationHandler.GetApplicationSpec)
+		apps.PUT("/:appId/spec", applicationHandler.UpdateApplicationSpec)
+
+		// Release task routes
+		apps.POST("/:appId/run", releaseTaskHandler.RunCommand)
+		apps.GET("/:appId/tasks/:taskId", releaseTaskHandler.GetReleaseTask)
+		apps.GET("/:appId/releases/:releaseId/tasks", releaseTaskHandler.GetReleaseTasks)
+
+		// Secret management routes
+		apps.GET("/:appId/secrets", appSecretHandler.GetAppSecrets)
+		apps.POST("/:appId/secrets", appSecretHandler.CreateAppSecret)
+		apps.PUT("/:appId/secrets/:name", appSecretHandler.UpdateAppSecret)
+		apps.DELETE("/:appId/secrets/:name", appSecretHandler.DeleteAppSecret)
+
+		// Environment management routes
+		apps.GET("/:appId/environments", environmentHandler.GetEnvironments)
+		apps.POST("/:appId/environments", environmentHandler.CreateEnvironment)
+		apps.PUT("/:appId/environments/:name", environmentHandler.UpdateEnvironment)
+		apps.DELETE("/:appId/environments/:name", environmentHandler.DeleteEnvironment)
+		apps.POST("/:appId/environments/:name/promote", environmentHandler.PromoteRelease)
 
 		// Domain management routes
 		apps.POST("/:appId/domains", domainHandler.CreateDomain)
@@ -362,12 +443,32 @@ func main() {
 		domainRepo,
 		pipelineRepo,
 		pipelineStepRepo,
+		releaseRepo,
+		envRepo,
+		appSpecRepo,
 		nil, // provisioner - will be implemented later
 		cryptoService,
 		logger,
 		true, // dryRun mode for MVP
 	)
 
+	// Initialize deployment worker
+	deploymentWorker := worker.NewDeploymentWorker(
+		jobRepo,
+		appRepo,
+		releaseRepo,
+		clusterRepo,
+		domainRepo,
+		envRepo,
+		releaseTaskRepo,
+		serviceRepo,
+		releaseDriftRepo,
+		desiredState,
+		cryptoService,
+		progressBroker,
+		logger,
+	)
+
 	// Initialize event projector worker
 	eventProjectorWorker := worker.NewEventProjectorWorker(
 		db,
@@ -388,6 +489,13 @@ func main() {
 		}
 	}()
 
+	go func() {
+		ctx := context.Background()
+		if err := deploymentWorker.Start(ctx); err != nil {
+			logger.Error("Deployment worker failed", zap.Error(err))
+		}
+	}()
+
 	go func() {
 		ctx := context.Background()
 		if err := eventProjectorWorker.Start(ctx); err != nil {
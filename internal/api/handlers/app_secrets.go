@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type AppSecretHandler struct {
+	appSecretService services.AppSecretService
+	validator        *validator.Validate
+}
+
+func NewAppSecretHandler(appSecretService services.AppSecretService) *AppSecretHandler {
+	return &AppSecretHandler{
+		appSecretService: appSecretService,
+		validator:        validator.New(),
+	}
+}
+
+// GetAppSecrets godoc
+// @Summary List application secrets
+// @Description List the names of an application's secrets. Values are never returned.
+// @Tags applications
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Success 200 {array} domain.AppSecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/secrets [get]
+func (h *AppSecretHandler) GetAppSecrets(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	secrets, err := h.appSecretService.GetAppSecrets(c.Request.Context(), userUUID, appID)
+	if err != nil {
+		writeAppSecretError(c, err, "Failed to get secrets")
+		return
+	}
+
+	c.JSON(http.StatusOK, secrets)
+}
+
+// CreateAppSecret godoc
+// @Summary Create application secret
+// @Description Create an encrypted secret that is exposed to the application as an environment variable (only admins and owners)
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param request body domain.CreateAppSecretRequest true "Secret name and value"
+// @Success 201 {object} domain.AppSecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/secrets [post]
+func (h *AppSecretHandler) CreateAppSecret(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateAppSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := h.appSecretService.CreateAppSecret(c.Request.Context(), userUUID, appID, &req)
+	if err != nil {
+		writeAppSecretError(c, err, "Failed to create secret")
+		return
+	}
+
+	c.JSON(http.StatusCreated, secret)
+}
+
+// UpdateAppSecret godoc
+// @Summary Update application secret
+// @Description Replace the value of an application secret (only admins and owners)
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param name path string true "Secret name"
+// @Param request body domain.UpdateAppSecretRequest true "Secret value"
+// @Success 200 {object} domain.AppSecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/secrets/{name} [put]
+func (h *AppSecretHandler) UpdateAppSecret(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateAppSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := h.appSecretService.UpdateAppSecret(c.Request.Context(), userUUID, appID, c.Param("name"), &req)
+	if err != nil {
+		writeAppSecretError(c, err, "Failed to update secret")
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// DeleteAppSecret godoc
+// @Summary Delete application secret
+// @Description Delete an application secret (only admins and owners)
+// @Tags applications
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param name path string true "Secret name"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/secrets/{name} [delete]
+func (h *AppSecretHandler) DeleteAppSecret(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.appSecretService.DeleteAppSecret(c.Request.Context(), userUUID, appID, c.Param("name"))
+	if err != nil {
+		writeAppSecretError(c, err, "Failed to delete secret")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseAppParams reads the authenticated user and application ID, writing the error
+// response if either is invalid
+func parseAppParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	appID, err := uuid.Parse(c.Param("appId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, appID, true
+}
+
+// writeAppSecretError maps an app secret service error to its response
+func writeAppSecretError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "secret not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Secret not found"})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+	case strings.Contains(err.Error(), "does not have access"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case strings.Contains(err.Error(), "insufficient permissions"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to manage secrets"})
+	case strings.Contains(err.Error(), "invalid secret name"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already exists"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
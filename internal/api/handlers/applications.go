@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -83,7 +86,7 @@ func (h *ApplicationHandler) CreateApplication(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 			return
 		}
-		if strings.Contains(err.Error(), "already exists") {
+		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "already used") {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
@@ -91,7 +94,7 @@ func (h *ApplicationHandler) CreateApplication(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if strings.Contains(err.Error(), "invalid repository ID") {
+		if strings.Contains(err.Error(), "invalid repository ID") || strings.Contains(err.Error(), "invalid application name") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -160,7 +163,7 @@ func (h *ApplicationHandler) GetApplicationsByCluster(c *gin.Context) {
 
 // GetApplication godoc
 // @Summary Get application details
-// @Description Get detailed information about an application
+// @Description Get detailed information about an application, including the current and desired replicas of the Deployment serving it in each environment
 // @Tags applications
 // @Produce json
 // @Security BearerAuth
@@ -271,6 +274,10 @@ func (h *ApplicationHandler) DeployApplication(c *gin.Context) {
 
 	response, err := h.applicationService.DeployApplication(c.Request.Context(), userUUID, appID, &req)
 	if err != nil {
+		if strings.Contains(err.Error(), "environment not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
 			return
@@ -279,10 +286,15 @@ func (h *ApplicationHandler) DeployApplication(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 			return
 		}
-		if strings.Contains(err.Error(), "is required") {
+		if strings.Contains(err.Error(), "is required") ||
+			strings.Contains(err.Error(), "invalid image") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.Contains(err.Error(), "is in progress") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deploy application"})
 		return
 	}
@@ -351,6 +363,10 @@ func (h *ApplicationHandler) RollbackApplication(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.Contains(err.Error(), "is in progress") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rollback application"})
 		return
 	}
@@ -358,6 +374,213 @@ func (h *ApplicationHandler) RollbackApplication(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// PromoteRelease godoc
+// @Summary Promote canary release
+// @Description Roll a canary release out to the stable Deployment and remove the canary
+// @Tags applications
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param releaseId path string true "Release ID"
+// @Success 202 {object} domain.ReleaseResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/releases/{releaseId}/promote [post]
+func (h *ApplicationHandler) PromoteRelease(c *gin.Context) {
+	h.finishCanary(c, h.applicationService.PromoteRelease, "Failed to promote release")
+}
+
+// AbortRelease godoc
+// @Summary Abort canary release
+// @Description Remove a canary release, returning all traffic to the stable Deployment
+// @Tags applications
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param releaseId path string true "Release ID"
+// @Success 202 {object} domain.ReleaseResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/releases/{releaseId}/abort [post]
+func (h *ApplicationHandler) AbortRelease(c *gin.Context) {
+	h.finishCanary(c, h.applicationService.AbortRelease, "Failed to abort release")
+}
+
+// finishCanary handles a promote or abort request of a canary release
+func (h *ApplicationHandler) finishCanary(c *gin.Context, finish func(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error), fallback string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	appID, err := uuid.Parse(c.Param("appId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	releaseID, err := uuid.Parse(c.Param("releaseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	response, err := finish(c.Request.Context(), userUUID, appID, releaseID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not belong") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "awaiting promotion") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// streamKeepAlive is how often an idle release stream sends a comment to keep the connection open
+const streamKeepAlive = 15 * time.Second
+
+// StreamRelease godoc
+// @Summary Stream release rollout progress
+// @Description Stream the rollout progress of a release as server-sent events. The stream starts with a "release" event holding the release, replays the progress of its current or last rollout, follows the rollout while it runs and ends with an "end" event.
+// @Tags applications
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param releaseId path string true "Release ID"
+// @Success 200 {object} domain.ReleaseProgressEvent
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/releases/{releaseId}/stream [get]
+func (h *ApplicationHandler) StreamRelease(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	appID, err := uuid.Parse(c.Param("appId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	releaseID, err := uuid.Parse(c.Param("releaseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	release, subscription, err := h.applicationService.SubscribeReleaseProgress(c.Request.Context(), userUUID, appID, releaseID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not belong") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream release"})
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep proxies from buffering the stream
+
+	c.SSEvent("release", release.ToResponse())
+	for _, event := range subscription.History {
+		c.SSEvent(string(event.Type), event)
+	}
+	c.Writer.Flush()
+
+	// Nothing will be published for a release that is not rolling out and has no progress
+	// buffered, such as one that finished before the server last started
+	if !release.IsRollingOut() && len(subscription.History) == 0 {
+		c.SSEvent("end", gin.H{"release_id": release.ID})
+		c.Writer.Flush()
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// A client that fell behind is disconnected without an end event, so that it
+				// reconnects and catches up from the buffered progress
+				if !subscription.Lagged() {
+					c.SSEvent("end", gin.H{"release_id": release.ID})
+					c.Writer.Flush()
+				}
+				return
+			}
+			c.SSEvent(string(event.Type), event)
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
 // GetReleasesByApplication godoc
 // @Summary Get application releases
 // @Description Get list of releases for an application
@@ -415,13 +638,151 @@ func (h *ApplicationHandler) GetReleasesByApplication(c *gin.Context) {
 	c.JSON(http.StatusOK, releases)
 }
 
+// GetApplicationSpec godoc
+// @Summary Get application deployment spec
+// @Description Get the latest deployment spec of an application, or the default spec (version 0) if none was saved
+// @Tags applications
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Success 200 {object} domain.ApplicationSpecResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/spec [get]
+func (h *ApplicationHandler) GetApplicationSpec(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	appIDStr := c.Param("appId")
+	appID, err := uuid.Parse(appIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	spec, err := h.applicationService.GetApplicationSpec(c.Request.Context(), userUUID, appID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get application spec"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spec)
+}
+
+// UpdateApplicationSpec godoc
+// @Summary Update application deployment spec
+// @Description Save a new version of the application's deployment spec. It is used by the next deployment. Only admins and owners can change its command, args, release tasks or processes.
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param request body domain.DeploymentSpec true "Deployment spec"
+// @Success 200 {object} domain.ApplicationSpecResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/spec [put]
+func (h *ApplicationHandler) UpdateApplicationSpec(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	appIDStr := c.Param("appId")
+	appID, err := uuid.Parse(appIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	var req domain.DeploymentSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec, err := h.applicationService.UpdateApplicationSpec(c.Request.Context(), userUUID, appID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient permissions") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to change application commands"})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid spec") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update application spec"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spec)
+}
+
 // DeleteApplication godoc
 // @Summary Delete application
-// @Description Delete an application (only admins and owners can delete)
+// @Description Delete an application: its domains and Ingresses, its workload, its infrastructure services and its namespaces are torn down in every cluster it runs in, then the application is deleted. The deletion is queued; poll the returned job for the progress of each step (only admins and owners can delete).
 // @Tags applications
+// @Produce json
 // @Security BearerAuth
 // @Param appId path string true "Application ID"
-// @Success 204
+// @Param keep_data query bool false "Keep the application's persistent volume claims, and the namespaces holding them"
+// @Success 202 {object} domain.JobResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -454,7 +815,13 @@ func (h *ApplicationHandler) DeleteApplication(c *gin.Context) {
 		return
 	}
 
-	err = h.applicationService.DeleteApplication(c.Request.Context(), userUUID, appID)
+	var req domain.DeleteApplicationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	job, err := h.applicationService.DeleteApplication(c.Request.Context(), userUUID, appID, &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
@@ -472,5 +839,5 @@ func (h *ApplicationHandler) DeleteApplication(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusAccepted, job.ToResponse())
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/PouryDev/oneclick/internal/app/deployment"
 	"github.com/PouryDev/oneclick/internal/domain"
 )
 
@@ -47,9 +48,12 @@ func (m *MockApplicationService) GetApplication(ctx context.Context, userID, app
 	return args.Get(0).(*domain.ApplicationDetail), args.Error(1)
 }
 
-func (m *MockApplicationService) DeleteApplication(ctx context.Context, userID, appID uuid.UUID) error {
-	args := m.Called(ctx, userID, appID)
-	return args.Error(0)
+func (m *MockApplicationService) DeleteApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeleteApplicationRequest) (*domain.Job, error) {
+	args := m.Called(ctx, userID, appID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Job), args.Error(1)
 }
 
 func (m *MockApplicationService) DeployApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeployApplicationRequest) (*domain.DeployApplicationResponse, error) {
@@ -60,6 +64,22 @@ func (m *MockApplicationService) DeployApplication(ctx context.Context, userID,
 	return args.Get(0).(*domain.DeployApplicationResponse), args.Error(1)
 }
 
+func (m *MockApplicationService) GetApplicationSpec(ctx context.Context, userID, appID uuid.UUID) (*domain.ApplicationSpecResponse, error) {
+	args := m.Called(ctx, userID, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpecResponse), args.Error(1)
+}
+
+func (m *MockApplicationService) UpdateApplicationSpec(ctx context.Context, userID, appID uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpecResponse, error) {
+	args := m.Called(ctx, userID, appID, spec)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpecResponse), args.Error(1)
+}
+
 func (m *MockApplicationService) RollbackApplication(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.DeployApplicationResponse, error) {
 	args := m.Called(ctx, userID, appID, releaseID)
 	if args.Get(0) == nil {
@@ -68,6 +88,30 @@ func (m *MockApplicationService) RollbackApplication(ctx context.Context, userID
 	return args.Get(0).(*domain.DeployApplicationResponse), args.Error(1)
 }
 
+func (m *MockApplicationService) PromoteRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error) {
+	args := m.Called(ctx, userID, appID, releaseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseResponse), args.Error(1)
+}
+
+func (m *MockApplicationService) AbortRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error) {
+	args := m.Called(ctx, userID, appID, releaseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseResponse), args.Error(1)
+}
+
+func (m *MockApplicationService) SubscribeReleaseProgress(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.Release, *deployment.ProgressSubscription, error) {
+	args := m.Called(ctx, userID, appID, releaseID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.Release), args.Get(1).(*deployment.ProgressSubscription), args.Error(2)
+}
+
 func (m *MockApplicationService) GetReleasesByApplication(ctx context.Context, userID, appID uuid.UUID) ([]domain.ReleaseSummary, error) {
 	args := m.Called(ctx, userID, appID)
 	if args.Get(0) == nil {
@@ -270,6 +314,20 @@ func TestApplicationHandler_DeployApplication(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  "image is required",
 		},
+		{
+			name:   "canary in progress",
+			userID: uuid.New().String(),
+			appID:  uuid.New().String(),
+			requestBody: domain.DeployApplicationRequest{
+				Image: "myapp",
+				Tag:   "v2",
+			},
+			mockSetup: func(m *MockApplicationService) {
+				m.On("DeployApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("*domain.DeployApplicationRequest")).Return(nil, errors.New("canary release 1 is in progress; promote or abort it first"))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "promote or abort it first",
+		},
 	}
 
 	for _, tt := range tests {
@@ -429,6 +487,7 @@ func TestApplicationHandler_DeleteApplication(t *testing.T) {
 		name           string
 		userID         string
 		appID          string
+		query          string
 		mockSetup      func(*MockApplicationService)
 		expectedStatus int
 		expectedError  string
@@ -438,16 +497,37 @@ func TestApplicationHandler_DeleteApplication(t *testing.T) {
 			userID: uuid.New().String(),
 			appID:  uuid.New().String(),
 			mockSetup: func(m *MockApplicationService) {
-				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(nil)
+				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), &domain.DeleteApplicationRequest{}).
+					Return(&domain.Job{ID: uuid.New(), Type: domain.JobTypeApplicationDelete, Status: domain.JobStatusPending}, nil)
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:   "deletion keeping data",
+			userID: uuid.New().String(),
+			appID:  uuid.New().String(),
+			query:  "?keep_data=true",
+			mockSetup: func(m *MockApplicationService) {
+				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), &domain.DeleteApplicationRequest{KeepData: true}).
+					Return(&domain.Job{ID: uuid.New(), Type: domain.JobTypeApplicationDelete, Status: domain.JobStatusPending}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "invalid keep_data",
+			userID:         uuid.New().String(),
+			appID:          uuid.New().String(),
+			query:          "?keep_data=sometimes",
+			mockSetup:      func(m *MockApplicationService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid query parameters",
 		},
 		{
 			name:   "application not found",
 			userID: uuid.New().String(),
 			appID:  uuid.New().String(),
 			mockSetup: func(m *MockApplicationService) {
-				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(errors.New("application not found"))
+				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.Anything).Return(nil, errors.New("application not found"))
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "Application not found",
@@ -457,7 +537,7 @@ func TestApplicationHandler_DeleteApplication(t *testing.T) {
 			userID: uuid.New().String(),
 			appID:  uuid.New().String(),
 			mockSetup: func(m *MockApplicationService) {
-				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(errors.New("insufficient permissions to delete application"))
+				m.On("DeleteApplication", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.Anything).Return(nil, errors.New("insufficient permissions to delete application"))
 			},
 			expectedStatus: http.StatusForbidden,
 			expectedError:  "Insufficient permissions to delete application",
@@ -485,7 +565,7 @@ func TestApplicationHandler_DeleteApplication(t *testing.T) {
 			router.DELETE("/apps/:appId", handler.DeleteApplication)
 
 			// Create request
-			req := httptest.NewRequest("DELETE", "/apps/"+tt.appID, nil)
+			req := httptest.NewRequest("DELETE", "/apps/"+tt.appID+tt.query, nil)
 
 			// Set user ID in context if provided
 			if tt.userID != "" {
@@ -512,3 +592,72 @@ func TestApplicationHandler_DeleteApplication(t *testing.T) {
 		})
 	}
 }
+
+func TestApplicationHandler_StreamRelease(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+	release := &domain.Release{ID: releaseID, AppID: appID, Status: domain.ReleaseStatusRunning, Phase: domain.ReleasePhaseRollingOut}
+
+	// The rollout ends while the client is streaming
+	broker := deployment.NewProgressBroker()
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressManifestApplied, Kind: "Deployment", Name: "api"})
+	subscription := broker.Subscribe(releaseID, true)
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressReplicas, Replicas: &domain.ReplicaCounts{Desired: 2, Ready: 2}})
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Status: domain.ReleaseStatusSucceeded, Phase: domain.ReleasePhaseCompleted})
+
+	mockService := new(MockApplicationService)
+	mockService.On("SubscribeReleaseProgress", mock.Anything, userID, appID, releaseID).Return(release, subscription, nil)
+
+	handler := NewApplicationHandler(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	router.GET("/apps/:appId/releases/:releaseId/stream", handler.StreamRelease)
+
+	req := httptest.NewRequest("GET", "/apps/"+appID.String()+"/releases/"+releaseID.String()+"/stream", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+
+	body := w.Body.String()
+	order := []string{"event:release", "event:manifest_applied", "event:replicas", "event:status", "event:end"}
+	last := -1
+	for _, name := range order {
+		index := bytes.Index([]byte(body), []byte(name))
+		assert.Greater(t, index, last, "%s out of order in %q", name, body)
+		last = index
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestApplicationHandler_StreamRelease_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+
+	mockService := new(MockApplicationService)
+	mockService.On("SubscribeReleaseProgress", mock.Anything, userID, appID, releaseID).Return(nil, nil, errors.New("release not found"))
+
+	handler := NewApplicationHandler(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	router.GET("/apps/:appId/releases/:releaseId/stream", handler.StreamRelease)
+
+	req := httptest.NewRequest("GET", "/apps/"+appID.String()+"/releases/"+releaseID.String()+"/stream", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type DeployPreviewHandler struct {
+	deployPreviewService services.DeployPreviewService
+	validator            *validator.Validate
+}
+
+func NewDeployPreviewHandler(deployPreviewService services.DeployPreviewService) *DeployPreviewHandler {
+	return &DeployPreviewHandler{
+		deployPreviewService: deployPreviewService,
+		validator:            validator.New(),
+	}
+}
+
+// PreviewDeploy godoc
+// @Summary Preview deployment
+// @Description Render the manifests a deploy of an image and tag, or a rollback to a release, would apply and dry-run them against the cluster. Returns each object with the action the deploy takes on it and a diff against the live object. Secret values are redacted. Nothing is changed in the cluster.
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param request body domain.DeployPreviewRequest true "Proposed deploy"
+// @Success 200 {object} domain.DeployPreviewResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/deploy/preview [post]
+func (h *DeployPreviewHandler) PreviewDeploy(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.DeployPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.deployPreviewService.PreviewDeploy(c.Request.Context(), userUUID, appID, &req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "application not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		case strings.Contains(err.Error(), "release not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		case strings.Contains(err.Error(), "environment not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		case strings.Contains(err.Error(), "does not have access"):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		case strings.Contains(err.Error(), "is required"),
+			strings.Contains(err.Error(), "cannot be set"),
+			strings.Contains(err.Error(), "does not belong"),
+			strings.Contains(err.Error(), "invalid spec"),
+			strings.Contains(err.Error(), "invalid image"),
+			strings.Contains(err.Error(), "failed to resolve image digest"),
+			strings.Contains(err.Error(), "require at least one domain"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview deployment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// GetReleaseManifests godoc
+// @Summary Download release manifests
+// @Description Render the manifests of a release, as published to its GitOps repository, and download them as a gzipped tarball with a YAML file per object in a directory named after the application. Secrets are left out. Blue/green and canary releases are rendered as a rolling update would deploy them.
+// @Tags applications
+// @Produce application/gzip
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param releaseId path string true "Release ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/releases/{releaseId}/manifests [get]
+func (h *DeployPreviewHandler) GetReleaseManifests(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	releaseID, err := uuid.Parse(c.Param("releaseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	manifests, err := h.deployPreviewService.GetReleaseManifests(c.Request.Context(), userUUID, appID, releaseID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "application not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		case strings.Contains(err.Error(), "release not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		case strings.Contains(err.Error(), "environment not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		case strings.Contains(err.Error(), "does not have access"):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		case strings.Contains(err.Error(), "does not belong"),
+			strings.Contains(err.Error(), "invalid spec"),
+			strings.Contains(err.Error(), "failed to resolve image digest"),
+			strings.Contains(err.Error(), "require at least one domain"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render release manifests"})
+		}
+		return
+	}
+
+	archive, err := tarManifests(manifests)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render release manifests"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.tar.gz", manifests.AppName, manifests.ReleaseID))
+	c.Data(http.StatusOK, "application/gzip", archive)
+}
+
+// tarManifests packs a release's manifests into a gzipped tarball, in a directory named after the
+// application
+func tarManifests(manifests *domain.ReleaseManifests) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+
+	for _, file := range manifests.Files {
+		header := &tar.Header{
+			Name:    path.Join(manifests.AppName, file.Name),
+			Mode:    0o644,
+			Size:    int64(len(file.Content)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
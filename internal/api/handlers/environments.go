@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type EnvironmentHandler struct {
+	environmentService services.EnvironmentService
+	validator          *validator.Validate
+}
+
+func NewEnvironmentHandler(environmentService services.EnvironmentService) *EnvironmentHandler {
+	return &EnvironmentHandler{
+		environmentService: environmentService,
+		validator:          validator.New(),
+	}
+}
+
+// GetEnvironments godoc
+// @Summary List application environments
+// @Description List an application's environments in promotion order, with the release last deployed to each
+// @Tags applications
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Success 200 {array} domain.EnvironmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/environments [get]
+func (h *EnvironmentHandler) GetEnvironments(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	envs, err := h.environmentService.GetEnvironments(c.Request.Context(), userUUID, appID)
+	if err != nil {
+		writeEnvironmentError(c, err, "Failed to get environments")
+		return
+	}
+
+	c.JSON(http.StatusOK, envs)
+}
+
+// CreateEnvironment godoc
+// @Summary Create application environment
+// @Description Create an environment with its own cluster, namespace, replica count and environment variables. Releases are promoted from one environment to the next in position order (only admins and owners).
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param request body domain.CreateEnvironmentRequest true "Environment data"
+// @Success 201 {object} domain.EnvironmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/environments [post]
+func (h *EnvironmentHandler) CreateEnvironment(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	env, err := h.environmentService.CreateEnvironment(c.Request.Context(), userUUID, appID, &req)
+	if err != nil {
+		writeEnvironmentError(c, err, "Failed to create environment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, env)
+}
+
+// UpdateEnvironment godoc
+// @Summary Update application environment
+// @Description Replace an environment's position, replica count and environment variables. They apply from the next release deployed to it (only admins and owners).
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param name path string true "Environment name"
+// @Param request body domain.UpdateEnvironmentRequest true "Environment settings"
+// @Success 200 {object} domain.EnvironmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/environments/{name} [put]
+func (h *EnvironmentHandler) UpdateEnvironment(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	env, err := h.environmentService.UpdateEnvironment(c.Request.Context(), userUUID, appID, c.Param("name"), &req)
+	if err != nil {
+		writeEnvironmentError(c, err, "Failed to update environment")
+		return
+	}
+
+	c.JSON(http.StatusOK, env)
+}
+
+// DeleteEnvironment godoc
+// @Summary Delete application environment
+// @Description Delete an environment with its releases and domains. Objects deployed to its namespace are not removed from the cluster (only admins and owners).
+// @Tags applications
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param name path string true "Environment name"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/environments/{name} [delete]
+func (h *EnvironmentHandler) DeleteEnvironment(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.environmentService.DeleteEnvironment(c.Request.Context(), userUUID, appID, c.Param("name"))
+	if err != nil {
+		writeEnvironmentError(c, err, "Failed to delete environment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PromoteRelease godoc
+// @Summary Promote release to the next environment
+// @Description Redeploy a succeeded release of an environment, with the same image, tag and deployment spec, to the next environment in position order. Without a release ID, the environment's latest succeeded release is promoted.
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param name path string true "Environment to promote from"
+// @Param request body domain.PromoteReleaseRequest false "Release to promote"
+// @Success 200 {object} domain.DeployApplicationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/environments/{name}/promote [post]
+func (h *EnvironmentHandler) PromoteRelease(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req domain.PromoteReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	response, err := h.environmentService.PromoteRelease(c.Request.Context(), userUUID, appID, c.Param("name"), &req)
+	if err != nil {
+		writeEnvironmentError(c, err, "Failed to promote release")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// writeEnvironmentError maps an environment service error to its response
+func writeEnvironmentError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "environment not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+	case strings.Contains(err.Error(), "release not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+	case strings.Contains(err.Error(), "cluster not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cluster not found"})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+	case strings.Contains(err.Error(), "does not have access"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case strings.Contains(err.Error(), "insufficient permissions"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to manage environments"})
+	case strings.Contains(err.Error(), "already"),
+		strings.Contains(err.Error(), "is in progress"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "does not belong"),
+		strings.Contains(err.Error(), "cannot be promoted"),
+		strings.Contains(err.Error(), "can be promoted"),
+		strings.Contains(err.Error(), "no succeeded release"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -555,3 +555,66 @@ func (h *JobHandler) GetJobsByOrg(c *gin.Context) {
 
 	c.JSON(http.StatusOK, jobs)
 }
+
+// GetJob godoc
+// @Summary Get a job
+// @Description Get a background job of an organization, with the progress of its steps for jobs that report it, such as application deletion
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} domain.JobResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/jobs/{jobId} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	orgIDStr := c.Param("orgId")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		h.logger.Warn("Invalid organization ID format", zap.String("orgID", orgIDStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID format"})
+		return
+	}
+
+	jobIDStr := c.Param("jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.logger.Warn("Invalid job ID format", zap.String("jobID", jobIDStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID format"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.logger.Error("User ID not found in context for GetJob")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userIDUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.Error("Invalid user ID in context", zap.Any("userID", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID in context"})
+		return
+	}
+
+	job, err := h.jobService.GetJob(c.Request.Context(), userIDUUID, orgID, jobID)
+	if err != nil {
+		h.logger.Error("Failed to get job", zap.Error(err), zap.String("jobID", jobIDStr))
+		if strings.Contains(err.Error(), "job not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "user does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type NamespacePolicyHandler struct {
+	namespacePolicyService services.NamespacePolicyService
+}
+
+func NewNamespacePolicyHandler(namespacePolicyService services.NamespacePolicyService) *NamespacePolicyHandler {
+	return &NamespacePolicyHandler{
+		namespacePolicyService: namespacePolicyService,
+	}
+}
+
+// GetNamespacePolicy godoc
+// @Summary Get the namespace policy
+// @Description Get the isolation applied to the namespaces of the organization's applications: the ResourceQuota and LimitRange of each namespace, and the ingress controller namespaces its default-deny NetworkPolicy admits traffic from. Organizations that have not configured a policy get the default one.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Success 200 {object} domain.NamespacePolicy
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/namespace-policy [get]
+func (h *NamespacePolicyHandler) GetNamespacePolicy(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.namespacePolicyService.GetNamespacePolicy(c.Request.Context(), userUUID, orgID)
+	if err != nil {
+		writeNamespacePolicyError(c, err, "Failed to get namespace policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateNamespacePolicy godoc
+// @Summary Update the namespace policy
+// @Description Replace the isolation applied to the namespaces of the organization's applications. Each namespace is updated with the next deploy to it (only admins and owners).
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Param request body domain.UpdateNamespacePolicyRequest true "Namespace policy"
+// @Success 200 {object} domain.NamespacePolicy
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/namespace-policy [put]
+func (h *NamespacePolicyHandler) UpdateNamespacePolicy(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateNamespacePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	policy, err := h.namespacePolicyService.UpdateNamespacePolicy(c.Request.Context(), userUUID, orgID, &req)
+	if err != nil {
+		writeNamespacePolicyError(c, err, "Failed to update namespace policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// writeNamespacePolicyError maps a namespace policy service error to its response
+func writeNamespacePolicyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "does not have access"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case strings.Contains(err.Error(), "insufficient permissions"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to manage the namespace policy"})
+	case strings.Contains(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
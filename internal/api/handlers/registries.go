@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type RegistryCredentialHandler struct {
+	registryCredentialService services.RegistryCredentialService
+	validator                 *validator.Validate
+}
+
+func NewRegistryCredentialHandler(registryCredentialService services.RegistryCredentialService) *RegistryCredentialHandler {
+	return &RegistryCredentialHandler{
+		registryCredentialService: registryCredentialService,
+		validator:                 validator.New(),
+	}
+}
+
+// GetRegistryCredentials godoc
+// @Summary List registry credentials
+// @Description List the organization's container registry credentials, without their passwords (only admins and owners)
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Success 200 {array} domain.RegistryCredentialResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/registries [get]
+func (h *RegistryCredentialHandler) GetRegistryCredentials(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	creds, err := h.registryCredentialService.GetRegistryCredentials(c.Request.Context(), userUUID, orgID)
+	if err != nil {
+		writeRegistryCredentialError(c, err, "Failed to get registry credentials")
+		return
+	}
+
+	c.JSON(http.StatusOK, creds)
+}
+
+// CreateRegistryCredential godoc
+// @Summary Add registry credentials
+// @Description Add the credentials the organization's images are pulled with, for one registry host: Docker Hub (dockerhub), GitHub Container Registry (ghcr), GitLab (gitlab) or any other registry (generic). The registry defaults to the provider's host. Releases are resolved to image digests with the credentials, and deployed with an image pull Secret holding them. The password, or access token, is stored encrypted (only admins and owners).
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Param request body domain.CreateRegistryCredentialRequest true "Registry credentials"
+// @Success 201 {object} domain.RegistryCredentialResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/registries [post]
+func (h *RegistryCredentialHandler) CreateRegistryCredential(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateRegistryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cred, err := h.registryCredentialService.CreateRegistryCredential(c.Request.Context(), userUUID, orgID, &req)
+	if err != nil {
+		writeRegistryCredentialError(c, err, "Failed to create registry credentials")
+		return
+	}
+
+	c.JSON(http.StatusCreated, cred)
+}
+
+// UpdateRegistryCredential godoc
+// @Summary Update registry credentials
+// @Description Replace the username and password of registry credentials (only admins and owners)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Param registryId path string true "Registry credential ID"
+// @Param request body domain.UpdateRegistryCredentialRequest true "Registry credentials"
+// @Success 200 {object} domain.RegistryCredentialResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/registries/{registryId} [put]
+func (h *RegistryCredentialHandler) UpdateRegistryCredential(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	credID, err := uuid.Parse(c.Param("registryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registry credential ID"})
+		return
+	}
+
+	var req domain.UpdateRegistryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cred, err := h.registryCredentialService.UpdateRegistryCredential(c.Request.Context(), userUUID, orgID, credID, &req)
+	if err != nil {
+		writeRegistryCredentialError(c, err, "Failed to update registry credentials")
+		return
+	}
+
+	c.JSON(http.StatusOK, cred)
+}
+
+// DeleteRegistryCredential godoc
+// @Summary Delete registry credentials
+// @Description Delete registry credentials. Images in the registry are pulled anonymously from the next deploy on, which removes the image pull Secret (only admins and owners).
+// @Tags organizations
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID"
+// @Param registryId path string true "Registry credential ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orgs/{orgId}/registries/{registryId} [delete]
+func (h *RegistryCredentialHandler) DeleteRegistryCredential(c *gin.Context) {
+	userUUID, orgID, ok := parseOrgParams(c)
+	if !ok {
+		return
+	}
+
+	credID, err := uuid.Parse(c.Param("registryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registry credential ID"})
+		return
+	}
+
+	err = h.registryCredentialService.DeleteRegistryCredential(c.Request.Context(), userUUID, orgID, credID)
+	if err != nil {
+		writeRegistryCredentialError(c, err, "Failed to delete registry credentials")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseOrgParams returns the authenticated user and the organization ID path parameter, or
+// writes the error response and returns false
+func parseOrgParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, orgID, true
+}
+
+// writeRegistryCredentialError maps a registry credential service error to its response
+func writeRegistryCredentialError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry credential not found"})
+	case strings.Contains(err.Error(), "does not have access"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case strings.Contains(err.Error(), "insufficient permissions"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to manage registry credentials"})
+	case strings.Contains(err.Error(), "invalid registry"),
+		strings.Contains(err.Error(), "is required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already exist"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
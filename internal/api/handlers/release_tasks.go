@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/services"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type ReleaseTaskHandler struct {
+	releaseTaskService services.ReleaseTaskService
+	validator          *validator.Validate
+}
+
+func NewReleaseTaskHandler(releaseTaskService services.ReleaseTaskService) *ReleaseTaskHandler {
+	return &ReleaseTaskHandler{
+		releaseTaskService: releaseTaskService,
+		validator:          validator.New(),
+	}
+}
+
+// RunCommand godoc
+// @Summary Run a one-off command
+// @Description Run a command to completion as a Kubernetes Job, with the image, environment, config and secrets of the latest succeeded release of the application, or of an environment. The command is queued; poll the returned task for its status and output (only admins and owners).
+// @Tags applications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param request body domain.RunCommandRequest true "Command"
+// @Success 202 {object} domain.ReleaseTask
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/run [post]
+func (h *ReleaseTaskHandler) RunCommand(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req domain.RunCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.releaseTaskService.RunCommand(c.Request.Context(), userUUID, appID, &req)
+	if err != nil {
+		writeReleaseTaskError(c, err, "Failed to run command")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
+
+// GetReleaseTask godoc
+// @Summary Get a release task
+// @Description Get a pre-deploy, post-deploy or one-off task with its status, exit code and captured output (only admins and owners)
+// @Tags applications
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} domain.ReleaseTask
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/tasks/{taskId} [get]
+func (h *ReleaseTaskHandler) GetReleaseTask(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := h.releaseTaskService.GetReleaseTask(c.Request.Context(), userUUID, appID, taskID)
+	if err != nil {
+		writeReleaseTaskError(c, err, "Failed to get task")
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// GetReleaseTasks godoc
+// @Summary List release tasks
+// @Description List the tasks run with a release, oldest first: its pre-deploy and post-deploy tasks and the one-off commands run with it (only admins and owners)
+// @Tags applications
+// @Produce json
+// @Security BearerAuth
+// @Param appId path string true "Application ID"
+// @Param releaseId path string true "Release ID"
+// @Success 200 {array} domain.ReleaseTask
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apps/{appId}/releases/{releaseId}/tasks [get]
+func (h *ReleaseTaskHandler) GetReleaseTasks(c *gin.Context) {
+	userUUID, appID, ok := parseAppParams(c)
+	if !ok {
+		return
+	}
+
+	releaseID, err := uuid.Parse(c.Param("releaseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	tasks, err := h.releaseTaskService.GetReleaseTasks(c.Request.Context(), userUUID, appID, releaseID)
+	if err != nil {
+		writeReleaseTaskError(c, err, "Failed to get tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// writeReleaseTaskError maps a release task service error to its response
+func writeReleaseTaskError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "task not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case strings.Contains(err.Error(), "release not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+	case strings.Contains(err.Error(), "environment not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+	case strings.Contains(err.Error(), "does not have access"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case strings.Contains(err.Error(), "insufficient permissions"):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	case strings.Contains(err.Error(), "is required"),
+		strings.Contains(err.Error(), "no succeeded release"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
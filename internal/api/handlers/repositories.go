@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -266,3 +267,266 @@ func (h *RepositoryHandler) DeleteRepository(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// RotateWebhookSecret godoc
+// @Summary Rotate repository webhook secret
+// @Description Generate a new webhook secret for a repository (only admins and owners). The plaintext secret is returned once.
+// @Tags repositories
+// @Produce json
+// @Security BearerAuth
+// @Param repoId path string true "Repository ID"
+// @Success 200 {object} domain.RepositoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /repos/{repoId}/webhook-secret [post]
+func (h *RepositoryHandler) RotateWebhookSecret(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	repoIDStr := c.Param("repoId")
+	repoID, err := uuid.Parse(repoIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid repository ID"})
+		return
+	}
+
+	repository, err := h.repositoryService.RotateWebhookSecret(c.Request.Context(), userUUID, repoID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient permissions") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to rotate webhook secret"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, repository)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List repository webhook deliveries
+// @Description List recent webhook deliveries recorded for a repository, newest first
+// @Tags repositories
+// @Produce json
+// @Security BearerAuth
+// @Param repoId path string true "Repository ID"
+// @Param limit query int false "Number of deliveries to return (default 20, max 100)"
+// @Param offset query int false "Number of deliveries to skip (default 0)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /repos/{repoId}/webhook-deliveries [get]
+func (h *RepositoryHandler) GetWebhookDeliveries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	repoIDStr := c.Param("repoId")
+	repoID, err := uuid.Parse(repoIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid repository ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.repositoryService.GetWebhookDeliveries(c.Request.Context(), userUUID, repoID, limit, offset)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// GetWebhookDelivery godoc
+// @Summary Get webhook delivery details
+// @Description Get a recorded webhook delivery including its headers and raw payload
+// @Tags repositories
+// @Produce json
+// @Security BearerAuth
+// @Param repoId path string true "Repository ID"
+// @Param deliveryId path string true "Webhook delivery ID"
+// @Success 200 {object} domain.WebhookDeliveryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /repos/{repoId}/webhook-deliveries/{deliveryId} [get]
+func (h *RepositoryHandler) GetWebhookDelivery(c *gin.Context) {
+	userUUID, repoID, deliveryID, ok := h.parseWebhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.repositoryService.GetWebhookDelivery(c.Request.Context(), userUUID, repoID, deliveryID)
+	if err != nil {
+		if strings.Contains(err.Error(), "webhook delivery not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook delivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhook godoc
+// @Summary Redeliver a webhook delivery
+// @Description Run a previously verified delivery's stored payload through webhook processing again. The outcome is recorded as a new delivery.
+// @Tags repositories
+// @Produce json
+// @Security BearerAuth
+// @Param repoId path string true "Repository ID"
+// @Param deliveryId path string true "Webhook delivery ID"
+// @Success 201 {object} domain.WebhookDeliveryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /repos/{repoId}/webhook-deliveries/{deliveryId}/redeliver [post]
+func (h *RepositoryHandler) RedeliverWebhook(c *gin.Context) {
+	userUUID, repoID, deliveryID, ok := h.parseWebhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.repositoryService.RedeliverWebhook(c.Request.Context(), userUUID, repoID, deliveryID)
+	if err != nil {
+		if strings.Contains(err.Error(), "webhook delivery not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "does not have access") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient permissions") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to redeliver webhook"})
+			return
+		}
+		if strings.Contains(err.Error(), "unverified webhook delivery") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only verified deliveries can be redelivered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, delivery)
+}
+
+// parseWebhookDeliveryParams extracts the user, repository and delivery IDs, writing an error response on failure
+func (h *RepositoryHandler) parseWebhookDeliveryParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	repoID, err := uuid.Parse(c.Param("repoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid repository ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, repoID, deliveryID, true
+}
@@ -52,11 +52,43 @@ func (m *MockRepositoryService) DeleteRepository(ctx context.Context, userID, re
 	return args.Error(0)
 }
 
-func (m *MockRepositoryService) ProcessWebhook(ctx context.Context, provider string, payload []byte, signature string) error {
-	args := m.Called(ctx, provider, payload, signature)
+func (m *MockRepositoryService) RotateWebhookSecret(ctx context.Context, userID, repoID uuid.UUID) (*domain.RepositoryResponse, error) {
+	args := m.Called(ctx, userID, repoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RepositoryResponse), args.Error(1)
+}
+
+func (m *MockRepositoryService) ProcessWebhook(ctx context.Context, req *domain.WebhookRequest) error {
+	args := m.Called(ctx, req)
 	return args.Error(0)
 }
 
+func (m *MockRepositoryService) GetWebhookDeliveries(ctx context.Context, userID, repoID uuid.UUID, limit, offset int) ([]domain.WebhookDeliverySummary, error) {
+	args := m.Called(ctx, userID, repoID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WebhookDeliverySummary), args.Error(1)
+}
+
+func (m *MockRepositoryService) GetWebhookDelivery(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error) {
+	args := m.Called(ctx, userID, repoID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDeliveryResponse), args.Error(1)
+}
+
+func (m *MockRepositoryService) RedeliverWebhook(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error) {
+	args := m.Called(ctx, userID, repoID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDeliveryResponse), args.Error(1)
+}
+
 func TestRepositoryHandler_CreateRepository(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -406,3 +438,206 @@ func TestRepositoryHandler_DeleteRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositoryHandler_RotateWebhookSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         string
+		repoID         string
+		mockSetup      func(*MockRepositoryService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:   "successful secret rotation",
+			userID: uuid.New().String(),
+			repoID: uuid.New().String(),
+			mockSetup: func(m *MockRepositoryService) {
+				repoResponse := &domain.RepositoryResponse{
+					ID:            uuid.New(),
+					Type:          "github",
+					URL:           "https://github.com/user/repo.git",
+					DefaultBranch: "main",
+					Config:        json.RawMessage(`{"secret":"***MASKED***"}`),
+					WebhookSecret: "new-secret",
+				}
+				m.On("RotateWebhookSecret", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(repoResponse, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "insufficient permissions",
+			userID: uuid.New().String(),
+			repoID: uuid.New().String(),
+			mockSetup: func(m *MockRepositoryService) {
+				m.On("RotateWebhookSecret", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(nil, errors.New("insufficient permissions to rotate webhook secret"))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "Insufficient permissions to rotate webhook secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRepositoryService)
+			tt.mockSetup(mockService)
+
+			handler := NewRepositoryHandler(mockService)
+
+			// Create gin router for testing
+			router := gin.New()
+
+			// Add middleware to set user_id in context
+			router.Use(func(c *gin.Context) {
+				if userID := c.GetHeader("X-User-ID"); userID != "" {
+					c.Set("user_id", userID)
+				}
+				c.Next()
+			})
+
+			router.POST("/repos/:repoId/webhook-secret", handler.RotateWebhookSecret)
+
+			// Create request
+			req := httptest.NewRequest("POST", "/repos/"+tt.repoID+"/webhook-secret", nil)
+			req.Header.Set("X-User-ID", tt.userID)
+
+			// Create response recorder
+			w := httptest.NewRecorder()
+
+			// Serve request
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, "new-secret", response["webhook_secret"])
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRepositoryHandler_RedeliverWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         string
+		repoID         string
+		deliveryID     string
+		mockSetup      func(*MockRepositoryService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:       "successful redelivery",
+			userID:     uuid.New().String(),
+			repoID:     uuid.New().String(),
+			deliveryID: uuid.New().String(),
+			mockSetup: func(m *MockRepositoryService) {
+				originalID := uuid.New()
+				deliveryResponse := &domain.WebhookDeliveryResponse{
+					WebhookDeliverySummary: domain.WebhookDeliverySummary{
+						ID:           uuid.New(),
+						Provider:     "github",
+						Event:        "push",
+						Verified:     true,
+						Status:       domain.WebhookDeliveryStatusProcessed,
+						PipelineIDs:  []uuid.UUID{uuid.New()},
+						RedeliveryOf: &originalID,
+					},
+					Payload: json.RawMessage(`{"ref":"refs/heads/main"}`),
+				}
+				m.On("RedeliverWebhook", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(deliveryResponse, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:       "unverified delivery",
+			userID:     uuid.New().String(),
+			repoID:     uuid.New().String(),
+			deliveryID: uuid.New().String(),
+			mockSetup: func(m *MockRepositoryService) {
+				m.On("RedeliverWebhook", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(nil, errors.New("cannot redeliver an unverified webhook delivery"))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Only verified deliveries can be redelivered",
+		},
+		{
+			name:       "delivery not found",
+			userID:     uuid.New().String(),
+			repoID:     uuid.New().String(),
+			deliveryID: uuid.New().String(),
+			mockSetup: func(m *MockRepositoryService) {
+				m.On("RedeliverWebhook", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID")).Return(nil, errors.New("webhook delivery not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Webhook delivery not found",
+		},
+		{
+			name:           "invalid delivery ID",
+			userID:         uuid.New().String(),
+			repoID:         uuid.New().String(),
+			deliveryID:     "invalid-uuid",
+			mockSetup:      func(m *MockRepositoryService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid delivery ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRepositoryService)
+			tt.mockSetup(mockService)
+
+			handler := NewRepositoryHandler(mockService)
+
+			// Create gin router for testing
+			router := gin.New()
+
+			// Add middleware to set user_id in context
+			router.Use(func(c *gin.Context) {
+				if userID := c.GetHeader("X-User-ID"); userID != "" {
+					c.Set("user_id", userID)
+				}
+				c.Next()
+			})
+
+			router.POST("/repos/:repoId/webhook-deliveries/:deliveryId/redeliver", handler.RedeliverWebhook)
+
+			// Create request
+			req := httptest.NewRequest("POST", "/repos/"+tt.repoID+"/webhook-deliveries/"+tt.deliveryID+"/redeliver", nil)
+			req.Header.Set("X-User-ID", tt.userID)
+
+			// Create response recorder
+			w := httptest.NewRecorder()
+
+			// Serve request
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, "processed", response["status"])
+				assert.NotEmpty(t, response["redelivery_of"])
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
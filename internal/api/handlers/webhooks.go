@@ -9,38 +9,37 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/PouryDev/oneclick/internal/app/services"
-	"github.com/PouryDev/oneclick/internal/app/webhook"
+	"github.com/PouryDev/oneclick/internal/domain"
 )
 
 type WebhookHandler struct {
 	repositoryService services.RepositoryService
-	verifier          *webhook.WebhookVerifier
 	logger            *zap.Logger
 }
 
 func NewWebhookHandler(repositoryService services.RepositoryService, logger *zap.Logger) *WebhookHandler {
 	return &WebhookHandler{
 		repositoryService: repositoryService,
-		verifier:          webhook.NewWebhookVerifier(),
 		logger:            logger,
 	}
 }
 
 // GitWebhook godoc
 // @Summary Git webhook endpoint
-// @Description Public webhook endpoint for Git providers (GitHub, GitLab, Gitea)
+// @Description Public webhook endpoint for Git providers (GitHub, GitLab, Gitea). Deliveries are verified against the webhook secret of the matching repository and recorded in its delivery log.
 // @Tags webhooks
 // @Accept json
 // @Produce json
 // @Param X-Hub-Signature-256 header string false "GitHub signature"
 // @Param X-Hub-Signature header string false "GitHub legacy signature"
+// @Param X-Gitlab-Token header string false "GitLab secret token"
 // @Param X-Gitlab-Signature header string false "GitLab signature"
 // @Param X-Gitea-Signature header string false "Gitea signature"
 // @Param provider query string true "Git provider (github, gitlab, gitea)"
-// @Param secret query string false "Webhook secret for signature verification"
 // @Success 202 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /hooks/git [post]
 func (h *WebhookHandler) GitWebhook(c *gin.Context) {
@@ -60,13 +59,6 @@ func (h *WebhookHandler) GitWebhook(c *gin.Context) {
 		return
 	}
 
-	// Get webhook secret from query parameter
-	secret := c.Query("secret")
-	if secret == "" {
-		h.logger.Warn("No webhook secret provided", zap.String("provider", provider))
-		// Continue without signature verification
-	}
-
 	// Read the request body
 	payload, err := io.ReadAll(c.Request.Body)
 	if err != nil {
@@ -75,41 +67,54 @@ func (h *WebhookHandler) GitWebhook(c *gin.Context) {
 		return
 	}
 
-	// Get signature from appropriate header based on provider
-	var signature string
+	req := &domain.WebhookRequest{
+		Provider: provider,
+		Headers:  make(map[string]string, len(c.Request.Header)),
+		Payload:  payload,
+	}
+	for key := range c.Request.Header {
+		req.Headers[key] = c.Request.Header.Get(key)
+	}
+
+	// Get signature, event type and delivery ID from the provider's headers
 	switch provider {
 	case "github":
-		signature = c.GetHeader("X-Hub-Signature-256")
-		if signature == "" {
-			signature = c.GetHeader("X-Hub-Signature") // Legacy SHA1
+		req.Signature = c.GetHeader("X-Hub-Signature-256")
+		if req.Signature == "" {
+			req.Signature = c.GetHeader("X-Hub-Signature") // Legacy SHA1
 		}
+		req.Event = c.GetHeader("X-GitHub-Event")
+		req.DeliveryID = c.GetHeader("X-GitHub-Delivery")
 	case "gitlab":
-		signature = c.GetHeader("X-Gitlab-Signature")
+		req.Signature = c.GetHeader("X-Gitlab-Signature")
+		if req.Signature == "" {
+			req.Signature = c.GetHeader("X-Gitlab-Token")
+		}
+		req.Event = c.GetHeader("X-Gitlab-Event")
+		req.DeliveryID = c.GetHeader("X-Gitlab-Event-UUID")
 	case "gitea":
-		signature = c.GetHeader("X-Gitea-Signature")
+		req.Signature = c.GetHeader("X-Gitea-Signature")
+		req.Event = c.GetHeader("X-Gitea-Event")
+		req.DeliveryID = c.GetHeader("X-Gitea-Delivery")
 	}
 
-	// Verify signature if secret is provided
-	if secret != "" && signature != "" {
-		err := h.verifier.VerifySignature(provider, payload, signature, secret)
-		if err != nil {
-			h.logger.Error("Signature verification failed",
-				zap.String("provider", provider),
-				zap.String("signature", signature),
-				zap.Error(err))
+	// Resolve the repository, verify the signature against its secret, record and process the delivery
+	err = h.repositoryService.ProcessWebhook(c.Request.Context(), req)
+	if err != nil {
+		switch err.Error() {
+		case "repository not found":
+			h.logger.Warn("Webhook received for unknown repository", zap.String("provider", provider))
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		case "missing webhook signature":
+			h.logger.Warn("Webhook received without signature", zap.String("provider", provider))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature required"})
+			return
+		case "invalid webhook signature":
+			h.logger.Warn("Signature verification failed", zap.String("provider", provider))
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
 			return
 		}
-		h.logger.Info("Signature verified successfully", zap.String("provider", provider))
-	} else if secret != "" {
-		h.logger.Warn("Secret provided but no signature found", zap.String("provider", provider))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature required when secret is provided"})
-		return
-	}
-
-	// Process the webhook
-	err = h.repositoryService.ProcessWebhook(c.Request.Context(), provider, payload, signature)
-	if err != nil {
 		h.logger.Error("Failed to process webhook",
 			zap.String("provider", provider),
 			zap.Error(err))
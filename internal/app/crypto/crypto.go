@@ -5,6 +5,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -127,3 +128,12 @@ func (c *Crypto) DecryptString(encryptedData string) (string, error) {
 
 	return string(decrypted), nil
 }
+
+// GenerateSecret returns a random hex-encoded secret built from the given number of bytes
+func GenerateSecret(numBytes int) (string, error) {
+	secret := make([]byte, numBytes)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
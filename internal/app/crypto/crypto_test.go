@@ -55,3 +55,13 @@ func TestCryptoMissingKey(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "ONECLICK_MASTER_KEY environment variable is required")
 }
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret(32)
+	assert.NoError(t, err)
+	assert.Len(t, secret, 64)
+
+	other, err := GenerateSecret(32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
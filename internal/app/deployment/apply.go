@@ -0,0 +1,409 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// FieldManager is the server-side apply field manager that owns the fields OneClick deploys
+const FieldManager = "oneclick"
+
+// ReplicasFieldManager is the field manager spec.replicas of an autoscaled Deployment is handed
+// over to, so that the HorizontalPodAutoscaler can take it over from there
+const ReplicasFieldManager = "oneclick-replicas-handover"
+
+// Labels set on every object OneClick applies, used to find objects to prune
+const (
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	LabelAppID     = "oneclick.io/app-id"
+
+	ManagedByOneClick = "oneclick"
+)
+
+// LabelOrgID records, with LabelAppID, the organization and application a namespace belongs to
+const LabelOrgID = "oneclick.io/org-id"
+
+// NamespaceLabels returns the labels of a namespace OneClick creates for an application
+func NamespaceLabels(namespace string, orgID, appID uuid.UUID) map[string]string {
+	return map[string]string{
+		"name":         namespace,
+		LabelManagedBy: ManagedByOneClick,
+		LabelOrgID:     orgID.String(),
+		LabelAppID:     appID.String(),
+	}
+}
+
+// ManagedLabels returns the labels that mark objects as managed by OneClick for an application
+func ManagedLabels(appID uuid.UUID) map[string]string {
+	return map[string]string{
+		LabelManagedBy: ManagedByOneClick,
+		LabelAppID:     appID.String(),
+	}
+}
+
+// prunableKinds are the kinds deleted when they are no longer part of an application's manifests
+var prunableKinds = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "", Version: "v1", Kind: "Service"},
+	{Group: "", Version: "v1", Kind: "ConfigMap"},
+	{Group: "", Version: "v1", Kind: "Secret"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "", Version: "v1", Kind: "ResourceQuota"},
+	{Group: "", Version: "v1", Kind: "LimitRange"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	// Kinds deploy sources commonly render besides the ones OneClick generates
+	{Group: "", Version: "v1", Kind: "ServiceAccount"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+}
+
+// Kinds of objects deleted on their own when an application is torn down
+var (
+	IngressKind               = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+	SecretKind                = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Secret"}
+	PersistentVolumeClaimKind = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}
+)
+
+// ManagedKinds returns the kinds of the objects that are pruned
+func ManagedKinds() []schema.GroupVersionKind {
+	return append([]schema.GroupVersionKind(nil), prunableKinds...)
+}
+
+// applyOrder is the order kinds are applied in; kinds that are not listed are applied last
+var applyOrder = map[string]int{
+	"Namespace":               0,
+	"ResourceQuota":           1,
+	"LimitRange":              1,
+	"NetworkPolicy":           1,
+	"ServiceAccount":          2,
+	"Role":                    2,
+	"RoleBinding":             2,
+	"Secret":                  3,
+	"ConfigMap":               4,
+	"PersistentVolumeClaim":   5,
+	"Service":                 6,
+	"Deployment":              7,
+	"StatefulSet":             7,
+	"CronJob":                 7,
+	"DaemonSet":               7,
+	"HorizontalPodAutoscaler": 8,
+	"PodDisruptionBudget":     8,
+	"Ingress":                 9,
+}
+
+// Applier server-side applies manifests to a cluster and prunes objects that are no longer deployed
+type Applier struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+// NewApplier creates a new applier that resolves resources with the given RESTMapper
+func NewApplier(client dynamic.Interface, mapper meta.RESTMapper) *Applier {
+	return &Applier{
+		client: client,
+		mapper: mapper,
+	}
+}
+
+// NewDiscoveryRESTMapper returns a cached RESTMapper backed by the cluster's discovery API
+func NewDiscoveryRESTMapper(client discovery.DiscoveryInterface) meta.ResettableRESTMapper {
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client))
+}
+
+// ParseManifest decodes a YAML manifest into an unstructured object
+func ParseManifest(manifest string) (*unstructured.Unstructured, error) {
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if obj.GetKind() == "" || obj.GetName() == "" {
+		return nil, fmt.Errorf("manifest must set kind and metadata.name")
+	}
+	return &obj, nil
+}
+
+// SortForApply sorts objects into the order they should be applied in
+func SortForApply(objects []*unstructured.Unstructured) {
+	order := func(obj *unstructured.Unstructured) int {
+		if o, ok := applyOrder[obj.GetKind()]; ok {
+			return o
+		}
+		return len(applyOrder)
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		if order(objects[i]) != order(objects[j]) {
+			return order(objects[i]) < order(objects[j])
+		}
+		if objects[i].GetKind() != objects[j].GetKind() {
+			return objects[i].GetKind() < objects[j].GetKind()
+		}
+		return objects[i].GetName() < objects[j].GetName()
+	})
+}
+
+// Apply server-side applies an object under the OneClick field manager, taking conflicting fields over
+func (a *Applier) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return a.apply(ctx, obj, nil)
+}
+
+// DryRunApply server-side applies an object without persisting it and returns the result
+func (a *Applier) DryRunApply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return a.apply(ctx, obj, []string{metav1.DryRunAll})
+}
+
+// apply server-side applies an object with the given dry-run mode
+func (a *Applier) apply(ctx context.Context, obj *unstructured.Unstructured, dryRun []string) (*unstructured.Unstructured, error) {
+	resource, err := a.resourceFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := resource.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        true,
+		DryRun:       dryRun,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s %q: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	return applied, nil
+}
+
+// ApplyAutoscaled server-side applies a Deployment scaled by a HorizontalPodAutoscaler without spec.replicas
+func (a *Applier) ApplyAutoscaled(ctx context.Context, obj *unstructured.Unstructured, replicas int32) (*unstructured.Unstructured, error) {
+	live, err := a.Get(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if live == nil {
+		return a.Apply(ctx, WithReplicas(obj, int64(replicas)))
+	}
+
+	// Dropping spec.replicas while OneClick still owns it would reset the Deployment to one replica
+	if liveReplicas, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); found {
+		if err := a.handOverReplicas(ctx, obj, liveReplicas); err != nil {
+			return nil, err
+		}
+	}
+	return a.Apply(ctx, obj)
+}
+
+// handOverReplicas applies a Deployment's replica count under ReplicasFieldManager
+func (a *Applier) handOverReplicas(ctx context.Context, obj *unstructured.Unstructured, replicas int64) error {
+	resource, err := a.resourceFor(obj)
+	if err != nil {
+		return err
+	}
+
+	handover := &unstructured.Unstructured{}
+	handover.SetAPIVersion(obj.GetAPIVersion())
+	handover.SetKind(obj.GetKind())
+	handover.SetNamespace(obj.GetNamespace())
+	handover.SetName(obj.GetName())
+	handover = WithReplicas(handover, replicas)
+
+	// Not forced: a conflict means the autoscaler already owns the count
+	_, err = resource.Apply(ctx, obj.GetName(), handover, metav1.ApplyOptions{FieldManager: ReplicasFieldManager})
+	if err != nil && !apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to hand over replicas of %s %q: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// WithReplicas returns a copy of a Deployment with spec.replicas set
+func WithReplicas(obj *unstructured.Unstructured, replicas int64) *unstructured.Unstructured {
+	scaled := obj.DeepCopy()
+	_ = unstructured.SetNestedField(scaled.Object, replicas, "spec", "replicas")
+	return scaled
+}
+
+// CheckNamespaced checks that every object is of a namespaced kind the cluster serves
+func (a *Applier) CheckNamespaced(objects []*unstructured.Unstructured) error {
+	for _, obj := range objects {
+		mapping, err := a.restMapping(obj.GroupVersionKind())
+		if err != nil {
+			return err
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			return fmt.Errorf("%s %s is cluster-scoped; a deploy source can only contain namespaced objects", obj.GetKind(), obj.GetName())
+		}
+	}
+	return nil
+}
+
+// Get returns the live version of an object, or nil if it does not exist
+func (a *Applier) Get(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	resource, err := a.resourceFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s %q: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return live, nil
+}
+
+// Delete deletes an object if it exists
+func (a *Applier) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	resource, err := a.resourceFor(obj)
+	if err != nil {
+		return err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err = resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %q: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// Prune deletes objects in the namespace that match the selector but were not applied
+func (a *Applier) Prune(ctx context.Context, namespace string, selector map[string]string, applied []*unstructured.Unstructured) ([]string, error) {
+	candidates, err := a.PruneCandidates(ctx, namespace, selector, applied)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, obj := range candidates {
+		if err := a.Delete(ctx, obj); err != nil {
+			return pruned, fmt.Errorf("failed to prune: %w", err)
+		}
+		pruned = append(pruned, fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName()))
+	}
+
+	return pruned, nil
+}
+
+// PruneCandidates returns the objects Prune would delete, without deleting them
+func (a *Applier) PruneCandidates(ctx context.Context, namespace string, selector map[string]string, applied []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	keep := make(map[string]bool, len(applied))
+	for _, obj := range applied {
+		keep[objectKey(obj.GroupVersionKind().GroupKind(), obj.GetName())] = true
+	}
+
+	matching, err := a.listMatching(ctx, namespace, selector, prunableKinds)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*unstructured.Unstructured
+	for _, obj := range matching {
+		if !keep[objectKey(obj.GroupVersionKind().GroupKind(), obj.GetName())] {
+			candidates = append(candidates, obj)
+		}
+	}
+
+	return candidates, nil
+}
+
+// DeleteMatching deletes the objects of the given kinds in the namespace that match the selector
+func (a *Applier) DeleteMatching(ctx context.Context, namespace string, selector map[string]string, kinds []schema.GroupVersionKind) ([]string, error) {
+	matching, err := a.listMatching(ctx, namespace, selector, kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, obj := range matching {
+		if err := a.Delete(ctx, obj); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName()))
+	}
+
+	return deleted, nil
+}
+
+// listMatching returns the objects of the given kinds that match the selector and have no controller
+func (a *Applier) listMatching(ctx context.Context, namespace string, selector map[string]string, kinds []schema.GroupVersionKind) ([]*unstructured.Unstructured, error) {
+	labelSelector := labels.SelectorFromSet(selector).String()
+
+	var matching []*unstructured.Unstructured
+	for _, gvk := range kinds {
+		mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue // Kind is not served by this cluster
+			}
+			return nil, fmt.Errorf("failed to resolve %s: %w", gvk.Kind, err)
+		}
+
+		list, err := a.client.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", mapping.Resource.Resource, err)
+		}
+
+		for i := range list.Items {
+			item := &list.Items[i]
+			if metav1.GetControllerOf(item) != nil {
+				continue
+			}
+			// Lists leave the kind of their items unset on some clients
+			item.SetGroupVersionKind(gvk)
+			matching = append(matching, item)
+		}
+	}
+
+	return matching, nil
+}
+
+// resourceFor returns the client for an object's resource, scoped to its namespace if namespaced
+func (a *Applier) resourceFor(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := a.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return a.client.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+	}
+	return a.client.Resource(mapping.Resource), nil
+}
+
+// restMapping resolves the resource for a kind, refreshing cached discovery once if it is unknown
+func (a *Applier) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if resettable, ok := a.mapper.(meta.ResettableRESTMapper); ok {
+			resettable.Reset()
+			mapping, err = a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource for %s: %w", gvk.String(), err)
+	}
+	return mapping, nil
+}
+
+// objectKey identifies an object within a namespace
+func objectKey(gk schema.GroupKind, name string) string {
+	return gk.String() + "/" + name
+}
@@ -0,0 +1,222 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	servicesGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	ingressesGVR   = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+)
+
+func newTestRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+func newTestObject(apiVersion, kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func newTestDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		deploymentsGVR: "DeploymentList",
+		servicesGVR:    "ServiceList",
+		configMapsGVR:  "ConfigMapList",
+		ingressesGVR:   "IngressList",
+	}, objects...)
+}
+
+func TestApplier_Apply(t *testing.T) {
+	client := newTestDynamicClient()
+
+	var patched []k8stesting.PatchAction
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		patched = append(patched, patch)
+		return true, newTestObject("v1", "Namespace", "", patch.GetName(), nil), nil
+	})
+
+	applier := NewApplier(client, newTestRESTMapper())
+
+	_, err := applier.Apply(context.Background(), newTestObject("networking.k8s.io/v1", "Ingress", "api", "api-ingress", nil))
+	require.NoError(t, err)
+	_, err = applier.Apply(context.Background(), newTestObject("v1", "Namespace", "", "api", nil))
+	require.NoError(t, err)
+
+	require.Len(t, patched, 2)
+	assert.Equal(t, types.ApplyPatchType, patched[0].GetPatchType())
+	assert.Equal(t, ingressesGVR, patched[0].GetResource())
+	assert.Equal(t, "api", patched[0].GetNamespace())
+	assert.Equal(t, "", patched[1].GetNamespace())
+
+	_, err = applier.Apply(context.Background(), newTestObject("example.com/v1", "Widget", "api", "w", nil))
+	assert.Error(t, err)
+}
+
+func TestApplier_Prune(t *testing.T) {
+	appLabels := map[string]string{LabelManagedBy: ManagedByOneClick, LabelAppID: "app-1"}
+	otherAppLabels := map[string]string{LabelManagedBy: ManagedByOneClick, LabelAppID: "app-2"}
+
+	owned := newTestObject("v1", "ConfigMap", "api", "owned-config", appLabels)
+	controller := true
+	owned.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "api", UID: "uid", Controller: &controller}})
+
+	client := newTestDynamicClient(
+		newTestObject("apps/v1", "Deployment", "api", "api", appLabels),
+		newTestObject("v1", "Service", "api", "api-service", appLabels),
+		newTestObject("v1", "ConfigMap", "api", "api-config", appLabels),
+		newTestObject("networking.k8s.io/v1", "Ingress", "api", "api-ingress", appLabels),
+		newTestObject("v1", "ConfigMap", "api", "other-config", otherAppLabels),
+		newTestObject("v1", "ConfigMap", "api", "unlabelled", nil),
+		owned,
+	)
+
+	applier := NewApplier(client, newTestRESTMapper())
+
+	applied := []*unstructured.Unstructured{
+		newTestObject("apps/v1", "Deployment", "api", "api", appLabels),
+		newTestObject("v1", "Service", "api", "api-service", appLabels),
+	}
+
+	pruned, err := applier.Prune(context.Background(), "api", appLabels, applied)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ConfigMap/api-config", "Ingress/api-ingress"}, pruned)
+
+	remaining, err := client.Resource(configMapsGVR).Namespace("api").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var names []string
+	for _, item := range remaining.Items {
+		names = append(names, item.GetName())
+	}
+	assert.ElementsMatch(t, []string{"other-config", "unlabelled", "owned-config"}, names)
+
+	_, err = client.Resource(deploymentsGVR).Namespace("api").Get(context.Background(), "api", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestApplier_DeleteMatching(t *testing.T) {
+	appLabels := map[string]string{LabelManagedBy: ManagedByOneClick, LabelAppID: "app-1"}
+	otherAppLabels := map[string]string{LabelManagedBy: ManagedByOneClick, LabelAppID: "app-2"}
+
+	client := newTestDynamicClient(
+		newTestObject("apps/v1", "Deployment", "api", "api", appLabels),
+		newTestObject("networking.k8s.io/v1", "Ingress", "api", "api-ingress", appLabels),
+		newTestObject("networking.k8s.io/v1", "Ingress", "api", "other-ingress", otherAppLabels),
+	)
+
+	applier := NewApplier(client, newTestRESTMapper())
+
+	// Kinds the cluster does not serve are skipped
+	deleted, err := applier.DeleteMatching(context.Background(), "api", appLabels, []schema.GroupVersionKind{IngressKind, PersistentVolumeClaimKind})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ingress/api-ingress"}, deleted)
+
+	remaining, err := client.Resource(ingressesGVR).Namespace("api").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, remaining.Items, 1)
+	assert.Equal(t, "other-ingress", remaining.Items[0].GetName())
+
+	_, err = client.Resource(deploymentsGVR).Namespace("api").Get(context.Background(), "api", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestApplier_ApplyAutoscaled(t *testing.T) {
+	live := newTestObject("apps/v1", "Deployment", "api", "api", nil)
+	require.NoError(t, unstructured.SetNestedField(live.Object, int64(4), "spec", "replicas"))
+	client := newTestDynamicClient(live)
+
+	var patches []*unstructured.Unstructured
+	var conflict bool
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := &unstructured.Unstructured{}
+		if err := patch.UnmarshalJSON(action.(k8stesting.PatchAction).GetPatch()); err != nil {
+			return true, nil, err
+		}
+		patches = append(patches, patch)
+		if conflict && len(patches) == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "api", errors.New("spec.replicas is owned by kube-controller-manager"))
+		}
+		return true, patch, nil
+	})
+
+	applier := NewApplier(client, newTestRESTMapper())
+	ctx := context.Background()
+	replicasOf := func(obj *unstructured.Unstructured) (int64, bool) {
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		return replicas, found
+	}
+
+	// The live replicas are handed over before OneClick stops applying them
+	deployment := newTestObject("apps/v1", "Deployment", "api", "api", nil)
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(30), "spec", "minReadySeconds"))
+	_, err := applier.ApplyAutoscaled(ctx, deployment, 2)
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+	replicas, found := replicasOf(patches[0])
+	assert.True(t, found)
+	assert.Equal(t, int64(4), replicas)
+	_, found, _ = unstructured.NestedInt64(patches[0].Object, "spec", "minReadySeconds")
+	assert.False(t, found, "the handover applies nothing but the replicas")
+	_, found = replicasOf(patches[1])
+	assert.False(t, found)
+
+	// The autoscaler already took the replicas over
+	patches, conflict = nil, true
+	_, err = applier.ApplyAutoscaled(ctx, deployment, 2)
+	require.NoError(t, err)
+	assert.Len(t, patches, 2)
+
+	// A new Deployment starts with the autoscaler's minimum
+	patches, conflict = nil, false
+	_, err = applier.ApplyAutoscaled(ctx, newTestObject("apps/v1", "Deployment", "api", "worker", nil), 2)
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	replicas, found = replicasOf(patches[0])
+	assert.True(t, found)
+	assert.Equal(t, int64(2), replicas)
+}
+
+func TestSortForApply(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newTestObject("networking.k8s.io/v1", "Ingress", "api", "api-ingress", nil),
+		newTestObject("apps/v1", "Deployment", "api", "api", nil),
+		newTestObject("v1", "Service", "api", "api-service", nil),
+		newTestObject("v1", "ConfigMap", "api", "api-config", nil),
+	}
+
+	SortForApply(objects)
+
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj.GetKind())
+	}
+	assert.Equal(t, []string{"ConfigMap", "Service", "Deployment", "Ingress"}, kinds)
+}
@@ -0,0 +1,125 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// DetectDrift compares an object as a release rendered it with its live version, or returns nil if in sync
+func (a *Applier) DetectDrift(ctx context.Context, obj *unstructured.Unstructured) (*domain.ObjectDrift, error) {
+	live, err := a.Get(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return a.detectDrift(ctx, obj, live)
+}
+
+// DetectAutoscaledDrift compares an autoscaled Deployment with its live version like DetectDrift,
+// leaving out the replicas its HorizontalPodAutoscaler sets
+func (a *Applier) DetectAutoscaledDrift(ctx context.Context, obj *unstructured.Unstructured) (*domain.ObjectDrift, error) {
+	live, err := a.Get(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if live != nil {
+		if liveReplicas, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); found {
+			obj = WithReplicas(obj, liveReplicas)
+		}
+	}
+	return a.detectDrift(ctx, obj, live)
+}
+
+// detectDrift compares an object with its live version, which is nil if it was deleted
+func (a *Applier) detectDrift(ctx context.Context, obj, live *unstructured.Unstructured) (*domain.ObjectDrift, error) {
+	drift := &domain.ObjectDrift{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+	if live == nil {
+		drift.Missing = true
+		return drift, nil
+	}
+
+	planned, err := a.DryRunApply(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	drift.Fields = DiffFields(live, planned)
+	if len(drift.Fields) == 0 {
+		return nil, nil
+	}
+	return drift, nil
+}
+
+// DiffFields returns the fields whose values differ between a live object and its replacement
+func DiffFields(live, planned *unstructured.Unstructured) []domain.FieldDrift {
+	liveFields := make(map[string]interface{})
+	flattenFields("", previewContent(live, nil), liveFields)
+	plannedFields := make(map[string]interface{})
+	flattenFields("", previewContent(planned, live), plannedFields)
+
+	paths := make([]string, 0, len(liveFields)+len(plannedFields))
+	for path := range liveFields {
+		paths = append(paths, path)
+	}
+	for path := range plannedFields {
+		if _, ok := liveFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var fields []domain.FieldDrift
+	for _, path := range paths {
+		liveValue, desiredValue := liveFields[path], plannedFields[path]
+		if reflect.DeepEqual(liveValue, desiredValue) {
+			continue
+		}
+		fields = append(fields, domain.FieldDrift{Path: path, Live: liveValue, Desired: desiredValue})
+	}
+	return fields
+}
+
+// flattenFields adds the leaf values of an object's content to fields by path
+func flattenFields(path string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fields[path] = v
+			return
+		}
+		for key, child := range v {
+			flattenFields(fieldPath(path, key), child, fields)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fields[path] = v
+			return
+		}
+		for i, child := range v {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		fields[path] = v
+	}
+}
+
+// fieldPath returns the path of a key of the map at path
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./[]\"") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,91 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestDiffFields(t *testing.T) {
+	live := newTestObject("apps/v1", "Deployment", "api", "api", map[string]string{LabelManagedBy: "oneclick"})
+	live.SetResourceVersion("41")
+	live.Object["spec"] = map[string]interface{}{
+		"replicas": int64(5),
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "api", "image": "ghcr.io/acme/api:hotfix"},
+				},
+			},
+		},
+	}
+	live.Object["status"] = map[string]interface{}{"readyReplicas": int64(5)}
+
+	planned := live.DeepCopy()
+	planned.SetResourceVersion("42")
+	planned.Object["status"] = map[string]interface{}{"readyReplicas": int64(3)}
+
+	assert.Empty(t, DiffFields(live, planned), "status and server-managed metadata are ignored")
+
+	require.NoError(t, unstructured.SetNestedField(planned.Object, int64(3), "spec", "replicas"))
+	planned.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"] = []interface{}{
+		map[string]interface{}{"name": "api", "image": "ghcr.io/acme/api@sha256:abc"},
+	}
+	planned.SetLabels(map[string]string{LabelManagedBy: "oneclick", LabelAppID: "app"})
+
+	assert.Equal(t, []domain.FieldDrift{
+		{Path: `metadata.labels["oneclick.io/app-id"]`, Live: nil, Desired: "app"},
+		{Path: "spec.replicas", Live: int64(5), Desired: int64(3)},
+		{Path: "spec.template.spec.containers[0].image", Live: "ghcr.io/acme/api:hotfix", Desired: "ghcr.io/acme/api@sha256:abc"},
+	}, DiffFields(live, planned))
+}
+
+func TestDiffFields_RedactsSecrets(t *testing.T) {
+	live := newTestObject("v1", "Secret", "api", "api-secrets", nil)
+	live.Object["data"] = map[string]interface{}{"API_KEY": "a2V5", "DATABASE_URL": "b2xk"}
+
+	planned := live.DeepCopy()
+	planned.Object["data"] = map[string]interface{}{"API_KEY": "a2V5", "DATABASE_URL": "bmV3"}
+
+	assert.Equal(t, []domain.FieldDrift{
+		{Path: "data.DATABASE_URL", Live: "(redacted)", Desired: "(redacted, changed)"},
+	}, DiffFields(live, planned))
+}
+
+func TestApplier_DetectDrift(t *testing.T) {
+	client := newTestDynamicClient(newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "debug"}))
+
+	// The fake client ignores dry-run; return what the API server would
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		planned := &unstructured.Unstructured{}
+		if err := planned.UnmarshalJSON(action.(k8stesting.PatchAction).GetPatch()); err != nil {
+			return true, nil, err
+		}
+		return true, planned, nil
+	})
+
+	applier := NewApplier(client, newTestRESTMapper())
+	ctx := context.Background()
+
+	drift, err := applier.DetectDrift(ctx, newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "debug"}))
+	require.NoError(t, err)
+	assert.Nil(t, drift)
+
+	drift, err = applier.DetectDrift(ctx, newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "info"}))
+	require.NoError(t, err)
+	require.NotNil(t, drift)
+	assert.False(t, drift.Missing)
+	assert.Equal(t, []domain.FieldDrift{{Path: "data.LOG_LEVEL", Live: "debug", Desired: "info"}}, drift.Fields)
+
+	drift, err = applier.DetectDrift(ctx, newTestConfigMap("api-flags", map[string]interface{}{"BETA": "true"}))
+	require.NoError(t, err)
+	require.NotNil(t, drift)
+	assert.Equal(t, domain.ObjectDrift{Kind: "ConfigMap", Name: "api-flags", Namespace: "api", Missing: true}, *drift)
+}
@@ -0,0 +1,45 @@
+package deployment
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// ExportManifests returns a YAML manifest per object of a release, leaving Secrets out
+func ExportManifests(objects []*unstructured.Unstructured) ([]domain.ManifestFile, error) {
+	files := make([]domain.ManifestFile, 0, len(objects))
+	names := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		if !Exported(obj) {
+			continue
+		}
+
+		name := strings.ToLower(obj.GetKind()) + "-" + obj.GetName() + ".yaml"
+		if names[name] {
+			// Kinds of the same name in different groups are told apart by their group
+			name = strings.ToLower(obj.GetKind()) + "." + obj.GroupVersionKind().Group + "-" + obj.GetName() + ".yaml"
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%s %s is exported twice", obj.GetKind(), obj.GetName())
+		}
+		names[name] = true
+
+		content, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		files = append(files, domain.ManifestFile{Name: name, Content: content})
+	}
+	return files, nil
+}
+
+// Exported reports whether an object is part of a release's exported manifests
+func Exported(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() != schema.GroupKind{Kind: "Secret"}
+}
@@ -0,0 +1,41 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportManifests(t *testing.T) {
+	secret := newTestObject("v1", "Secret", "acme-api", "api-secrets", nil)
+	secret.Object["data"] = map[string]interface{}{"DATABASE_URL": "cG9zdGdyZXM6Ly9kYg=="}
+	configMap := newTestObject("v1", "ConfigMap", "acme-api", "api-config", nil)
+	configMap.Object["data"] = map[string]interface{}{"LOG_LEVEL": "info"}
+	deploymentObj := newTestObject("apps/v1", "Deployment", "acme-api", "api", map[string]string{"app": "api"})
+	knativeService := newTestObject("serving.knative.dev/v1", "Service", "acme-api", "api", nil)
+	service := newTestObject("v1", "Service", "acme-api", "api", nil)
+
+	files, err := ExportManifests([]*unstructured.Unstructured{secret, configMap, service, deploymentObj, knativeService})
+	require.NoError(t, err)
+
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+	assert.Equal(t, []string{
+		"configmap-api-config.yaml",
+		"service-api.yaml",
+		"deployment-api.yaml",
+		"service.serving.knative.dev-api.yaml",
+	}, names, "secrets are left out")
+
+	var exported map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(files[0].Content, &exported))
+	assert.Equal(t, configMap.Object, exported)
+
+	_, err = ExportManifests([]*unstructured.Unstructured{service, service, service})
+	assert.ErrorContains(t, err, "Service api is exported twice")
+}
@@ -1,9 +1,28 @@
 package deployment
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/google/uuid"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
 	"github.com/PouryDev/oneclick/internal/domain"
 )
 
@@ -13,12 +32,176 @@ type DeploymentConfig struct {
 	Namespace   string
 	Image       string
 	Tag         string
-	Replicas    int32
+	ImageDigest string // When set, the image is pulled by digest rather than by tag
+	Replicas    int32  // Ignored when autoscaling
 	Port        int32
 	Environment map[string]string
 	Config      map[string]string
 	Resources   *ResourceConfig
 	HealthCheck *HealthCheckConfig
+
+	Secrets map[string]string // Decrypted secret values, rendered into a Secret rather than inline env
+
+	RegistryAuths map[string]RegistryAuth // Decrypted registry credentials by registry host, rendered into an image pull Secret
+
+	Ports        []PortConfig // All container ports; when empty, Port is exposed on Service port 80
+	Command      []string
+	Args         []string
+	ServiceType  string // Defaults to ClusterIP
+	NodeSelector map[string]string
+
+	Strategy        *StrategyConfig // Defaults to a rolling update with the Kubernetes defaults
+	MinReadySeconds int32           // Time a new pod must stay ready before it counts as available
+	Slot            string          // Blue/green slot to generate; the Service routes to the slot's pods
+	Canary          bool            // Generate the canary Deployment, Service and Ingress rather than the stable ones
+
+	Autoscaling *AutoscalingConfig // Scale the Deployment with a HorizontalPodAutoscaler rather than Replicas
+
+	Processes []ProcessConfig // Additional processes, rolled out with the main web process
+	Process   *ProcessConfig  // The process being generated, nil for the main web process; see ProcessDeploymentConfig
+
+	Volumes []VolumeConfig // Persistent volumes mounted into the main web process
+
+	Isolation *IsolationConfig // Quota, limits and NetworkPolicy of the namespace; nil to leave the namespace open
+
+	Source *SourceConfig // Deploy source the objects are rendered from; nil to generate them
+}
+
+// SourceConfig is a deploy source in the application's repository that its objects are rendered
+// from rather than generated
+type SourceConfig struct {
+	Type        string                 // manifests, kustomize or helm
+	Path        string                 // Directory of the manifests, overlay or chart in the repository
+	Containers  []string               // Containers that run the release image whatever image they reference
+	Values      map[string]interface{} // Helm only
+	ValuesFiles []string               // Helm only, relative to the chart
+}
+
+// ProcessConfig represents an additional process of an application, run from the release image
+type ProcessConfig struct {
+	Name      string
+	Type      string // One of the domain.ProcessType* constants
+	Command   []string
+	Args      []string
+	Replicas  int32 // Web and worker only
+	Resources *ResourceConfig
+	Ports     []PortConfig // Web only
+	Schedule  string       // Cron only
+}
+
+// VolumeConfig represents a persistent volume of an application and where it is mounted
+type VolumeConfig struct {
+	Name         string
+	Size         string
+	StorageClass string // Empty for the cluster's default storage class
+	AccessMode   string // One of the domain.VolumeAccess* constants
+	MountPath    string
+}
+
+// AutoscalingConfig represents the HorizontalPodAutoscaler of an application
+type AutoscalingConfig struct {
+	MinReplicas             int32
+	MaxReplicas             int32
+	TargetCPUUtilization    int32 // Percentage of the CPU request, 0 to not scale on CPU
+	TargetMemoryUtilization int32 // Percentage of the memory request, 0 to not scale on memory
+	Metrics                 []CustomMetricConfig
+}
+
+// CustomMetricConfig represents a per-pod custom metric and its target average value
+type CustomMetricConfig struct {
+	Name               string
+	TargetAverageValue string
+}
+
+// RegistryAuth represents the credentials pods pull an application's image with
+type RegistryAuth struct {
+	Username string
+	Password string
+}
+
+// StrategyConfig represents the deployment strategy of an application
+type StrategyConfig struct {
+	Type           string // One of the domain.Strategy* constants
+	MaxSurge       string // Rolling only
+	MaxUnavailable string // Rolling only
+	CanaryWeight   int32  // Canary only, defaults to domain.DefaultCanaryWeight
+}
+
+// TaskConfig represents a one-off command run to completion as a Kubernetes Job
+type TaskConfig struct {
+	Name           string // Unique among the application's tasks; names the Job <app>-<name>
+	Command        []string
+	Args           []string
+	TimeoutSeconds int64
+}
+
+// IsolationConfig represents the isolation of the namespace an application is deployed to, from
+// its organization's namespace policy
+type IsolationConfig struct {
+	ResourceQuota     map[string]string // Hard limits by resource name; no ResourceQuota when empty
+	LimitRange        *LimitRangeConfig // No LimitRange when nil
+	IngressNamespaces []string          // Namespaces of the ingress controllers admitted by the NetworkPolicy
+}
+
+// LimitRangeConfig represents the defaults and bounds of the resources of each container
+type LimitRangeConfig struct {
+	Default        map[string]string
+	DefaultRequest map[string]string
+	Min            map[string]string
+	Max            map[string]string
+}
+
+// Names of the objects that isolate an application's namespace
+const (
+	ResourceQuotaName = "oneclick-quota"
+	LimitRangeName    = "oneclick-limits"
+	NetworkPolicyName = "oneclick-isolation"
+)
+
+// NewIsolationConfig returns the isolation of a namespace under an organization's namespace policy
+func NewIsolationConfig(policy *domain.NamespacePolicy) *IsolationConfig {
+	isolation := &IsolationConfig{
+		ResourceQuota:     policy.ResourceQuota,
+		IngressNamespaces: policy.IngressNamespaces,
+	}
+	if policy.LimitRange != nil {
+		isolation.LimitRange = &LimitRangeConfig{
+			Default:        policy.LimitRange.Default,
+			DefaultRequest: policy.LimitRange.DefaultRequest,
+			Min:            policy.LimitRange.Min,
+			Max:            policy.LimitRange.Max,
+		}
+	}
+	return isolation
+}
+
+// Blue/green slots
+const (
+	SlotBlue  = "blue"
+	SlotGreen = "green"
+)
+
+// LabelSlot is the pod label holding the blue/green slot a pod belongs to
+const LabelSlot = "oneclick.io/slot"
+
+// LabelTask is the label holding the name of the task a Job and its pod run
+const LabelTask = "oneclick.io/task"
+
+// taskJobTTLSeconds is how long a finished task's Job and pod are kept
+const taskJobTTLSeconds = 24 * 60 * 60
+
+// NGINX ingress annotations that make an Ingress send a share of its host's traffic to a canary
+const (
+	annotationCanary       = "nginx.ingress.kubernetes.io/canary"
+	annotationCanaryWeight = "nginx.ingress.kubernetes.io/canary-weight"
+)
+
+// PortConfig represents a container port and the Service port it is exposed on
+type PortConfig struct {
+	Name        string
+	Port        int32
+	Protocol    string
+	ServicePort int32
 }
 
 // ResourceConfig represents resource limits and requests
@@ -34,6 +217,20 @@ type HealthCheckConfig struct {
 	LivenessPath  string
 	ReadinessPath string
 	Port          int32
+
+	// Liveness and Readiness override the port and default timings of a probe. Zero timings
+	// are left to Kubernetes.
+	Liveness  *ProbeSettings
+	Readiness *ProbeSettings
+}
+
+// ProbeSettings represents the port and timings of a probe
+type ProbeSettings struct {
+	Port                int32
+	InitialDelaySeconds int32
+	PeriodSeconds       int32
+	TimeoutSeconds      int32
+	FailureThreshold    int32
 }
 
 // DeploymentGenerator generates Kubernetes deployment YAML
@@ -44,268 +241,1293 @@ func NewDeploymentGenerator() *DeploymentGenerator {
 	return &DeploymentGenerator{}
 }
 
-// GenerateDeployment generates a Kubernetes Deployment YAML
-func (g *DeploymentGenerator) GenerateDeployment(config *DeploymentConfig) (string, error) {
+// BuildDeployment builds the Kubernetes Deployment for an application
+func (g *DeploymentGenerator) BuildDeployment(config *DeploymentConfig) (*appsv1.Deployment, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if config.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if config.Tag == "" {
+		return nil, fmt.Errorf("tag is required")
+	}
+
+	// The autoscaler owns the replicas of an autoscaled Deployment. Canaries are not autoscaled
+	// and run the autoscaler's minimum.
+	var replicas *int32
+	switch {
+	case Autoscaled(config):
+	case config.Autoscaling != nil:
+		replicas = &config.Autoscaling.MinReplicas
+	case config.Replicas == 0:
+		replicas = new(int32)
+		*replicas = 1
+	default:
+		replicas = &config.Replicas
+	}
+
+	template, err := buildPodTemplate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DeploymentName(config),
+			Namespace: namespaceOf(config),
+			Labels:    template.Labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels(config),
+			},
+			Strategy:        deploymentStrategy(config),
+			MinReadySeconds: config.MinReadySeconds,
+			Template:        template,
+		},
+	}, nil
+}
+
+// BuildStatefulSet builds the Kubernetes StatefulSet of an application; see Stateful
+func (g *DeploymentGenerator) BuildStatefulSet(config *DeploymentConfig) (*appsv1.StatefulSet, error) {
 	if config.AppName == "" {
-		return "", fmt.Errorf("app name is required")
+		return nil, fmt.Errorf("app name is required")
 	}
 	if config.Image == "" {
-		return "", fmt.Errorf("image is required")
+		return nil, fmt.Errorf("image is required")
 	}
 	if config.Tag == "" {
-		return "", fmt.Errorf("tag is required")
+		return nil, fmt.Errorf("tag is required")
+	}
+	if !Stateful(config) {
+		return nil, fmt.Errorf("only applications with ReadWriteOnce volumes and more than one replica run as stateful sets")
+	}
+
+	template, err := buildPodTemplate(config)
+	if err != nil {
+		return nil, err
 	}
 
-	namespace := config.Namespace
-	if namespace == "" {
-		namespace = "default"
+	var claimTemplates []corev1.PersistentVolumeClaim
+	for _, volume := range config.Volumes {
+		if volume.AccessMode != domain.VolumeAccessReadWriteOnce {
+			continue
+		}
+		claim, err := buildPersistentVolumeClaim(config, &volume, volume.Name)
+		if err != nil {
+			return nil, err
+		}
+		claim.TypeMeta = metav1.TypeMeta{}
+		claim.Namespace = ""
+		claimTemplates = append(claimTemplates, *claim)
 	}
 
 	replicas := config.Replicas
-	if replicas == 0 {
-		replicas = 1
+	return &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DeploymentName(config),
+			Namespace: namespaceOf(config),
+			Labels:    template.Labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: ServiceName(config),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels(config),
+			},
+			MinReadySeconds:      config.MinReadySeconds,
+			Template:             template,
+			VolumeClaimTemplates: claimTemplates,
+		},
+	}, nil
+}
+
+// BuildPersistentVolumeClaim builds the PersistentVolumeClaim of a volume the application's pods
+// share, or nil for a ReadWriteOnce volume of a StatefulSet, which every replica claims for itself
+func (g *DeploymentGenerator) BuildPersistentVolumeClaim(config *DeploymentConfig, volume *VolumeConfig) (*corev1.PersistentVolumeClaim, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if claimedPerReplica(config, volume) {
+		return nil, nil
+	}
+	return buildPersistentVolumeClaim(config, volume, PersistentVolumeClaimName(config, volume))
+}
+
+// buildPersistentVolumeClaim builds a claim for a volume, with the cluster's default storage
+// class unless the volume names one
+func buildPersistentVolumeClaim(config *DeploymentConfig, volume *VolumeConfig, name string) (*corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(volume.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size for volume %s: %w", volume.Name, err)
 	}
 
-	port := config.Port
-	if port == 0 {
-		port = 8080
+	var storageClass *string
+	if volume.StorageClass != "" {
+		storageClass = &volume.StorageClass
 	}
 
-	imageName := fmt.Sprintf("%s:%s", config.Image, config.Tag)
+	return &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(volume.AccessMode)},
+			StorageClassName: storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}, nil
+}
 
-	var envVars strings.Builder
-	for key, value := range config.Environment {
-		envVars.WriteString(fmt.Sprintf("        - name: %s\n", key))
-		envVars.WriteString(fmt.Sprintf("          value: \"%s\"\n", value))
+// BuildCronJob builds the Kubernetes CronJob of a cron process
+func (g *DeploymentGenerator) BuildCronJob(config *DeploymentConfig) (*batchv1.CronJob, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if config.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if config.Tag == "" {
+		return nil, fmt.Errorf("tag is required")
+	}
+	if config.Process == nil || config.Process.Type != domain.ProcessTypeCron {
+		return nil, fmt.Errorf("only cron processes run as cron jobs")
 	}
 
-	var configVars strings.Builder
-	for key, value := range config.Config {
-		configVars.WriteString(fmt.Sprintf("        - name: %s\n", key))
-		configVars.WriteString(fmt.Sprintf("          value: \"%s\"\n", value))
+	template, err := buildPodTemplate(config)
+	if err != nil {
+		return nil, err
 	}
+	template.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
 
-	var resources strings.Builder
-	if config.Resources != nil {
-		resources.WriteString("        resources:\n")
-		if config.Resources.CPURequest != "" || config.Resources.MemoryRequest != "" {
-			resources.WriteString("          requests:\n")
-			if config.Resources.CPURequest != "" {
-				resources.WriteString(fmt.Sprintf("            cpu: %s\n", config.Resources.CPURequest))
-			}
-			if config.Resources.MemoryRequest != "" {
-				resources.WriteString(fmt.Sprintf("            memory: %s\n", config.Resources.MemoryRequest))
-			}
+	return &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DeploymentName(config),
+			Namespace: namespaceOf(config),
+			Labels:    template.Labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          config.Process.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: template.Labels},
+				Spec:       batchv1.JobSpec{Template: template},
+			},
+		},
+	}, nil
+}
+
+// BuildTaskJob builds the Kubernetes Job that runs a task with the main web process's settings
+func (g *DeploymentGenerator) BuildTaskJob(config *DeploymentConfig, task *TaskConfig) (*batchv1.Job, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if config.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if config.Tag == "" {
+		return nil, fmt.Errorf("tag is required")
+	}
+	if task.Name == "" || len(task.Command) == 0 {
+		return nil, fmt.Errorf("task name and command are required")
+	}
+
+	// A process without a type has no ports, probes or Service
+	taskConfig := ProcessDeploymentConfig(config, &ProcessConfig{
+		Name:      task.Name,
+		Command:   task.Command,
+		Args:      task.Args,
+		Resources: config.Resources,
+	})
+	template, err := buildPodTemplate(taskConfig)
+	if err != nil {
+		return nil, err
+	}
+	template.Labels = map[string]string{LabelTask: task.Name}
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	backoffLimit := int32(0)
+	ttl := int32(taskJobTTLSeconds)
+	timeout := task.TimeoutSeconds
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TaskJobName(config, task),
+			Namespace: namespaceOf(config),
+			Labels:    map[string]string{LabelTask: task.Name},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &timeout,
+			TTLSecondsAfterFinished: &ttl,
+			Template:                template,
+		},
+	}, nil
+}
+
+// TaskJobName returns the name of the Job of a task, <app>-<task>, with the application name
+// shortened to keep it a valid label value
+func TaskJobName(config *DeploymentConfig, task *TaskConfig) string {
+	appName := config.AppName
+	if maxLen := validation.LabelValueMaxLength - len(task.Name) - 1; len(appName) > maxLen {
+		appName = strings.TrimRight(appName[:maxLen], "-")
+	}
+	return fmt.Sprintf("%s-%s", appName, task.Name)
+}
+
+// buildPodTemplate builds the pod template of the Deployment or CronJob being generated
+func buildPodTemplate(config *DeploymentConfig) (corev1.PodTemplateSpec, error) {
+	container := corev1.Container{
+		Name:    config.AppName,
+		Image:   imageRef(config),
+		Command: config.Command,
+		Args:    config.Args,
+		Env:     buildEnv(config.Environment, config.Config, secretName(config), config.Secrets),
+	}
+
+	if serves(config) {
+		for _, p := range portsOf(config) {
+			container.Ports = append(container.Ports, corev1.ContainerPort{
+				Name:          p.Name,
+				ContainerPort: p.Port,
+				Protocol:      corev1.Protocol(p.Protocol),
+			})
 		}
-		if config.Resources.CPULimit != "" || config.Resources.MemoryLimit != "" {
-			resources.WriteString("          limits:\n")
-			if config.Resources.CPULimit != "" {
-				resources.WriteString(fmt.Sprintf("            cpu: %s\n", config.Resources.CPULimit))
-			}
-			if config.Resources.MemoryLimit != "" {
-				resources.WriteString(fmt.Sprintf("            memory: %s\n", config.Resources.MemoryLimit))
-			}
+	}
+
+	if config.Resources != nil {
+		resources, err := buildResources(config.Resources)
+		if err != nil {
+			return corev1.PodTemplateSpec{}, err
 		}
+		container.Resources = resources
 	}
 
-	var healthChecks strings.Builder
 	if config.HealthCheck != nil {
+		probePort := config.HealthCheck.Port
+		if probePort == 0 {
+			probePort = portsOf(config)[0].Port
+		}
 		if config.HealthCheck.LivenessPath != "" {
-			healthChecks.WriteString("        livenessProbe:\n")
-			healthChecks.WriteString("          httpGet:\n")
-			healthChecks.WriteString(fmt.Sprintf("            path: %s\n", config.HealthCheck.LivenessPath))
-			healthChecks.WriteString(fmt.Sprintf("            port: %d\n", config.HealthCheck.Port))
-			healthChecks.WriteString("          initialDelaySeconds: 30\n")
-			healthChecks.WriteString("          periodSeconds: 10\n")
+			container.LivenessProbe = buildHTTPProbe(config.HealthCheck.LivenessPath, probePort, config.HealthCheck.Liveness, 30, 10)
 		}
 		if config.HealthCheck.ReadinessPath != "" {
-			healthChecks.WriteString("        readinessProbe:\n")
-			healthChecks.WriteString("          httpGet:\n")
-			healthChecks.WriteString(fmt.Sprintf("            path: %s\n", config.HealthCheck.ReadinessPath))
-			healthChecks.WriteString(fmt.Sprintf("            port: %d\n", config.HealthCheck.Port))
-			healthChecks.WriteString("          initialDelaySeconds: 5\n")
-			healthChecks.WriteString("          periodSeconds: 5\n")
-		}
-	}
-
-	yaml := fmt.Sprintf(`apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    app: %s
-    version: %s
-spec:
-  replicas: %d
-  selector:
-    matchLabels:
-      app: %s
-  template:
-    metadata:
-      labels:
-        app: %s
-        version: %s
-    spec:
-      containers:
-      - name: %s
-        image: %s
-        ports:
-        - containerPort: %d
-        env:%s%s%s%s
-`,
-		config.AppName,
-		namespace,
-		config.AppName,
-		config.Tag,
-		replicas,
-		config.AppName,
-		config.AppName,
-		config.Tag,
-		config.AppName,
-		imageName,
-		port,
-		envVars.String(),
-		configVars.String(),
-		resources.String(),
-		healthChecks.String(),
-	)
-
-	return yaml, nil
+			container.ReadinessProbe = buildHTTPProbe(config.HealthCheck.ReadinessPath, probePort, config.HealthCheck.Readiness, 5, 5)
+		}
+	}
+
+	// Claimed per replica volumes are added to the pods by the StatefulSet
+	var volumes []corev1.Volume
+	for _, volume := range config.Volumes {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+		})
+		if claimedPerReplica(config, &volume) {
+			continue
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: volume.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: PersistentVolumeClaimName(config, &volume),
+				},
+			},
+		})
+	}
+
+	labels := selectorLabels(config)
+	if len(validation.IsValidLabelValue(config.Tag)) == 0 {
+		labels["version"] = config.Tag
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: podAnnotations(config),
+		},
+		Spec: corev1.PodSpec{
+			Containers:       []corev1.Container{container},
+			Volumes:          volumes,
+			NodeSelector:     config.NodeSelector,
+			ImagePullSecrets: imagePullSecrets(config),
+		},
+	}, nil
+}
+
+// BuildService builds the Kubernetes Service for an application
+func (g *DeploymentGenerator) BuildService(config *DeploymentConfig) (*corev1.Service, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+
+	ports := portsOf(config)
+	servicePorts := make([]corev1.ServicePort, 0, len(ports))
+	for _, p := range ports {
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       p.Name,
+			Port:       p.ServicePort,
+			TargetPort: intstr.FromInt32(p.Port),
+			Protocol:   corev1.Protocol(p.Protocol),
+		})
+	}
+
+	serviceType := corev1.ServiceType(config.ServiceType)
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceName(config),
+			Namespace: namespaceOf(config),
+			Labels:    selectorLabels(config),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selectorLabels(config),
+			Ports:    servicePorts,
+			Type:     serviceType,
+		},
+	}, nil
+}
+
+// BuildIngress builds the Kubernetes Ingress for an application, or its canary
+func (g *DeploymentGenerator) BuildIngress(config *DeploymentConfig, domains []string) (*networkingv1.Ingress, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("at least one domain is required")
+	}
+
+	servicePort := portsOf(config)[0].ServicePort
+	pathType := networkingv1.PathTypePrefix
+	rules := make([]networkingv1.IngressRule, 0, len(domains))
+	for _, domain := range domains {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: domain,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: ServiceName(config),
+									Port: networkingv1.ServiceBackendPort{Number: servicePort},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	var annotations map[string]string
+	if config.Canary {
+		annotations = map[string]string{
+			annotationCanary:       "true",
+			annotationCanaryWeight: fmt.Sprintf("%d", canaryWeight(config.Strategy)),
+		}
+	}
+
+	return &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ingressName(config),
+			Namespace:   namespaceOf(config),
+			Labels:      selectorLabels(config),
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: rules,
+		},
+	}, nil
+}
+
+// BuildConfigMap builds the Kubernetes ConfigMap for an application, or nil if it has no config
+func (g *DeploymentGenerator) BuildConfigMap(config *DeploymentConfig) (*corev1.ConfigMap, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if len(config.Config) == 0 {
+		return nil, nil // No config map needed if no config
+	}
+
+	data := make(map[string]string, len(config.Config))
+	for key, value := range config.Config {
+		data[key] = value
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-config", config.AppName),
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Data: data,
+	}, nil
+}
+
+// BuildSecret builds the Opaque Secret holding an application's secret values, or nil if it has none
+func (g *DeploymentGenerator) BuildSecret(config *DeploymentConfig) (*corev1.Secret, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if len(config.Secrets) == 0 {
+		return nil, nil // No secret needed if no secret values
+	}
+
+	data := make(map[string][]byte, len(config.Secrets))
+	for key, value := range config.Secrets {
+		data[key] = []byte(value)
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName(config),
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, nil
+}
+
+// BuildPullSecret builds the kubernetes.io/dockerconfigjson Secret pods pull an application's
+// image with, or nil if its registry needs no credentials
+func (g *DeploymentGenerator) BuildPullSecret(config *DeploymentConfig) (*corev1.Secret, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if len(config.RegistryAuths) == 0 {
+		return nil, nil // Images are pulled anonymously
+	}
+
+	dockerConfig, err := dockerConfigJSON(config.RegistryAuths)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pullSecretName(config),
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+	}, nil
+}
+
+// GenerateDeployment generates a Kubernetes Deployment YAML
+func (g *DeploymentGenerator) GenerateDeployment(config *DeploymentConfig) (string, error) {
+	deployment, err := g.BuildDeployment(config)
+	if err != nil {
+		return "", err
+	}
+	return MarshalManifest(deployment)
+}
+
+// GenerateStatefulSet generates a Kubernetes StatefulSet YAML
+func (g *DeploymentGenerator) GenerateStatefulSet(config *DeploymentConfig) (string, error) {
+	statefulSet, err := g.BuildStatefulSet(config)
+	if err != nil {
+		return "", err
+	}
+	return MarshalManifest(statefulSet)
+}
+
+// GeneratePersistentVolumeClaim generates a Kubernetes PersistentVolumeClaim YAML, or "" for a
+// volume claimed per replica
+func (g *DeploymentGenerator) GeneratePersistentVolumeClaim(config *DeploymentConfig, volume *VolumeConfig) (string, error) {
+	claim, err := g.BuildPersistentVolumeClaim(config, volume)
+	if err != nil || claim == nil {
+		return "", err
+	}
+	return MarshalManifest(claim)
 }
 
 // GenerateService generates a Kubernetes Service YAML
 func (g *DeploymentGenerator) GenerateService(config *DeploymentConfig) (string, error) {
+	service, err := g.BuildService(config)
+	if err != nil {
+		return "", err
+	}
+	return MarshalManifest(service)
+}
+
+// BuildHorizontalPodAutoscaler builds the HorizontalPodAutoscaler that scales an application's
+// Deployment, or nil if the application is not autoscaled
+func (g *DeploymentGenerator) BuildHorizontalPodAutoscaler(config *DeploymentConfig) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
+	}
+	if !Autoscaled(config) {
+		return nil, nil
+	}
+	autoscaling := config.Autoscaling
+
+	var metrics []autoscalingv2.MetricSpec
+	utilization := []struct {
+		resource corev1.ResourceName
+		target   int32
+	}{
+		{corev1.ResourceCPU, autoscaling.TargetCPUUtilization},
+		{corev1.ResourceMemory, autoscaling.TargetMemoryUtilization},
+	}
+	for _, u := range utilization {
+		if u.target == 0 {
+			continue
+		}
+		target := u.target
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: u.resource,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &target,
+				},
+			},
+		})
+	}
+	for _, metric := range autoscaling.Metrics {
+		value, err := resource.ParseQuantity(metric.TargetAverageValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target of metric %s %q: %w", metric.Name, metric.TargetAverageValue, err)
+		}
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: metric.Name},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: &value,
+				},
+			},
+		})
+	}
+
+	minReplicas := autoscaling.MinReplicas
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      HorizontalPodAutoscalerName(config),
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       DeploymentName(config),
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}, nil
+}
+
+// GenerateHorizontalPodAutoscaler generates a Kubernetes HorizontalPodAutoscaler YAML, or "" if
+// the application is not autoscaled
+func (g *DeploymentGenerator) GenerateHorizontalPodAutoscaler(config *DeploymentConfig) (string, error) {
+	hpa, err := g.BuildHorizontalPodAutoscaler(config)
+	if err != nil || hpa == nil {
+		return "", err
+	}
+	return MarshalManifest(hpa)
+}
+
+// BuildResourceQuota builds the ResourceQuota of an application's namespace, or nil if its
+// organization sets no quota
+func (g *DeploymentGenerator) BuildResourceQuota(config *DeploymentConfig) (*corev1.ResourceQuota, error) {
 	if config.AppName == "" {
-		return "", fmt.Errorf("app name is required")
+		return nil, fmt.Errorf("app name is required")
+	}
+	if config.Isolation == nil || len(config.Isolation.ResourceQuota) == 0 {
+		return nil, nil
 	}
 
-	namespace := config.Namespace
-	if namespace == "" {
-		namespace = "default"
+	hard, err := resourceList(config.Isolation.ResourceQuota)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource quota: %w", err)
 	}
 
-	port := config.Port
-	if port == 0 {
-		port = 8080
+	return &corev1.ResourceQuota{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ResourceQuota"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceQuotaName,
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Spec: corev1.ResourceQuotaSpec{Hard: hard},
+	}, nil
+}
+
+// BuildLimitRange builds the LimitRange of the containers of an application's namespace, or nil
+// if its organization sets no limits
+func (g *DeploymentGenerator) BuildLimitRange(config *DeploymentConfig) (*corev1.LimitRange, error) {
+	if config.AppName == "" {
+		return nil, fmt.Errorf("app name is required")
 	}
+	if config.Isolation == nil || config.Isolation.LimitRange == nil {
+		return nil, nil
+	}
+	limits := config.Isolation.LimitRange
 
-	yaml := fmt.Sprintf(`apiVersion: v1
-kind: Service
-metadata:
-  name: %s-service
-  namespace: %s
-  labels:
-    app: %s
-spec:
-  selector:
-    app: %s
-  ports:
-  - port: 80
-    targetPort: %d
-    protocol: TCP
-  type: ClusterIP
-`,
-		config.AppName,
-		namespace,
-		config.AppName,
-		config.AppName,
-		port,
-	)
+	item := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+	fields := []struct {
+		name   string
+		values map[string]string
+		list   *corev1.ResourceList
+	}{
+		{"default", limits.Default, &item.Default},
+		{"default request", limits.DefaultRequest, &item.DefaultRequest},
+		{"min", limits.Min, &item.Min},
+		{"max", limits.Max, &item.Max},
+	}
+	for _, field := range fields {
+		list, err := resourceList(field.values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit range %s: %w", field.name, err)
+		}
+		*field.list = list
+	}
 
-	return yaml, nil
+	return &corev1.LimitRange{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "LimitRange"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LimitRangeName,
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+	}, nil
 }
 
-// GenerateIngress generates a Kubernetes Ingress YAML
-func (g *DeploymentGenerator) GenerateIngress(config *DeploymentConfig, domains []string) (string, error) {
+// BuildNetworkPolicy builds the NetworkPolicy isolating an application's namespace, or nil
+func (g *DeploymentGenerator) BuildNetworkPolicy(config *DeploymentConfig) (*networkingv1.NetworkPolicy, error) {
 	if config.AppName == "" {
-		return "", fmt.Errorf("app name is required")
+		return nil, fmt.Errorf("app name is required")
 	}
-	if len(domains) == 0 {
-		return "", fmt.Errorf("at least one domain is required")
+	if config.Isolation == nil {
+		return nil, nil
 	}
 
-	namespace := config.Namespace
-	if namespace == "" {
-		namespace = "default"
+	// The namespace's pods are the application's processes and its infrastructure services
+	rules := []networkingv1.NetworkPolicyIngressRule{{
+		From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+	}}
+	if len(config.Isolation.IngressNamespaces) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      corev1.LabelMetadataName,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   config.Isolation.IngressNamespaces,
+					}},
+				},
+			}},
+		})
 	}
 
-	var hosts strings.Builder
-	var paths strings.Builder
-	for i, domain := range domains {
-		hosts.WriteString(fmt.Sprintf("    - host: %s\n", domain))
-		if i == 0 {
-			paths.WriteString(fmt.Sprintf("      http:\n        paths:\n        - path: /\n          pathType: Prefix\n          backend:\n            service:\n              name: %s-service\n              port:\n                number: 80\n", config.AppName))
+	serviceType := corev1.ServiceType(config.ServiceType)
+	if serviceType == corev1.ServiceTypeNodePort || serviceType == corev1.ServiceTypeLoadBalancer {
+		var ports []networkingv1.NetworkPolicyPort
+		for _, p := range portsOf(config) {
+			port := intstr.FromInt32(p.Port)
+			protocol := corev1.Protocol(p.Protocol)
+			ports = append(ports, networkingv1.NetworkPolicyPort{Port: &port, Protocol: &protocol})
 		}
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{Ports: ports})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      NetworkPolicyName,
+			Namespace: namespaceOf(config),
+			Labels:    appLabels(config),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{}, // Every pod of the namespace
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     rules,
+		},
+	}, nil
+}
+
+// GenerateResourceQuota generates a Kubernetes ResourceQuota YAML, or "" if there is no quota
+func (g *DeploymentGenerator) GenerateResourceQuota(config *DeploymentConfig) (string, error) {
+	quota, err := g.BuildResourceQuota(config)
+	if err != nil || quota == nil {
+		return "", err
+	}
+	return MarshalManifest(quota)
+}
+
+// GenerateLimitRange generates a Kubernetes LimitRange YAML, or "" if there are no limits
+func (g *DeploymentGenerator) GenerateLimitRange(config *DeploymentConfig) (string, error) {
+	limitRange, err := g.BuildLimitRange(config)
+	if err != nil || limitRange == nil {
+		return "", err
+	}
+	return MarshalManifest(limitRange)
+}
+
+// GenerateNetworkPolicy generates a Kubernetes NetworkPolicy YAML, or "" if the namespace is not
+// isolated
+func (g *DeploymentGenerator) GenerateNetworkPolicy(config *DeploymentConfig) (string, error) {
+	policy, err := g.BuildNetworkPolicy(config)
+	if err != nil || policy == nil {
+		return "", err
 	}
+	return MarshalManifest(policy)
+}
 
-	yaml := fmt.Sprintf(`apiVersion: networking.k8s.io/v1
-kind: Ingress
-metadata:
-  name: %s-ingress
-  namespace: %s
-  labels:
-    app: %s
-spec:
-  rules:%s%s
-`,
-		config.AppName,
-		namespace,
-		config.AppName,
-		hosts.String(),
-		paths.String(),
-	)
+// GenerateCronJob generates a Kubernetes CronJob YAML
+func (g *DeploymentGenerator) GenerateCronJob(config *DeploymentConfig) (string, error) {
+	cronJob, err := g.BuildCronJob(config)
+	if err != nil {
+		return "", err
+	}
+	return MarshalManifest(cronJob)
+}
 
-	return yaml, nil
+// GenerateIngress generates a Kubernetes Ingress YAML
+func (g *DeploymentGenerator) GenerateIngress(config *DeploymentConfig, domains []string) (string, error) {
+	ingress, err := g.BuildIngress(config, domains)
+	if err != nil {
+		return "", err
+	}
+	return MarshalManifest(ingress)
 }
 
 // GenerateConfigMap generates a Kubernetes ConfigMap YAML
 func (g *DeploymentGenerator) GenerateConfigMap(config *DeploymentConfig) (string, error) {
-	if config.AppName == "" {
-		return "", fmt.Errorf("app name is required")
+	configMap, err := g.BuildConfigMap(config)
+	if err != nil || configMap == nil {
+		return "", err
 	}
-	if len(config.Config) == 0 {
-		return "", nil // No config map needed if no config
+	return MarshalManifest(configMap)
+}
+
+// GenerateSecret generates a Kubernetes Secret YAML
+func (g *DeploymentGenerator) GenerateSecret(config *DeploymentConfig) (string, error) {
+	secret, err := g.BuildSecret(config)
+	if err != nil || secret == nil {
+		return "", err
 	}
+	return MarshalManifest(secret)
+}
 
-	namespace := config.Namespace
-	if namespace == "" {
-		namespace = "default"
+// GeneratePullSecret generates a Kubernetes image pull Secret YAML
+func (g *DeploymentGenerator) GeneratePullSecret(config *DeploymentConfig) (string, error) {
+	secret, err := g.BuildPullSecret(config)
+	if err != nil || secret == nil {
+		return "", err
 	}
+	return MarshalManifest(secret)
+}
 
-	var data strings.Builder
-	for key, value := range config.Config {
-		data.WriteString(fmt.Sprintf("  %s: \"%s\"\n", key, value))
+// MarshalManifest serializes a typed Kubernetes object to YAML with sorted keys
+func MarshalManifest(obj runtime.Object) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %T: %w", obj, err)
 	}
 
-	yaml := fmt.Sprintf(`apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: %s-config
-  namespace: %s
-  labels:
-    app: %s
-data:
-%s
-`,
-		config.AppName,
-		namespace,
-		config.AppName,
-		data.String(),
-	)
+	delete(content, "status")
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "spec", "template", "metadata", "creationTimestamp")
 
-	return yaml, nil
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %T: %w", obj, err)
+	}
+	return string(out), nil
+}
+
+// appLabels returns the labels that identify an application's objects and pods
+func appLabels(config *DeploymentConfig) map[string]string {
+	return map[string]string{"app": config.AppName}
 }
 
-// GenerateFromApplication creates deployment configuration from application and release
+// VolumeClaimLabels returns the labels of an application's PersistentVolumeClaims, both its own
+// and the ones its StatefulSet claims for each replica
+func VolumeClaimLabels(appName string) map[string]string {
+	return map[string]string{"app": appName}
+}
+
+// selectorLabels returns the labels that select the pods of the Deployment being generated
+func selectorLabels(config *DeploymentConfig) map[string]string {
+	if config.Process != nil {
+		return map[string]string{"app": DeploymentName(config)}
+	}
+	if config.Canary {
+		return map[string]string{"app": fmt.Sprintf("%s-canary", config.AppName)}
+	}
+	labels := appLabels(config)
+	if config.Slot != "" {
+		labels[LabelSlot] = config.Slot
+	}
+	return labels
+}
+
+// imageRef returns the container image of the Deployment being generated, pinned to its digest
+// when one was resolved so every rollout and rollback of a release runs the same bits
+func imageRef(config *DeploymentConfig) string {
+	if config.ImageDigest != "" {
+		return fmt.Sprintf("%s@%s", config.Image, config.ImageDigest)
+	}
+	return fmt.Sprintf("%s:%s", config.Image, config.Tag)
+}
+
+// DeploymentName returns the name of the Deployment being generated, or of the CronJob of a
+// cron process
+func DeploymentName(config *DeploymentConfig) string {
+	switch {
+	case config.Process != nil:
+		return fmt.Sprintf("%s-%s", config.AppName, config.Process.Name)
+	case config.Canary:
+		return fmt.Sprintf("%s-canary", config.AppName)
+	case config.Slot != "":
+		return fmt.Sprintf("%s-%s", config.AppName, config.Slot)
+	default:
+		return config.AppName
+	}
+}
+
+// HorizontalPodAutoscalerName returns the name of an application's HorizontalPodAutoscaler
+func HorizontalPodAutoscalerName(config *DeploymentConfig) string {
+	return fmt.Sprintf("%s-hpa", config.AppName)
+}
+
+// Autoscaled reports whether the Deployment being generated is scaled by a
+// HorizontalPodAutoscaler, and so leaves its replicas out
+func Autoscaled(config *DeploymentConfig) bool {
+	return config.Autoscaling != nil && !config.Canary
+}
+
+// AutoscaledDeployment reports whether an object is the Deployment being generated and leaves
+// its replicas to the HorizontalPodAutoscaler, to be applied with Applier.ApplyAutoscaled
+func AutoscaledDeployment(config *DeploymentConfig, obj *unstructured.Unstructured) bool {
+	if !Autoscaled(config) || obj.GetKind() != "Deployment" || obj.GetName() != DeploymentName(config) {
+		return false
+	}
+	_, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas")
+	return !found
+}
+
+// Stateful reports whether the application runs as a StatefulSet rather than a Deployment
+func Stateful(config *DeploymentConfig) bool {
+	if config.Process != nil || config.Canary || config.Slot != "" || config.Replicas <= 1 {
+		return false
+	}
+	return mountsReadWriteOnce(config)
+}
+
+// mountsReadWriteOnce reports whether the pods being generated mount a ReadWriteOnce volume
+func mountsReadWriteOnce(config *DeploymentConfig) bool {
+	for _, volume := range config.Volumes {
+		if volume.AccessMode == domain.VolumeAccessReadWriteOnce {
+			return true
+		}
+	}
+	return false
+}
+
+// claimedPerReplica reports whether every replica claims a volume of its own, from the
+// StatefulSet's claim templates, rather than mounting a shared PersistentVolumeClaim
+func claimedPerReplica(config *DeploymentConfig, volume *VolumeConfig) bool {
+	return volume.AccessMode == domain.VolumeAccessReadWriteOnce && Stateful(config)
+}
+
+// PersistentVolumeClaimName returns the name of the PersistentVolumeClaim of a shared volume
+func PersistentVolumeClaimName(config *DeploymentConfig, volume *VolumeConfig) string {
+	return fmt.Sprintf("%s-%s", config.AppName, volume.Name)
+}
+
+// ServiceName returns the name of the Service being generated
+func ServiceName(config *DeploymentConfig) string {
+	if config.Process != nil {
+		return fmt.Sprintf("%s-service", DeploymentName(config))
+	}
+	if config.Canary {
+		return fmt.Sprintf("%s-canary-service", config.AppName)
+	}
+	return fmt.Sprintf("%s-service", config.AppName)
+}
+
+// ProcessDeploymentConfig returns the configuration that generates the objects of a process
+func ProcessDeploymentConfig(config *DeploymentConfig, process *ProcessConfig) *DeploymentConfig {
+	processConfig := *config
+	processConfig.Process = process
+	processConfig.Processes = nil
+	processConfig.Command = process.Command
+	processConfig.Args = process.Args
+	processConfig.Replicas = process.Replicas
+	processConfig.Resources = process.Resources
+	processConfig.Ports = process.Ports
+	processConfig.Port = 0
+	processConfig.ServiceType = ""
+	processConfig.HealthCheck = nil
+	processConfig.Autoscaling = nil
+	processConfig.Slot = ""
+	processConfig.Canary = false
+	processConfig.Volumes = nil
+	return &processConfig
+}
+
+// ProcessDeploymentNames returns the names of the Deployments of web and worker processes
+func ProcessDeploymentNames(config *DeploymentConfig) []string {
+	if config.Canary {
+		return nil
+	}
+	var names []string
+	for i := range config.Processes {
+		process := &config.Processes[i]
+		if process.Type != domain.ProcessTypeCron {
+			names = append(names, DeploymentName(ProcessDeploymentConfig(config, process)))
+		}
+	}
+	return names
+}
+
+// serves reports whether the pods being generated serve traffic
+func serves(config *DeploymentConfig) bool {
+	return config.Process == nil || config.Process.Type == domain.ProcessTypeWeb
+}
+
+// ingressName returns the name of the Ingress being generated
+func ingressName(config *DeploymentConfig) string {
+	if config.Canary {
+		return fmt.Sprintf("%s-canary-ingress", config.AppName)
+	}
+	return fmt.Sprintf("%s-ingress", config.AppName)
+}
+
+// ActiveSlot returns the blue/green slot an application's Service routes to, or "" if it does
+// not route to a slot
+func ActiveSlot(service *corev1.Service) string {
+	return service.Spec.Selector[LabelSlot]
+}
+
+// NextSlot returns the slot a release is deployed to when the active slot is serving traffic
+func NextSlot(active string) string {
+	if active == SlotBlue {
+		return SlotGreen
+	}
+	return SlotBlue
+}
+
+// namespaceOf returns the namespace the application is deployed to
+func namespaceOf(config *DeploymentConfig) string {
+	if config.Namespace == "" {
+		return "default"
+	}
+	return config.Namespace
+}
+
+// portsOf returns the application's ports with defaults filled in. There is always at least one.
+func portsOf(config *DeploymentConfig) []PortConfig {
+	if len(config.Ports) == 0 {
+		port := config.Port
+		if port == 0 {
+			port = 8080
+		}
+		return []PortConfig{{Port: port, Protocol: "TCP", ServicePort: 80}}
+	}
+
+	ports := make([]PortConfig, 0, len(config.Ports))
+	for _, p := range config.Ports {
+		if p.Protocol == "" {
+			p.Protocol = "TCP"
+		}
+		if p.ServicePort == 0 {
+			p.ServicePort = p.Port
+		}
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// secretName returns the name of the application's Secret
+func secretName(config *DeploymentConfig) string {
+	return fmt.Sprintf("%s-secrets", config.AppName)
+}
+
+// pullSecretName returns the name of the application's image pull Secret
+func pullSecretName(config *DeploymentConfig) string {
+	return fmt.Sprintf("%s-registry", config.AppName)
+}
+
+// imagePullSecrets returns the image pull Secrets of an application's pods
+func imagePullSecrets(config *DeploymentConfig) []corev1.LocalObjectReference {
+	if len(config.RegistryAuths) == 0 {
+		return nil
+	}
+	return []corev1.LocalObjectReference{{Name: pullSecretName(config)}}
+}
+
+// dockerHubConfigKey is the key Docker Hub credentials are stored under in Docker config files
+const dockerHubConfigKey = "https://index.docker.io/v1/"
+
+// dockerConfigJSON returns the Docker config file holding registry credentials, in the format
+// `docker login` writes and the kubelet reads from image pull Secrets
+func dockerConfigJSON(auths map[string]RegistryAuth) ([]byte, error) {
+	type dockerAuth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+
+	config := struct {
+		Auths map[string]dockerAuth `json:"auths"`
+	}{Auths: make(map[string]dockerAuth, len(auths))}
+	for host, auth := range auths {
+		key := host
+		if host == domain.DefaultRegistry {
+			key = dockerHubConfigKey
+		}
+		config.Auths[key] = dockerAuth{
+			Username: auth.Username,
+			Password: auth.Password,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password)),
+		}
+	}
+
+	// Map keys are marshalled sorted, so equal credentials always produce an identical Secret
+	return json.Marshal(config)
+}
+
+// SecretsChecksumAnnotation is the pod template annotation holding a checksum of secret values
+const SecretsChecksumAnnotation = "oneclick.io/secrets-checksum"
+
+// podAnnotations returns the pod template annotations of an application
+func podAnnotations(config *DeploymentConfig) map[string]string {
+	if len(config.Secrets) == 0 {
+		return nil
+	}
+	return map[string]string{SecretsChecksumAnnotation: secretsChecksum(config.Secrets)}
+}
+
+// secretsChecksum returns a SHA-256 checksum of the secret names and values
+func secretsChecksum(secrets map[string]string) string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		hash.Write([]byte(name))
+		hash.Write([]byte{0})
+		hash.Write([]byte(secrets[name]))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// buildEnv builds the container environment from the environment, config and secret values
+func buildEnv(environment, config map[string]string, secretName string, secrets map[string]string) []corev1.EnvVar {
+	values := make(map[string]string, len(environment)+len(config))
+	for key, value := range config {
+		values[key] = value
+	}
+	for key, value := range environment {
+		values[key] = value
+	}
+	for key := range secrets {
+		delete(values, key)
+	}
+
+	names := make([]string, 0, len(values)+len(secrets))
+	for name := range values {
+		names = append(names, name)
+	}
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]corev1.EnvVar, 0, len(names))
+	for _, name := range names {
+		if _, ok := secrets[name]; ok {
+			env = append(env, corev1.EnvVar{
+				Name: name,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Key:                  name,
+					},
+				},
+			})
+			continue
+		}
+		env = append(env, corev1.EnvVar{Name: name, Value: values[name]})
+	}
+	return env
+}
+
+// buildResources builds container resource requirements, rejecting invalid quantities
+func buildResources(config *ResourceConfig) (corev1.ResourceRequirements, error) {
+	var requirements corev1.ResourceRequirements
+
+	quantities := []struct {
+		list  *corev1.ResourceList
+		name  corev1.ResourceName
+		value string
+	}{
+		{&requirements.Requests, corev1.ResourceCPU, config.CPURequest},
+		{&requirements.Requests, corev1.ResourceMemory, config.MemoryRequest},
+		{&requirements.Limits, corev1.ResourceCPU, config.CPULimit},
+		{&requirements.Limits, corev1.ResourceMemory, config.MemoryLimit},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(q.value)
+		if err != nil {
+			return requirements, fmt.Errorf("invalid %s quantity %q: %w", q.name, q.value, err)
+		}
+		if *q.list == nil {
+			*q.list = corev1.ResourceList{}
+		}
+		(*q.list)[q.name] = quantity
+	}
+
+	return requirements, nil
+}
+
+// resourceList parses quantities by resource name, or returns nil if there are none
+func resourceList(values map[string]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	list := make(corev1.ResourceList, len(values))
+	for name, value := range values {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity %q: %w", name, value, err)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// buildStrategy builds the Deployment strategy, leaving unset parameters to Kubernetes
+func buildStrategy(config *StrategyConfig) appsv1.DeploymentStrategy {
+	var strategy appsv1.DeploymentStrategy
+	if config == nil || config.Type != domain.StrategyRolling {
+		return strategy
+	}
+	if config.MaxSurge == "" && config.MaxUnavailable == "" {
+		return strategy
+	}
+
+	rollingUpdate := &appsv1.RollingUpdateDeployment{}
+	if config.MaxSurge != "" {
+		maxSurge := intstr.Parse(config.MaxSurge)
+		rollingUpdate.MaxSurge = &maxSurge
+	}
+	if config.MaxUnavailable != "" {
+		maxUnavailable := intstr.Parse(config.MaxUnavailable)
+		rollingUpdate.MaxUnavailable = &maxUnavailable
+	}
+
+	strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	strategy.RollingUpdate = rollingUpdate
+	return strategy
+}
+
+// deploymentStrategy returns the update strategy of the Deployment being generated
+func deploymentStrategy(config *DeploymentConfig) appsv1.DeploymentStrategy {
+	if mountsReadWriteOnce(config) {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	return buildStrategy(config.Strategy)
+}
+
+// canaryWeight returns the percentage of traffic sent to a canary
+func canaryWeight(config *StrategyConfig) int32 {
+	if config == nil || config.CanaryWeight == 0 {
+		return domain.DefaultCanaryWeight
+	}
+	return config.CanaryWeight
+}
+
+// buildHTTPProbe builds an HTTP GET probe. Without settings the default timings are used.
+func buildHTTPProbe(path string, port int32, settings *ProbeSettings, initialDelaySeconds, periodSeconds int32) *corev1.Probe {
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromInt32(port),
+			},
+		},
+		InitialDelaySeconds: initialDelaySeconds,
+		PeriodSeconds:       periodSeconds,
+	}
+
+	if settings != nil {
+		if settings.Port != 0 {
+			probe.HTTPGet.Port = intstr.FromInt32(settings.Port)
+		}
+		probe.InitialDelaySeconds = settings.InitialDelaySeconds
+		probe.PeriodSeconds = settings.PeriodSeconds
+		probe.TimeoutSeconds = settings.TimeoutSeconds
+		probe.FailureThreshold = settings.FailureThreshold
+	}
+
+	return probe
+}
+
+// GenerateFromApplication creates deployment configuration from application and release using
+// the default deployment spec
 func (g *DeploymentGenerator) GenerateFromApplication(app *domain.Application, release *domain.Release, meta *domain.ReleaseMeta) *DeploymentConfig {
+	spec := domain.DefaultDeploymentSpec()
+	return g.GenerateFromSpec(app, release, meta, &spec)
+}
+
+// GenerateFromSpec creates deployment configuration from application, release and the
+// application's deployment spec
+func (g *DeploymentGenerator) GenerateFromSpec(app *domain.Application, release *domain.Release, meta *domain.ReleaseMeta, spec *domain.DeploymentSpec) *DeploymentConfig {
+	withDefaults := spec.WithDefaults()
+	spec = &withDefaults
+
 	config := &DeploymentConfig{
-		AppName:   app.Name,
-		Namespace: app.Name, // Use app name as namespace
-		Image:     release.Image,
-		Tag:       release.Tag,
-		Replicas:  1,
-		Port:      8080,
+		AppName:      app.Name,
+		Namespace:    app.Namespace,
+		Image:        release.Image,
+		Tag:          release.Tag,
+		ImageDigest:  release.ImageDigest,
+		Replicas:     spec.Replicas,
+		Command:      spec.Command,
+		Args:         spec.Args,
+		ServiceType:  spec.ServiceType,
+		NodeSelector: spec.NodeSelector,
 	}
 
 	if meta != nil {
@@ -313,34 +1535,185 @@ func (g *DeploymentGenerator) GenerateFromApplication(app *domain.Application, r
 		config.Config = meta.Config
 	}
 
-	// Set default health check paths
-	config.HealthCheck = &HealthCheckConfig{
-		LivenessPath:  "/health",
-		ReadinessPath: "/ready",
-		Port:          8080,
+	for _, port := range spec.Ports {
+		config.Ports = append(config.Ports, PortConfig{
+			Name:        port.Name,
+			Port:        port.Port,
+			Protocol:    port.Protocol,
+			ServicePort: port.ServicePort,
+		})
+	}
+	if len(config.Ports) > 0 {
+		config.Port = config.Ports[0].Port
+	}
+
+	if spec.LivenessProbe != nil || spec.ReadinessProbe != nil {
+		config.HealthCheck = &HealthCheckConfig{Port: config.Port}
+		if probe := spec.LivenessProbe; probe != nil {
+			config.HealthCheck.LivenessPath = probe.Path
+			config.HealthCheck.Liveness = probeSettings(probe)
+		}
+		if probe := spec.ReadinessProbe; probe != nil {
+			config.HealthCheck.ReadinessPath = probe.Path
+			config.HealthCheck.Readiness = probeSettings(probe)
+		}
+	}
+
+	if spec.Resources != nil {
+		config.Resources = &ResourceConfig{
+			CPURequest:    spec.Resources.CPURequest,
+			CPULimit:      spec.Resources.CPULimit,
+			MemoryRequest: spec.Resources.MemoryRequest,
+			MemoryLimit:   spec.Resources.MemoryLimit,
+		}
+	}
+
+	if spec.Strategy != nil {
+		config.Strategy = &StrategyConfig{
+			Type:           spec.Strategy.Type,
+			MaxSurge:       spec.Strategy.MaxSurge,
+			MaxUnavailable: spec.Strategy.MaxUnavailable,
+			CanaryWeight:   spec.Strategy.CanaryWeight,
+		}
+	}
+
+	if spec.Rollout != nil {
+		config.MinReadySeconds = spec.Rollout.MinReadySeconds
+	}
+
+	if spec.Autoscaling != nil {
+		config.Autoscaling = &AutoscalingConfig{
+			MinReplicas:             spec.Autoscaling.MinReplicas,
+			MaxReplicas:             spec.Autoscaling.MaxReplicas,
+			TargetCPUUtilization:    spec.Autoscaling.TargetCPUUtilization,
+			TargetMemoryUtilization: spec.Autoscaling.TargetMemoryUtilization,
+		}
+		for _, metric := range spec.Autoscaling.Metrics {
+			config.Autoscaling.Metrics = append(config.Autoscaling.Metrics, CustomMetricConfig{
+				Name:               metric.Name,
+				TargetAverageValue: metric.TargetAverageValue,
+			})
+		}
+	}
+
+	for _, process := range spec.Processes {
+		processConfig := ProcessConfig{
+			Name:     process.Name,
+			Type:     process.Type,
+			Command:  process.Command,
+			Args:     process.Args,
+			Replicas: process.Replicas,
+			Schedule: process.Schedule,
+		}
+		for _, port := range process.Ports {
+			processConfig.Ports = append(processConfig.Ports, PortConfig{
+				Name:        port.Name,
+				Port:        port.Port,
+				Protocol:    port.Protocol,
+				ServicePort: port.ServicePort,
+			})
+		}
+		if process.Resources != nil {
+			processConfig.Resources = &ResourceConfig{
+				CPURequest:    process.Resources.CPURequest,
+				CPULimit:      process.Resources.CPULimit,
+				MemoryRequest: process.Resources.MemoryRequest,
+				MemoryLimit:   process.Resources.MemoryLimit,
+			}
+		}
+		config.Processes = append(config.Processes, processConfig)
+	}
+
+	for _, volume := range spec.Volumes {
+		config.Volumes = append(config.Volumes, VolumeConfig{
+			Name:         volume.Name,
+			Size:         volume.Size,
+			StorageClass: volume.StorageClass,
+			AccessMode:   volume.AccessMode,
+			MountPath:    volume.MountPath,
+		})
 	}
 
-	// Set default resources
-	config.Resources = &ResourceConfig{
-		CPURequest:    "100m",
-		CPULimit:      "500m",
-		MemoryRequest: "128Mi",
-		MemoryLimit:   "512Mi",
+	if spec.SourceType() != domain.SourceGenerated {
+		config.Source = &SourceConfig{
+			Type:        spec.Source.Type,
+			Containers:  spec.Source.Containers,
+			Values:      spec.Source.Values,
+			ValuesFiles: spec.Source.ValuesFiles,
+		}
+		if app.Path != nil {
+			config.Source.Path = *app.Path
+		}
 	}
 
 	return config
 }
 
-// GenerateAllManifests generates all Kubernetes manifests for an application
+// ApplyEnvironment points a deployment configuration at an environment
+func (g *DeploymentGenerator) ApplyEnvironment(config *DeploymentConfig, env *domain.Environment) {
+	config.Namespace = env.Namespace
+	if env.Replicas != nil {
+		config.Replicas = *env.Replicas
+	}
+
+	if len(env.EnvVars) > 0 {
+		environment := make(map[string]string, len(config.Environment)+len(env.EnvVars))
+		for key, value := range config.Environment {
+			environment[key] = value
+		}
+		for key, value := range env.EnvVars {
+			environment[key] = value
+		}
+		config.Environment = environment
+	}
+}
+
+// probeSettings converts a probe spec to probe settings
+func probeSettings(probe *domain.ProbeSpec) *ProbeSettings {
+	return &ProbeSettings{
+		Port:                probe.Port,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+}
+
+// GenerateAllManifests generates all Kubernetes manifests for an application, or for its canary
 func (g *DeploymentGenerator) GenerateAllManifests(config *DeploymentConfig, domains []string) (map[string]string, error) {
 	manifests := make(map[string]string)
 
-	// Generate Deployment
-	deployment, err := g.GenerateDeployment(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate deployment: %w", err)
+	// Generate the isolation of the namespace
+	if err := g.generateIsolationManifests(config, manifests); err != nil {
+		return nil, err
+	}
+
+	// Generate the Deployment, or the StatefulSet of replicas with volumes of their own
+	if Stateful(config) {
+		statefulSet, err := g.GenerateStatefulSet(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate statefulset: %w", err)
+		}
+		manifests["statefulset.yaml"] = statefulSet
+	} else {
+		deployment, err := g.GenerateDeployment(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate deployment: %w", err)
+		}
+		manifests["deployment.yaml"] = deployment
+	}
+
+	// Generate PersistentVolumeClaims of shared volumes
+	for i := range config.Volumes {
+		volume := &config.Volumes[i]
+		claim, err := g.GeneratePersistentVolumeClaim(config, volume)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate persistent volume claim %s: %w", volume.Name, err)
+		}
+		if claim != "" {
+			manifests[fmt.Sprintf("pvc-%s.yaml", volume.Name)] = claim
+		}
 	}
-	manifests["deployment.yaml"] = deployment
 
 	// Generate Service
 	service, err := g.GenerateService(config)
@@ -349,13 +1722,18 @@ func (g *DeploymentGenerator) GenerateAllManifests(config *DeploymentConfig, dom
 	}
 	manifests["service.yaml"] = service
 
-	// Generate ConfigMap if needed
-	if len(config.Config) > 0 {
-		configMap, err := g.GenerateConfigMap(config)
+	// Generate the ConfigMap, Secret and image pull Secret if needed
+	if err := g.generateConfigManifests(config, manifests); err != nil {
+		return nil, err
+	}
+
+	// Generate HorizontalPodAutoscaler if autoscaling
+	if Autoscaled(config) {
+		hpa, err := g.GenerateHorizontalPodAutoscaler(config)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate configmap: %w", err)
+			return nil, fmt.Errorf("failed to generate horizontal pod autoscaler: %w", err)
 		}
-		manifests["configmap.yaml"] = configMap
+		manifests["hpa.yaml"] = hpa
 	}
 
 	// Generate Ingress if domains provided
@@ -367,5 +1745,152 @@ func (g *DeploymentGenerator) GenerateAllManifests(config *DeploymentConfig, dom
 		manifests["ingress.yaml"] = ingress
 	}
 
+	// Generate the processes' Deployments, Services and CronJobs
+	if !config.Canary {
+		for i := range config.Processes {
+			process := ProcessDeploymentConfig(config, &config.Processes[i])
+			if err := g.generateProcessManifests(process, manifests); err != nil {
+				return nil, fmt.Errorf("failed to generate process %s: %w", process.Process.Name, err)
+			}
+		}
+	}
+
 	return manifests, nil
 }
+
+// generateIsolationManifests adds the ResourceQuota, LimitRange and NetworkPolicy that isolate the
+// application's namespace, if it is isolated
+func (g *DeploymentGenerator) generateIsolationManifests(config *DeploymentConfig, manifests map[string]string) error {
+	if config.Isolation == nil {
+		return nil
+	}
+
+	isolation := []struct {
+		filename string
+		generate func(*DeploymentConfig) (string, error)
+	}{
+		{"resourcequota.yaml", g.GenerateResourceQuota},
+		{"limitrange.yaml", g.GenerateLimitRange},
+		{"networkpolicy.yaml", g.GenerateNetworkPolicy},
+	}
+	for _, object := range isolation {
+		manifest, err := object.generate(config)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", strings.TrimSuffix(object.filename, ".yaml"), err)
+		}
+		if manifest != "" {
+			manifests[object.filename] = manifest
+		}
+	}
+	return nil
+}
+
+// generateConfigManifests adds the ConfigMap of the application's config values, the Secret its
+// pods read their secrets from and the Secret they pull the image with, each only when needed
+func (g *DeploymentGenerator) generateConfigManifests(config *DeploymentConfig, manifests map[string]string) error {
+	if len(config.Config) > 0 {
+		configMap, err := g.GenerateConfigMap(config)
+		if err != nil {
+			return fmt.Errorf("failed to generate configmap: %w", err)
+		}
+		manifests["configmap.yaml"] = configMap
+	}
+
+	if len(config.Secrets) > 0 {
+		secret, err := g.GenerateSecret(config)
+		if err != nil {
+			return fmt.Errorf("failed to generate secret: %w", err)
+		}
+		manifests["secret.yaml"] = secret
+	}
+
+	// The image pull Secret is only needed if the registry needs credentials
+	if len(config.RegistryAuths) > 0 {
+		pullSecret, err := g.GeneratePullSecret(config)
+		if err != nil {
+			return fmt.Errorf("failed to generate image pull secret: %w", err)
+		}
+		manifests["pull-secret.yaml"] = pullSecret
+	}
+
+	return nil
+}
+
+// generateProcessManifests adds the manifests of a process
+func (g *DeploymentGenerator) generateProcessManifests(config *DeploymentConfig, manifests map[string]string) error {
+	name := config.Process.Name
+
+	if config.Process.Type == domain.ProcessTypeCron {
+		cronJob, err := g.GenerateCronJob(config)
+		if err != nil {
+			return err
+		}
+		manifests[fmt.Sprintf("cronjob-%s.yaml", name)] = cronJob
+		return nil
+	}
+
+	deployment, err := g.GenerateDeployment(config)
+	if err != nil {
+		return err
+	}
+	manifests[fmt.Sprintf("deployment-%s.yaml", name)] = deployment
+
+	if serves(config) {
+		service, err := g.GenerateService(config)
+		if err != nil {
+			return err
+		}
+		manifests[fmt.Sprintf("service-%s.yaml", name)] = service
+	}
+
+	return nil
+}
+
+// GenerateObjects generates the objects of an application, labelled and sorted in apply order
+func (g *DeploymentGenerator) GenerateObjects(config *DeploymentConfig, domains []string, appID uuid.UUID) ([]*unstructured.Unstructured, error) {
+	manifests, err := g.GenerateAllManifests(config, domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate manifests: %w", err)
+	}
+
+	objects, err := parseManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	return managedObjects(objects, config.Namespace, appID), nil
+}
+
+// parseManifests parses generated manifests into objects
+func parseManifests(manifests map[string]string) ([]*unstructured.Unstructured, error) {
+	objects := make([]*unstructured.Unstructured, 0, len(manifests))
+	for filename, manifest := range manifests {
+		obj, err := ParseManifest(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// managedObjects places objects without a namespace in the application's namespace, labels them
+// as managed by OneClick for the application and sorts them in apply order
+func managedObjects(objects []*unstructured.Unstructured, namespace string, appID uuid.UUID) []*unstructured.Unstructured {
+	for _, obj := range objects {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+
+		objLabels := obj.GetLabels()
+		if objLabels == nil {
+			objLabels = make(map[string]string)
+		}
+		for key, value := range ManagedLabels(appID) {
+			objLabels[key] = value
+		}
+		obj.SetLabels(objLabels)
+	}
+	SortForApply(objects)
+
+	return objects
+}
@@ -1,10 +1,19 @@
 package deployment
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/PouryDev/oneclick/internal/domain"
 )
@@ -114,6 +123,7 @@ func TestDeploymentGenerator_GenerateFromApplication(t *testing.T) {
 	app := &domain.Application{
 		ID:            uuid.New(),
 		Name:          "test-app",
+		Namespace:     "0f3a9c1e-test-app",
 		DefaultBranch: "main",
 	}
 
@@ -135,7 +145,7 @@ func TestDeploymentGenerator_GenerateFromApplication(t *testing.T) {
 	config := generator.GenerateFromApplication(app, release, meta)
 
 	assert.Equal(t, "test-app", config.AppName)
-	assert.Equal(t, "test-app", config.Namespace)
+	assert.Equal(t, "0f3a9c1e-test-app", config.Namespace)
 	assert.Equal(t, "myapp", config.Image)
 	assert.Equal(t, "v1.0.0", config.Tag)
 	assert.Equal(t, int32(1), config.Replicas)
@@ -209,3 +219,730 @@ func TestDeploymentGenerator_GenerateAllManifests_NoConfig(t *testing.T) {
 	assert.NotContains(t, manifests, "configmap.yaml")
 	assert.NotContains(t, manifests, "ingress.yaml")
 }
+
+func TestDeploymentGenerator_GenerateDeployment_EscapesValues(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	value := "say \"hi\"\nkind: Secret\ncolon: value"
+	config := &DeploymentConfig{
+		AppName: "test-app",
+		Image:   "myapp",
+		Tag:     "latest",
+		Environment: map[string]string{
+			"GREETING": value,
+		},
+	}
+
+	manifest, err := generator.GenerateDeployment(config)
+	require.NoError(t, err)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifest), &deployment))
+	assert.Equal(t, "Deployment", deployment.Kind)
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	require.Len(t, env, 1)
+	assert.Equal(t, "GREETING", env[0].Name)
+	assert.Equal(t, value, env[0].Value)
+}
+
+func TestDeploymentGenerator_GenerateIngress_EveryHostHasBackend(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName: "test-app",
+	}
+
+	manifest, err := generator.GenerateIngress(config, []string{"example.com", "app.example.com", "www.example.com"})
+	require.NoError(t, err)
+
+	var ingress networkingv1.Ingress
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifest), &ingress))
+	require.Len(t, ingress.Spec.Rules, 3)
+	for _, rule := range ingress.Spec.Rules {
+		require.NotNil(t, rule.HTTP, "host %s has no backend", rule.Host)
+		require.Len(t, rule.HTTP.Paths, 1)
+		assert.Equal(t, "test-app-service", rule.HTTP.Paths[0].Backend.Service.Name)
+		assert.Equal(t, int32(80), rule.HTTP.Paths[0].Backend.Service.Port.Number)
+	}
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Deterministic(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName: "test-app",
+		Image:   "myapp",
+		Tag:     "latest",
+		Environment: map[string]string{
+			"B": "2", "A": "1", "C": "3", "D": "4",
+		},
+		Config: map[string]string{
+			"Y": "y", "X": "x", "Z": "z",
+		},
+	}
+
+	first, err := generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, err := generator.GenerateAllManifests(config, []string{"example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, first, again)
+	}
+}
+
+func TestDeploymentGenerator_GenerateDeployment_InvalidResources(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName: "test-app",
+		Image:   "myapp",
+		Tag:     "latest",
+		Resources: &ResourceConfig{
+			CPURequest: "lots",
+		},
+	}
+
+	_, err := generator.GenerateDeployment(config)
+	assert.Error(t, err)
+}
+
+func TestDeploymentGenerator_BuildDeployment_ImageDigest(t *testing.T) {
+	generator := NewDeploymentGenerator()
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	deployment, err := generator.BuildDeployment(&DeploymentConfig{
+		AppName:     "test-app",
+		Image:       "ghcr.io/acme/api",
+		Tag:         "v1.2.0",
+		ImageDigest: digest,
+	})
+	require.NoError(t, err)
+
+	// Pulled by digest, still labeled with the tag
+	assert.Equal(t, "ghcr.io/acme/api@"+digest, deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "v1.2.0", deployment.Spec.Template.Labels["version"])
+}
+
+func TestDeploymentGenerator_GenerateFromSpec(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	app := &domain.Application{ID: uuid.New(), Name: "api"}
+	release := &domain.Release{ID: uuid.New(), Image: "ghcr.io/acme/api", Tag: "v2"}
+	spec := &domain.DeploymentSpec{
+		Replicas: 3,
+		Ports: []domain.PortSpec{
+			{Name: "http", Port: 3000, ServicePort: 80},
+			{Name: "metrics", Port: 9090},
+		},
+		Command:        []string{"/app/server"},
+		Args:           []string{"--listen", ":3000"},
+		ReadinessProbe: &domain.ProbeSpec{Path: "/healthz", PeriodSeconds: 2, FailureThreshold: 5},
+		Resources:      &domain.ResourceSpec{CPURequest: "250m"},
+		ServiceType:    domain.ServiceTypeLoadBalancer,
+		NodeSelector:   map[string]string{"pool": "general"},
+		Rollout:        &domain.RolloutSpec{MinReadySeconds: 15},
+	}
+
+	config := generator.GenerateFromSpec(app, release, nil, spec)
+
+	deployment, err := generator.BuildDeployment(config)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+	assert.Equal(t, int32(15), deployment.Spec.MinReadySeconds)
+	assert.Equal(t, map[string]string{"pool": "general"}, deployment.Spec.Template.Spec.NodeSelector)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"/app/server"}, container.Command)
+	assert.Equal(t, []string{"--listen", ":3000"}, container.Args)
+	require.Len(t, container.Ports, 2)
+	assert.Equal(t, int32(3000), container.Ports[0].ContainerPort)
+	assert.Equal(t, "metrics", container.Ports[1].Name)
+	assert.Nil(t, container.LivenessProbe)
+	require.NotNil(t, container.ReadinessProbe)
+	assert.Equal(t, "/healthz", container.ReadinessProbe.HTTPGet.Path)
+	assert.Equal(t, int32(3000), container.ReadinessProbe.HTTPGet.Port.IntVal)
+	assert.Equal(t, int32(0), container.ReadinessProbe.InitialDelaySeconds)
+	assert.Equal(t, int32(2), container.ReadinessProbe.PeriodSeconds)
+	assert.Equal(t, int32(5), container.ReadinessProbe.FailureThreshold)
+	assert.Equal(t, "250m", container.Resources.Requests.Cpu().String())
+	assert.Nil(t, container.Resources.Limits)
+
+	service, err := generator.BuildService(config)
+	require.NoError(t, err)
+	assert.Equal(t, "LoadBalancer", string(service.Spec.Type))
+	require.Len(t, service.Spec.Ports, 2)
+	assert.Equal(t, int32(80), service.Spec.Ports[0].Port)
+	assert.Equal(t, int32(3000), service.Spec.Ports[0].TargetPort.IntVal)
+	assert.Equal(t, int32(9090), service.Spec.Ports[1].Port)
+
+	ingress, err := generator.BuildIngress(config, []string{"api.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(80), ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number)
+}
+
+func TestDeploymentGenerator_ApplyEnvironment(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	app := &domain.Application{ID: uuid.New(), Name: "api"}
+	release := &domain.Release{ID: uuid.New(), Image: "ghcr.io/acme/api", Tag: "v2"}
+	meta := &domain.ReleaseMeta{Environment: map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"}}
+	spec := &domain.DeploymentSpec{Replicas: 1}
+
+	config := generator.GenerateFromSpec(app, release, meta, spec)
+	replicas := int32(4)
+	generator.ApplyEnvironment(config, &domain.Environment{
+		Name:      "production",
+		Namespace: "api-production",
+		Replicas:  &replicas,
+		EnvVars:   map[string]string{"LOG_LEVEL": "warn"},
+	})
+
+	assert.Equal(t, "api-production", config.Namespace)
+	assert.Equal(t, int32(4), config.Replicas)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "warn", "REGION": "eu"}, config.Environment)
+	assert.Equal(t, "debug", meta.Environment["LOG_LEVEL"], "release metadata is not modified")
+
+	// Without a replica count the spec's applies
+	config = generator.GenerateFromSpec(app, release, meta, spec)
+	generator.ApplyEnvironment(config, &domain.Environment{Name: "staging", Namespace: "api-staging"})
+	assert.Equal(t, int32(1), config.Replicas)
+	assert.Equal(t, meta.Environment, config.Environment)
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Secrets(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:     "test-app",
+		Namespace:   "test-ns",
+		Image:       "myapp",
+		Tag:         "latest",
+		Environment: map[string]string{"DATABASE_URL": "plain", "PORT": "8080"},
+		Secrets:     map[string]string{"DATABASE_URL": "postgres://secret", "API_KEY": "key"},
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	require.Contains(t, manifests, "secret.yaml")
+
+	var secret corev1.Secret
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["secret.yaml"]), &secret))
+	assert.Equal(t, "test-app-secrets", secret.Name)
+	assert.Equal(t, "test-ns", secret.Namespace)
+	assert.Equal(t, corev1.SecretTypeOpaque, secret.Type)
+	assert.Equal(t, []byte("postgres://secret"), secret.Data["DATABASE_URL"])
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.NotContains(t, manifests["deployment.yaml"], "postgres://secret")
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	require.Len(t, env, 3)
+	assert.Equal(t, "API_KEY", env[0].Name)
+	assert.Equal(t, "DATABASE_URL", env[1].Name)
+	assert.Empty(t, env[1].Value)
+	require.NotNil(t, env[1].ValueFrom)
+	assert.Equal(t, "test-app-secrets", env[1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "DATABASE_URL", env[1].ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, corev1.EnvVar{Name: "PORT", Value: "8080"}, env[2])
+
+	checksum := deployment.Spec.Template.Annotations[SecretsChecksumAnnotation]
+	assert.NotEmpty(t, checksum)
+
+	// Changing a secret value changes the pod template, forcing a rollout
+	config.Secrets["API_KEY"] = "rotated"
+	changed, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, yaml.UnmarshalStrict([]byte(changed["deployment.yaml"]), &deployment))
+	assert.NotEqual(t, checksum, deployment.Spec.Template.Annotations[SecretsChecksumAnnotation])
+
+	// Without secrets no Secret is generated
+	config.Secrets = nil
+	manifests, err = generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "secret.yaml")
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_PullSecret(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:   "test-app",
+		Namespace: "test-ns",
+		Image:     "acme/api",
+		Tag:       "v1.0.0",
+		RegistryAuths: map[string]RegistryAuth{
+			"docker.io": {Username: "acme", Password: "dckr_pat"},
+			"ghcr.io":   {Username: "acme-bot", Password: "ghp_token"},
+		},
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	require.Contains(t, manifests, "pull-secret.yaml")
+
+	var secret corev1.Secret
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["pull-secret.yaml"]), &secret))
+	assert.Equal(t, "test-app-registry", secret.Name)
+	assert.Equal(t, "test-ns", secret.Namespace)
+	assert.Equal(t, corev1.SecretTypeDockerConfigJson, secret.Type)
+	assert.JSONEq(t, `{"auths": {
+		"https://index.docker.io/v1/": {"username": "acme", "password": "dckr_pat", "auth": "YWNtZTpkY2tyX3BhdA=="},
+		"ghcr.io": {"username": "acme-bot", "password": "ghp_token", "auth": "YWNtZS1ib3Q6Z2hwX3Rva2Vu"}
+	}}`, string(secret.Data[corev1.DockerConfigJsonKey]))
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "test-app-registry"}}, deployment.Spec.Template.Spec.ImagePullSecrets)
+
+	// Images pulled anonymously have no pull Secret
+	config.RegistryAuths = nil
+	manifests, err = generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "pull-secret.yaml")
+	assert.NotContains(t, manifests["deployment.yaml"], "imagePullSecrets")
+}
+
+func TestDeploymentGenerator_GenerateDeployment_RollingParameters(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:  "test-app",
+		Image:    "myapp",
+		Tag:      "latest",
+		Strategy: &StrategyConfig{Type: domain.StrategyRolling, MaxSurge: "25%", MaxUnavailable: "0"},
+	}
+
+	deployment, err := generator.BuildDeployment(config)
+	require.NoError(t, err)
+	assert.Equal(t, appsv1.RollingUpdateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	require.NotNil(t, deployment.Spec.Strategy.RollingUpdate)
+	assert.Equal(t, "25%", deployment.Spec.Strategy.RollingUpdate.MaxSurge.String())
+	assert.Equal(t, 0, deployment.Spec.Strategy.RollingUpdate.MaxUnavailable.IntValue())
+
+	// Without parameters the Kubernetes defaults are left in place
+	config.Strategy = nil
+	deployment, err = generator.BuildDeployment(config)
+	require.NoError(t, err)
+	assert.Nil(t, deployment.Spec.Strategy.RollingUpdate)
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_BlueGreenSlot(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:  "test-app",
+		Image:    "myapp",
+		Tag:      "v2",
+		Strategy: &StrategyConfig{Type: domain.StrategyBlueGreen},
+		Slot:     SlotGreen,
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.Equal(t, "test-app-green", deployment.Name)
+	assert.Equal(t, map[string]string{"app": "test-app", LabelSlot: SlotGreen}, deployment.Spec.Selector.MatchLabels)
+
+	var service corev1.Service
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["service.yaml"]), &service))
+	assert.Equal(t, "test-app-service", service.Name)
+	assert.Equal(t, SlotGreen, ActiveSlot(&service))
+
+	var ingress networkingv1.Ingress
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["ingress.yaml"]), &ingress))
+	assert.Equal(t, "test-app-service", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+
+	assert.Equal(t, SlotBlue, NextSlot(""))
+	assert.Equal(t, SlotBlue, NextSlot(SlotGreen))
+	assert.Equal(t, SlotGreen, NextSlot(SlotBlue))
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Canary(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:   "test-app",
+		Namespace: "test-ns",
+		Image:     "myapp",
+		Tag:       "v2",
+		Secrets:   map[string]string{"API_KEY": "key"},
+		Strategy:  &StrategyConfig{Type: domain.StrategyCanary, CanaryWeight: 20},
+		Canary:    true,
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.Equal(t, "test-app-canary", deployment.Name)
+	// Canary pods must not match the stable Service's app=test-app selector
+	assert.Equal(t, map[string]string{"app": "test-app-canary"}, deployment.Spec.Selector.MatchLabels)
+	assert.Equal(t, "test-app-secrets", deployment.Spec.Template.Spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name)
+
+	var service corev1.Service
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["service.yaml"]), &service))
+	assert.Equal(t, "test-app-canary-service", service.Name)
+	assert.Equal(t, map[string]string{"app": "test-app-canary"}, service.Spec.Selector)
+
+	var ingress networkingv1.Ingress
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["ingress.yaml"]), &ingress))
+	assert.Equal(t, "test-app-canary-ingress", ingress.Name)
+	assert.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/canary"])
+	assert.Equal(t, "20", ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+	assert.Equal(t, "example.com", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, "test-app-canary-service", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+
+	// The Secret is shared with the stable Deployment
+	var secret corev1.Secret
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["secret.yaml"]), &secret))
+	assert.Equal(t, "test-app-secrets", secret.Name)
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Autoscaling(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:   "test-app",
+		Namespace: "test-ns",
+		Image:     "myapp",
+		Tag:       "v2",
+		Replicas:  3,
+		Slot:      SlotBlue,
+		Autoscaling: &AutoscalingConfig{
+			MinReplicas:          2,
+			MaxReplicas:          8,
+			TargetCPUUtilization: 75,
+			Metrics:              []CustomMetricConfig{{Name: "http_requests_per_second", TargetAverageValue: "250m"}},
+		},
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+
+	// The autoscaler owns the replicas, so redeploys leave them alone
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.Nil(t, deployment.Spec.Replicas)
+	assert.NotContains(t, manifests["deployment.yaml"], "replicas")
+
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["hpa.yaml"]), &hpa))
+	assert.Equal(t, "test-app-hpa", hpa.Name)
+	assert.Equal(t, "test-ns", hpa.Namespace)
+	assert.Equal(t, autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test-app-blue"}, hpa.Spec.ScaleTargetRef)
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(8), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 2)
+	assert.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, int32(75), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Equal(t, "http_requests_per_second", hpa.Spec.Metrics[1].Pods.Metric.Name)
+	assert.Equal(t, "250m", hpa.Spec.Metrics[1].Pods.Target.AverageValue.String())
+
+	// Canaries run the minimum and leave the autoscaler scaling the stable Deployment
+	config.Slot = ""
+	config.Canary = true
+	manifests, err = generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "hpa.yaml")
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deployment))
+	assert.Equal(t, int32(2), *deployment.Spec.Replicas)
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Processes(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:     "test-app",
+		Namespace:   "test-ns",
+		Image:       "myapp",
+		Tag:         "v2",
+		Environment: map[string]string{"ENV": "production"},
+		Secrets:     map[string]string{"API_KEY": "key"},
+		HealthCheck: &HealthCheckConfig{ReadinessPath: "/ready"},
+		Slot:        SlotGreen,
+		Processes: []ProcessConfig{
+			{Name: "admin", Type: domain.ProcessTypeWeb, Command: []string{"./admin"}, Ports: []PortConfig{{Port: 9000}}},
+			{
+				Name:      "queue",
+				Type:      domain.ProcessTypeWorker,
+				Command:   []string{"./consume"},
+				Args:      []string{"--queue", "emails"},
+				Replicas:  3,
+				Resources: &ResourceConfig{MemoryLimit: "1Gi"},
+			},
+			{Name: "cleanup", Type: domain.ProcessTypeCron, Command: []string{"./cleanup"}, Schedule: "*/15 * * * *"},
+		},
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+
+	// Workers run from the release image with the application's env, without ports, probes or a Service
+	var worker appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment-queue.yaml"]), &worker))
+	assert.Equal(t, "test-app-queue", worker.Name)
+	assert.Equal(t, "test-ns", worker.Namespace)
+	assert.Equal(t, int32(3), *worker.Spec.Replicas)
+	assert.Equal(t, map[string]string{"app": "test-app-queue"}, worker.Spec.Selector.MatchLabels)
+	container := worker.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "myapp:v2", container.Image)
+	assert.Equal(t, []string{"./consume"}, container.Command)
+	assert.Equal(t, []string{"--queue", "emails"}, container.Args)
+	assert.Empty(t, container.Ports)
+	assert.Nil(t, container.ReadinessProbe)
+	assert.Equal(t, "1Gi", container.Resources.Limits.Memory().String())
+	assert.Equal(t, "test-app-secrets", container.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, corev1.EnvVar{Name: "ENV", Value: "production"}, container.Env[1])
+	assert.NotContains(t, manifests, "service-queue.yaml")
+
+	// Web processes get a Service of their own; processes are not split into slots
+	var web appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment-admin.yaml"]), &web))
+	assert.Equal(t, "test-app-admin", web.Name)
+	assert.Equal(t, int32(9000), web.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+	var service corev1.Service
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["service-admin.yaml"]), &service))
+	assert.Equal(t, "test-app-admin-service", service.Name)
+	assert.Equal(t, map[string]string{"app": "test-app-admin"}, service.Spec.Selector)
+
+	var cronJob batchv1.CronJob
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["cronjob-cleanup.yaml"]), &cronJob))
+	assert.Equal(t, "test-app-cleanup", cronJob.Name)
+	assert.Equal(t, "*/15 * * * *", cronJob.Spec.Schedule)
+	assert.Equal(t, batchv1.ForbidConcurrent, cronJob.Spec.ConcurrencyPolicy)
+	pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyOnFailure, pod.RestartPolicy)
+	assert.Equal(t, []string{"./cleanup"}, pod.Containers[0].Command)
+	assert.Empty(t, pod.Containers[0].Ports)
+	assert.NotContains(t, manifests, "deployment-cleanup.yaml")
+
+	// The Ingress still routes to the main web process
+	var ingress networkingv1.Ingress
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["ingress.yaml"]), &ingress))
+	assert.Equal(t, "test-app-service", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+
+	assert.Equal(t, []string{"test-app-admin", "test-app-queue"}, ProcessDeploymentNames(config))
+
+	// Canaries leave the processes alone
+	config.Slot = ""
+	config.Canary = true
+	manifests, err = generator.GenerateAllManifests(config, []string{"example.com"})
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "deployment-queue.yaml")
+	assert.NotContains(t, manifests, "cronjob-cleanup.yaml")
+	assert.Empty(t, ProcessDeploymentNames(config))
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Volumes(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:   "test-app",
+		Namespace: "test-ns",
+		Image:     "myapp",
+		Tag:       "v2",
+		Replicas:  1,
+		Volumes: []VolumeConfig{
+			{Name: "data", Size: "10Gi", AccessMode: domain.VolumeAccessReadWriteOnce, MountPath: "/var/lib/data"},
+			{Name: "uploads", Size: "50Gi", StorageClass: "efs-sc", AccessMode: domain.VolumeAccessReadWriteMany, MountPath: "/app/uploads"},
+		},
+		Processes: []ProcessConfig{
+			{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}},
+		},
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+
+	// A single replica mounts every volume from its claim, and is recreated so the
+	// ReadWriteOnce volume is released before the new pod mounts it
+	assert.False(t, Stateful(config))
+	var deploy appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment.yaml"]), &deploy))
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deploy.Spec.Strategy.Type)
+	pod := deploy.Spec.Template.Spec
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "data", MountPath: "/var/lib/data"},
+		{Name: "uploads", MountPath: "/app/uploads"},
+	}, pod.Containers[0].VolumeMounts)
+	require.Len(t, pod.Volumes, 2)
+	assert.Equal(t, "test-app-data", pod.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "test-app-uploads", pod.Volumes[1].PersistentVolumeClaim.ClaimName)
+
+	var claim corev1.PersistentVolumeClaim
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["pvc-uploads.yaml"]), &claim))
+	assert.Equal(t, "test-app-uploads", claim.Name)
+	assert.Equal(t, "test-ns", claim.Namespace)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, claim.Spec.AccessModes)
+	assert.Equal(t, "efs-sc", *claim.Spec.StorageClassName)
+	assert.Equal(t, "50Gi", claim.Spec.Resources.Requests.Storage().String())
+	var defaultClaim corev1.PersistentVolumeClaim
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["pvc-data.yaml"]), &defaultClaim))
+	assert.Nil(t, defaultClaim.Spec.StorageClassName)
+
+	// Processes do not mount the application's volumes
+	var worker appsv1.Deployment
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["deployment-queue.yaml"]), &worker))
+	assert.Empty(t, worker.Spec.Template.Spec.Volumes)
+	assert.Empty(t, worker.Spec.Template.Spec.Containers[0].VolumeMounts)
+	assert.NotEqual(t, appsv1.RecreateDeploymentStrategyType, worker.Spec.Strategy.Type)
+
+	// More than one replica runs as a StatefulSet that gives every replica a ReadWriteOnce
+	// volume of its own, while the ReadWriteMany volume stays shared
+	config.Replicas = 3
+	assert.True(t, Stateful(config))
+	manifests, err = generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "deployment.yaml")
+	assert.NotContains(t, manifests, "pvc-data.yaml")
+	assert.Contains(t, manifests, "pvc-uploads.yaml")
+	assert.Equal(t, []string{"test-app-queue"}, ProcessDeploymentNames(config))
+
+	var statefulSet appsv1.StatefulSet
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["statefulset.yaml"]), &statefulSet))
+	assert.Equal(t, "test-app", statefulSet.Name)
+	assert.Equal(t, int32(3), *statefulSet.Spec.Replicas)
+	assert.Equal(t, "test-app-service", statefulSet.Spec.ServiceName)
+	assert.Equal(t, map[string]string{"app": "test-app"}, statefulSet.Spec.Selector.MatchLabels)
+	require.Len(t, statefulSet.Spec.VolumeClaimTemplates, 1)
+	template := statefulSet.Spec.VolumeClaimTemplates[0]
+	assert.Equal(t, "data", template.Name)
+	assert.Equal(t, "10Gi", template.Spec.Resources.Requests.Storage().String())
+	pod = statefulSet.Spec.Template.Spec
+	assert.Len(t, pod.Containers[0].VolumeMounts, 2)
+	require.Len(t, pod.Volumes, 1)
+	assert.Equal(t, "test-app-uploads", pod.Volumes[0].PersistentVolumeClaim.ClaimName)
+}
+
+func TestDeploymentGenerator_GenerateAllManifests_Isolation(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:     "test-app",
+		Namespace:   "0f3a9c1e-test-app",
+		Image:       "myapp",
+		Tag:         "v1",
+		Replicas:    1,
+		ServiceType: "LoadBalancer",
+		Ports:       []PortConfig{{Name: "http", Port: 8080, ServicePort: 80}},
+		Isolation: NewIsolationConfig(&domain.NamespacePolicy{
+			ResourceQuota: map[string]string{"requests.cpu": "4", "pods": "20"},
+			LimitRange: &domain.LimitRangeSpec{
+				Default:        map[string]string{"memory": "512Mi"},
+				DefaultRequest: map[string]string{"cpu": "100m"},
+			},
+			IngressNamespaces: []string{"ingress-nginx"},
+		}),
+	}
+
+	manifests, err := generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+
+	var quota corev1.ResourceQuota
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["resourcequota.yaml"]), &quota))
+	assert.Equal(t, ResourceQuotaName, quota.Name)
+	assert.Equal(t, "0f3a9c1e-test-app", quota.Namespace)
+	assert.Equal(t, "4", quota.Spec.Hard.Name("requests.cpu", "").String())
+	assert.Equal(t, "20", quota.Spec.Hard.Pods().String())
+
+	var limitRange corev1.LimitRange
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["limitrange.yaml"]), &limitRange))
+	require.Len(t, limitRange.Spec.Limits, 1)
+	assert.Equal(t, corev1.LimitTypeContainer, limitRange.Spec.Limits[0].Type)
+	assert.Equal(t, "512Mi", limitRange.Spec.Limits[0].Default.Memory().String())
+	assert.Equal(t, "100m", limitRange.Spec.Limits[0].DefaultRequest.Cpu().String())
+	assert.Empty(t, limitRange.Spec.Limits[0].Max)
+
+	// Every pod of the namespace denies traffic from outside it, except from the ingress
+	// controller and, for a LoadBalancer Service, on the application's ports
+	var policy networkingv1.NetworkPolicy
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["networkpolicy.yaml"]), &policy))
+	assert.Equal(t, NetworkPolicyName, policy.Name)
+	assert.Empty(t, policy.Spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+	require.Len(t, policy.Spec.Ingress, 3)
+	assert.NotNil(t, policy.Spec.Ingress[0].From[0].PodSelector)
+	assert.Nil(t, policy.Spec.Ingress[0].From[0].NamespaceSelector)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{
+		Key:      "kubernetes.io/metadata.name",
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   []string{"ingress-nginx"},
+	}}, policy.Spec.Ingress[1].From[0].NamespaceSelector.MatchExpressions)
+	assert.Empty(t, policy.Spec.Ingress[2].From)
+	require.Len(t, policy.Spec.Ingress[2].Ports, 1)
+	assert.Equal(t, int32(8080), policy.Spec.Ingress[2].Ports[0].Port.IntVal)
+
+	// Without quota or limits, the namespace is still isolated
+	config.Isolation = NewIsolationConfig(domain.DefaultNamespacePolicy(uuid.New()))
+	config.ServiceType = ""
+	manifests, err = generator.GenerateAllManifests(config, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, manifests, "resourcequota.yaml")
+	assert.NotContains(t, manifests, "limitrange.yaml")
+	var defaultPolicy networkingv1.NetworkPolicy
+	require.NoError(t, yaml.UnmarshalStrict([]byte(manifests["networkpolicy.yaml"]), &defaultPolicy))
+	assert.Len(t, defaultPolicy.Spec.Ingress, 2)
+
+	// Invalid quantities are rejected before anything is applied
+	config.Isolation.ResourceQuota = map[string]string{"requests.memory": "lots"}
+	_, err = generator.GenerateAllManifests(config, nil)
+	assert.ErrorContains(t, err, "failed to generate resourcequota")
+}
+
+func TestDeploymentGenerator_BuildTaskJob(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:       "test-app",
+		Namespace:     "test-ns",
+		Image:         "myapp",
+		Tag:           "v2",
+		ImageDigest:   "sha256:abc",
+		Environment:   map[string]string{"ENV": "production"},
+		Secrets:       map[string]string{"DATABASE_URL": "postgres://db"},
+		HealthCheck:   &HealthCheckConfig{ReadinessPath: "/ready"},
+		Resources:     &ResourceConfig{MemoryLimit: "512Mi"},
+		RegistryAuths: map[string]RegistryAuth{"ghcr.io": {Username: "robot", Password: "token"}},
+	}
+
+	job, err := generator.BuildTaskJob(config, &TaskConfig{
+		Name:           "pre-deploy-3f2a9c1e",
+		Command:        []string{"rails"},
+		Args:           []string{"db:migrate"},
+		TimeoutSeconds: 300,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-app-pre-deploy-3f2a9c1e", job.Name)
+	assert.Equal(t, "test-ns", job.Namespace)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int64(300), *job.Spec.ActiveDeadlineSeconds)
+
+	// Task pods run the release image with its env and secrets, but are never routed to
+	pod := job.Spec.Template
+	assert.Equal(t, map[string]string{LabelTask: "pre-deploy-3f2a9c1e"}, pod.Labels)
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, "myapp@sha256:abc", container.Image)
+	assert.Equal(t, []string{"rails"}, container.Command)
+	assert.Equal(t, []string{"db:migrate"}, container.Args)
+	assert.Empty(t, container.Ports)
+	assert.Nil(t, container.ReadinessProbe)
+	assert.Equal(t, "512Mi", container.Resources.Limits.Memory().String())
+	assert.Equal(t, "test-app-secrets", container.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.NotEmpty(t, pod.Spec.ImagePullSecrets)
+
+	_, err = generator.BuildTaskJob(config, &TaskConfig{Name: "run-3f2a9c1e"})
+	assert.Error(t, err)
+
+	// Long application names are shortened to keep the Job name a valid label value
+	config.AppName = strings.Repeat("a", 60)
+	name := TaskJobName(config, &TaskConfig{Name: "post-deploy-3f2a9c1e"})
+	assert.Len(t, name, 63)
+	assert.True(t, strings.HasSuffix(name, "-post-deploy-3f2a9c1e"))
+}
@@ -0,0 +1,170 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// Placeholders secret values are replaced with in previews
+const (
+	redactedValue        = "(redacted)"
+	redactedChangedValue = "(redacted, changed)"
+)
+
+// serverManagedFields are the metadata fields the API server maintains
+var serverManagedFields = []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"}
+
+// PreviewApply previews server-side applying an object against its live version
+func (a *Applier) PreviewApply(ctx context.Context, obj *unstructured.Unstructured) (domain.ObjectPreview, error) {
+	preview := domain.ObjectPreview{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Action:    domain.ObjectActionCreate,
+	}
+
+	manifest, err := previewManifest(obj, nil)
+	if err != nil {
+		return preview, err
+	}
+	preview.Manifest = manifest
+
+	live, err := a.Get(ctx, obj)
+	if err != nil {
+		return preview, err
+	}
+	if live != nil {
+		preview.Action = domain.ObjectActionUpdate
+	}
+
+	planned, err := a.DryRunApply(ctx, obj)
+	if err != nil {
+		// Objects in a namespace that does not exist yet cannot be dry-run; they are created as rendered
+		if live == nil && apierrors.IsNotFound(err) {
+			return preview, nil
+		}
+		preview.Error = err.Error()
+		return preview, nil
+	}
+	if live == nil {
+		return preview, nil
+	}
+
+	diff, err := DiffObjects(live, planned)
+	if err != nil {
+		return preview, err
+	}
+	if diff == "" {
+		preview.Action = domain.ObjectActionUnchanged
+	}
+	preview.Diff = diff
+
+	return preview, nil
+}
+
+// PreviewAutoscaled previews ApplyAutoscaled
+func (a *Applier) PreviewAutoscaled(ctx context.Context, obj *unstructured.Unstructured, replicas int32) (domain.ObjectPreview, error) {
+	live, err := a.Get(ctx, obj)
+	if err != nil {
+		return domain.ObjectPreview{}, err
+	}
+	if live != nil {
+		if liveReplicas, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); found {
+			return a.PreviewApply(ctx, WithReplicas(obj, liveReplicas))
+		}
+	}
+	return a.PreviewApply(ctx, WithReplicas(obj, int64(replicas)))
+}
+
+// PreviewDelete previews deleting a live object
+func PreviewDelete(live *unstructured.Unstructured) (domain.ObjectPreview, error) {
+	manifest, err := previewManifest(live, nil)
+	if err != nil {
+		return domain.ObjectPreview{}, err
+	}
+	return domain.ObjectPreview{
+		Kind:      live.GetKind(),
+		Name:      live.GetName(),
+		Namespace: live.GetNamespace(),
+		Action:    domain.ObjectActionDelete,
+		Manifest:  manifest,
+	}, nil
+}
+
+// DiffObjects returns the unified diff from a live object to its replacement, with Secrets redacted
+func DiffObjects(live, planned *unstructured.Unstructured) (string, error) {
+	from, err := previewManifest(live, nil)
+	if err != nil {
+		return "", err
+	}
+	to, err := previewManifest(planned, live)
+	if err != nil {
+		return "", err
+	}
+	if from == to {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "live",
+		ToFile:   "dry-run",
+		Context:  3,
+	})
+}
+
+// previewManifest renders an object as YAML without its status, server metadata or secret values
+func previewManifest(obj, previous *unstructured.Unstructured) (string, error) {
+	out, err := yaml.Marshal(previewContent(obj, previous))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s %q: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return string(out), nil
+}
+
+// previewContent returns a copy of an object's content without its status, server-managed
+// metadata or secret values, as previewManifest renders it
+func previewContent(obj, previous *unstructured.Unstructured) map[string]interface{} {
+	content := obj.DeepCopy().Object
+	delete(content, "status")
+	for _, field := range serverManagedFields {
+		unstructured.RemoveNestedField(content, "metadata", field)
+	}
+
+	if obj.GetKind() == "Secret" {
+		var previousContent map[string]interface{}
+		if previous != nil {
+			previousContent = previous.Object
+		}
+		redactSecretValues(content, previousContent)
+	}
+	return content
+}
+
+// redactSecretValues replaces the values of a Secret, marking those that differ from previous
+func redactSecretValues(secret, previous map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := secret[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		previousValues, _ := previous[field].(map[string]interface{})
+
+		for key, value := range values {
+			previousValue, existed := previousValues[key]
+			if previous == nil || (existed && previousValue == value) {
+				values[key] = redactedValue
+			} else {
+				values[key] = redactedChangedValue
+			}
+		}
+	}
+}
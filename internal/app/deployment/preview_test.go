@@ -0,0 +1,115 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func newTestConfigMap(name string, data map[string]interface{}) *unstructured.Unstructured {
+	obj := newTestObject("v1", "ConfigMap", "api", name, nil)
+	obj.Object["data"] = data
+	return obj
+}
+
+func TestDiffObjects(t *testing.T) {
+	live := newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "info", "PORT": "8080"})
+	live.SetResourceVersion("41")
+	live.SetUID("uid")
+	live.Object["metadata"].(map[string]interface{})["managedFields"] = []interface{}{map[string]interface{}{"manager": "oneclick"}}
+
+	planned := live.DeepCopy()
+	planned.SetResourceVersion("42")
+
+	diff, err := DiffObjects(live, planned)
+	require.NoError(t, err)
+	assert.Empty(t, diff, "server-managed metadata is ignored")
+
+	planned.Object["data"] = map[string]interface{}{"LOG_LEVEL": "debug", "PORT": "8080"}
+
+	diff, err = DiffObjects(live, planned)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- live\n+++ dry-run\n")
+	assert.Contains(t, diff, "-  LOG_LEVEL: info\n+  LOG_LEVEL: debug\n")
+	assert.NotContains(t, diff, "resourceVersion")
+	assert.NotContains(t, diff, "managedFields")
+}
+
+func TestDiffObjects_RedactsSecrets(t *testing.T) {
+	live := newTestObject("v1", "Secret", "api", "api-secrets", nil)
+	live.Object["data"] = map[string]interface{}{"API_KEY": "a2V5", "DATABASE_URL": "b2xk"}
+
+	planned := live.DeepCopy()
+	planned.Object["data"] = map[string]interface{}{"API_KEY": "a2V5", "DATABASE_URL": "bmV3", "TOKEN": "dG9rZW4="}
+
+	diff, err := DiffObjects(live, planned)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-  DATABASE_URL: (redacted)\n+  DATABASE_URL: (redacted, changed)\n")
+	assert.Contains(t, diff, "+  TOKEN: (redacted, changed)\n")
+	assert.NotContains(t, diff, "+  API_KEY")
+	for _, value := range []string{"a2V5", "b2xk", "bmV3", "dG9rZW4="} {
+		assert.NotContains(t, diff, value)
+	}
+
+	deleted, err := PreviewDelete(live)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ObjectActionDelete, deleted.Action)
+	assert.Contains(t, deleted.Manifest, "API_KEY: (redacted)")
+	assert.NotContains(t, deleted.Manifest, "a2V5")
+}
+
+func TestApplier_PreviewApply(t *testing.T) {
+	client := newTestDynamicClient(newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "info"}))
+
+	// The fake client ignores dry-run; return what the API server would
+	var dryRunErr error
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if dryRunErr != nil {
+			return true, nil, dryRunErr
+		}
+		planned := &unstructured.Unstructured{}
+		if err := planned.UnmarshalJSON(action.(k8stesting.PatchAction).GetPatch()); err != nil {
+			return true, nil, err
+		}
+		return true, planned, nil
+	})
+
+	applier := NewApplier(client, newTestRESTMapper())
+	ctx := context.Background()
+
+	preview, err := applier.PreviewApply(ctx, newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "debug"}))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ObjectActionUpdate, preview.Action)
+	assert.Contains(t, preview.Diff, "+  LOG_LEVEL: debug")
+	assert.Contains(t, preview.Manifest, "LOG_LEVEL: debug")
+
+	preview, err = applier.PreviewApply(ctx, newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "info"}))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ObjectActionUnchanged, preview.Action)
+	assert.Empty(t, preview.Diff)
+
+	// Objects in a namespace that does not exist yet are created as rendered
+	dryRunErr = apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "api")
+	preview, err = applier.PreviewApply(ctx, newTestConfigMap("api-flags", map[string]interface{}{"BETA": "true"}))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ObjectActionCreate, preview.Action)
+	assert.Empty(t, preview.Error)
+
+	// A rejected dry-run is reported on the object
+	dryRunErr = apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "api-config", errors.New("denied by policy"))
+	preview, err = applier.PreviewApply(ctx, newTestConfigMap("api-config", map[string]interface{}{"LOG_LEVEL": "debug"}))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ObjectActionUpdate, preview.Action)
+	assert.Contains(t, preview.Error, "denied by policy")
+	assert.Empty(t, preview.Diff)
+}
@@ -0,0 +1,190 @@
+package deployment
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// Limits of the progress kept per release
+const (
+	progressBufferSize       = 500              // Events replayed to a new subscriber
+	progressSubscriberBuffer = 64               // Events a subscriber may fall behind by before it is disconnected
+	progressRetention        = 10 * time.Minute // How long the events of an ended rollout are kept
+)
+
+// ProgressBroker fans the rollout progress of releases out to their subscribers
+type ProgressBroker struct {
+	mu       sync.Mutex
+	releases map[uuid.UUID]*releaseProgress
+	now      func() time.Time
+}
+
+// releaseProgress is the buffered progress and the subscribers of a release
+type releaseProgress struct {
+	events      []domain.ReleaseProgressEvent
+	subscribers map[*progressSubscriber]struct{}
+	ended       bool
+	endedAt     time.Time
+}
+
+// progressSubscriber is a subscriber's channel, whether it receives task output, and whether it
+// was disconnected for falling behind
+type progressSubscriber struct {
+	ch       chan domain.ReleaseProgressEvent
+	taskLogs bool
+	lagged   bool
+}
+
+// NewProgressBroker creates a new progress broker
+func NewProgressBroker() *ProgressBroker {
+	return &ProgressBroker{
+		releases: make(map[uuid.UUID]*releaseProgress),
+		now:      time.Now,
+	}
+}
+
+// Publish records a progress event and sends it to the release's subscribers
+func (b *ProgressBroker) Publish(event domain.ReleaseProgressEvent) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+
+	if event.Time.IsZero() {
+		event.Time = b.now()
+	}
+
+	progress := b.progress(event.ReleaseID)
+	progress.ended = false // A canary that is promoted or aborted starts a new rollout
+	progress.events = append(progress.events, event)
+	if overflow := len(progress.events) - progressBufferSize; overflow > 0 {
+		progress.events = append([]domain.ReleaseProgressEvent(nil), progress.events[overflow:]...)
+	}
+
+	for subscriber := range progress.subscribers {
+		if !subscriber.receives(event) {
+			continue
+		}
+		select {
+		case subscriber.ch <- event:
+		default:
+			subscriber.lagged = true
+			progress.disconnect(subscriber)
+		}
+	}
+
+	if event.EndsRollout() {
+		progress.ended = true
+		progress.endedAt = b.now()
+		for subscriber := range progress.subscribers {
+			progress.disconnect(subscriber)
+		}
+	}
+}
+
+// ProgressSubscription follows the rollout progress of a release
+type ProgressSubscription struct {
+	// History holds the buffered events of the release, oldest first
+	History []domain.ReleaseProgressEvent
+	// Events delivers the events published after History. It is closed when the rollout ends,
+	// when the subscriber falls behind, or when the subscription is closed.
+	Events <-chan domain.ReleaseProgressEvent
+
+	broker     *ProgressBroker
+	releaseID  uuid.UUID
+	subscriber *progressSubscriber
+}
+
+// Subscribe follows the progress of a release, with the output of its tasks if taskLogs is set
+func (b *ProgressBroker) Subscribe(releaseID uuid.UUID, taskLogs bool) *ProgressSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+
+	progress := b.progress(releaseID)
+	subscriber := &progressSubscriber{ch: make(chan domain.ReleaseProgressEvent, progressSubscriberBuffer), taskLogs: taskLogs}
+	if progress.ended {
+		close(subscriber.ch)
+	} else {
+		progress.subscribers[subscriber] = struct{}{}
+	}
+
+	history := make([]domain.ReleaseProgressEvent, 0, len(progress.events))
+	for _, event := range progress.events {
+		if subscriber.receives(event) {
+			history = append(history, event)
+		}
+	}
+
+	return &ProgressSubscription{
+		History:    history,
+		Events:     subscriber.ch,
+		broker:     b,
+		releaseID:  releaseID,
+		subscriber: subscriber,
+	}
+}
+
+// receives reports whether an event is delivered to the subscriber
+func (s *progressSubscriber) receives(event domain.ReleaseProgressEvent) bool {
+	return s.taskLogs || event.Type != domain.ReleaseProgressTaskLog
+}
+
+// Lagged reports whether the subscription was disconnected for falling behind, rather than
+// because the rollout ended
+func (s *ProgressSubscription) Lagged() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.subscriber.lagged
+}
+
+// Close stops the subscription
+func (s *ProgressSubscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	progress, ok := b.releases[s.releaseID]
+	if !ok {
+		return
+	}
+	if _, ok := progress.subscribers[s.subscriber]; ok {
+		progress.disconnect(s.subscriber)
+	}
+	if len(progress.events) == 0 && len(progress.subscribers) == 0 {
+		delete(b.releases, s.releaseID)
+	}
+}
+
+// progress returns the progress of a release, creating it if it is not tracked yet
+func (b *ProgressBroker) progress(releaseID uuid.UUID) *releaseProgress {
+	progress, ok := b.releases[releaseID]
+	if !ok {
+		progress = &releaseProgress{subscribers: make(map[*progressSubscriber]struct{})}
+		b.releases[releaseID] = progress
+	}
+	return progress
+}
+
+// expire forgets the progress of rollouts that ended longer than the retention ago
+func (b *ProgressBroker) expire() {
+	now := b.now()
+	for releaseID, progress := range b.releases {
+		if progress.ended && now.Sub(progress.endedAt) > progressRetention {
+			delete(b.releases, releaseID)
+		}
+	}
+}
+
+// disconnect removes a subscriber and closes its channel
+func (p *releaseProgress) disconnect(subscriber *progressSubscriber) {
+	delete(p.subscribers, subscriber)
+	close(subscriber.ch)
+}
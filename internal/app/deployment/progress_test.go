@@ -0,0 +1,124 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestProgressBroker_ReplaysAndFollowsRollout(t *testing.T) {
+	broker := NewProgressBroker()
+	releaseID := uuid.New()
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressManifestApplied, Name: "api"})
+
+	subscription := broker.Subscribe(releaseID, true)
+	defer subscription.Close()
+	require.Len(t, subscription.History, 1)
+	assert.Equal(t, domain.ReleaseProgressManifestApplied, subscription.History[0].Type)
+	assert.False(t, subscription.History[0].Time.IsZero())
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressPodScheduled, Name: "api-7d9f-x2k4"})
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Status: domain.ReleaseStatusSucceeded})
+
+	var received []domain.ReleaseProgressType
+	for event := range subscription.Events {
+		received = append(received, event.Type)
+	}
+	assert.Equal(t, []domain.ReleaseProgressType{domain.ReleaseProgressPodScheduled, domain.ReleaseProgressStatus}, received)
+	assert.False(t, subscription.Lagged())
+
+	// A late subscriber gets the whole rollout and a closed channel
+	late := broker.Subscribe(releaseID, true)
+	defer late.Close()
+	assert.Len(t, late.History, 3)
+	_, open := <-late.Events
+	assert.False(t, open)
+}
+
+func TestProgressBroker_DisconnectsLaggingSubscriber(t *testing.T) {
+	broker := NewProgressBroker()
+	releaseID := uuid.New()
+
+	subscription := broker.Subscribe(releaseID, true)
+	defer subscription.Close()
+
+	for i := 0; i <= progressSubscriberBuffer; i++ {
+		broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressReplicas})
+	}
+
+	count := 0
+	for range subscription.Events {
+		count++
+	}
+	assert.Equal(t, progressSubscriberBuffer, count)
+	assert.True(t, subscription.Lagged())
+}
+
+func TestProgressBroker_CanaryPromotionStartsNewRollout(t *testing.T) {
+	broker := NewProgressBroker()
+	releaseID := uuid.New()
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Phase: domain.ReleasePhaseCanary})
+
+	ended := broker.Subscribe(releaseID, true)
+	_, open := <-ended.Events
+	assert.False(t, open, "a canary awaiting promotion ends the rollout")
+	ended.Close()
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Phase: domain.ReleasePhasePromoting})
+
+	following := broker.Subscribe(releaseID, true)
+	defer following.Close()
+	assert.Len(t, following.History, 2)
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressManifestApplied})
+	event := <-following.Events
+	assert.Equal(t, domain.ReleaseProgressManifestApplied, event.Type)
+}
+
+func TestProgressBroker_ExpiresEndedRollouts(t *testing.T) {
+	broker := NewProgressBroker()
+	now := time.Now()
+	broker.now = func() time.Time { return now }
+
+	releaseID := uuid.New()
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Status: domain.ReleaseStatusFailed})
+
+	now = now.Add(progressRetention + time.Second)
+
+	subscription := broker.Subscribe(releaseID, true)
+	defer subscription.Close()
+	assert.Empty(t, subscription.History)
+}
+
+func TestProgressBroker_WithholdsTaskLogs(t *testing.T) {
+	broker := NewProgressBroker()
+	releaseID := uuid.New()
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressTaskStarted, Name: "api-pre-deploy-1a2b3c4d"})
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressTaskLog, Message: "DATABASE_URL=postgres://...\n"})
+
+	member := broker.Subscribe(releaseID, false)
+	defer member.Close()
+	require.Len(t, member.History, 1)
+	assert.Equal(t, domain.ReleaseProgressTaskStarted, member.History[0].Type)
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressTaskLog, Message: "migrated\n"})
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressStatus, Status: domain.ReleaseStatusSucceeded})
+
+	var received []domain.ReleaseProgressType
+	for event := range member.Events {
+		received = append(received, event.Type)
+	}
+	assert.Equal(t, []domain.ReleaseProgressType{domain.ReleaseProgressStatus}, received)
+
+	admin := broker.Subscribe(releaseID, true)
+	defer admin.Close()
+	assert.Len(t, admin.History, 4)
+}
@@ -0,0 +1,215 @@
+package deployment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podTemplatePaths are the paths of the pod templates of the workload kinds a deploy source can
+// run the release image in
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// restrictedSourceKinds are the kinds a deploy source cannot contain, as they would loosen the
+// isolation and quotas the namespace policy sets for the application's namespace
+var restrictedSourceKinds = map[schema.GroupKind]bool{
+	{Group: "networking.k8s.io", Kind: "NetworkPolicy"}:       true,
+	{Kind: "ResourceQuota"}:                                   true,
+	{Kind: "LimitRange"}:                                      true,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:        true,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}: true,
+}
+
+// GenerateSourceObjects returns the objects of a release rendered from its deploy source
+func (g *DeploymentGenerator) GenerateSourceObjects(config *DeploymentConfig, rendered []*unstructured.Unstructured, appID uuid.UUID) ([]*unstructured.Unstructured, error) {
+	manifests := make(map[string]string)
+	if err := g.generateIsolationManifests(config, manifests); err != nil {
+		return nil, fmt.Errorf("failed to generate manifests: %w", err)
+	}
+	if err := g.generateConfigManifests(config, manifests); err != nil {
+		return nil, fmt.Errorf("failed to generate manifests: %w", err)
+	}
+
+	objects, err := parseManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	generated := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		generated[objectKey(obj.GroupVersionKind().GroupKind(), obj.GetName())] = true
+	}
+
+	injected := 0
+	for _, obj := range rendered {
+		obj = obj.DeepCopy()
+		if namespace := obj.GetNamespace(); namespace != "" && namespace != config.Namespace {
+			return nil, fmt.Errorf("%s %s is in namespace %s, but the application is deployed to %s", obj.GetKind(), obj.GetName(), namespace, config.Namespace)
+		}
+		if restrictedSourceKinds[obj.GroupVersionKind().GroupKind()] {
+			return nil, fmt.Errorf("%s %s cannot be part of the deploy source: the namespace policy manages %s objects", obj.GetKind(), obj.GetName(), obj.GetKind())
+		}
+		if generated[objectKey(obj.GroupVersionKind().GroupKind(), obj.GetName())] {
+			return nil, fmt.Errorf("%s %s is generated by OneClick and cannot be part of the deploy source", obj.GetKind(), obj.GetName())
+		}
+
+		n, err := injectRelease(config, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inject release into %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		injected += n
+		objects = append(objects, obj)
+	}
+	if injected == 0 {
+		return nil, fmt.Errorf("no container of the deploy source runs the release image %s: reference it in a container's image or list the container in the source's containers", config.Image)
+	}
+
+	return managedObjects(objects, config.Namespace, appID), nil
+}
+
+// injectRelease sets the release image and environment on the containers of a workload that run it
+func injectRelease(config *DeploymentConfig, obj *unstructured.Unstructured) (int, error) {
+	templatePath, ok := podTemplatePaths[obj.GetKind()]
+	if !ok {
+		return 0, nil
+	}
+	template, found, err := unstructured.NestedMap(obj.Object, templatePath...)
+	if err != nil || !found {
+		return 0, err
+	}
+
+	env := buildEnv(config.Environment, config.Config, secretName(config), config.Secrets)
+	injected := 0
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, err := unstructured.NestedSlice(template, "spec", field)
+		if err != nil {
+			return 0, err
+		}
+		injectedInto := 0
+		for i, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok || !runsRelease(config, container) {
+				continue
+			}
+			container["image"] = imageRef(config)
+			merged, err := mergeEnv(container["env"], env)
+			if err != nil {
+				return 0, err
+			}
+			if len(merged) > 0 {
+				container["env"] = merged
+			}
+			containers[i] = container
+			injectedInto++
+		}
+		if injectedInto > 0 {
+			if err := unstructured.SetNestedSlice(template, containers, "spec", field); err != nil {
+				return 0, err
+			}
+		}
+		injected += injectedInto
+	}
+	if injected == 0 {
+		return 0, nil
+	}
+
+	for _, pullSecret := range imagePullSecrets(config) {
+		pullSecrets, _, err := unstructured.NestedSlice(template, "spec", "imagePullSecrets")
+		if err != nil {
+			return 0, err
+		}
+		if !hasPullSecret(pullSecrets, pullSecret.Name) {
+			pullSecrets = append(pullSecrets, map[string]interface{}{"name": pullSecret.Name})
+		}
+		if err := unstructured.SetNestedSlice(template, pullSecrets, "spec", "imagePullSecrets"); err != nil {
+			return 0, err
+		}
+	}
+
+	if annotations := podAnnotations(config); len(annotations) > 0 {
+		templateAnnotations, _, err := unstructured.NestedStringMap(template, "metadata", "annotations")
+		if err != nil {
+			return 0, err
+		}
+		if templateAnnotations == nil {
+			templateAnnotations = make(map[string]string, len(annotations))
+		}
+		for key, value := range annotations {
+			templateAnnotations[key] = value
+		}
+		if err := unstructured.SetNestedStringMap(template, templateAnnotations, "metadata", "annotations"); err != nil {
+			return 0, err
+		}
+	}
+
+	return injected, unstructured.SetNestedMap(obj.Object, template, templatePath...)
+}
+
+// runsRelease reports whether a container of a deploy source runs the release image
+func runsRelease(config *DeploymentConfig, container map[string]interface{}) bool {
+	name, _ := container["name"].(string)
+	for _, listed := range config.Source.Containers {
+		if name == listed {
+			return true
+		}
+	}
+	image, _ := container["image"].(string)
+	return imageRepository(image) == config.Image
+}
+
+// imageRepository returns an image reference without its tag or digest
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// mergeEnv adds environment variables to a container's env, replacing the ones of the same name
+func mergeEnv(existing interface{}, env []corev1.EnvVar) ([]interface{}, error) {
+	merged, _ := existing.([]interface{})
+	index := make(map[string]int, len(merged))
+	for i, e := range merged {
+		if variable, ok := e.(map[string]interface{}); ok {
+			if name, ok := variable["name"].(string); ok {
+				index[name] = i
+			}
+		}
+	}
+
+	for i := range env {
+		variable, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&env[i])
+		if err != nil {
+			return nil, err
+		}
+		if j, ok := index[env[i].Name]; ok {
+			merged[j] = variable
+			continue
+		}
+		merged = append(merged, variable)
+	}
+	return merged, nil
+}
+
+// hasPullSecret reports whether a pod's image pull Secrets include the named Secret
+func hasPullSecret(pullSecrets []interface{}, name string) bool {
+	for _, s := range pullSecrets {
+		if ref, ok := s.(map[string]interface{}); ok && ref["name"] == name {
+			return true
+		}
+	}
+	return false
+}
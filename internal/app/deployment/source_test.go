@@ -0,0 +1,144 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func newSourceDeployment(name string, containers ...map[string]interface{}) *unstructured.Unstructured {
+	podContainers := make([]interface{}, len(containers))
+	for i, container := range containers {
+		podContainers[i] = container
+	}
+	obj := newTestObject("apps/v1", "Deployment", "", name, nil)
+	obj.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": podContainers},
+		},
+	}
+	return obj
+}
+
+func TestDeploymentGenerator_GenerateSourceObjects(t *testing.T) {
+	generator := NewDeploymentGenerator()
+	appID := uuid.New()
+
+	config := &DeploymentConfig{
+		AppName:       "api",
+		Namespace:     "acme-api",
+		Image:         "ghcr.io/acme/api",
+		Tag:           "v2",
+		ImageDigest:   "sha256:abc",
+		Environment:   map[string]string{"LOG_LEVEL": "info"},
+		Secrets:       map[string]string{"DATABASE_URL": "postgres://db"},
+		RegistryAuths: map[string]RegistryAuth{"ghcr.io": {Username: "acme", Password: "token"}},
+		Source:        &SourceConfig{Type: domain.SourceHelm, Containers: []string{"migrate"}},
+	}
+
+	web := newSourceDeployment("api-web",
+		map[string]interface{}{
+			"name":  "web",
+			"image": "ghcr.io/acme/api:latest",
+			"env": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+				map[string]interface{}{"name": "PORT", "value": "8080"},
+			},
+		},
+		map[string]interface{}{"name": "proxy", "image": "envoyproxy/envoy:v1.30"},
+	)
+	migrate := newSourceDeployment("api-migrate", map[string]interface{}{"name": "migrate", "image": "busybox"})
+	service := newTestObject("v1", "Service", "acme-api", "api-web", nil)
+	rendered := []*unstructured.Unstructured{web, migrate, service}
+
+	objects, err := generator.GenerateSourceObjects(config, rendered, appID)
+	require.NoError(t, err)
+
+	byName := make(map[string]*unstructured.Unstructured)
+	for _, obj := range objects {
+		byName[obj.GetKind()+"/"+obj.GetName()] = obj
+		assert.Equal(t, "acme-api", obj.GetNamespace(), "%s/%s", obj.GetKind(), obj.GetName())
+		assert.Equal(t, appID.String(), obj.GetLabels()[LabelAppID], "%s/%s", obj.GetKind(), obj.GetName())
+	}
+	assert.Contains(t, byName, "Secret/api-secrets")
+	assert.Contains(t, byName, "Secret/api-registry")
+	assert.Contains(t, byName, "Service/api-web")
+	assert.Equal(t, "Secret", objects[0].GetKind(), "objects are sorted in apply order")
+
+	containers, _, err := unstructured.NestedSlice(byName["Deployment/api-web"].Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	webContainer := containers[0].(map[string]interface{})
+	assert.Equal(t, "ghcr.io/acme/api@sha256:abc", webContainer["image"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+		map[string]interface{}{"name": "PORT", "value": "8080"},
+		map[string]interface{}{
+			"name": "DATABASE_URL",
+			"valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]interface{}{"name": "api-secrets", "key": "DATABASE_URL"},
+			},
+		},
+	}, webContainer["env"])
+	assert.Equal(t, "envoyproxy/envoy:v1.30", containers[1].(map[string]interface{})["image"], "containers running other images are left alone")
+
+	pullSecrets, _, _ := unstructured.NestedSlice(byName["Deployment/api-web"].Object, "spec", "template", "spec", "imagePullSecrets")
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "api-registry"}}, pullSecrets)
+	annotations, _, _ := unstructured.NestedStringMap(byName["Deployment/api-web"].Object, "spec", "template", "metadata", "annotations")
+	assert.NotEmpty(t, annotations[SecretsChecksumAnnotation])
+
+	image, _, _ := unstructured.NestedSlice(byName["Deployment/api-migrate"].Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "ghcr.io/acme/api@sha256:abc", image[0].(map[string]interface{})["image"], "listed containers run the release image")
+
+	original, _, _ := unstructured.NestedSlice(web.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "ghcr.io/acme/api:latest", original[0].(map[string]interface{})["image"], "rendered objects are left unchanged")
+}
+
+func TestDeploymentGenerator_GenerateSourceObjects_Rejects(t *testing.T) {
+	generator := NewDeploymentGenerator()
+
+	config := &DeploymentConfig{
+		AppName:   "api",
+		Namespace: "acme-api",
+		Image:     "ghcr.io/acme/api",
+		Tag:       "v2",
+		Secrets:   map[string]string{"API_KEY": "secret"},
+		Source:    &SourceConfig{Type: domain.SourceManifests},
+	}
+	web := newSourceDeployment("api", map[string]interface{}{"name": "web", "image": "ghcr.io/acme/api"})
+
+	otherNamespace := web.DeepCopy()
+	otherNamespace.SetNamespace("kube-system")
+	_, err := generator.GenerateSourceObjects(config, []*unstructured.Unstructured{otherNamespace}, uuid.New())
+	assert.ErrorContains(t, err, "is in namespace kube-system")
+
+	secret := newTestObject("v1", "Secret", "", "api-secrets", nil)
+	_, err = generator.GenerateSourceObjects(config, []*unstructured.Unstructured{web, secret}, uuid.New())
+	assert.ErrorContains(t, err, "is generated by OneClick")
+
+	for _, restricted := range []*unstructured.Unstructured{
+		newTestObject("networking.k8s.io/v1", "NetworkPolicy", "", "allow-all", nil),
+		newTestObject("v1", "ResourceQuota", "", "unlimited", nil),
+		newTestObject("v1", "LimitRange", "", "unlimited", nil),
+		newTestObject("rbac.authorization.k8s.io/v1", "Role", "", "admin", nil),
+		newTestObject("rbac.authorization.k8s.io/v1", "RoleBinding", "", "admin", nil),
+	} {
+		_, err = generator.GenerateSourceObjects(config, []*unstructured.Unstructured{web, restricted}, uuid.New())
+		assert.ErrorContains(t, err, "cannot be part of the deploy source", restricted.GetKind())
+	}
+
+	other := newSourceDeployment("worker", map[string]interface{}{"name": "worker", "image": "ghcr.io/acme/worker:v1"})
+	_, err = generator.GenerateSourceObjects(config, []*unstructured.Unstructured{other}, uuid.New())
+	assert.ErrorContains(t, err, "no container of the deploy source runs the release image")
+}
+
+func TestImageRepository(t *testing.T) {
+	assert.Equal(t, "ghcr.io/acme/api", imageRepository("ghcr.io/acme/api:v1"))
+	assert.Equal(t, "ghcr.io/acme/api", imageRepository("ghcr.io/acme/api@sha256:abc"))
+	assert.Equal(t, "registry:5000/api", imageRepository("registry:5000/api"))
+	assert.Equal(t, "registry:5000/api", imageRepository("registry:5000/api:v1"))
+}
@@ -2,6 +2,7 @@ package kubeclient
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,7 +11,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -20,6 +24,7 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/kubectl/pkg/scheme"
 
+	"github.com/PouryDev/oneclick/internal/app/deployment"
 	"github.com/PouryDev/oneclick/internal/domain"
 )
 
@@ -30,6 +35,8 @@ type KubernetesClientInterface interface {
 	GetPodLogs(ctx context.Context, podName, namespace string, req domain.PodLogsRequest) (*domain.PodLogsResponse, error)
 	GetPodDescribe(ctx context.Context, podName, namespace string) (*domain.PodDescribeResponse, error)
 	ExecInPod(ctx context.Context, podName, namespace string, req domain.PodExecRequest, conn *websocket.Conn) error
+	GetReplicaStatus(ctx context.Context, appName, namespace string) (*domain.ReplicaStatus, error)
+	GetVolumeStatuses(ctx context.Context, appName, namespace string) ([]domain.VolumeStatus, error)
 }
 
 // KubernetesClient wraps the Kubernetes client with additional functionality
@@ -180,6 +187,231 @@ func (k *KubernetesClient) GetPodDetail(ctx context.Context, podName, namespace
 	return podDetail, nil
 }
 
+// GetReplicaStatus returns the replicas of the Deployment serving an application in a namespace:
+// the one its HorizontalPodAutoscaler scales, or the blue/green slot its Service routes to. It
+// returns nil if the application is not deployed to the namespace.
+func (k *KubernetesClient) GetReplicaStatus(ctx context.Context, appName, namespace string) (*domain.ReplicaStatus, error) {
+	config := &deployment.DeploymentConfig{AppName: appName, Namespace: namespace}
+
+	hpa, err := k.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, deployment.HorizontalPodAutoscalerName(config), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get horizontal pod autoscaler: %w", err)
+		}
+		hpa = nil
+	}
+
+	name := deployment.DeploymentName(config)
+	if hpa != nil {
+		name = hpa.Spec.ScaleTargetRef.Name
+	} else {
+		service, err := k.clientset.CoreV1().Services(namespace).Get(ctx, deployment.ServiceName(config), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+		if err == nil {
+			config.Slot = deployment.ActiveSlot(service)
+			name = deployment.DeploymentName(config)
+		}
+	}
+
+	deploy, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return replicaStatus(deploy, hpa), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	// Applications whose replicas have volumes of their own run as a StatefulSet
+	statefulSet, err := k.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get statefulset: %w", err)
+	}
+
+	return statefulSetReplicaStatus(statefulSet), nil
+}
+
+// replicaStatus returns the replicas of a Deployment and, if it is autoscaled, of its
+// HorizontalPodAutoscaler. The autoscaler's desired replicas are ahead of the Deployment's
+// until it has scaled it.
+func replicaStatus(deploy *appsv1.Deployment, hpa *autoscalingv2.HorizontalPodAutoscaler) *domain.ReplicaStatus {
+	status := &domain.ReplicaStatus{
+		Namespace:       deploy.Namespace,
+		Kind:            "Deployment",
+		Deployment:      deploy.Name,
+		CurrentReplicas: deploy.Status.Replicas,
+		ReadyReplicas:   deploy.Status.ReadyReplicas,
+	}
+	if deploy.Spec.Replicas != nil {
+		status.DesiredReplicas = *deploy.Spec.Replicas
+	}
+
+	if hpa != nil {
+		status.Autoscaled = true
+		status.MaxReplicas = hpa.Spec.MaxReplicas
+		if hpa.Spec.MinReplicas != nil {
+			status.MinReplicas = *hpa.Spec.MinReplicas
+		}
+		if hpa.Status.DesiredReplicas > 0 {
+			status.DesiredReplicas = hpa.Status.DesiredReplicas
+		}
+	}
+
+	return status
+}
+
+// statefulSetReplicaStatus returns the replicas of a StatefulSet, which is never autoscaled
+func statefulSetReplicaStatus(statefulSet *appsv1.StatefulSet) *domain.ReplicaStatus {
+	status := &domain.ReplicaStatus{
+		Namespace:       statefulSet.Namespace,
+		Kind:            "StatefulSet",
+		Deployment:      statefulSet.Name,
+		CurrentReplicas: statefulSet.Status.Replicas,
+		ReadyReplicas:   statefulSet.Status.ReadyReplicas,
+	}
+	if statefulSet.Spec.Replicas != nil {
+		status.DesiredReplicas = *statefulSet.Spec.Replicas
+	}
+	return status
+}
+
+// GetVolumeStatuses returns the PersistentVolumeClaims of an application in a namespace, with the
+// usage of those its pods mount. Usage is read from the stats summary of the kubelets the pods
+// run on; volumes whose usage cannot be read are returned without it.
+func (k *KubernetesClient) GetVolumeStatuses(ctx context.Context, appName, namespace string) ([]domain.VolumeStatus, error) {
+	selector := labels.Set{"app": appName}.AsSelector().String()
+
+	claims, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	if len(claims.Items) == 0 {
+		return nil, nil
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	usage := make(map[string]volumeUsage)
+	nodes := make(map[string]bool)
+	for _, pod := range pods.Items {
+		node := pod.Spec.NodeName
+		if node == "" || nodes[node] {
+			continue
+		}
+		nodes[node] = true
+
+		summary, err := k.nodeStatsSummary(ctx, node)
+		if err != nil {
+			k.logger.Debug("Failed to get node stats summary", zap.Error(err), zap.String("node", node))
+			continue
+		}
+		for name, volume := range claimUsage(summary, namespace) {
+			usage[name] = volume
+		}
+	}
+
+	statuses := make([]domain.VolumeStatus, 0, len(claims.Items))
+	for i := range claims.Items {
+		statuses = append(statuses, volumeStatus(&claims.Items[i], usage))
+	}
+	return statuses, nil
+}
+
+// statsSummary is the part of a kubelet's stats summary that reports the usage of the volumes
+// mounted by its pods
+type statsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			PVCRef *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef,omitempty"`
+			CapacityBytes  *uint64 `json:"capacityBytes,omitempty"`
+			UsedBytes      *uint64 `json:"usedBytes,omitempty"`
+			AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+		} `json:"volume,omitempty"`
+	} `json:"pods"`
+}
+
+// volumeUsage is how much of a PersistentVolumeClaim is used
+type volumeUsage struct {
+	used      *int64
+	available *int64
+}
+
+// nodeStatsSummary reads the stats summary of a node's kubelet through the API server
+func (k *KubernetesClient) nodeStatsSummary(ctx context.Context, node string) (*statsSummary, error) {
+	raw, err := k.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node, "proxy", "stats", "summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary statsSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse stats summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// claimUsage returns the usage of the PersistentVolumeClaims of a namespace reported in a stats
+// summary, by claim name
+func claimUsage(summary *statsSummary, namespace string) map[string]volumeUsage {
+	usage := make(map[string]volumeUsage)
+	for _, pod := range summary.Pods {
+		for _, volume := range pod.Volumes {
+			if volume.PVCRef == nil || volume.PVCRef.Namespace != namespace || volume.UsedBytes == nil {
+				continue
+			}
+			usage[volume.PVCRef.Name] = volumeUsage{
+				used:      bytesOf(volume.UsedBytes),
+				available: bytesOf(volume.AvailableBytes),
+			}
+		}
+	}
+	return usage
+}
+
+// bytesOf converts a byte count of a stats summary
+func bytesOf(value *uint64) *int64 {
+	if value == nil {
+		return nil
+	}
+	bytes := int64(*value)
+	return &bytes
+}
+
+// volumeStatus returns the status of a PersistentVolumeClaim and its usage, if it is known
+func volumeStatus(claim *corev1.PersistentVolumeClaim, usage map[string]volumeUsage) domain.VolumeStatus {
+	status := domain.VolumeStatus{
+		Namespace: claim.Namespace,
+		Name:      claim.Name,
+		Phase:     string(claim.Status.Phase),
+	}
+	if claim.Spec.StorageClassName != nil {
+		status.StorageClass = *claim.Spec.StorageClassName
+	}
+	if len(claim.Spec.AccessModes) > 0 {
+		status.AccessMode = string(claim.Spec.AccessModes[0])
+	}
+	if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+		status.Capacity = capacity.String()
+	}
+	if volume, ok := usage[claim.Name]; ok {
+		status.UsedBytes = volume.used
+		status.AvailableBytes = volume.available
+	}
+	return status
+}
+
 // GetPodLogs returns pod logs with optional streaming
 func (k *KubernetesClient) GetPodLogs(ctx context.Context, podName, namespace string, req domain.PodLogsRequest) (*domain.PodLogsResponse, error) {
 	logReq := k.clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
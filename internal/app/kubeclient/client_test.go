@@ -2,6 +2,7 @@ package kubeclient
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"testing"
 	"time"
@@ -9,8 +10,14 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PouryDev/oneclick/internal/domain"
 )
 
 // MockKubernetesClient is a mock implementation of KubernetesClient
@@ -371,3 +378,80 @@ func TestGetTotalRestartCount(t *testing.T) {
 		})
 	}
 }
+
+func TestReplicaStatus(t *testing.T) {
+	replicas := int32(3)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-green", Namespace: "api"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 2},
+	}
+
+	status := replicaStatus(deploy, nil)
+	assert.Equal(t, &domain.ReplicaStatus{
+		Namespace:       "api",
+		Kind:            "Deployment",
+		Deployment:      "api-green",
+		CurrentReplicas: 3,
+		DesiredReplicas: 3,
+		ReadyReplicas:   2,
+	}, status)
+
+	// The autoscaler wants more replicas than the Deployment has been scaled to yet
+	minReplicas := int32(2)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		Spec:   autoscalingv2.HorizontalPodAutoscalerSpec{MinReplicas: &minReplicas, MaxReplicas: 10},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 3, DesiredReplicas: 6},
+	}
+
+	status = replicaStatus(deploy, hpa)
+	assert.True(t, status.Autoscaled)
+	assert.Equal(t, int32(3), status.CurrentReplicas)
+	assert.Equal(t, int32(6), status.DesiredReplicas)
+	assert.Equal(t, int32(2), status.MinReplicas)
+	assert.Equal(t, int32(10), status.MaxReplicas)
+}
+
+func TestVolumeStatus(t *testing.T) {
+	var summary statsSummary
+	err := json.Unmarshal([]byte(`{"pods": [
+		{"volume": [
+			{"name": "kube-api-access", "usedBytes": 4096},
+			{"name": "data", "pvcRef": {"name": "data-api-0", "namespace": "api"}, "capacityBytes": 10737418240, "usedBytes": 1073741824, "availableBytes": 9663676416}
+		]},
+		{"volume": [
+			{"name": "data", "pvcRef": {"name": "data-api-0", "namespace": "other"}, "usedBytes": 1}
+		]}
+	]}`), &summary)
+	require.NoError(t, err)
+
+	usage := claimUsage(&summary, "api")
+	require.Len(t, usage, 1)
+
+	storageClass := "standard"
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-api-0", Namespace: "api"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: &storageClass,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase:    corev1.ClaimBound,
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+
+	status := volumeStatus(claim, usage)
+	assert.Equal(t, "Bound", status.Phase)
+	assert.Equal(t, "standard", status.StorageClass)
+	assert.Equal(t, "ReadWriteOnce", status.AccessMode)
+	assert.Equal(t, "10Gi", status.Capacity)
+	if assert.NotNil(t, status.UsedBytes) && assert.NotNil(t, status.AvailableBytes) {
+		assert.Equal(t, int64(1073741824), *status.UsedBytes)
+		assert.Equal(t, int64(9663676416), *status.AvailableBytes)
+	}
+
+	// Claims that no pod mounts have no usage
+	status = volumeStatus(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-api-1", Namespace: "api"}}, usage)
+	assert.Nil(t, status.UsedBytes)
+}
@@ -0,0 +1,281 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// manifestMediaTypes are the manifest formats a tag is resolved to, multi-platform indexes first,
+// so the digest is the one the kubelet pulls for any node architecture
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// maxManifestSize bounds the manifest read to compute a digest the registry did not return
+const maxManifestSize = 4 << 20
+
+// ErrUnauthorized is returned when a registry rejects the request's credentials, or requires
+// credentials and none were given
+var ErrUnauthorized = errors.New("registry authentication failed")
+
+// Credentials authenticate to a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Client talks to container registries over the OCI distribution API
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a registry client
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{httpClient: httpClient}
+}
+
+// ResolveDigest returns the digest of the manifest a tag points to. creds may be nil for
+// anonymous access.
+func (c *Client) ResolveDigest(ctx context.Context, ref Reference, tag string, creds *Credentials) (string, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", apiBaseURL(ref.Registry), ref.Repository, url.PathEscape(tag))
+
+	// The digest header is enough; registries that omit it on HEAD get a GET
+	resp, err := c.fetchManifest(ctx, http.MethodHead, manifestURL, ref, creds)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); IsDigest(digest) {
+		return digest, nil
+	}
+
+	resp, err = c.fetchManifest(ctx, http.MethodGet, manifestURL, ref, creds)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	manifest, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest of %s:%s: %w", ref, tag, err)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !IsDigest(digest) {
+		digest = "sha256:"
+	}
+	if err := verifyDigest(digest, manifest); err != nil {
+		return "", fmt.Errorf("manifest of %s:%s: %w", ref, tag, err)
+	}
+	if digest == "sha256:" {
+		sum := sha256.Sum256(manifest)
+		digest += hex.EncodeToString(sum[:])
+	}
+	return digest, nil
+}
+
+// fetchManifest requests a manifest, answering the registry's authentication challenge if it
+// returns one
+func (c *Client) fetchManifest(ctx context.Context, method, manifestURL string, ref Reference, creds *Credentials) (*http.Response, error) {
+	resp, err := c.doManifestRequest(ctx, method, manifestURL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		authorization, err := c.authorize(ctx, challenge, ref, creds)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.doManifestRequest(ctx, method, manifestURL, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w for %s", ErrUnauthorized, ref)
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("manifest not found: %s", ref)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("registry returned %s for %s", resp.Status, ref)
+	}
+}
+
+func (c *Client) doManifestRequest(ctx context.Context, method, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry: %w", err)
+	}
+	return resp, nil
+}
+
+// authorize returns the Authorization header that answers a registry's WWW-Authenticate
+// challenge: basic credentials, or a bearer token from the registry's token service
+func (c *Client) authorize(ctx context.Context, challenge string, ref Reference, creds *Credentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == nil {
+			return "", fmt.Errorf("%w for %s: registry requires credentials", ErrUnauthorized, ref)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, params, ref, creds)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("%w for %s: unsupported authentication challenge %q", ErrUnauthorized, ref, challenge)
+	}
+}
+
+// fetchToken gets a pull token for a repository from a registry's token service
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, ref Reference, creds *Credentials) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("%w for %s: bearer challenge without a realm", ErrUnauthorized, ref)
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach registry token service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("%w for %s", ErrUnauthorized, ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token service returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", errors.New("registry token service returned no token")
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:acme/api:pull"
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+
+	return scheme, params
+}
+
+// verifyDigest checks a manifest against the digest the registry returned for it
+func verifyDigest(digest string, manifest []byte) error {
+	var h hash.Hash
+	algorithm, expected, _ := strings.Cut(digest, ":")
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	if expected == "" {
+		return nil
+	}
+
+	h.Write(manifest)
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("digest mismatch: registry returned %s, content is %s:%s", digest, algorithm, actual)
+	}
+	return nil
+}
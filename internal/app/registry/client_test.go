@@ -0,0 +1,221 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is a registry:2 compatible stand-in serving manifests behind token authentication
+type fakeRegistry struct {
+	server    *httptest.Server
+	manifests map[string][]byte // "<repository>:<tag>" to manifest
+	username  string
+	password  string
+	// anonymous allows pulls with a token fetched without credentials
+	anonymous bool
+	// omitHeadDigest leaves the digest header out of HEAD responses, as some registries do
+	omitHeadDigest bool
+	// requests records the method of each manifest request
+	requests []string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{
+		manifests: map[string][]byte{},
+		username:  "robot",
+		password:  "s3cret",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", r.serveToken)
+	mux.HandleFunc("/v2/", r.serveManifest)
+	r.server = httptest.NewServer(mux)
+	t.Cleanup(r.server.Close)
+
+	return r
+}
+
+// host returns the registry's host, as it appears in image names
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *fakeRegistry) push(repository, tag string, manifest []byte) string {
+	r.manifests[repository+":"+tag] = manifest
+	sum := sha256.Sum256(manifest)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (r *fakeRegistry) serveToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	switch {
+	case ok && (username != r.username || password != r.password):
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case !ok && !r.anonymous:
+		// registry:2 token services hand out tokens without access instead of failing
+		json.NewEncoder(w).Encode(map[string]string{"token": "no-access"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"token": "pull:" + req.URL.Query().Get("scope")})
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	repository, tag, found := strings.Cut(path, "/manifests/")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.requests = append(r.requests, req.Method)
+
+	scope := "repository:" + repository + ":pull"
+	if req.Header.Get("Authorization") != "Bearer pull:"+scope {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake-registry",scope="%s"`, r.server.URL, scope))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	manifest, ok := r.manifests[repository+":"+tag]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	sum := sha256.Sum256(manifest)
+	w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+	if req.Method == http.MethodGet || !r.omitHeadDigest {
+		w.Header().Set("Docker-Content-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+	}
+	if req.Method == http.MethodGet {
+		w.Write(manifest)
+	}
+}
+
+func TestClient_ResolveDigest(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+
+	tests := []struct {
+		name           string
+		anonymous      bool
+		omitHeadDigest bool
+		creds          *Credentials
+		tag            string
+		expectedErr    string
+		expectedReqs   []string
+	}{
+		{
+			name:         "credentials",
+			creds:        &Credentials{Username: "robot", Password: "s3cret"},
+			tag:          "v1.0.0",
+			expectedReqs: []string{http.MethodHead, http.MethodHead},
+		},
+		{
+			name:         "anonymous pull",
+			anonymous:    true,
+			tag:          "v1.0.0",
+			expectedReqs: []string{http.MethodHead, http.MethodHead},
+		},
+		{
+			name:           "digest computed from manifest",
+			omitHeadDigest: true,
+			creds:          &Credentials{Username: "robot", Password: "s3cret"},
+			tag:            "v1.0.0",
+			expectedReqs:   []string{http.MethodHead, http.MethodHead, http.MethodGet, http.MethodGet},
+		},
+		{
+			name:        "private repository without credentials",
+			tag:         "v1.0.0",
+			expectedErr: "registry authentication failed",
+		},
+		{
+			name:        "wrong credentials",
+			creds:       &Credentials{Username: "robot", Password: "wrong"},
+			tag:         "v1.0.0",
+			expectedErr: "registry authentication failed",
+		},
+		{
+			name:        "unknown tag",
+			creds:       &Credentials{Username: "robot", Password: "s3cret"},
+			tag:         "v9.9.9",
+			expectedErr: "manifest not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newFakeRegistry(t)
+			registry.anonymous = tt.anonymous
+			registry.omitHeadDigest = tt.omitHeadDigest
+			expectedDigest := registry.push("acme/api", "v1.0.0", manifest)
+
+			ref, err := ParseReference(registry.host() + "/acme/api")
+			require.NoError(t, err)
+
+			digest, err := NewClient(nil).ResolveDigest(context.Background(), ref, tt.tag, tt.creds)
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expectedDigest, digest)
+			assert.Equal(t, tt.expectedReqs, registry.requests)
+		})
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image       string
+		expected    Reference
+		expectedErr string
+	}{
+		{image: "nginx", expected: Reference{Registry: "docker.io", Repository: "library/nginx"}},
+		{image: "acme/api", expected: Reference{Registry: "docker.io", Repository: "acme/api"}},
+		{image: "index.docker.io/acme/api", expected: Reference{Registry: "docker.io", Repository: "acme/api"}},
+		{image: "ghcr.io/acme/api", expected: Reference{Registry: "ghcr.io", Repository: "acme/api"}},
+		{image: "registry.gitlab.com/acme/platform/api", expected: Reference{Registry: "registry.gitlab.com", Repository: "acme/platform/api"}},
+		{image: "localhost:5000/api", expected: Reference{Registry: "localhost:5000", Repository: "api"}},
+		{image: "ghcr.io/acme/api:v1.0.0", expectedErr: "must not include a tag"},
+		{image: "ghcr.io/acme/api@sha256:abc", expectedErr: "must not include a digest"},
+		{image: "ghcr.io/Acme/API", expectedErr: "invalid image"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseReference(tt.image)
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ref)
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:acme/api:pull,push"`)
+
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:acme/api:pull,push",
+	}, params)
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// dockerHubAPIHost serves the distribution API of docker.io
+const dockerHubAPIHost = "registry-1.docker.io"
+
+var (
+	// repositoryPattern matches a repository path: lowercase components separated by slashes
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	// digestPattern matches a content digest such as sha256:<64 hex characters>
+	digestPattern = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+)
+
+// Reference is an image repository in a registry
+type Reference struct {
+	Registry   string // Host, with port if any, e.g. docker.io, ghcr.io or localhost:5000
+	Repository string // Path within the registry, e.g. library/nginx or acme/api
+}
+
+// ParseReference parses an image name without a tag or digest, such as nginx, acme/api or
+// ghcr.io/acme/api. Names without a registry host are on Docker Hub.
+func ParseReference(image string) (Reference, error) {
+	if strings.ContainsAny(image, "@") {
+		return Reference{}, fmt.Errorf("invalid image %q: image must not include a digest", image)
+	}
+
+	ref := Reference{Registry: domain.DefaultRegistry, Repository: image}
+	if first, rest, found := strings.Cut(image, "/"); found && isRegistryHost(first) {
+		ref.Registry = NormalizeRegistry(first)
+		ref.Repository = rest
+	}
+
+	if strings.Contains(ref.Repository, ":") {
+		return Reference{}, fmt.Errorf("invalid image %q: image must not include a tag", image)
+	}
+	if !repositoryPattern.MatchString(ref.Repository) {
+		return Reference{}, fmt.Errorf("invalid image %q", image)
+	}
+	if ref.Registry == domain.DefaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	return ref, nil
+}
+
+// String returns the image name the reference was parsed from, in its canonical form
+func (r Reference) String() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// NormalizeRegistry returns the canonical name of a registry host, so credentials for Docker Hub
+// match however its host is spelled
+func NormalizeRegistry(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "/"))
+	switch host {
+	case "index.docker.io", dockerHubAPIHost:
+		return domain.DefaultRegistry
+	}
+	return host
+}
+
+// IsDigest checks if a string is a content digest
+func IsDigest(digest string) bool {
+	return digestPattern.MatchString(digest)
+}
+
+// isRegistryHost checks if the first component of an image name is a registry host rather
+// than a Docker Hub namespace: hosts contain a dot or a port, or are localhost
+func isRegistryHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// apiBaseURL returns the base URL of a registry's distribution API. Loopback registries are
+// served over plain HTTP, as Docker treats them as insecure registries by default.
+func apiBaseURL(registry string) string {
+	if registry == domain.DefaultRegistry {
+		return "https://" + dockerHubAPIHost
+	}
+
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return "http://" + registry
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
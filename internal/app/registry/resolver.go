@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+// Resolver resolves image tags to digests with an organization's registry credentials
+type Resolver struct {
+	client   *Client
+	credRepo repo.RegistryCredentialRepository
+	crypto   *crypto.Crypto
+}
+
+// NewResolver creates a resolver
+func NewResolver(client *Client, credRepo repo.RegistryCredentialRepository, crypto *crypto.Crypto) *Resolver {
+	return &Resolver{
+		client:   client,
+		credRepo: credRepo,
+		crypto:   crypto,
+	}
+}
+
+// ResolveImageDigest returns the digest an image tag points to, authenticating with the
+// organization's credentials for the image's registry if it has any
+func (r *Resolver) ResolveImageDigest(ctx context.Context, orgID uuid.UUID, image, tag string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	creds, err := r.credentials(ctx, orgID, ref.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	return r.client.ResolveDigest(ctx, ref, tag, creds)
+}
+
+// PullCredentials returns the registry of an image and the organization's credentials for it,
+// or nil credentials if the image is pulled anonymously
+func (r *Resolver) PullCredentials(ctx context.Context, orgID uuid.UUID, image string) (string, *Credentials, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", nil, err
+	}
+
+	creds, err := r.credentials(ctx, orgID, ref.Registry)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return ref.Registry, creds, nil
+}
+
+// credentials returns the organization's credentials for a registry, or nil for anonymous access
+func (r *Resolver) credentials(ctx context.Context, orgID uuid.UUID, registry string) (*Credentials, error) {
+	cred, err := r.credRepo.GetRegistryCredentialByRegistry(ctx, orgID, registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+	if cred == nil {
+		return nil, nil
+	}
+
+	password, err := r.crypto.Decrypt(cred.PasswordEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt registry credentials: %w", err)
+	}
+
+	return &Credentials{Username: cred.Username, Password: string(password)}, nil
+}
@@ -0,0 +1,377 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/registry"
+	"github.com/PouryDev/oneclick/internal/app/source"
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+// Resolver resolves the desired state of releases, which the deployment worker rolls out and the
+// deploy preview renders
+type Resolver struct {
+	specRepo     repo.ApplicationSpecRepository
+	secretRepo   repo.AppSecretRepository
+	domainRepo   repo.DomainRepository
+	policyRepo   repo.NamespacePolicyRepository
+	repoRepo     repo.RepositoryRepository
+	snapshotRepo repo.ReleaseSnapshotRepository
+	registry     *registry.Resolver
+	crypto       *crypto.Crypto
+	generator    *deployment.DeploymentGenerator
+	renderer     *source.Renderer
+}
+
+// NewResolver creates a resolver
+func NewResolver(
+	specRepo repo.ApplicationSpecRepository,
+	secretRepo repo.AppSecretRepository,
+	domainRepo repo.DomainRepository,
+	policyRepo repo.NamespacePolicyRepository,
+	repoRepo repo.RepositoryRepository,
+	snapshotRepo repo.ReleaseSnapshotRepository,
+	registry *registry.Resolver,
+	crypto *crypto.Crypto,
+) *Resolver {
+	return &Resolver{
+		specRepo:     specRepo,
+		secretRepo:   secretRepo,
+		domainRepo:   domainRepo,
+		policyRepo:   policyRepo,
+		repoRepo:     repoRepo,
+		snapshotRepo: snapshotRepo,
+		registry:     registry,
+		crypto:       crypto,
+		generator:    deployment.NewDeploymentGenerator(),
+		renderer:     source.NewRenderer(),
+	}
+}
+
+// State is the desired state of a release
+type State struct {
+	Spec    *domain.DeploymentSpec
+	Config  *deployment.DeploymentConfig
+	Domains []string // Domain names the Ingress routes to the release
+}
+
+// Resolve returns the desired state of a release deployed to an environment, or to its application
+func (r *Resolver) Resolve(ctx context.Context, app *domain.Application, release *domain.Release, env *domain.Environment, meta *domain.ReleaseMeta, spec *domain.DeploymentSpec) (*State, error) {
+	if spec == nil {
+		var err error
+		spec, err = r.Spec(ctx, app.ID, meta.SpecVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	secrets, err := r.secrets(ctx, app.ID, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := r.domains(ctx, app.ID, release.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	registryAuths, err := r.registryAuths(ctx, app.OrgID, release.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	isolation, err := r.isolation(ctx, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.state(app, release, env, meta, spec, &snapshot{
+		Secrets:       secrets,
+		RegistryAuths: registryAuths,
+		Domains:       domains,
+		Isolation:     isolation,
+	}), nil
+}
+
+// ErrNotRecorded is returned for a release without a snapshot of what it was rolled out with
+var ErrNotRecorded = errors.New("release has no snapshot of what it was rolled out with; redeploy it to record one")
+
+// ResolvePinned returns the desired state of a release as it was last rolled out
+func (r *Resolver) ResolvePinned(ctx context.Context, app *domain.Application, release *domain.Release, env *domain.Environment, meta *domain.ReleaseMeta) (*State, error) {
+	if meta.SnapshotID == "" {
+		return nil, ErrNotRecorded
+	}
+
+	spec := domain.DefaultDeploymentSpec()
+	if meta.SpecVersion > 0 {
+		pinned, err := r.Spec(ctx, app.ID, meta.SpecVersion)
+		if err != nil {
+			return nil, err
+		}
+		spec = *pinned
+	}
+
+	s, err := r.loadSnapshot(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	return r.state(app, release, env, meta, &spec, s), nil
+}
+
+// state generates the deployment configuration of a release from its spec and the rest of what it
+// is rolled out with
+func (r *Resolver) state(app *domain.Application, release *domain.Release, env *domain.Environment, meta *domain.ReleaseMeta, spec *domain.DeploymentSpec, s *snapshot) *State {
+	config := r.generator.GenerateFromSpec(app, release, meta, spec)
+	if env != nil {
+		r.generator.ApplyEnvironment(config, env)
+	}
+	config.Secrets = s.Secrets
+	config.RegistryAuths = s.RegistryAuths
+	config.Isolation = s.Isolation
+
+	return &State{Spec: spec, Config: config, Domains: s.Domains}
+}
+
+// Record pins the release metadata to a snapshot of what the release is rolled out with
+func (r *Resolver) Record(ctx context.Context, app *domain.Application, meta *domain.ReleaseMeta, config *deployment.DeploymentConfig, domains []string) (bool, error) {
+	data, err := json.Marshal(&snapshot{
+		Secrets:       config.Secrets,
+		RegistryAuths: config.RegistryAuths,
+		Domains:       domains,
+		Isolation:     config.Isolation,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if meta.SnapshotID != "" {
+		pinned, err := r.loadSnapshot(ctx, meta)
+		if err != nil {
+			return false, err
+		}
+		pinnedData, err := json.Marshal(pinned)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(data, pinnedData) {
+			return false, nil
+		}
+	}
+
+	encrypted, err := r.crypto.Encrypt(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt release snapshot: %w", err)
+	}
+	created, err := r.snapshotRepo.CreateReleaseSnapshot(ctx, app.ID, encrypted)
+	if err != nil {
+		return false, fmt.Errorf("failed to create release snapshot: %w", err)
+	}
+	meta.SnapshotID = created.ID.String()
+	return true, nil
+}
+
+// Spec returns the deployment spec version a release is pinned to, or the latest one
+func (r *Resolver) Spec(ctx context.Context, appID uuid.UUID, version int) (*domain.DeploymentSpec, error) {
+	if version > 0 {
+		spec, err := r.specRepo.GetApplicationSpecByVersion(ctx, appID, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment spec: %w", err)
+		}
+		if spec == nil {
+			return nil, fmt.Errorf("deployment spec version %d not found", version)
+		}
+		return &spec.Spec, nil
+	}
+
+	spec, err := r.specRepo.GetLatestApplicationSpec(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment spec: %w", err)
+	}
+	if spec == nil {
+		defaultSpec := domain.DefaultDeploymentSpec()
+		return &defaultSpec, nil
+	}
+	return &spec.Spec, nil
+}
+
+// ImageDigest returns the digest a release is pinned to, or the one its tag points to
+func (r *Resolver) ImageDigest(ctx context.Context, orgID uuid.UUID, release *domain.Release) (string, error) {
+	if release.ImageDigest != "" {
+		return release.ImageDigest, nil
+	}
+	digest, err := r.registry.ResolveImageDigest(ctx, orgID, release.Image, release.Tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve image digest: %w", err)
+	}
+	return digest, nil
+}
+
+// RenderSource renders the deploy source of a release from its application's repository, at the
+// commit the release was built from, or the head of its branch or of the default branch
+func (r *Resolver) RenderSource(ctx context.Context, app *domain.Application, meta *domain.ReleaseMeta, config *deployment.DeploymentConfig) (*source.Result, error) {
+	_, repository, err := r.RepositoryAccess(ctx, app.RepoID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.renderer.Render(ctx, &source.Request{
+		Repository:  repository,
+		Ref:         SourceRef(app, meta),
+		Source:      config.Source,
+		ReleaseName: config.AppName,
+		Namespace:   config.Namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render deploy source: %w", err)
+	}
+	return result, nil
+}
+
+// RepositoryAccess returns a repository connected to an organization and what git needs to
+// access it, with its token decrypted
+func (r *Resolver) RepositoryAccess(ctx context.Context, repoID uuid.UUID) (*domain.Repository, source.Repository, error) {
+	repository, err := r.repoRepo.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return nil, source.Repository{}, fmt.Errorf("failed to get repository: %w", err)
+	}
+	if repository == nil {
+		return nil, source.Repository{}, fmt.Errorf("repository not found")
+	}
+	repoConfig, err := repository.GetConfig()
+	if err != nil {
+		return nil, source.Repository{}, fmt.Errorf("failed to parse repository config: %w", err)
+	}
+	var token string
+	if repoConfig.Token != "" {
+		token, err = r.crypto.DecryptString(repoConfig.Token)
+		if err != nil {
+			return nil, source.Repository{}, fmt.Errorf("failed to decrypt repository token: %w", err)
+		}
+	}
+	return repository, source.Repository{URL: repository.URL, Type: repository.Type, Token: token}, nil
+}
+
+// SourceRef returns the commit, branch or default branch a release's source is rendered at
+func SourceRef(app *domain.Application, meta *domain.ReleaseMeta) string {
+	switch {
+	case meta.CommitSHA != "":
+		return meta.CommitSHA
+	case meta.Branch != "":
+		return meta.Branch
+	default:
+		return app.DefaultBranch
+	}
+}
+
+// snapshot is what a release was rolled out with besides its spec
+type snapshot struct {
+	Secrets       map[string]string                  `json:"secrets"`
+	RegistryAuths map[string]deployment.RegistryAuth `json:"registry_auths,omitempty"`
+	Domains       []string                           `json:"domains"`
+	Isolation     *deployment.IsolationConfig        `json:"isolation"`
+}
+
+// loadSnapshot returns the snapshot a release's metadata pins
+func (r *Resolver) loadSnapshot(ctx context.Context, meta *domain.ReleaseMeta) (*snapshot, error) {
+	id, err := uuid.Parse(meta.SnapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release snapshot %q: %w", meta.SnapshotID, err)
+	}
+	stored, err := r.snapshotRepo.GetReleaseSnapshotByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release snapshot: %w", err)
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("release snapshot %s not found", id)
+	}
+	data, err := r.crypto.Decrypt(stored.DataEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt release snapshot: %w", err)
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse release snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// secrets returns the decrypted secret values of the snapshot a release pins, or the application's
+// current ones
+func (r *Resolver) secrets(ctx context.Context, appID uuid.UUID, meta *domain.ReleaseMeta) (map[string]string, error) {
+	if meta.SnapshotID != "" {
+		s, err := r.loadSnapshot(ctx, meta)
+		if err != nil {
+			return nil, err
+		}
+		return s.Secrets, nil
+	}
+
+	appSecrets, err := r.secretRepo.GetAppSecretsByAppID(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application secrets: %w", err)
+	}
+
+	secrets := make(map[string]string, len(appSecrets))
+	for _, secret := range appSecrets {
+		value, err := r.crypto.Decrypt(secret.ValueEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", secret.Name, err)
+		}
+		secrets[secret.Name] = string(value)
+	}
+	return secrets, nil
+}
+
+// registryAuths returns the organization's credentials for the registry of an image, which the
+// application's pods pull the image with, or nil if the image is pulled anonymously
+func (r *Resolver) registryAuths(ctx context.Context, orgID uuid.UUID, image string) (map[string]deployment.RegistryAuth, error) {
+	host, creds, err := r.registry.PullCredentials(ctx, orgID, image)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return nil, nil
+	}
+	return map[string]deployment.RegistryAuth{
+		host: {Username: creds.Username, Password: creds.Password},
+	}, nil
+}
+
+// isolation returns the isolation of the namespaces of an organization's applications, under its
+// namespace policy or the default policy if it has not configured one
+func (r *Resolver) isolation(ctx context.Context, orgID uuid.UUID) (*deployment.IsolationConfig, error) {
+	policy, err := r.policyRepo.GetNamespacePolicy(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace policy: %w", err)
+	}
+	if policy == nil {
+		policy = domain.DefaultNamespacePolicy(orgID)
+	}
+	return deployment.NewIsolationConfig(policy), nil
+}
+
+// domains returns the domain names the Ingress of an application's environment routes, or of the
+// application itself if environmentID is nil
+func (r *Resolver) domains(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID) ([]string, error) {
+	appDomains, err := r.domainRepo.GetDomainsByAppID(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application domains: %w", err)
+	}
+
+	domains := make([]string, 0, len(appDomains))
+	for _, d := range appDomains {
+		if d.InEnvironment(environmentID) {
+			domains = append(domains, d.Domain)
+		}
+	}
+	return domains, nil
+}
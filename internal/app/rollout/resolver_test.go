@@ -0,0 +1,239 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// newTestCrypto creates a Crypto instance backed by a fixed test master key
+func newTestCrypto(t *testing.T) *crypto.Crypto {
+	t.Setenv("ONECLICK_MASTER_KEY", "!@#$%^&*()_+-=[]{}|;':\",./<>?123")
+	cryptoService, err := crypto.NewCrypto()
+	require.NoError(t, err)
+	return cryptoService
+}
+
+type MockApplicationSpecRepository struct {
+	mock.Mock
+}
+
+func (m *MockApplicationSpecRepository) CreateApplicationSpec(ctx context.Context, appID, createdBy uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, createdBy, spec)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetLatestApplicationSpec(ctx context.Context, appID uuid.UUID) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetApplicationSpecByVersion(ctx context.Context, appID uuid.UUID, version int) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+type MockAppSecretRepository struct {
+	mock.Mock
+}
+
+func (m *MockAppSecretRepository) CreateAppSecret(ctx context.Context, secret *domain.AppSecret) (*domain.AppSecret, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretByName(ctx context.Context, appID uuid.UUID, name string) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.AppSecret, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) UpdateAppSecretValue(ctx context.Context, appID uuid.UUID, name string, valueEncrypted []byte) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name, valueEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) DeleteAppSecret(ctx context.Context, appID uuid.UUID, name string) error {
+	args := m.Called(ctx, appID, name)
+	return args.Error(0)
+}
+
+type MockReleaseSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseSnapshotRepository) CreateReleaseSnapshot(ctx context.Context, appID uuid.UUID, dataEncrypted []byte) (*domain.ReleaseSnapshot, error) {
+	args := m.Called(ctx, appID, dataEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseSnapshot), args.Error(1)
+}
+
+func (m *MockReleaseSnapshotRepository) GetReleaseSnapshotByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseSnapshot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseSnapshot), args.Error(1)
+}
+
+func TestResolver_Record(t *testing.T) {
+	ctx := context.Background()
+	cryptoService := newTestCrypto(t)
+	app := &domain.Application{ID: uuid.New()}
+
+	current, err := cryptoService.Encrypt([]byte("rotated"))
+	require.NoError(t, err)
+	secretRepo := &MockAppSecretRepository{}
+	secretRepo.On("GetAppSecretsByAppID", ctx, app.ID).Return([]domain.AppSecret{{Name: "API_KEY", ValueEncrypted: current}}, nil)
+
+	stored := &domain.ReleaseSnapshot{ID: uuid.New(), AppID: app.ID}
+	snapshotRepo := &MockReleaseSnapshotRepository{}
+	snapshotRepo.On("CreateReleaseSnapshot", ctx, app.ID, mock.Anything).Run(func(args mock.Arguments) {
+		stored.DataEncrypted = args.Get(2).([]byte)
+	}).Return(stored, nil).Once()
+	snapshotRepo.On("GetReleaseSnapshotByID", ctx, stored.ID).Return(stored, nil)
+
+	resolver := NewResolver(nil, secretRepo, nil, nil, nil, snapshotRepo, nil, cryptoService)
+
+	config := &deployment.DeploymentConfig{
+		Secrets:   map[string]string{"API_KEY": "original"},
+		Isolation: &deployment.IsolationConfig{IngressNamespaces: []string{"ingress-nginx"}},
+	}
+	meta := &domain.ReleaseMeta{}
+	pinned, err := resolver.Record(ctx, app, meta, config, []string{"api.example.com"})
+	require.NoError(t, err)
+	assert.True(t, pinned)
+	assert.Equal(t, stored.ID.String(), meta.SnapshotID)
+	assert.NotContains(t, string(stored.DataEncrypted), "original", "the snapshot is encrypted")
+
+	pinned, err = resolver.Record(ctx, app, meta, config, []string{"api.example.com"})
+	require.NoError(t, err)
+	assert.False(t, pinned, "a rollout with the same inputs keeps the snapshot")
+
+	changed := &domain.ReleaseSnapshot{ID: uuid.New(), AppID: app.ID}
+	snapshotRepo.On("CreateReleaseSnapshot", ctx, app.ID, mock.Anything).Return(changed, nil).Once()
+	promoted := *meta
+	pinned, err = resolver.Record(ctx, app, &promoted, config, []string{"api.example.com", "www.example.com"})
+	require.NoError(t, err)
+	assert.True(t, pinned, "a rollout with other domains takes a new snapshot")
+	assert.Equal(t, changed.ID.String(), promoted.SnapshotID)
+
+	secrets, err := resolver.secrets(ctx, app.ID, meta)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "original"}, secrets, "a release pinned to a snapshot deploys its secrets")
+
+	secrets, err = resolver.secrets(ctx, app.ID, &domain.ReleaseMeta{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "rotated"}, secrets, "a release without a snapshot deploys the current secrets")
+
+	snapshotRepo.AssertExpectations(t)
+}
+
+func TestResolver_ResolvePinned(t *testing.T) {
+	ctx := context.Background()
+	cryptoService := newTestCrypto(t)
+	app := &domain.Application{ID: uuid.New(), OrgID: uuid.New(), Name: "api", Namespace: "acme-api"}
+	release := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v1"}
+
+	stored := &domain.ReleaseSnapshot{ID: uuid.New(), AppID: app.ID}
+	snapshotRepo := &MockReleaseSnapshotRepository{}
+	snapshotRepo.On("CreateReleaseSnapshot", ctx, app.ID, mock.Anything).Run(func(args mock.Arguments) {
+		stored.DataEncrypted = args.Get(2).([]byte)
+	}).Return(stored, nil)
+	snapshotRepo.On("GetReleaseSnapshotByID", ctx, stored.ID).Return(stored, nil)
+
+	resolver := NewResolver(nil, nil, nil, nil, nil, snapshotRepo, nil, cryptoService)
+
+	_, err := resolver.ResolvePinned(ctx, app, release, nil, &domain.ReleaseMeta{})
+	assert.ErrorIs(t, err, ErrNotRecorded)
+
+	isolation := &deployment.IsolationConfig{ResourceQuota: map[string]string{"requests.cpu": "4"}, IngressNamespaces: []string{"ingress-nginx"}}
+	meta := &domain.ReleaseMeta{}
+	_, err = resolver.Record(ctx, app, meta, &deployment.DeploymentConfig{
+		Secrets:       map[string]string{"API_KEY": "original"},
+		RegistryAuths: map[string]deployment.RegistryAuth{"ghcr.io": {Username: "acme", Password: "token"}},
+		Isolation:     isolation,
+	}, []string{"api.example.com"})
+	require.NoError(t, err)
+
+	// No repository but the snapshot's is read: the application's current state cannot leak in
+	state, err := resolver.ResolvePinned(ctx, app, release, nil, meta)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "original"}, state.Config.Secrets)
+	assert.Equal(t, map[string]deployment.RegistryAuth{"ghcr.io": {Username: "acme", Password: "token"}}, state.Config.RegistryAuths)
+	assert.Equal(t, isolation, state.Config.Isolation)
+	assert.Equal(t, []string{"api.example.com"}, state.Domains)
+	defaultSpec := domain.DefaultDeploymentSpec()
+	assert.Equal(t, &defaultSpec, state.Spec, "a release pinned to no spec version ran the default spec")
+}
+
+func TestResolver_Spec(t *testing.T) {
+	ctx := context.Background()
+	appID := uuid.New()
+	pinned := &domain.ApplicationSpec{Version: 2, Spec: domain.DeploymentSpec{Ports: []domain.PortSpec{{Port: 8080}}}}
+	latest := &domain.ApplicationSpec{Version: 3, Spec: domain.DeploymentSpec{Ports: []domain.PortSpec{{Port: 9090}}}}
+
+	specRepo := &MockApplicationSpecRepository{}
+	specRepo.On("GetApplicationSpecByVersion", ctx, appID, 2).Return(pinned, nil)
+	specRepo.On("GetApplicationSpecByVersion", ctx, appID, 7).Return(nil, nil)
+	specRepo.On("GetLatestApplicationSpec", ctx, appID).Return(latest, nil)
+	resolver := NewResolver(specRepo, nil, nil, nil, nil, nil, nil, nil)
+
+	spec, err := resolver.Spec(ctx, appID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &pinned.Spec, spec, "a pinned release runs its version")
+
+	spec, err = resolver.Spec(ctx, appID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &latest.Spec, spec, "a release that is not pinned runs the latest version")
+
+	_, err = resolver.Spec(ctx, appID, 7)
+	assert.EqualError(t, err, "deployment spec version 7 not found")
+
+	unsaved := &MockApplicationSpecRepository{}
+	unsaved.On("GetLatestApplicationSpec", ctx, appID).Return(nil, nil)
+	spec, err = NewResolver(unsaved, nil, nil, nil, nil, nil, nil, nil).Spec(ctx, appID, 0)
+	require.NoError(t, err)
+	defaultSpec := domain.DefaultDeploymentSpec()
+	assert.Equal(t, &defaultSpec, spec, "an application without a saved spec runs the default spec")
+}
+
+func TestSourceRef(t *testing.T) {
+	app := &domain.Application{DefaultBranch: "main"}
+
+	assert.Equal(t, "4f7a9c2", SourceRef(app, &domain.ReleaseMeta{CommitSHA: "4f7a9c2", Branch: "release"}))
+	assert.Equal(t, "release", SourceRef(app, &domain.ReleaseMeta{Branch: "release"}))
+	assert.Equal(t, "main", SourceRef(app, &domain.ReleaseMeta{}))
+}
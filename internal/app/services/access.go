@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+// accessibleApplication returns an application and the user's role in its organization
+func accessibleApplication(ctx context.Context, appRepo repo.ApplicationRepository, orgRepo repo.OrganizationRepository, userID, appID uuid.UUID) (*domain.Application, string, error) {
+	app, err := appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, "", err
+	}
+	if app == nil {
+		return nil, "", errors.New("application not found")
+	}
+
+	role, err := orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" {
+		return nil, "", errors.New("user does not have access to this organization")
+	}
+
+	return app, role, nil
+}
+
+// requireManager allows only owners and admins to perform an action
+func requireManager(role, action string) error {
+	if role != domain.RoleOwner && role != domain.RoleAdmin {
+		return fmt.Errorf("insufficient permissions to %s", action)
+	}
+	return nil
+}
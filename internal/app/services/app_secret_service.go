@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type AppSecretService interface {
+	GetAppSecrets(ctx context.Context, userID, appID uuid.UUID) ([]domain.AppSecretResponse, error)
+	CreateAppSecret(ctx context.Context, userID, appID uuid.UUID, req *domain.CreateAppSecretRequest) (*domain.AppSecretResponse, error)
+	UpdateAppSecret(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.UpdateAppSecretRequest) (*domain.AppSecretResponse, error)
+	DeleteAppSecret(ctx context.Context, userID, appID uuid.UUID, name string) error
+}
+
+type appSecretService struct {
+	secretRepo repo.AppSecretRepository
+	appRepo    repo.ApplicationRepository
+	orgRepo    repo.OrganizationRepository
+	crypto     *crypto.Crypto
+}
+
+func NewAppSecretService(
+	secretRepo repo.AppSecretRepository,
+	appRepo repo.ApplicationRepository,
+	orgRepo repo.OrganizationRepository,
+	crypto *crypto.Crypto,
+) AppSecretService {
+	return &appSecretService{
+		secretRepo: secretRepo,
+		appRepo:    appRepo,
+		orgRepo:    orgRepo,
+		crypto:     crypto,
+	}
+}
+
+func (s *appSecretService) GetAppSecrets(ctx context.Context, userID, appID uuid.UUID) ([]domain.AppSecretResponse, error) {
+	if _, _, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID); err != nil {
+		return nil, err
+	}
+
+	secrets, err := s.secretRepo.GetAppSecretsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]domain.AppSecretResponse, 0, len(secrets))
+	for _, secret := range secrets {
+		responses = append(responses, secret.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (s *appSecretService) CreateAppSecret(ctx context.Context, userID, appID uuid.UUID, req *domain.CreateAppSecretRequest) (*domain.AppSecretResponse, error) {
+	if err := s.checkManageAccess(ctx, userID, appID); err != nil {
+		return nil, err
+	}
+
+	if err := validateAppSecretName(req.Name); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.secretRepo.GetAppSecretByName(ctx, appID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("secret already exists")
+	}
+
+	valueEncrypted, err := s.crypto.Encrypt([]byte(req.Value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	created, err := s.secretRepo.CreateAppSecret(ctx, &domain.AppSecret{
+		AppID:          appID,
+		Name:           req.Name,
+		ValueEncrypted: valueEncrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := created.ToResponse()
+	return &response, nil
+}
+
+func (s *appSecretService) UpdateAppSecret(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.UpdateAppSecretRequest) (*domain.AppSecretResponse, error) {
+	if err := s.checkManageAccess(ctx, userID, appID); err != nil {
+		return nil, err
+	}
+
+	valueEncrypted, err := s.crypto.Encrypt([]byte(req.Value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	updated, err := s.secretRepo.UpdateAppSecretValue(ctx, appID, name, valueEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("secret not found")
+	}
+
+	response := updated.ToResponse()
+	return &response, nil
+}
+
+func (s *appSecretService) DeleteAppSecret(ctx context.Context, userID, appID uuid.UUID, name string) error {
+	if err := s.checkManageAccess(ctx, userID, appID); err != nil {
+		return err
+	}
+
+	existing, err := s.secretRepo.GetAppSecretByName(ctx, appID, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("secret not found")
+	}
+
+	return s.secretRepo.DeleteAppSecret(ctx, appID, name)
+}
+
+// checkManageAccess allows only owners and admins to change an application's secrets
+func (s *appSecretService) checkManageAccess(ctx context.Context, userID, appID uuid.UUID) error {
+	_, role, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID)
+	if err != nil {
+		return err
+	}
+	return requireManager(role, "manage application secrets")
+}
+
+// validateAppSecretName checks that a secret name is usable both as an environment variable
+// and as a key of the Kubernetes Secret it is rendered into
+func validateAppSecretName(name string) error {
+	errs := append(validation.IsEnvVarName(name), validation.IsConfigMapKey(name)...)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
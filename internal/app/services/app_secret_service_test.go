@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockAppSecretRepository is a mock implementation of AppSecretRepository
+type MockAppSecretRepository struct {
+	mock.Mock
+}
+
+func (m *MockAppSecretRepository) CreateAppSecret(ctx context.Context, secret *domain.AppSecret) (*domain.AppSecret, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretByName(ctx context.Context, appID uuid.UUID, name string) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.AppSecret, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) UpdateAppSecretValue(ctx context.Context, appID uuid.UUID, name string, valueEncrypted []byte) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name, valueEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) DeleteAppSecret(ctx context.Context, appID uuid.UUID, name string) error {
+	args := m.Called(ctx, appID, name)
+	return args.Error(0)
+}
+
+func TestAppSecretService_CreateAppSecret_EncryptsValue(t *testing.T) {
+	secretRepo := &MockAppSecretRepository{}
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	cryptoService := newTestCrypto(t)
+
+	service := NewAppSecretService(secretRepo, appRepo, orgRepo, cryptoService)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	secretRepo.On("GetAppSecretByName", ctx, appID, "DATABASE_URL").Return(nil, nil)
+
+	var stored *domain.AppSecret
+	secretRepo.On("CreateAppSecret", ctx, mock.AnythingOfType("*domain.AppSecret")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.AppSecret)
+		}).
+		Return(&domain.AppSecret{ID: uuid.New(), AppID: appID, Name: "DATABASE_URL"}, nil)
+
+	resp, err := service.CreateAppSecret(ctx, userID, appID, &domain.CreateAppSecretRequest{
+		Name:  "DATABASE_URL",
+		Value: "postgres://user:pass@db/app",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "DATABASE_URL", resp.Name)
+
+	// The value is stored encrypted and decrypts back to the original
+	assert.NotContains(t, string(stored.ValueEncrypted), "postgres://")
+	decrypted, err := cryptoService.Decrypt(stored.ValueEncrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://user:pass@db/app", string(decrypted))
+
+	secretRepo.AssertExpectations(t)
+}
+
+func TestAppSecretService_CreateAppSecret_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		secretName  string
+		existing    *domain.AppSecret
+		expectError string
+	}{
+		{
+			name:        "member cannot manage secrets",
+			role:        "member",
+			secretName:  "API_KEY",
+			expectError: "insufficient permissions",
+		},
+		{
+			name:        "invalid environment variable name",
+			role:        domain.RoleOwner,
+			secretName:  "1-API KEY",
+			expectError: "invalid secret name",
+		},
+		{
+			name:        "duplicate name",
+			role:        domain.RoleOwner,
+			secretName:  "API_KEY",
+			existing:    &domain.AppSecret{Name: "API_KEY"},
+			expectError: "secret already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretRepo := &MockAppSecretRepository{}
+			appRepo := &MockApplicationRepository{}
+			orgRepo := &MockOrganizationRepository{}
+
+			service := NewAppSecretService(secretRepo, appRepo, orgRepo, newTestCrypto(t))
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+			appID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+			if tt.existing != nil {
+				secretRepo.On("GetAppSecretByName", ctx, appID, tt.secretName).Return(tt.existing, nil)
+			}
+
+			_, err := service.CreateAppSecret(ctx, userID, appID, &domain.CreateAppSecretRequest{Name: tt.secretName, Value: "v"})
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+			secretRepo.AssertNotCalled(t, "CreateAppSecret", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAppSecretService_UpdateAppSecret_NotFound(t *testing.T) {
+	secretRepo := &MockAppSecretRepository{}
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewAppSecretService(secretRepo, appRepo, orgRepo, newTestCrypto(t))
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleOwner, nil)
+	secretRepo.On("UpdateAppSecretValue", ctx, appID, "API_KEY", mock.AnythingOfType("[]uint8")).Return(nil, nil)
+
+	_, err := service.UpdateAppSecret(ctx, userID, appID, "API_KEY", &domain.UpdateAppSecretRequest{Value: "rotated"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "secret not found", err.Error())
+}
@@ -1,15 +1,27 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 
+	"github.com/PouryDev/oneclick/internal/app/crypto"
 	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/kubeclient"
+	"github.com/PouryDev/oneclick/internal/app/registry"
 	"github.com/PouryDev/oneclick/internal/domain"
 	"github.com/PouryDev/oneclick/internal/repo"
 )
@@ -18,10 +30,15 @@ type ApplicationService interface {
 	CreateApplication(ctx context.Context, userID, clusterID uuid.UUID, req *domain.CreateApplicationRequest) (*domain.ApplicationResponse, error)
 	GetApplicationsByCluster(ctx context.Context, userID, clusterID uuid.UUID) ([]domain.ApplicationSummary, error)
 	GetApplication(ctx context.Context, userID, appID uuid.UUID) (*domain.ApplicationDetail, error)
-	DeleteApplication(ctx context.Context, userID, appID uuid.UUID) error
+	DeleteApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeleteApplicationRequest) (*domain.Job, error)
 	DeployApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeployApplicationRequest) (*domain.DeployApplicationResponse, error)
 	RollbackApplication(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.DeployApplicationResponse, error)
+	PromoteRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error)
+	AbortRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error)
 	GetReleasesByApplication(ctx context.Context, userID, appID uuid.UUID) ([]domain.ReleaseSummary, error)
+	SubscribeReleaseProgress(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.Release, *deployment.ProgressSubscription, error)
+	GetApplicationSpec(ctx context.Context, userID, appID uuid.UUID) (*domain.ApplicationSpecResponse, error)
+	UpdateApplicationSpec(ctx context.Context, userID, appID uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpecResponse, error)
 }
 
 type applicationService struct {
@@ -30,7 +47,14 @@ type applicationService struct {
 	clusterRepo repo.ClusterRepository
 	repoRepo    repo.RepositoryRepository
 	orgRepo     repo.OrganizationRepository
+	jobRepo     repo.JobRepository
+	specRepo    repo.ApplicationSpecRepository
+	envRepo     repo.EnvironmentRepository
+	driftRepo   repo.ReleaseDriftRepository
+	progress    *deployment.ProgressBroker
 	deployer    *deployment.DeploymentGenerator
+	crypto      crypto.CryptoService
+	kubeClient  kubeclient.KubernetesClientInterface // Used for every cluster when set, for tests
 }
 
 func NewApplicationService(
@@ -39,6 +63,13 @@ func NewApplicationService(
 	clusterRepo repo.ClusterRepository,
 	repoRepo repo.RepositoryRepository,
 	orgRepo repo.OrganizationRepository,
+	jobRepo repo.JobRepository,
+	specRepo repo.ApplicationSpecRepository,
+	envRepo repo.EnvironmentRepository,
+	driftRepo repo.ReleaseDriftRepository,
+	progress *deployment.ProgressBroker,
+	cryptoService crypto.CryptoService,
+	kubeClient kubeclient.KubernetesClientInterface,
 ) ApplicationService {
 	return &applicationService{
 		appRepo:     appRepo,
@@ -46,7 +77,14 @@ func NewApplicationService(
 		clusterRepo: clusterRepo,
 		repoRepo:    repoRepo,
 		orgRepo:     orgRepo,
+		jobRepo:     jobRepo,
+		specRepo:    specRepo,
+		envRepo:     envRepo,
+		driftRepo:   driftRepo,
+		progress:    progress,
 		deployer:    deployment.NewDeploymentGenerator(),
+		crypto:      cryptoService,
+		kubeClient:  kubeClient,
 	}
 }
 
@@ -96,11 +134,33 @@ func (s *applicationService) CreateApplication(ctx context.Context, userID, clus
 		return nil, errors.New("application name already exists in this cluster")
 	}
 
+	// The application's namespace is named after it and its organization, so applications of
+	// organizations sharing a cluster never deploy to the same namespace
+	namespace := domain.ApplicationNamespace(cluster.OrgID, req.Name)
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid application name %q: must be lowercase alphanumeric characters or '-'", req.Name)
+	}
+	existingApp, err = s.appRepo.GetApplicationByNamespaceInCluster(ctx, clusterID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if existingApp != nil {
+		return nil, fmt.Errorf("namespace %s is already used by another application in this cluster", namespace)
+	}
+	existingEnv, err := s.envRepo.GetEnvironmentByNamespace(ctx, clusterID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if existingEnv != nil {
+		return nil, fmt.Errorf("namespace %s is already used by an environment in this cluster", namespace)
+	}
+
 	// Create application
 	application := &domain.Application{
 		OrgID:         cluster.OrgID,
 		ClusterID:     clusterID,
 		Name:          req.Name,
+		Namespace:     namespace,
 		RepoID:        repoID,
 		DefaultBranch: req.DefaultBranch,
 	}
@@ -188,39 +248,151 @@ func (s *applicationService) GetApplication(ctx context.Context, userID, appID u
 		detail.Status = string(latestRelease.Status)
 	}
 
+	replicas, volumes, err := s.runtimeStatuses(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	detail.Replicas = replicas
+	detail.Volumes = volumes
+
+	drift, err := s.driftRepo.GetReleaseDriftByAppID(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release drift: %w", err)
+	}
+	detail.Drift = drift
+
 	return detail, nil
 }
 
-func (s *applicationService) DeleteApplication(ctx context.Context, userID, appID uuid.UUID) error {
+// runtimeStatuses returns the replicas and volumes of an application in each of its environments,
+// or in its own namespace if it has none. Namespaces it is not deployed to and clusters that cannot be
+// reached are left out, so the application is still shown while its cluster is down.
+func (s *applicationService) runtimeStatuses(ctx context.Context, app *domain.Application) ([]domain.ReplicaStatus, []domain.VolumeStatus, error) {
+	envs, err := s.envRepo.GetEnvironmentsByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(envs) == 0 {
+		envs = []domain.Environment{{ClusterID: app.ClusterID, Namespace: app.Namespace}}
+	}
+
+	clients := make(map[uuid.UUID]kubeclient.KubernetesClientInterface)
+	var statuses []domain.ReplicaStatus
+	var volumes []domain.VolumeStatus
+	for _, env := range envs {
+		client, ok := clients[env.ClusterID]
+		if !ok {
+			client, _ = s.clusterClient(ctx, env.ClusterID)
+			clients[env.ClusterID] = client
+		}
+		if client == nil {
+			continue
+		}
+
+		status, err := client.GetReplicaStatus(ctx, app.Name, env.Namespace)
+		if err != nil || status == nil {
+			continue
+		}
+		status.Environment = env.Name
+		statuses = append(statuses, *status)
+
+		envVolumes, err := client.GetVolumeStatuses(ctx, app.Name, env.Namespace)
+		if err != nil {
+			continue
+		}
+		for _, volume := range envVolumes {
+			volume.Environment = env.Name
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return statuses, volumes, nil
+}
+
+// clusterClient creates a Kubernetes client for a cluster from its kubeconfig
+func (s *applicationService) clusterClient(ctx context.Context, clusterID uuid.UUID) (kubeclient.KubernetesClientInterface, error) {
+	if s.kubeClient != nil {
+		return s.kubeClient, nil
+	}
+
+	cluster, err := s.clusterRepo.GetClusterByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("cluster not found")
+	}
+
+	kubeconfigBytes, err := s.crypto.Decrypt(cluster.KubeconfigEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt kubeconfig: %w", err)
+	}
+
+	client, err := kubeclient.NewKubernetesClient(kubeconfigBytes, zap.NewNop())
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// DeleteApplication queues the job that tears the application down in its clusters and then
+// deletes it. Deleting an application that is already being deleted returns the queued job.
+func (s *applicationService) DeleteApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeleteApplicationRequest) (*domain.Job, error) {
 	// Get application
 	app, err := s.appRepo.GetApplicationByID(ctx, appID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if app == nil {
-		return errors.New("application not found")
+		return nil, errors.New("application not found")
 	}
 
 	// Check if user has access to the organization
 	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if role == "" {
-		return errors.New("user does not have access to this organization")
+		return nil, errors.New("user does not have access to this organization")
 	}
 
 	// Only allow owners and admins to delete applications
 	if role != domain.RoleOwner && role != domain.RoleAdmin {
-		return errors.New("insufficient permissions to delete application")
+		return nil, errors.New("insufficient permissions to delete application")
 	}
 
-	err = s.appRepo.DeleteApplication(ctx, appID)
+	queued, err := s.queuedDeleteJob(ctx, app)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if queued != nil {
+		return queued, nil
 	}
 
-	return nil
+	job, err := s.jobRepo.CreateJob(ctx, app.NewDeleteJob(req.KeepData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue application deletion: %w", err)
+	}
+
+	return job, nil
+}
+
+// queuedDeleteJob returns the pending or running job deleting an application, if any
+func (s *applicationService) queuedDeleteJob(ctx context.Context, app *domain.Application) (*domain.Job, error) {
+	jobs, err := s.jobRepo.GetJobsByOrgID(ctx, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Type != domain.JobTypeApplicationDelete || job.Payload.Config["app_id"] != app.ID.String() {
+			continue
+		}
+		if job.Status == domain.JobStatusPending || job.Status == domain.JobStatusProcessing {
+			return job, nil
+		}
+	}
+	return nil, nil
 }
 
 func (s *applicationService) DeployApplication(ctx context.Context, userID, appID uuid.UUID, req *domain.DeployApplicationRequest) (*domain.DeployApplicationResponse, error) {
@@ -249,15 +421,45 @@ func (s *applicationService) DeployApplication(ctx context.Context, userID, appI
 	if req.Tag == "" {
 		return nil, errors.New("tag is required")
 	}
+	// The tag is resolved to a digest in the image's registry when the release is rolled out
+	if _, err := registry.ParseReference(req.Image); err != nil {
+		return nil, err
+	}
+
+	env, err := resolveDeployEnvironment(ctx, s.envRepo, appID, req.Environment)
+	if err != nil {
+		return nil, err
+	}
+	var environmentID *uuid.UUID
+	if env != nil {
+		environmentID = &env.ID
+	}
+
+	if err := CheckNoCanaryInProgress(ctx, s.releaseRepo, appID, environmentID); err != nil {
+		return nil, err
+	}
+
+	// Pin the release to the current deployment spec, so a rollback redeploys it unchanged
+	spec, err := s.specRepo.GetLatestApplicationSpec(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	meta := &domain.ReleaseMeta{}
+	if spec != nil {
+		meta.SpecVersion = spec.Version
+	}
 
 	// Create release record
 	release := &domain.Release{
-		AppID:     appID,
-		Image:     req.Image,
-		Tag:       req.Tag,
-		CreatedBy: userID,
-		Status:    domain.ReleaseStatusPending,
-		Meta:      json.RawMessage("{}"),
+		AppID:         appID,
+		EnvironmentID: environmentID,
+		Image:         req.Image,
+		Tag:           req.Tag,
+		CreatedBy:     userID,
+		Status:        domain.ReleaseStatusPending,
+	}
+	if err := release.SetMeta(meta); err != nil {
+		return nil, err
 	}
 
 	createdRelease, err := s.releaseRepo.CreateRelease(ctx, release)
@@ -265,15 +467,18 @@ func (s *applicationService) DeployApplication(ctx context.Context, userID, appI
 		return nil, err
 	}
 
-	// TODO: In a real implementation, this would publish to a message queue
-	// For now, we'll simulate the deployment process
-	go s.processDeployment(context.Background(), createdRelease)
+	if err := QueueDeployment(ctx, s.jobRepo, s.releaseRepo, app.OrgID, createdRelease); err != nil {
+		return nil, err
+	}
 
 	response := &domain.DeployApplicationResponse{
 		ReleaseID: createdRelease.ID,
 		Status:    string(createdRelease.Status),
 		Message:   "Deployment initiated",
 	}
+	if env != nil {
+		response.Environment = env.Name
+	}
 
 	return response, nil
 }
@@ -309,14 +514,27 @@ func (s *applicationService) RollbackApplication(ctx context.Context, userID, ap
 		return nil, errors.New("release does not belong to this application")
 	}
 
-	// Create new release with the same image/tag
+	if err := CheckNoCanaryInProgress(ctx, s.releaseRepo, appID, rollbackRelease.EnvironmentID); err != nil {
+		return nil, err
+	}
+
+	// Create new release with the same image/tag, in the same environment
 	newRelease := &domain.Release{
-		AppID:     appID,
-		Image:     rollbackRelease.Image,
-		Tag:       rollbackRelease.Tag,
-		CreatedBy: userID,
-		Status:    domain.ReleaseStatusPending,
-		Meta:      rollbackRelease.Meta,
+		AppID:         appID,
+		EnvironmentID: rollbackRelease.EnvironmentID,
+		Image:         rollbackRelease.Image,
+		Tag:           rollbackRelease.Tag,
+		ImageDigest:   rollbackRelease.ImageDigest,
+		CreatedBy:     userID,
+		Status:        domain.ReleaseStatusPending,
+	}
+	rollbackMeta, err := rollbackRelease.GetMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release metadata: %w", err)
+	}
+	meta := rollbackMeta.WithoutRolloutOutcome()
+	if err := newRelease.SetMeta(&meta); err != nil {
+		return nil, err
 	}
 
 	createdRelease, err := s.releaseRepo.CreateRelease(ctx, newRelease)
@@ -324,9 +542,9 @@ func (s *applicationService) RollbackApplication(ctx context.Context, userID, ap
 		return nil, err
 	}
 
-	// TODO: In a real implementation, this would publish to a message queue
-	// For now, we'll simulate the deployment process
-	go s.processDeployment(context.Background(), createdRelease)
+	if err := QueueDeployment(ctx, s.jobRepo, s.releaseRepo, app.OrgID, createdRelease); err != nil {
+		return nil, err
+	}
 
 	response := &domain.DeployApplicationResponse{
 		ReleaseID: createdRelease.ID,
@@ -337,6 +555,125 @@ func (s *applicationService) RollbackApplication(ctx context.Context, userID, ap
 	return response, nil
 }
 
+func (s *applicationService) PromoteRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error) {
+	return s.finishCanary(ctx, userID, appID, releaseID, domain.ReleasePhasePromoting, (*domain.Release).NewPromoteJob)
+}
+
+func (s *applicationService) AbortRelease(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseResponse, error) {
+	return s.finishCanary(ctx, userID, appID, releaseID, domain.ReleasePhaseAborting, (*domain.Release).NewAbortJob)
+}
+
+// finishCanary moves a canary release that awaits promotion to the promoting or aborting phase
+// and queues the job that carries it out. The phase only changes if the release is still in the
+// canary phase, which keeps it from being promoted or aborted twice.
+func (s *applicationService) finishCanary(ctx context.Context, userID, appID, releaseID uuid.UUID, phase domain.ReleasePhase, newJob func(*domain.Release, uuid.UUID) *domain.Job) (*domain.ReleaseResponse, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	release, err := s.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, errors.New("release not found")
+	}
+	if release.AppID != appID {
+		return nil, errors.New("release does not belong to this application")
+	}
+	if !release.IsAwaitingPromotion() {
+		return nil, fmt.Errorf("release is not a canary awaiting promotion (status %s, phase %s)", release.Status, release.Phase)
+	}
+
+	updated, err := s.releaseRepo.TransitionReleasePhase(ctx, releaseID, domain.ReleasePhaseCanary, phase)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		// Another promote or abort got there first
+		return nil, errors.New("release is no longer a canary awaiting promotion")
+	}
+
+	if _, err := s.jobRepo.CreateJob(ctx, newJob(release, app.OrgID)); err != nil {
+		if _, updateErr := s.releaseRepo.TransitionReleasePhase(ctx, releaseID, phase, domain.ReleasePhaseCanary); updateErr != nil {
+			return nil, fmt.Errorf("failed to queue release job: %w (and failed to restore canary phase: %v)", err, updateErr)
+		}
+		return nil, fmt.Errorf("failed to queue release job: %w", err)
+	}
+
+	response := updated.ToResponse()
+	return &response, nil
+}
+
+// SubscribeReleaseProgress follows the rollout progress of a release. The subscription starts
+// before the release is read, so the returned release is never older than the first event the
+// subscription delivers. The caller must close the subscription.
+func (s *applicationService) SubscribeReleaseProgress(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.Release, *deployment.ProgressSubscription, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if app == nil {
+		return nil, nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role == "" {
+		return nil, nil, errors.New("user does not have access to this organization")
+	}
+
+	// Task output is read with the same permissions as the tasks themselves
+	subscription := s.progress.Subscribe(releaseID, role == domain.RoleOwner || role == domain.RoleAdmin)
+
+	release, err := s.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		subscription.Close()
+		return nil, nil, err
+	}
+	if release == nil {
+		subscription.Close()
+		return nil, nil, errors.New("release not found")
+	}
+	if release.AppID != appID {
+		subscription.Close()
+		return nil, nil, errors.New("release does not belong to this application")
+	}
+
+	return release, subscription, nil
+}
+
+// CheckNoCanaryInProgress rejects a new rollout while the latest release in its environment is a
+// canary, whose objects the rollout would otherwise replace without promoting or aborting it
+func CheckNoCanaryInProgress(ctx context.Context, releaseRepo repo.ReleaseRepository, appID uuid.UUID, environmentID *uuid.UUID) error {
+	latest, err := releaseRepo.GetLatestReleaseByEnvironment(ctx, appID, environmentID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.HasCanaryInProgress() {
+		return fmt.Errorf("canary release %s is in progress; promote or abort it first", latest.ID)
+	}
+	return nil
+}
+
 func (s *applicationService) GetReleasesByApplication(ctx context.Context, userID, appID uuid.UUID) ([]domain.ReleaseSummary, error) {
 	// Get application
 	app, err := s.appRepo.GetApplicationByID(ctx, appID)
@@ -364,26 +701,533 @@ func (s *applicationService) GetReleasesByApplication(ctx context.Context, userI
 	return releases, nil
 }
 
-// processDeployment simulates the deployment process
-func (s *applicationService) processDeployment(ctx context.Context, release *domain.Release) {
-	// Update status to running
-	now := time.Now()
-	_, err := s.releaseRepo.UpdateReleaseStatus(ctx, release.ID, domain.ReleaseStatusRunning, &now, nil)
+func (s *applicationService) GetApplicationSpec(ctx context.Context, userID, appID uuid.UUID) (*domain.ApplicationSpecResponse, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	spec, err := s.specRepo.GetLatestApplicationSpec(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		return &domain.ApplicationSpecResponse{
+			AppID:   appID,
+			Version: 0,
+			Spec:    domain.DefaultDeploymentSpec(),
+		}, nil
+	}
+
+	response := spec.ToResponse()
+	return &response, nil
+}
+
+func (s *applicationService) UpdateApplicationSpec(ctx context.Context, userID, appID uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpecResponse, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	normalized := spec.WithDefaults()
+	if err := validateDeploymentSpec(&normalized); err != nil {
+		return nil, err
+	}
+
+	// Commands run with the application's secrets, so only owners and admins can change them
+	if err := requireManager(role, "change application commands"); err != nil {
+		changed, checkErr := s.changesCommands(ctx, appID, &normalized)
+		if checkErr != nil {
+			return nil, checkErr
+		}
+		if changed {
+			return nil, err
+		}
+	}
+
+	// Releases can only be committed to a repository of the application's organization
+	if normalized.GitOps != nil {
+		repository, err := s.repoRepo.GetRepositoryByID(ctx, normalized.GitOps.RepositoryID)
+		if err != nil {
+			return nil, err
+		}
+		if repository == nil || repository.OrgID != app.OrgID {
+			return nil, fmt.Errorf("invalid spec: gitops repository %s is not connected to the organization", normalized.GitOps.RepositoryID)
+		}
+	}
+
+	created, err := s.specRepo.CreateApplicationSpec(ctx, appID, userID, &normalized)
 	if err != nil {
-		fmt.Printf("Failed to update release status to running: %v\n", err)
-		return
+		return nil, err
+	}
+
+	response := created.ToResponse()
+	return &response, nil
+}
+
+// changesCommands reports whether a spec runs commands the application's current spec does not
+func (s *applicationService) changesCommands(ctx context.Context, appID uuid.UUID, spec *domain.DeploymentSpec) (bool, error) {
+	proposed, err := specCommands(spec)
+	if err != nil || proposed == nil {
+		return false, err
+	}
+
+	current := domain.DefaultDeploymentSpec()
+	latest, err := s.specRepo.GetLatestApplicationSpec(ctx, appID)
+	if err != nil {
+		return false, err
+	}
+	if latest != nil {
+		current = latest.Spec
+	}
+	existing, err := specCommands(&current)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(proposed, existing), nil
+}
+
+// specCommands returns the commands, release tasks and processes of a spec, or nil if it has none
+func specCommands(spec *domain.DeploymentSpec) ([]byte, error) {
+	if len(spec.Command) == 0 && len(spec.Args) == 0 && spec.PreDeploy == nil && spec.PostDeploy == nil && len(spec.Processes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(struct {
+		Command    []string                `json:"command,omitempty"`
+		Args       []string                `json:"args,omitempty"`
+		PreDeploy  *domain.ReleaseTaskSpec `json:"pre_deploy,omitempty"`
+		PostDeploy *domain.ReleaseTaskSpec `json:"post_deploy,omitempty"`
+		Processes  []domain.ProcessSpec    `json:"processes,omitempty"`
+	}{spec.Command, spec.Args, spec.PreDeploy, spec.PostDeploy, spec.Processes})
+}
+
+// validateDeploymentSpec checks the parts of a deployment spec that the request validator
+// cannot, so that invalid specs are rejected when saved rather than when deployed
+func validateDeploymentSpec(spec *domain.DeploymentSpec) error {
+	if spec.SourceType() == domain.SourceGenerated {
+		if err := validatePorts(spec.Ports); err != nil {
+			return fmt.Errorf("invalid spec: %w", err)
+		}
+	} else if err := validateSource(spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+	if err := validateResources(spec.Resources); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	if spec.Strategy != nil {
+		if err := validateStrategy(spec.Strategy); err != nil {
+			return err
+		}
+	}
+
+	if spec.Autoscaling != nil {
+		if err := validateAutoscaling(spec.Autoscaling, spec.Resources); err != nil {
+			return err
+		}
+	}
+
+	// A pod that must stay ready for longer than the rollout may take could never become available
+	if rollout := spec.RolloutSettings(); rollout.MinReadySeconds >= rollout.TimeoutSeconds {
+		return fmt.Errorf("invalid spec: min_ready_seconds (%d) must be less than the rollout timeout_seconds (%d)", rollout.MinReadySeconds, rollout.TimeoutSeconds)
+	}
+
+	for key, value := range spec.NodeSelector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid spec: node selector key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid spec: node selector value %q: %s", value, strings.Join(errs, "; "))
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, process := range spec.Processes {
+		if names[process.Name] {
+			return fmt.Errorf("invalid spec: duplicate process %q", process.Name)
+		}
+		names[process.Name] = true
+
+		if err := validateProcess(&process); err != nil {
+			return fmt.Errorf("invalid spec: process %q: %w", process.Name, err)
+		}
+	}
+
+	if err := validateVolumes(spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	if spec.GitOps != nil {
+		if err := validateGitOps(spec); err != nil {
+			return fmt.Errorf("invalid spec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateGitOps checks where a spec commits its releases' manifests. A commit holds one set of
+// objects that is applied in place, so releases must be rolled out with a rolling update. Without
+// apply, OneClick does not touch the cluster around the commit, so release tasks and auto_heal
+// cannot be used.
+func validateGitOps(spec *domain.DeploymentSpec) error {
+	gitops := spec.GitOps
+	if spec.Strategy != nil && spec.Strategy.Type != domain.StrategyRolling {
+		return fmt.Errorf("the %s strategy cannot be used with gitops", spec.Strategy.Type)
+	}
+
+	dir := filepath.FromSlash(strings.TrimPrefix(gitops.Path, "/"))
+	if !filepath.IsLocal(dir) || filepath.Clean(dir) == "." {
+		return fmt.Errorf("gitops path %q must be a directory in the repository", gitops.Path)
+	}
+	if strings.HasPrefix(gitops.Branch, "-") {
+		return fmt.Errorf("invalid gitops branch %q", gitops.Branch)
+	}
+
+	if !gitops.AppliesRelease() {
+		if spec.PreDeploy != nil || spec.PostDeploy != nil {
+			return errors.New("pre_deploy and post_deploy tasks require gitops apply")
+		}
+		if spec.RolloutSettings().AutoHeal {
+			return errors.New("auto_heal requires gitops apply")
+		}
+	}
+	return nil
+}
+
+// validateSource checks a deploy source rendered from the application's repository. Its objects
+// are applied in place, so the strategies that run a release next to the running one cannot be
+// used with it, nor the autoscaler, processes and volumes, which only exist as generated objects.
+func validateSource(spec *domain.DeploymentSpec) error {
+	source := spec.Source
+	if spec.Strategy != nil && spec.Strategy.Type != domain.StrategyRolling {
+		return fmt.Errorf("the %s strategy requires the generated source", spec.Strategy.Type)
+	}
+	if spec.Autoscaling != nil {
+		return errors.New("autoscaling requires the generated source")
+	}
+	if len(spec.Processes) > 0 {
+		return errors.New("processes require the generated source")
+	}
+	if len(spec.Volumes) > 0 {
+		return errors.New("volumes require the generated source")
+	}
+
+	for _, name := range source.Containers {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return fmt.Errorf("source container %q: %s", name, strings.Join(errs, "; "))
+		}
+	}
+
+	if source.Type != domain.SourceHelm && (len(source.Values) > 0 || len(source.ValuesFiles) > 0) {
+		return errors.New("values and values_files only apply to the helm source")
+	}
+	for _, file := range source.ValuesFiles {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return fmt.Errorf("values file %q must be a path in the chart", file)
+		}
+	}
+
+	return nil
+}
+
+// validateVolumes checks that volumes have unique names and mount paths and valid sizes, and that
+// ReadWriteOnce volumes, which a single node mounts, are only used with rolling updates of a fixed
+// number of replicas: blue/green and canary releases run next to the running release, and the
+// autoscaler could not scale the StatefulSet that serves more than one replica.
+func validateVolumes(spec *domain.DeploymentSpec) error {
+	names := make(map[string]bool)
+	mountPaths := make(map[string]bool)
+	for _, volume := range spec.Volumes {
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("volume name %q: %s", volume.Name, strings.Join(errs, "; "))
+		}
+		if names[volume.Name] {
+			return fmt.Errorf("duplicate volume %q", volume.Name)
+		}
+		names[volume.Name] = true
+
+		size, err := resource.ParseQuantity(volume.Size)
+		if err != nil {
+			return fmt.Errorf("volume %q: size %q is not a valid quantity", volume.Name, volume.Size)
+		}
+		if size.Sign() <= 0 {
+			return fmt.Errorf("volume %q: size must be positive", volume.Name)
+		}
+
+		if volume.StorageClass != "" {
+			if errs := validation.IsDNS1123Subdomain(volume.StorageClass); len(errs) > 0 {
+				return fmt.Errorf("volume %q: storage class %q: %s", volume.Name, volume.StorageClass, strings.Join(errs, "; "))
+			}
+		}
+
+		mountPath := path.Clean(volume.MountPath)
+		if !path.IsAbs(mountPath) || mountPath == "/" {
+			return fmt.Errorf("volume %q: mount path %q must be an absolute path other than /", volume.Name, volume.MountPath)
+		}
+		if mountPaths[mountPath] {
+			return fmt.Errorf("duplicate volume mount path %q", mountPath)
+		}
+		mountPaths[mountPath] = true
+
+		if volume.AccessMode == domain.VolumeAccessReadWriteMany {
+			continue
+		}
+		if spec.Strategy != nil && spec.Strategy.Type != domain.StrategyRolling {
+			return fmt.Errorf("volume %q: ReadWriteOnce volumes require the rolling strategy", volume.Name)
+		}
+		if spec.Autoscaling != nil {
+			return fmt.Errorf("volume %q: ReadWriteOnce volumes cannot be used with autoscaling", volume.Name)
+		}
+	}
+
+	return nil
+}
+
+// reservedProcessNames are the names of the application's own objects, which processes would
+// collide with: the main web process and the blue/green and canary Deployments
+var reservedProcessNames = map[string]bool{
+	domain.ProcessTypeWeb: true,
+	"canary":              true,
+	deployment.SlotBlue:   true,
+	deployment.SlotGreen:  true,
+}
+
+// validateProcess checks that a process has a valid name and only sets the fields of its type
+func validateProcess(process *domain.ProcessSpec) error {
+	if errs := validation.IsDNS1123Label(process.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name: %s", strings.Join(errs, "; "))
+	}
+	if reservedProcessNames[process.Name] {
+		return errors.New("name is reserved")
+	}
+
+	if process.Type == domain.ProcessTypeCron {
+		if process.Schedule == "" {
+			return errors.New("cron processes require a schedule")
+		}
+		if err := validateSchedule(process.Schedule); err != nil {
+			return err
+		}
+		if process.Replicas != 0 {
+			return errors.New("replicas do not apply to cron processes")
+		}
+	} else if process.Schedule != "" {
+		return errors.New("schedule only applies to cron processes")
+	}
+
+	if process.Type == domain.ProcessTypeWeb {
+		if err := validatePorts(process.Ports); err != nil {
+			return err
+		}
+	} else if len(process.Ports) > 0 {
+		return errors.New("ports only apply to web processes")
+	}
+
+	return validateResources(process.Resources)
+}
+
+// validateSchedule checks that a schedule is a five-field cron expression or one of the
+// predefined schedules such as @hourly. Time zones are not supported: schedules run in UTC.
+func validateSchedule(schedule string) error {
+	if strings.Contains(schedule, "TZ=") {
+		return fmt.Errorf("schedule %q must not set a time zone", schedule)
+	}
+	if strings.HasPrefix(schedule, "@") {
+		switch schedule {
+		case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+			return nil
+		}
+		return fmt.Errorf("schedule %q is not a predefined schedule", schedule)
 	}
+	if fields := strings.Fields(schedule); len(fields) != 5 {
+		return fmt.Errorf("schedule %q must have five fields: minute, hour, day of month, month and day of week", schedule)
+	}
+	return nil
+}
 
-	// Simulate deployment time
-	time.Sleep(5 * time.Second)
+// validatePorts checks that there is at least one port, that ports are named when there is more
+// than one, and that no port is used twice
+func validatePorts(ports []domain.PortSpec) error {
+	if len(ports) == 0 {
+		return errors.New("at least one port is required")
+	}
+
+	names := make(map[string]bool)
+	containerPorts := make(map[string]bool)
+	servicePorts := make(map[string]bool)
+	for _, port := range ports {
+		if len(ports) > 1 && port.Name == "" {
+			return errors.New("every port must be named when there is more than one")
+		}
+		if port.Name != "" {
+			if errs := validation.IsValidPortName(port.Name); len(errs) > 0 {
+				return fmt.Errorf("port name %q: %s", port.Name, strings.Join(errs, "; "))
+			}
+			if names[port.Name] {
+				return fmt.Errorf("duplicate port name %q", port.Name)
+			}
+			names[port.Name] = true
+		}
+
+		containerKey := fmt.Sprintf("%d/%s", port.Port, port.Protocol)
+		if containerPorts[containerKey] {
+			return fmt.Errorf("duplicate port %s", containerKey)
+		}
+		containerPorts[containerKey] = true
+
+		serviceKey := fmt.Sprintf("%d/%s", port.ServicePort, port.Protocol)
+		if servicePorts[serviceKey] {
+			return fmt.Errorf("duplicate service port %s", serviceKey)
+		}
+		servicePorts[serviceKey] = true
+	}
 
-	// Simulate success (in real implementation, this would check actual deployment status)
-	finishedAt := time.Now()
-	_, err = s.releaseRepo.UpdateReleaseStatus(ctx, release.ID, domain.ReleaseStatusSucceeded, nil, &finishedAt)
+	return nil
+}
+
+// validateResources checks that resource requests and limits are valid quantities
+func validateResources(resources *domain.ResourceSpec) error {
+	if resources == nil {
+		return nil
+	}
+
+	quantities := map[string]string{
+		"cpu_request":    resources.CPURequest,
+		"cpu_limit":      resources.CPULimit,
+		"memory_request": resources.MemoryRequest,
+		"memory_limit":   resources.MemoryLimit,
+	}
+	for field, value := range quantities {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("%s %q is not a valid quantity", field, value)
+		}
+	}
+
+	return nil
+}
+
+// validateStrategy checks that a strategy only sets the parameters of its type, and that rolling
+// update parameters are pod counts or percentages that allow the rollout to make progress
+func validateStrategy(strategy *domain.StrategySpec) error {
+	if strategy.Type != domain.StrategyRolling && (strategy.MaxSurge != "" || strategy.MaxUnavailable != "") {
+		return errors.New("invalid spec: max_surge and max_unavailable only apply to the rolling strategy")
+	}
+	if strategy.Type != domain.StrategyCanary && strategy.CanaryWeight != 0 {
+		return errors.New("invalid spec: canary_weight only applies to the canary strategy")
+	}
+
+	surgeZero, err := validateRollingParameter("max_surge", strategy.MaxSurge)
 	if err != nil {
-		fmt.Printf("Failed to update release status to succeeded: %v\n", err)
-		return
+		return err
 	}
+	unavailableZero, err := validateRollingParameter("max_unavailable", strategy.MaxUnavailable)
+	if err != nil {
+		return err
+	}
+	if surgeZero && unavailableZero {
+		return errors.New("invalid spec: max_surge and max_unavailable cannot both be zero")
+	}
+
+	return nil
+}
 
-	fmt.Printf("Deployment completed for release %s\n", release.ID)
+// validateAutoscaling checks that autoscaling has a replica range and at least one target, that
+// utilization targets have the resource request they are relative to, and that custom metrics
+// are Prometheus metric names with positive quantity targets
+func validateAutoscaling(autoscaling *domain.AutoscalingSpec, resources *domain.ResourceSpec) error {
+	if autoscaling.MaxReplicas < autoscaling.MinReplicas {
+		return fmt.Errorf("invalid spec: autoscaling max_replicas (%d) must be at least min_replicas (%d)", autoscaling.MaxReplicas, autoscaling.MinReplicas)
+	}
+	if autoscaling.TargetCPUUtilization == 0 && autoscaling.TargetMemoryUtilization == 0 && len(autoscaling.Metrics) == 0 {
+		return errors.New("invalid spec: autoscaling requires a CPU, memory or custom metric target")
+	}
+	if autoscaling.TargetCPUUtilization != 0 && (resources == nil || resources.CPURequest == "") {
+		return errors.New("invalid spec: autoscaling on CPU utilization requires a cpu_request")
+	}
+	if autoscaling.TargetMemoryUtilization != 0 && (resources == nil || resources.MemoryRequest == "") {
+		return errors.New("invalid spec: autoscaling on memory utilization requires a memory_request")
+	}
+
+	names := make(map[string]bool)
+	for _, metric := range autoscaling.Metrics {
+		if !metricNamePattern.MatchString(metric.Name) {
+			return fmt.Errorf("invalid spec: autoscaling metric name %q is not a valid Prometheus metric name", metric.Name)
+		}
+		if names[metric.Name] {
+			return fmt.Errorf("invalid spec: duplicate autoscaling metric %q", metric.Name)
+		}
+		names[metric.Name] = true
+
+		target, err := resource.ParseQuantity(metric.TargetAverageValue)
+		if err != nil || target.Sign() <= 0 {
+			return fmt.Errorf("invalid spec: autoscaling metric %q target_average_value %q must be a positive quantity", metric.Name, metric.TargetAverageValue)
+		}
+	}
+
+	return nil
+}
+
+// metricNamePattern matches Prometheus metric names
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// validateRollingParameter checks that a rolling update parameter is empty, a non-negative pod
+// count or a percentage, and reports whether it is zero
+func validateRollingParameter(field, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	number := strings.TrimSuffix(value, "%")
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 || number != strconv.Itoa(n) {
+		return false, fmt.Errorf("invalid spec: %s %q must be a pod count or a percentage", field, value)
+	}
+	if number != value && n > 100 {
+		return false, fmt.Errorf("invalid spec: %s %q cannot exceed 100%%", field, value)
+	}
+
+	return n == 0, nil
+}
+
+// QueueDeployment enqueues the rollout of a release for the DeploymentWorker. A release
+// that cannot be queued would never leave pending, so it is marked failed instead.
+func QueueDeployment(ctx context.Context, jobRepo repo.JobRepository, releaseRepo repo.ReleaseRepository, orgID uuid.UUID, release *domain.Release) error {
+	if _, err := jobRepo.CreateJob(ctx, release.NewDeployJob(orgID)); err != nil {
+		finishedAt := time.Now()
+		if _, updateErr := releaseRepo.UpdateReleaseStatus(ctx, release.ID, domain.ReleaseStatusFailed, nil, &finishedAt); updateErr != nil {
+			return fmt.Errorf("failed to queue deployment: %w (and failed to mark release failed: %v)", err, updateErr)
+		}
+		return fmt.Errorf("failed to queue deployment: %w", err)
+	}
+	return nil
 }
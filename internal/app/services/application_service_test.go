@@ -0,0 +1,1262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockReleaseRepository is a mock implementation of ReleaseRepository
+type MockReleaseRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseRepository) CreateRelease(ctx context.Context, release *domain.Release) (*domain.Release, error) {
+	args := m.Called(ctx, release)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetReleaseByID(ctx context.Context, id uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetReleasesByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseSummary, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.ReleaseSummary), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByAppID(ctx context.Context, appID uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByEnvironment(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, appID, environmentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByAppIDAndStatus(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID, status domain.ReleaseStatus) (*domain.Release, error) {
+	args := m.Called(ctx, appID, environmentID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetCurrentReleases(ctx context.Context) ([]domain.Release, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseStatus(ctx context.Context, id uuid.UUID, status domain.ReleaseStatus, startedAt, finishedAt *time.Time) (*domain.Release, error) {
+	args := m.Called(ctx, id, status, startedAt, finishedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleasePhase(ctx context.Context, id uuid.UUID, phase domain.ReleasePhase) (*domain.Release, error) {
+	args := m.Called(ctx, id, phase)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) TransitionReleasePhase(ctx context.Context, id uuid.UUID, from, to domain.ReleasePhase) (*domain.Release, error) {
+	args := m.Called(ctx, id, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseMeta(ctx context.Context, id uuid.UUID, meta []byte) (*domain.Release, error) {
+	args := m.Called(ctx, id, meta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseImageDigest(ctx context.Context, id uuid.UUID, digest string) (*domain.Release, error) {
+	args := m.Called(ctx, id, digest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) DeleteRelease(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockReleaseDriftRepository is a mock implementation of ReleaseDriftRepository
+type MockReleaseDriftRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseDriftRepository) UpsertReleaseDrift(ctx context.Context, drift *domain.ReleaseDrift) error {
+	args := m.Called(ctx, drift)
+	return args.Error(0)
+}
+
+func (m *MockReleaseDriftRepository) GetReleaseDriftByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseDrift, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.ReleaseDrift), args.Error(1)
+}
+
+// MockApplicationSpecRepository is a mock implementation of ApplicationSpecRepository
+type MockApplicationSpecRepository struct {
+	mock.Mock
+}
+
+func (m *MockApplicationSpecRepository) CreateApplicationSpec(ctx context.Context, appID, createdBy uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, createdBy, spec)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetLatestApplicationSpec(ctx context.Context, appID uuid.UUID) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetApplicationSpecByVersion(ctx context.Context, appID uuid.UUID, version int) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func TestApplicationService_DeployApplication_QueuesDeployJob(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+	specRepo := &MockApplicationSpecRepository{}
+	envRepo := &MockEnvironmentRepository{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, jobRepo, specRepo, envRepo, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+
+	app := &domain.Application{ID: appID, OrgID: orgID, Name: "test-app"}
+	appRepo.On("GetApplicationByID", ctx, appID).Return(app, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+
+	createdRelease := &domain.Release{
+		ID:     releaseID,
+		AppID:  appID,
+		Image:  "ghcr.io/acme/api",
+		Tag:    "v1.2.0",
+		Status: domain.ReleaseStatusPending,
+	}
+	envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment(nil), nil)
+	releaseRepo.On("GetLatestReleaseByEnvironment", ctx, appID, (*uuid.UUID)(nil)).Return(nil, nil)
+	specRepo.On("GetLatestApplicationSpec", ctx, appID).Return(&domain.ApplicationSpec{AppID: appID, Version: 3}, nil)
+	releaseRepo.On("CreateRelease", ctx, mock.MatchedBy(func(release *domain.Release) bool {
+		meta, err := release.GetMeta()
+		return err == nil && meta.SpecVersion == 3
+	})).Return(createdRelease, nil)
+	jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+		return job.Type == domain.JobTypeReleaseDeploy &&
+			job.OrgID == orgID &&
+			job.Status == domain.JobStatusPending &&
+			job.Payload.ReleaseID != nil && *job.Payload.ReleaseID == releaseID &&
+			job.Payload.Config["app_id"] == appID.String()
+	})).Return(&domain.Job{ID: uuid.New()}, nil)
+
+	resp, err := service.DeployApplication(ctx, userID, appID, &domain.DeployApplicationRequest{
+		Image: "ghcr.io/acme/api",
+		Tag:   "v1.2.0",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, releaseID, resp.ReleaseID)
+	assert.Equal(t, string(domain.ReleaseStatusPending), resp.Status)
+	releaseRepo.AssertNotCalled(t, "UpdateReleaseStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestApplicationService_RollbackApplication_FailsReleaseWhenQueueFails(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+	specRepo := &MockApplicationSpecRepository{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, jobRepo, specRepo, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	targetID := uuid.New()
+	releaseID := uuid.New()
+
+	app := &domain.Application{ID: appID, OrgID: orgID, Name: "test-app"}
+	appRepo.On("GetApplicationByID", ctx, appID).Return(app, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+
+	target := &domain.Release{ID: targetID, AppID: appID, Image: "ghcr.io/acme/api", Tag: "v1.1.0"}
+	releaseRepo.On("GetReleaseByID", ctx, targetID).Return(target, nil)
+	releaseRepo.On("GetLatestReleaseByEnvironment", ctx, appID, (*uuid.UUID)(nil)).Return(nil, nil)
+	releaseRepo.On("CreateRelease", ctx, mock.AnythingOfType("*domain.Release")).Return(&domain.Release{
+		ID:     releaseID,
+		AppID:  appID,
+		Image:  target.Image,
+		Tag:    target.Tag,
+		Status: domain.ReleaseStatusPending,
+	}, nil)
+	jobRepo.On("CreateJob", ctx, mock.AnythingOfType("*domain.Job")).Return((*domain.Job)(nil), errors.New("connection refused"))
+	releaseRepo.On("UpdateReleaseStatus", ctx, releaseID, domain.ReleaseStatusFailed, (*time.Time)(nil), mock.AnythingOfType("*time.Time")).
+		Return(&domain.Release{ID: releaseID, Status: domain.ReleaseStatusFailed}, nil)
+
+	resp, err := service.RollbackApplication(ctx, userID, appID, targetID)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "failed to queue deployment")
+	releaseRepo.AssertExpectations(t)
+}
+
+func TestApplicationService_GetApplication_Replicas(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	envRepo := &MockEnvironmentRepository{}
+	driftRepo := &MockReleaseDriftRepository{}
+	kubeClient := &MockKubernetesClient{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, nil, nil, envRepo, driftRepo, nil, nil, kubeClient)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	clusterID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, ClusterID: clusterID, Name: "api"}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	releaseRepo.On("GetLatestReleaseByAppID", ctx, appID).Return(nil, nil)
+	releaseRepo.On("GetReleasesByAppID", ctx, appID).Return([]domain.ReleaseSummary{}, nil)
+	envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{
+		{Name: "staging", ClusterID: clusterID, Namespace: "api-staging"},
+		{Name: "production", ClusterID: clusterID, Namespace: "api-production"},
+		{Name: "preview", ClusterID: clusterID, Namespace: "api-preview"},
+	}, nil)
+	kubeClient.On("GetReplicaStatus", ctx, "api", "api-staging").Return(&domain.ReplicaStatus{
+		Namespace: "api-staging", Deployment: "api", CurrentReplicas: 1, DesiredReplicas: 1, ReadyReplicas: 1,
+	}, nil)
+	kubeClient.On("GetReplicaStatus", ctx, "api", "api-production").Return(&domain.ReplicaStatus{
+		Namespace: "api-production", Deployment: "api", CurrentReplicas: 3, DesiredReplicas: 5, ReadyReplicas: 3,
+		Autoscaled: true, MinReplicas: 2, MaxReplicas: 10,
+	}, nil)
+	// Never deployed to preview
+	kubeClient.On("GetReplicaStatus", ctx, "api", "api-preview").Return(nil, nil)
+	used := int64(2 << 30)
+	kubeClient.On("GetVolumeStatuses", ctx, "api", "api-staging").Return(nil, nil)
+	kubeClient.On("GetVolumeStatuses", ctx, "api", "api-production").Return([]domain.VolumeStatus{
+		{Namespace: "api-production", Name: "api-uploads", Phase: "Bound", AccessMode: "ReadWriteMany", Capacity: "10Gi", UsedBytes: &used},
+	}, nil)
+	driftRepo.On("GetReleaseDriftByAppID", ctx, appID).Return([]domain.ReleaseDrift{
+		{AppID: appID, Environment: "production", Status: domain.DriftStatusDrifted, Objects: []domain.ObjectDrift{
+			{Kind: "Deployment", Name: "api", Fields: []domain.FieldDrift{{Path: "spec.replicas", Live: int64(1), Desired: int64(3)}}},
+		}},
+	}, nil)
+
+	detail, err := service.GetApplication(ctx, userID, appID)
+
+	require.NoError(t, err)
+	require.Len(t, detail.Replicas, 2)
+	assert.Equal(t, "staging", detail.Replicas[0].Environment)
+	assert.False(t, detail.Replicas[0].Autoscaled)
+	assert.Equal(t, "production", detail.Replicas[1].Environment)
+	assert.Equal(t, int32(3), detail.Replicas[1].CurrentReplicas)
+	assert.Equal(t, int32(5), detail.Replicas[1].DesiredReplicas)
+	assert.True(t, detail.Replicas[1].Autoscaled)
+	require.Len(t, detail.Volumes, 1)
+	assert.Equal(t, "production", detail.Volumes[0].Environment)
+	assert.Equal(t, "api-uploads", detail.Volumes[0].Name)
+	kubeClient.AssertNotCalled(t, "GetVolumeStatuses", ctx, "api", "api-preview")
+	require.Len(t, detail.Drift, 1)
+	assert.Equal(t, domain.DriftStatusDrifted, detail.Drift[0].Status)
+}
+
+func TestApplicationService_GetApplicationSpec_DefaultsWhenUnset(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	specRepo := &MockApplicationSpecRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, nil, specRepo, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	specRepo.On("GetLatestApplicationSpec", ctx, appID).Return(nil, nil)
+
+	resp, err := service.GetApplicationSpec(ctx, userID, appID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Version)
+	assert.Equal(t, domain.DefaultDeploymentSpec(), resp.Spec)
+	assert.Nil(t, resp.CreatedBy)
+}
+
+func TestApplicationService_UpdateApplicationSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        domain.DeploymentSpec
+		expectError string
+	}{
+		{
+			name: "valid spec is saved with defaults",
+			spec: domain.DeploymentSpec{
+				Ports:          []domain.PortSpec{{Port: 3000}},
+				ReadinessProbe: &domain.ProbeSpec{Path: "/healthz", PeriodSeconds: 3},
+				Resources:      &domain.ResourceSpec{CPURequest: "250m", MemoryLimit: "1Gi"},
+				NodeSelector:   map[string]string{"kubernetes.io/arch": "arm64"},
+			},
+		},
+		{
+			name: "unnamed ports",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Port: 3000}, {Port: 9090}},
+			},
+			expectError: "every port must be named",
+		},
+		{
+			name: "duplicate ports",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Name: "http", Port: 3000}, {Name: "alt", Port: 3000}},
+			},
+			expectError: "duplicate port",
+		},
+		{
+			name: "invalid quantity",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Resources: &domain.ResourceSpec{MemoryLimit: "a lot"},
+			},
+			expectError: "memory_limit",
+		},
+		{
+			name: "invalid node selector",
+			spec: domain.DeploymentSpec{
+				Ports:        []domain.PortSpec{{Port: 3000}},
+				NodeSelector: map[string]string{"bad key!": "x"},
+			},
+			expectError: "node selector key",
+		},
+		{
+			name: "canary strategy",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyCanary, CanaryWeight: 25},
+			},
+		},
+		{
+			name: "rolling parameters on blue/green",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyBlueGreen, MaxSurge: "1"},
+			},
+			expectError: "only apply to the rolling strategy",
+		},
+		{
+			name: "invalid max surge",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyRolling, MaxSurge: "25 %"},
+			},
+			expectError: "max_surge",
+		},
+		{
+			name: "rolling update that cannot progress",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyRolling, MaxSurge: "0%", MaxUnavailable: "0"},
+			},
+			expectError: "cannot both be zero",
+		},
+		{
+			name: "rollout settings",
+			spec: domain.DeploymentSpec{
+				Ports:   []domain.PortSpec{{Port: 3000}},
+				Rollout: &domain.RolloutSpec{TimeoutSeconds: 120, MinReadySeconds: 30},
+			},
+		},
+		{
+			name: "min ready seconds beyond the rollout timeout",
+			spec: domain.DeploymentSpec{
+				Ports:   []domain.PortSpec{{Port: 3000}},
+				Rollout: &domain.RolloutSpec{MinReadySeconds: 300},
+			},
+			expectError: "min_ready_seconds",
+		},
+		{
+			name: "autoscaling",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Resources: &domain.ResourceSpec{CPURequest: "250m"},
+				Autoscaling: &domain.AutoscalingSpec{
+					MinReplicas:          2,
+					MaxReplicas:          10,
+					TargetCPUUtilization: 70,
+					Metrics:              []domain.CustomMetricSpec{{Name: "http_requests_per_second", TargetAverageValue: "100"}},
+				},
+			},
+		},
+		{
+			name: "autoscaling max below min",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 5, MaxReplicas: 2, Metrics: []domain.CustomMetricSpec{{Name: "queue_depth", TargetAverageValue: "30"}}},
+			},
+			expectError: "must be at least min_replicas",
+		},
+		{
+			name: "autoscaling without target",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 1, MaxReplicas: 3},
+			},
+			expectError: "requires a CPU, memory or custom metric target",
+		},
+		{
+			name: "autoscaling on CPU without request",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Resources:   &domain.ResourceSpec{CPULimit: "1"},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 1, MaxReplicas: 3, TargetCPUUtilization: 80},
+			},
+			expectError: "requires a cpu_request",
+		},
+		{
+			name: "autoscaling metric with invalid name",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 1, MaxReplicas: 3, Metrics: []domain.CustomMetricSpec{{Name: "http-requests", TargetAverageValue: "100"}}},
+			},
+			expectError: "not a valid Prometheus metric name",
+		},
+		{
+			name: "autoscaling metric with invalid target",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 1, MaxReplicas: 3, Metrics: []domain.CustomMetricSpec{{Name: "queue_depth", TargetAverageValue: "-5"}}},
+			},
+			expectError: "must be a positive quantity",
+		},
+		{
+			name: "processes",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{
+					{Name: "admin", Type: domain.ProcessTypeWeb, Command: []string{"./admin"}, Ports: []domain.PortSpec{{Port: 9000}}},
+					{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}, Replicas: 3},
+					{Name: "cleanup", Type: domain.ProcessTypeCron, Command: []string{"./cleanup"}, Schedule: "@hourly"},
+				},
+			},
+		},
+		{
+			name: "duplicate process",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{
+					{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}},
+					{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}},
+				},
+			},
+			expectError: "duplicate process",
+		},
+		{
+			name: "reserved process name",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "canary", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}}},
+			},
+			expectError: "name is reserved",
+		},
+		{
+			name: "web process without ports",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "admin", Type: domain.ProcessTypeWeb, Command: []string{"./admin"}}},
+			},
+			expectError: "at least one port is required",
+		},
+		{
+			name: "worker with ports",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}, Ports: []domain.PortSpec{{Port: 9000}}}},
+			},
+			expectError: "ports only apply to web processes",
+		},
+		{
+			name: "cron process without schedule",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "cleanup", Type: domain.ProcessTypeCron, Command: []string{"./cleanup"}}},
+			},
+			expectError: "require a schedule",
+		},
+		{
+			name: "cron schedule with time zone",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "cleanup", Type: domain.ProcessTypeCron, Command: []string{"./cleanup"}, Schedule: "TZ=Europe/Berlin 0 3 * * *"}},
+			},
+			expectError: "must not set a time zone",
+		},
+		{
+			name: "cron schedule with six fields",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				Processes: []domain.ProcessSpec{{Name: "cleanup", Type: domain.ProcessTypeCron, Command: []string{"./cleanup"}, Schedule: "0 0 3 * * *"}},
+			},
+			expectError: "must have five fields",
+		},
+		{
+			name: "volumes with valid sizes and mount paths",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Port: 3000}},
+				Volumes: []domain.VolumeSpec{
+					{Name: "data", Size: "10Gi", MountPath: "/var/lib/data"},
+					{Name: "uploads", Size: "50Gi", StorageClass: "efs-sc", AccessMode: domain.VolumeAccessReadWriteMany, MountPath: "/app/uploads"},
+				},
+			},
+		},
+		{
+			name: "volume with invalid size",
+			spec: domain.DeploymentSpec{
+				Ports:   []domain.PortSpec{{Port: 3000}},
+				Volumes: []domain.VolumeSpec{{Name: "data", Size: "10 gigs", MountPath: "/data"}},
+			},
+			expectError: "not a valid quantity",
+		},
+		{
+			name: "volumes with the same mount path",
+			spec: domain.DeploymentSpec{
+				Ports: []domain.PortSpec{{Port: 3000}},
+				Volumes: []domain.VolumeSpec{
+					{Name: "data", Size: "1Gi", MountPath: "/data"},
+					{Name: "cache", Size: "1Gi", MountPath: "/data/"},
+				},
+			},
+			expectError: "duplicate volume mount path",
+		},
+		{
+			name: "volume mounted at the root",
+			spec: domain.DeploymentSpec{
+				Ports:   []domain.PortSpec{{Port: 3000}},
+				Volumes: []domain.VolumeSpec{{Name: "data", Size: "1Gi", MountPath: "/"}},
+			},
+			expectError: "must be an absolute path other than /",
+		},
+		{
+			name: "ReadWriteOnce volume with blue/green",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyBlueGreen},
+				Volumes:  []domain.VolumeSpec{{Name: "data", Size: "1Gi", MountPath: "/data"}},
+			},
+			expectError: "require the rolling strategy",
+		},
+		{
+			name: "ReadWriteOnce volume with autoscaling",
+			spec: domain.DeploymentSpec{
+				Ports:       []domain.PortSpec{{Port: 3000}},
+				Resources:   &domain.ResourceSpec{CPURequest: "100m"},
+				Autoscaling: &domain.AutoscalingSpec{MinReplicas: 1, MaxReplicas: 5, TargetCPUUtilization: 70},
+				Volumes:     []domain.VolumeSpec{{Name: "data", Size: "1Gi", MountPath: "/data"}},
+			},
+			expectError: "cannot be used with autoscaling",
+		},
+		{
+			name: "generated source without ports",
+			spec: domain.DeploymentSpec{
+				Source: &domain.SourceSpec{Type: domain.SourceGenerated},
+			},
+			expectError: "at least one port is required",
+		},
+		{
+			name: "deploy source with canary strategy",
+			spec: domain.DeploymentSpec{
+				Source:   &domain.SourceSpec{Type: domain.SourceHelm},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyCanary},
+			},
+			expectError: "the canary strategy requires the generated source",
+		},
+		{
+			name: "deploy source with processes",
+			spec: domain.DeploymentSpec{
+				Source:    &domain.SourceSpec{Type: domain.SourceKustomize},
+				Processes: []domain.ProcessSpec{{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}}},
+			},
+			expectError: "processes require the generated source",
+		},
+		{
+			name: "values on a manifests source",
+			spec: domain.DeploymentSpec{
+				Source: &domain.SourceSpec{Type: domain.SourceManifests, Values: map[string]interface{}{"replicaCount": 2}},
+			},
+			expectError: "only apply to the helm source",
+		},
+		{
+			name: "values file outside the chart",
+			spec: domain.DeploymentSpec{
+				Source: &domain.SourceSpec{Type: domain.SourceHelm, ValuesFiles: []string{"../secrets.yaml"}},
+			},
+			expectError: "must be a path in the chart",
+		},
+		{
+			name: "invalid source container",
+			spec: domain.DeploymentSpec{
+				Source: &domain.SourceSpec{Type: domain.SourceHelm, Containers: []string{"API"}},
+			},
+			expectError: "source container",
+		},
+		{
+			name: "gitops with canary",
+			spec: domain.DeploymentSpec{
+				Ports:    []domain.PortSpec{{Port: 3000}},
+				Strategy: &domain.StrategySpec{Type: domain.StrategyCanary},
+				GitOps:   &domain.GitOpsSpec{RepositoryID: uuid.New(), Path: "apps/api"},
+			},
+			expectError: "the canary strategy cannot be used with gitops",
+		},
+		{
+			name: "gitops path outside the repository",
+			spec: domain.DeploymentSpec{
+				Ports:  []domain.PortSpec{{Port: 3000}},
+				GitOps: &domain.GitOpsSpec{RepositoryID: uuid.New(), Path: "apps/../../api"},
+			},
+			expectError: "must be a directory in the repository",
+		},
+		{
+			name: "gitops path at the root of the repository",
+			spec: domain.DeploymentSpec{
+				Ports:  []domain.PortSpec{{Port: 3000}},
+				GitOps: &domain.GitOpsSpec{RepositoryID: uuid.New(), Path: "/"},
+			},
+			expectError: "must be a directory in the repository",
+		},
+		{
+			name: "release task without gitops apply",
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate"}},
+				GitOps:    &domain.GitOpsSpec{RepositoryID: uuid.New(), Path: "apps/api", Apply: new(bool)},
+			},
+			expectError: "pre_deploy and post_deploy tasks require gitops apply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			specRepo := &MockApplicationSpecRepository{}
+
+			service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, nil, specRepo, nil, nil, nil, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+			appID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+			var saved *domain.DeploymentSpec
+			specRepo.On("CreateApplicationSpec", ctx, appID, userID, mock.AnythingOfType("*domain.DeploymentSpec")).
+				Run(func(args mock.Arguments) {
+					saved = args.Get(3).(*domain.DeploymentSpec)
+				}).
+				Return(&domain.ApplicationSpec{AppID: appID, Version: 1, CreatedBy: userID}, nil)
+
+			resp, err := service.UpdateApplicationSpec(ctx, userID, appID, &tt.spec)
+
+			if tt.expectError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid spec")
+				assert.Contains(t, err.Error(), tt.expectError)
+				specRepo.AssertNotCalled(t, "CreateApplicationSpec", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, resp.Version)
+			assert.Equal(t, int32(1), saved.Replicas)
+			assert.Equal(t, domain.ServiceTypeClusterIP, saved.ServiceType)
+			assert.Equal(t, "TCP", saved.Ports[0].Protocol)
+			assert.Equal(t, int32(3000), saved.Ports[0].ServicePort)
+		})
+	}
+}
+
+func TestApplicationService_UpdateApplicationSpec_Source(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	specRepo := &MockApplicationSpecRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, nil, specRepo, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	var saved *domain.DeploymentSpec
+	specRepo.On("CreateApplicationSpec", ctx, appID, userID, mock.AnythingOfType("*domain.DeploymentSpec")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(3).(*domain.DeploymentSpec)
+		}).
+		Return(&domain.ApplicationSpec{AppID: appID, Version: 1, CreatedBy: userID}, nil)
+
+	// A chart brings its own ports, so none are required
+	spec := &domain.DeploymentSpec{
+		Source: &domain.SourceSpec{
+			Type:        domain.SourceHelm,
+			Containers:  []string{"api"},
+			Values:      map[string]interface{}{"replicaCount": 3},
+			ValuesFiles: []string{"values-production.yaml"},
+		},
+		PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate"}},
+	}
+	_, err := service.UpdateApplicationSpec(ctx, userID, appID, spec)
+
+	require.NoError(t, err)
+	assert.Empty(t, saved.Ports)
+	assert.Equal(t, domain.SourceHelm, saved.SourceType())
+	assert.Equal(t, []string{"values-production.yaml"}, saved.Source.ValuesFiles)
+}
+
+func TestApplicationService_UpdateApplicationSpec_GitOps(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	specRepo := &MockApplicationSpecRepository{}
+	repoRepo := &MockRepositoryRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, repoRepo, orgRepo, nil, specRepo, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	gitopsRepoID := uuid.New()
+	otherRepoID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	repoRepo.On("GetRepositoryByID", ctx, gitopsRepoID).Return(&domain.Repository{ID: gitopsRepoID, OrgID: orgID}, nil)
+	repoRepo.On("GetRepositoryByID", ctx, otherRepoID).Return(&domain.Repository{ID: otherRepoID, OrgID: uuid.New()}, nil)
+	specRepo.On("CreateApplicationSpec", ctx, appID, userID, mock.AnythingOfType("*domain.DeploymentSpec")).
+		Return(&domain.ApplicationSpec{AppID: appID, Version: 1, CreatedBy: userID}, nil)
+
+	spec := &domain.DeploymentSpec{
+		Ports:  []domain.PortSpec{{Port: 3000}},
+		GitOps: &domain.GitOpsSpec{RepositoryID: otherRepoID, Path: "apps/api"},
+	}
+	_, err := service.UpdateApplicationSpec(ctx, userID, appID, spec)
+	assert.ErrorContains(t, err, "is not connected to the organization")
+	specRepo.AssertNotCalled(t, "CreateApplicationSpec", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	spec.GitOps.RepositoryID = gitopsRepoID
+	_, err = service.UpdateApplicationSpec(ctx, userID, appID, spec)
+	assert.NoError(t, err)
+	specRepo.AssertCalled(t, "CreateApplicationSpec", ctx, appID, userID, mock.AnythingOfType("*domain.DeploymentSpec"))
+}
+
+func TestApplicationService_UpdateApplicationSpec_Commands(t *testing.T) {
+	current := domain.DeploymentSpec{
+		Ports:     []domain.PortSpec{{Port: 3000}},
+		PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate"}},
+	}.WithDefaults()
+
+	tests := []struct {
+		name        string
+		role        string
+		spec        domain.DeploymentSpec
+		expectError string
+	}{
+		{
+			name: "member changes the pre-deploy command",
+			role: domain.RoleMember,
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"sh", "-c", "env"}},
+			},
+			expectError: "insufficient permissions to change application commands",
+		},
+		{
+			name: "member adds a process",
+			role: domain.RoleMember,
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate"}},
+				Processes: []domain.ProcessSpec{{Name: "queue", Type: domain.ProcessTypeWorker, Command: []string{"./consume"}}},
+			},
+			expectError: "insufficient permissions to change application commands",
+		},
+		{
+			name: "member keeps the commands",
+			role: domain.RoleMember,
+			spec: domain.DeploymentSpec{
+				Replicas:  3,
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate"}},
+			},
+		},
+		{
+			name: "member removes the commands",
+			role: domain.RoleMember,
+			spec: domain.DeploymentSpec{Ports: []domain.PortSpec{{Port: 3000}}},
+		},
+		{
+			name: "admin changes the pre-deploy command",
+			role: domain.RoleAdmin,
+			spec: domain.DeploymentSpec{
+				Ports:     []domain.PortSpec{{Port: 3000}},
+				PreDeploy: &domain.ReleaseTaskSpec{Command: []string{"./migrate", "--all"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			specRepo := &MockApplicationSpecRepository{}
+
+			service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, nil, specRepo, nil, nil, nil, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+			appID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+			specRepo.On("GetLatestApplicationSpec", ctx, appID).Return(&domain.ApplicationSpec{AppID: appID, Version: 1, Spec: current}, nil)
+			specRepo.On("CreateApplicationSpec", ctx, appID, userID, mock.AnythingOfType("*domain.DeploymentSpec")).
+				Return(&domain.ApplicationSpec{AppID: appID, Version: 2, CreatedBy: userID}, nil)
+
+			_, err := service.UpdateApplicationSpec(ctx, userID, appID, &tt.spec)
+
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				specRepo.AssertNotCalled(t, "CreateApplicationSpec", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			specRepo.AssertCalled(t, "CreateApplicationSpec", ctx, appID, userID, mock.Anything)
+		})
+	}
+}
+
+func TestApplicationService_DeployApplication_RejectsDuringCanary(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	envRepo := &MockEnvironmentRepository{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, nil, nil, envRepo, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment(nil), nil)
+	releaseRepo.On("GetLatestReleaseByEnvironment", ctx, appID, (*uuid.UUID)(nil)).Return(&domain.Release{
+		ID:     uuid.New(),
+		AppID:  appID,
+		Status: domain.ReleaseStatusRunning,
+		Phase:  domain.ReleasePhaseCanary,
+	}, nil)
+
+	resp, err := service.DeployApplication(ctx, userID, appID, &domain.DeployApplicationRequest{Image: "api", Tag: "v2"})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "promote or abort it first")
+	releaseRepo.AssertNotCalled(t, "CreateRelease", mock.Anything, mock.Anything)
+}
+
+func TestApplicationService_PromoteRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      domain.ReleaseStatus
+		phase       domain.ReleasePhase
+		expectError string
+	}{
+		{
+			name:   "canary awaiting promotion",
+			status: domain.ReleaseStatusRunning,
+			phase:  domain.ReleasePhaseCanary,
+		},
+		{
+			name:        "already promoting",
+			status:      domain.ReleaseStatusRunning,
+			phase:       domain.ReleasePhasePromoting,
+			expectError: "not a canary awaiting promotion",
+		},
+		{
+			name:        "completed rolling release",
+			status:      domain.ReleaseStatusSucceeded,
+			phase:       domain.ReleasePhaseCompleted,
+			expectError: "not a canary awaiting promotion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			releaseRepo := &MockReleaseRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			jobRepo := &MockJobRepository{}
+
+			service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+			appID := uuid.New()
+			releaseID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+			releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(&domain.Release{
+				ID:     releaseID,
+				AppID:  appID,
+				Status: tt.status,
+				Phase:  tt.phase,
+			}, nil)
+			releaseRepo.On("TransitionReleasePhase", ctx, releaseID, domain.ReleasePhaseCanary, domain.ReleasePhasePromoting).Return(&domain.Release{
+				ID:     releaseID,
+				AppID:  appID,
+				Status: domain.ReleaseStatusRunning,
+				Phase:  domain.ReleasePhasePromoting,
+			}, nil)
+			jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+				return job.Type == domain.JobTypeReleasePromote &&
+					job.Payload.ReleaseID != nil && *job.Payload.ReleaseID == releaseID
+			})).Return(&domain.Job{ID: uuid.New()}, nil)
+
+			resp, err := service.PromoteRelease(ctx, userID, appID, releaseID)
+
+			if tt.expectError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				releaseRepo.AssertNotCalled(t, "TransitionReleasePhase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				jobRepo.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.ReleasePhasePromoting, resp.Phase)
+			jobRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestApplicationService_AbortRelease_RestoresPhaseWhenQueueFails(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(&domain.Release{
+		ID:     releaseID,
+		AppID:  appID,
+		Status: domain.ReleaseStatusRunning,
+		Phase:  domain.ReleasePhaseCanary,
+	}, nil)
+	releaseRepo.On("TransitionReleasePhase", ctx, releaseID, domain.ReleasePhaseCanary, domain.ReleasePhaseAborting).Return(&domain.Release{ID: releaseID}, nil)
+	jobRepo.On("CreateJob", ctx, mock.AnythingOfType("*domain.Job")).Return((*domain.Job)(nil), errors.New("connection refused"))
+	releaseRepo.On("TransitionReleasePhase", ctx, releaseID, domain.ReleasePhaseAborting, domain.ReleasePhaseCanary).Return(&domain.Release{ID: releaseID}, nil)
+
+	resp, err := service.AbortRelease(ctx, userID, appID, releaseID)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "failed to queue release job")
+	releaseRepo.AssertExpectations(t)
+}
+
+func TestApplicationService_PromoteRelease_ConcurrentTransition(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(&domain.Release{
+		ID:     releaseID,
+		AppID:  appID,
+		Status: domain.ReleaseStatusRunning,
+		Phase:  domain.ReleasePhaseCanary,
+	}, nil)
+	// An abort moved the release out of the canary phase after it was read
+	releaseRepo.On("TransitionReleasePhase", ctx, releaseID, domain.ReleasePhaseCanary, domain.ReleasePhasePromoting).Return(nil, nil)
+
+	resp, err := service.PromoteRelease(ctx, userID, appID, releaseID)
+
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "release is no longer a canary awaiting promotion")
+	jobRepo.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+}
+
+func TestApplicationService_CreateApplication_OrganizationNamespace(t *testing.T) {
+	longName := strings.Repeat("billing-", 8) + "api"
+
+	tests := []struct {
+		name string
+		org  uuid.UUID
+		app  string
+	}{
+		{name: "first organization", org: uuid.MustParse("0f3a9c1e-0000-4000-8000-000000000000"), app: "api"},
+		{name: "second organization", org: uuid.MustParse("7b21d4aa-0000-4000-8000-000000000000"), app: "api"},
+		{name: "long name", org: uuid.MustParse("0f3a9c1e-0000-4000-8000-000000000000"), app: longName},
+		{name: "long name with another suffix", org: uuid.MustParse("0f3a9c1e-0000-4000-8000-000000000000"), app: longName + "s"},
+	}
+
+	namespaces := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			clusterRepo := &MockClusterRepository{}
+			repositoryRepo := &MockRepositoryRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			envRepo := &MockEnvironmentRepository{}
+
+			service := NewApplicationService(appRepo, nil, clusterRepo, repositoryRepo, orgRepo, nil, nil, envRepo, nil, nil, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			clusterID := uuid.New()
+			repoID := uuid.New()
+
+			clusterRepo.On("GetClusterByID", ctx, clusterID).Return(&domain.Cluster{ID: clusterID, OrgID: tt.org}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, tt.org).Return(domain.RoleMember, nil)
+			repositoryRepo.On("GetRepositoryByID", ctx, repoID).Return(&domain.Repository{ID: repoID, OrgID: tt.org}, nil)
+			appRepo.On("GetApplicationByNameInCluster", ctx, clusterID, tt.app).Return(nil, nil)
+			appRepo.On("GetApplicationByNamespaceInCluster", ctx, clusterID, mock.Anything).Return(nil, nil)
+			envRepo.On("GetEnvironmentByNamespace", ctx, clusterID, mock.Anything).Return(nil, nil)
+			created := &domain.Application{}
+			appRepo.On("CreateApplication", ctx, mock.Anything).Run(func(args mock.Arguments) {
+				*created = *args.Get(1).(*domain.Application)
+			}).Return(created, nil)
+
+			resp, err := service.CreateApplication(ctx, userID, clusterID, &domain.CreateApplicationRequest{
+				Name:          tt.app,
+				RepoID:        repoID.String(),
+				DefaultBranch: "main",
+			})
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(resp.Namespace, tt.org.String()[:8]+"-"), resp.Namespace)
+			assert.LessOrEqual(t, len(resp.Namespace), 63)
+			assert.Empty(t, validation.IsDNS1123Label(resp.Namespace))
+			for other, namespace := range namespaces {
+				assert.NotEqual(t, namespace, resp.Namespace, "same namespace as %s", other)
+			}
+			namespaces[tt.name] = resp.Namespace
+		})
+	}
+	assert.Equal(t, "0f3a9c1e-api", namespaces["first organization"])
+}
+
+func TestApplicationService_DeleteApplication_QueuesTeardown(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api"}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	jobRepo.On("GetJobsByOrgID", ctx, orgID).Return([]domain.Job{{
+		// A failed deletion does not stop the application from being deleted again
+		ID:      uuid.New(),
+		Type:    domain.JobTypeApplicationDelete,
+		Status:  domain.JobStatusFailed,
+		Payload: domain.JobPayload{Config: map[string]interface{}{"app_id": appID.String()}},
+	}}, nil)
+	jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+		return job.Type == domain.JobTypeApplicationDelete &&
+			job.OrgID == orgID &&
+			job.Status == domain.JobStatusPending &&
+			job.Payload.Config["app_id"] == appID.String() &&
+			job.Payload.Config["keep_data"] == true &&
+			len(job.Progress) == 5 &&
+			job.Progress[0].Name == domain.TeardownStepDomains &&
+			job.Progress[0].Status == domain.JobStepPending
+	})).Return(&domain.Job{ID: uuid.New(), Type: domain.JobTypeApplicationDelete}, nil)
+
+	job, err := service.DeleteApplication(ctx, userID, appID, &domain.DeleteApplicationRequest{KeepData: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobTypeApplicationDelete, job.Type)
+	appRepo.AssertNotCalled(t, "DeleteApplication", mock.Anything, mock.Anything)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestApplicationService_DeleteApplication_ReturnsQueuedTeardown(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	queued := domain.Job{
+		ID:      uuid.New(),
+		Type:    domain.JobTypeApplicationDelete,
+		Status:  domain.JobStatusProcessing,
+		Payload: domain.JobPayload{Config: map[string]interface{}{"app_id": appID.String()}},
+	}
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleOwner, nil)
+	jobRepo.On("GetJobsByOrgID", ctx, orgID).Return([]domain.Job{
+		{ID: uuid.New(), Type: domain.JobTypeApplicationDelete, Status: domain.JobStatusPending, Payload: domain.JobPayload{Config: map[string]interface{}{"app_id": uuid.New().String()}}},
+		queued,
+	}, nil)
+
+	job, err := service.DeleteApplication(ctx, userID, appID, &domain.DeleteApplicationRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, queued.ID, job.ID)
+	jobRepo.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+}
+
+func TestApplicationService_DeleteApplication_RequiresAdmin(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewApplicationService(appRepo, nil, nil, nil, orgRepo, jobRepo, nil, nil, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+
+	job, err := service.DeleteApplication(ctx, userID, appID, &domain.DeleteApplicationRequest{})
+
+	assert.EqualError(t, err, "insufficient permissions to delete application")
+	assert.Nil(t, job)
+	jobRepo.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+}
+
+func TestApplicationService_SubscribeReleaseProgress(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	broker := deployment.NewProgressBroker()
+
+	service := NewApplicationService(appRepo, releaseRepo, nil, nil, orgRepo, nil, nil, nil, nil, broker, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	releaseID := uuid.New()
+	otherReleaseID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+	releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(&domain.Release{ID: releaseID, AppID: appID, Status: domain.ReleaseStatusRunning}, nil)
+	releaseRepo.On("GetReleaseByID", ctx, otherReleaseID).Return(&domain.Release{ID: otherReleaseID, AppID: uuid.New()}, nil)
+
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressManifestApplied})
+	broker.Publish(domain.ReleaseProgressEvent{ReleaseID: releaseID, Type: domain.ReleaseProgressTaskLog, Message: "migrated\n"})
+
+	release, subscription, err := service.SubscribeReleaseProgress(ctx, userID, appID, releaseID)
+	assert.NoError(t, err)
+	assert.Equal(t, releaseID, release.ID)
+	assert.Len(t, subscription.History, 1, "members do not see task output")
+	subscription.Close()
+
+	_, _, err = service.SubscribeReleaseProgress(ctx, userID, appID, otherReleaseID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not belong")
+}
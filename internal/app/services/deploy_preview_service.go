@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/registry"
+	"github.com/PouryDev/oneclick/internal/app/rollout"
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type DeployPreviewService interface {
+	PreviewDeploy(ctx context.Context, userID, appID uuid.UUID, req *domain.DeployPreviewRequest) (*domain.DeployPreviewResponse, error)
+	GetReleaseManifests(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseManifests, error)
+}
+
+type deployPreviewService struct {
+	appRepo     repo.ApplicationRepository
+	releaseRepo repo.ReleaseRepository
+	clusterRepo repo.ClusterRepository
+	orgRepo     repo.OrganizationRepository
+	specRepo    repo.ApplicationSpecRepository
+	envRepo     repo.EnvironmentRepository
+	desired     *rollout.Resolver
+	crypto      *crypto.Crypto
+	generator   *deployment.DeploymentGenerator
+}
+
+func NewDeployPreviewService(
+	appRepo repo.ApplicationRepository,
+	releaseRepo repo.ReleaseRepository,
+	clusterRepo repo.ClusterRepository,
+	orgRepo repo.OrganizationRepository,
+	specRepo repo.ApplicationSpecRepository,
+	envRepo repo.EnvironmentRepository,
+	desired *rollout.Resolver,
+	crypto *crypto.Crypto,
+) DeployPreviewService {
+	return &deployPreviewService{
+		appRepo:     appRepo,
+		releaseRepo: releaseRepo,
+		clusterRepo: clusterRepo,
+		orgRepo:     orgRepo,
+		specRepo:    specRepo,
+		envRepo:     envRepo,
+		desired:     desired,
+		crypto:      crypto,
+		generator:   deployment.NewDeploymentGenerator(),
+	}
+}
+
+// previewClients are the clients of the cluster a preview is dry-run against
+type previewClients struct {
+	clientset *kubernetes.Clientset
+	applier   *deployment.Applier
+}
+
+// PreviewDeploy renders the manifests a deploy or rollback would apply, the way the deployment
+// worker would, and dry-runs them against the cluster of the application or of the environment
+// the release is deployed to. Nothing is changed in the cluster or stored.
+func (s *deployPreviewService) PreviewDeploy(ctx context.Context, userID, appID uuid.UUID, req *domain.DeployPreviewRequest) (*domain.DeployPreviewResponse, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	release, env, meta, err := s.proposedRelease(ctx, app, req)
+	if err != nil {
+		return nil, err
+	}
+
+	specVersion := meta.SpecVersion
+	if req.Spec != nil {
+		if err := validateDeploymentSpec(req.Spec); err != nil {
+			return nil, err
+		}
+		specVersion = 0
+	}
+
+	state, err := s.releaseState(ctx, app, release, env, meta, req.Spec)
+	if err != nil {
+		return nil, err
+	}
+	spec, config, domainNames := state.Spec, state.Config, state.Domains
+
+	clusterID := app.ClusterID
+	if env != nil {
+		clusterID = env.ClusterID
+	}
+	clients, err := s.connect(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := domain.StrategyRolling
+	if spec.Strategy != nil {
+		strategy = spec.Strategy.Type
+	}
+
+	response := &domain.DeployPreviewResponse{
+		AppID:       app.ID,
+		Image:       release.Image,
+		Tag:         release.Tag,
+		ImageDigest: release.ImageDigest,
+		SpecVersion: specVersion,
+		Strategy:    strategy,
+		Source:      spec.SourceType(),
+		Namespace:   config.Namespace,
+		Objects:     []domain.ObjectPreview{},
+	}
+	if env != nil {
+		response.Environment = env.Name
+	}
+
+	// The worker creates the namespace before applying anything to it
+	_, err = clients.clientset.CoreV1().Namespaces().Get(ctx, config.Namespace, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+	if apierrors.IsNotFound(err) {
+		namespace := &unstructured.Unstructured{}
+		namespace.SetAPIVersion("v1")
+		namespace.SetKind("Namespace")
+		namespace.SetName(config.Namespace)
+		namespace.SetLabels(deployment.NamespaceLabels(config.Namespace, app.OrgID, app.ID))
+
+		preview, err := clients.applier.PreviewApply(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		response.Objects = append(response.Objects, preview)
+	}
+
+	// Generate the objects of the slot or canary the strategy deploys, and the objects to keep
+	// when pruning the application's objects that are no longer generated
+	var keep []*unstructured.Unstructured
+	var slotReplicas *int32
+	prune := true
+	switch strategy {
+	case domain.StrategyBlueGreen:
+		service, err := clients.clientset.CoreV1().Services(config.Namespace).Get(ctx, deployment.ServiceName(config), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+		activeSlot := ""
+		if err == nil {
+			activeSlot = deployment.ActiveSlot(service)
+		}
+		config.Slot = deployment.NextSlot(activeSlot)
+
+		previous := *config
+		previous.Slot = activeSlot
+		if deployment.Autoscaled(config) {
+			// The worker starts an autoscaled slot with the replicas of the slot it replaces
+			replicas := config.Autoscaling.MinReplicas
+			live, err := clients.clientset.AppsV1().Deployments(config.Namespace).Get(ctx, deployment.DeploymentName(&previous), metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get deployment: %w", err)
+			}
+			if err == nil && live.Spec.Replicas != nil {
+				replicas = *live.Spec.Replicas
+			}
+			slotReplicas = &replicas
+		}
+
+		if activeSlot != "" {
+			ref := &unstructured.Unstructured{}
+			ref.SetAPIVersion("apps/v1")
+			ref.SetKind("Deployment")
+			ref.SetNamespace(config.Namespace)
+			ref.SetName(deployment.DeploymentName(&previous))
+			keep = append(keep, ref)
+		}
+	case domain.StrategyCanary:
+		if len(domainNames) == 0 {
+			return nil, errors.New("canary deployments route traffic through the Ingress and require at least one domain")
+		}
+		config.Canary = true
+		prune = false
+	}
+
+	var objects []*unstructured.Unstructured
+	if config.Source != nil {
+		rendered, err := s.desired.RenderSource(ctx, app, meta, config)
+		if err != nil {
+			return nil, err
+		}
+		if err := clients.applier.CheckNamespaced(rendered.Objects); err != nil {
+			return nil, err
+		}
+		response.Commit = rendered.Commit
+		objects, err = s.generator.GenerateSourceObjects(config, rendered.Objects, app.ID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		objects, err = s.generator.GenerateObjects(config, domainNames, app.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, obj := range objects {
+		var preview domain.ObjectPreview
+		switch {
+		case deployment.AutoscaledDeployment(config, obj) && slotReplicas != nil:
+			preview, err = clients.applier.PreviewApply(ctx, deployment.WithReplicas(obj, int64(*slotReplicas)))
+		case deployment.AutoscaledDeployment(config, obj):
+			preview, err = clients.applier.PreviewAutoscaled(ctx, obj, config.Autoscaling.MinReplicas)
+		default:
+			preview, err = clients.applier.PreviewApply(ctx, obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		response.Objects = append(response.Objects, preview)
+	}
+
+	if prune {
+		candidates, err := clients.applier.PruneCandidates(ctx, config.Namespace, deployment.ManagedLabels(app.ID), append(keep, objects...))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range candidates {
+			preview, err := deployment.PreviewDelete(obj)
+			if err != nil {
+				return nil, err
+			}
+			response.Objects = append(response.Objects, preview)
+		}
+	}
+
+	return response, nil
+}
+
+// GetReleaseManifests renders the manifests of a release the way the deployment worker would
+// roll it out again, without its Secrets, as they are published to a GitOps repository. Blue/green
+// and canary releases are rendered as a rolling update would deploy them. The cluster is not
+// contacted.
+func (s *deployPreviewService) GetReleaseManifests(ctx context.Context, userID, appID, releaseID uuid.UUID) (*domain.ReleaseManifests, error) {
+	// Get application
+	app, err := s.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, errors.New("application not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, app.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	release, err := s.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, errors.New("release not found")
+	}
+	if release.AppID != app.ID {
+		return nil, errors.New("release does not belong to this application")
+	}
+
+	var env *domain.Environment
+	if release.EnvironmentID != nil {
+		env, err = s.envRepo.GetEnvironmentByID(ctx, *release.EnvironmentID)
+		if err != nil {
+			return nil, err
+		}
+		if env == nil {
+			return nil, errors.New("environment not found")
+		}
+	}
+
+	meta, err := release.GetMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release metadata: %w", err)
+	}
+
+	state, err := s.releaseState(ctx, app, release, env, meta, nil)
+	if err != nil {
+		return nil, err
+	}
+	config, domainNames := state.Config, state.Domains
+
+	var objects []*unstructured.Unstructured
+	if config.Source != nil {
+		rendered, err := s.desired.RenderSource(ctx, app, meta, config)
+		if err != nil {
+			return nil, err
+		}
+		objects, err = s.generator.GenerateSourceObjects(config, rendered.Objects, app.ID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		objects, err = s.generator.GenerateObjects(config, domainNames, app.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := deployment.ExportManifests(objects)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ReleaseManifests{AppName: app.Name, ReleaseID: release.ID, Files: files}, nil
+}
+
+// releaseState resolves the desired state of a release the way the worker would, pinning a new
+// release to the digest its tag points to as the worker does when it rolls it out
+func (s *deployPreviewService) releaseState(ctx context.Context, app *domain.Application, release *domain.Release, env *domain.Environment, meta *domain.ReleaseMeta, spec *domain.DeploymentSpec) (*rollout.State, error) {
+	digest, err := s.desired.ImageDigest(ctx, app.OrgID, release)
+	if err != nil {
+		return nil, err
+	}
+	release.ImageDigest = digest
+
+	return s.desired.Resolve(ctx, app, release, env, meta, spec)
+}
+
+// proposedRelease returns the release a preview deploys and its environment: a new release of
+// the requested image and tag pinned to the latest spec, or a redeploy of the release a rollback
+// returns to in that release's environment. The environment is nil for applications without
+// environments.
+func (s *deployPreviewService) proposedRelease(ctx context.Context, app *domain.Application, req *domain.DeployPreviewRequest) (*domain.Release, *domain.Environment, *domain.ReleaseMeta, error) {
+	if req.ReleaseID != nil {
+		if req.Image != "" || req.Tag != "" {
+			return nil, nil, nil, errors.New("image and tag cannot be set when previewing a rollback")
+		}
+		if req.Environment != "" {
+			return nil, nil, nil, errors.New("environment cannot be set when previewing a rollback")
+		}
+
+		rollbackRelease, err := s.releaseRepo.GetReleaseByID(ctx, *req.ReleaseID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if rollbackRelease == nil {
+			return nil, nil, nil, errors.New("release not found")
+		}
+		if rollbackRelease.AppID != app.ID {
+			return nil, nil, nil, errors.New("release does not belong to this application")
+		}
+
+		var env *domain.Environment
+		if rollbackRelease.EnvironmentID != nil {
+			env, err = s.envRepo.GetEnvironmentByID(ctx, *rollbackRelease.EnvironmentID)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if env == nil {
+				return nil, nil, nil, errors.New("environment not found")
+			}
+		}
+
+		rollbackMeta, err := rollbackRelease.GetMeta()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse release metadata: %w", err)
+		}
+		meta := rollbackMeta.WithoutRolloutOutcome()
+
+		release := &domain.Release{
+			AppID:         app.ID,
+			EnvironmentID: rollbackRelease.EnvironmentID,
+			Image:         rollbackRelease.Image,
+			Tag:           rollbackRelease.Tag,
+			ImageDigest:   rollbackRelease.ImageDigest,
+		}
+		return release, env, &meta, nil
+	}
+
+	if req.Image == "" {
+		return nil, nil, nil, errors.New("image is required")
+	}
+	if req.Tag == "" {
+		return nil, nil, nil, errors.New("tag is required")
+	}
+	if _, err := registry.ParseReference(req.Image); err != nil {
+		return nil, nil, nil, err
+	}
+
+	env, err := resolveDeployEnvironment(ctx, s.envRepo, app.ID, req.Environment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	meta := &domain.ReleaseMeta{}
+	spec, err := s.specRepo.GetLatestApplicationSpec(ctx, app.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if spec != nil {
+		meta.SpecVersion = spec.Version
+	}
+
+	release := &domain.Release{AppID: app.ID, Image: req.Image, Tag: req.Tag}
+	if env != nil {
+		release.EnvironmentID = &env.ID
+	}
+	return release, env, meta, nil
+}
+
+// connect creates the clients of the cluster a preview is dry-run against
+func (s *deployPreviewService) connect(ctx context.Context, clusterID uuid.UUID) (*previewClients, error) {
+	cluster, err := s.clusterRepo.GetClusterByID(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	if cluster == nil {
+		return nil, errors.New("cluster not found")
+	}
+
+	kubeconfigBytes, err := s.crypto.Decrypt(cluster.KubeconfigEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt kubeconfig: %w", err)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	return &previewClients{
+		clientset: clientset,
+		applier:   deployment.NewApplier(dynamicClient, deployment.NewDiscoveryRESTMapper(clientset.Discovery())),
+	}, nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestDeployPreviewService_PreviewDeploy_Rejected(t *testing.T) {
+	appID := uuid.New()
+	releaseID := uuid.New()
+
+	tests := []struct {
+		name        string
+		req         *domain.DeployPreviewRequest
+		release     *domain.Release
+		expectError string
+	}{
+		{
+			name:        "image is required",
+			req:         &domain.DeployPreviewRequest{Tag: "v2"},
+			expectError: "image is required",
+		},
+		{
+			name:        "image with a tag",
+			req:         &domain.DeployPreviewRequest{Image: "api:v2", Tag: "v2"},
+			expectError: "image must not include a tag",
+		},
+		{
+			name:        "rollback with an image",
+			req:         &domain.DeployPreviewRequest{ReleaseID: &releaseID, Image: "api"},
+			expectError: "image and tag cannot be set when previewing a rollback",
+		},
+		{
+			name:        "rollback to an unknown release",
+			req:         &domain.DeployPreviewRequest{ReleaseID: &releaseID},
+			expectError: "release not found",
+		},
+		{
+			name:        "rollback to another application's release",
+			req:         &domain.DeployPreviewRequest{ReleaseID: &releaseID},
+			release:     &domain.Release{ID: releaseID, AppID: uuid.New(), Image: "api", Tag: "v1"},
+			expectError: "release does not belong to this application",
+		},
+		{
+			name:        "rollback with an environment",
+			req:         &domain.DeployPreviewRequest{ReleaseID: &releaseID, Environment: "production"},
+			expectError: "environment cannot be set when previewing a rollback",
+		},
+		{
+			name:        "unknown environment",
+			req:         &domain.DeployPreviewRequest{Image: "api", Tag: "v2", Environment: "qa"},
+			expectError: "environment not found",
+		},
+		{
+			name: "invalid proposed spec",
+			req: &domain.DeployPreviewRequest{Image: "api", Tag: "v2", Spec: &domain.DeploymentSpec{
+				Ports:   []domain.PortSpec{{Port: 8080}},
+				Rollout: &domain.RolloutSpec{TimeoutSeconds: 60, MinReadySeconds: 60},
+			}},
+			expectError: "invalid spec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			releaseRepo := &MockReleaseRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			specRepo := &MockApplicationSpecRepository{}
+			envRepo := &MockEnvironmentRepository{}
+
+			service := NewDeployPreviewService(appRepo, releaseRepo, nil, orgRepo, specRepo, envRepo, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api"}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("member", nil)
+			releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(tt.release, nil)
+			specRepo.On("GetLatestApplicationSpec", ctx, appID).Return(nil, nil)
+			envRepo.On("GetEnvironmentByName", ctx, appID, "qa").Return(nil, nil)
+			envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment(nil), nil)
+
+			_, err := service.PreviewDeploy(ctx, userID, appID, tt.req)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+		})
+	}
+}
+
+func TestDeployPreviewService_PreviewDeploy_AccessDenied(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewDeployPreviewService(appRepo, nil, nil, orgRepo, nil, nil, nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return("", nil)
+
+	_, err := service.PreviewDeploy(ctx, userID, appID, &domain.DeployPreviewRequest{Image: "api", Tag: "v2"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "user does not have access to this organization", err.Error())
+}
+
+func TestDeployPreviewService_GetReleaseManifests_Rejected(t *testing.T) {
+	appID := uuid.New()
+	releaseID := uuid.New()
+	envID := uuid.New()
+
+	tests := []struct {
+		name        string
+		role        string
+		release     *domain.Release
+		expectError string
+	}{
+		{
+			name:        "no access to the organization",
+			expectError: "user does not have access to this organization",
+		},
+		{
+			name:        "unknown release",
+			role:        "member",
+			expectError: "release not found",
+		},
+		{
+			name:        "another application's release",
+			role:        "member",
+			release:     &domain.Release{ID: releaseID, AppID: uuid.New(), Image: "api", Tag: "v1"},
+			expectError: "release does not belong to this application",
+		},
+		{
+			name:        "deleted environment",
+			role:        "member",
+			release:     &domain.Release{ID: releaseID, AppID: appID, EnvironmentID: &envID, Image: "api", Tag: "v1"},
+			expectError: "environment not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appRepo := &MockApplicationRepository{}
+			releaseRepo := &MockReleaseRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			envRepo := &MockEnvironmentRepository{}
+
+			service := NewDeployPreviewService(appRepo, releaseRepo, nil, orgRepo, nil, envRepo, nil, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api"}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+			releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(tt.release, nil)
+			envRepo.On("GetEnvironmentByID", ctx, envID).Return(nil, nil)
+
+			_, err := service.GetReleaseManifests(ctx, userID, appID, releaseID)
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectError, err.Error())
+		})
+	}
+}
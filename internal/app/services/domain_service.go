@@ -25,6 +25,7 @@ type DomainService interface {
 type domainService struct {
 	domainRepo repo.DomainRepository
 	appRepo    repo.ApplicationRepository
+	envRepo    repo.EnvironmentRepository
 	jobRepo    repo.JobRepository
 	orgRepo    repo.OrganizationRepository
 	crypto     *crypto.Crypto
@@ -34,6 +35,7 @@ type domainService struct {
 func NewDomainService(
 	domainRepo repo.DomainRepository,
 	appRepo repo.ApplicationRepository,
+	envRepo repo.EnvironmentRepository,
 	jobRepo repo.JobRepository,
 	orgRepo repo.OrganizationRepository,
 	crypto *crypto.Crypto,
@@ -42,6 +44,7 @@ func NewDomainService(
 	return &domainService{
 		domainRepo: domainRepo,
 		appRepo:    appRepo,
+		envRepo:    envRepo,
 		jobRepo:    jobRepo,
 		orgRepo:    orgRepo,
 		crypto:     crypto,
@@ -80,6 +83,20 @@ func (s *domainService) CreateDomain(ctx context.Context, userID, appID uuid.UUI
 		return nil, errors.New("domain already exists for this application")
 	}
 
+	// Route the domain through the Ingress of the named environment
+	var environmentID *uuid.UUID
+	if req.Environment != "" {
+		env, err := s.envRepo.GetEnvironmentByName(ctx, appID, req.Environment)
+		if err != nil {
+			s.logger.Error("Failed to get environment", zap.Error(err), zap.String("appID", appID.String()), zap.String("environment", req.Environment))
+			return nil, errors.New("failed to get environment")
+		}
+		if env == nil {
+			return nil, errors.New("environment not found")
+		}
+		environmentID = &env.ID
+	}
+
 	// Encrypt provider configuration if provided
 	providerConfig := req.ProviderConfig
 	if providerConfig.CloudflareToken != "" {
@@ -112,6 +129,7 @@ func (s *domainService) CreateDomain(ctx context.Context, userID, appID uuid.UUI
 	// Create domain record
 	domainRecord := &domain.Domain{
 		AppID:          appID,
+		EnvironmentID:  environmentID,
 		Domain:         req.Domain,
 		Provider:       req.Provider,
 		ProviderConfig: providerConfig,
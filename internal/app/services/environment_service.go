@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type EnvironmentService interface {
+	GetEnvironments(ctx context.Context, userID, appID uuid.UUID) ([]domain.EnvironmentResponse, error)
+	CreateEnvironment(ctx context.Context, userID, appID uuid.UUID, req *domain.CreateEnvironmentRequest) (*domain.EnvironmentResponse, error)
+	UpdateEnvironment(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.UpdateEnvironmentRequest) (*domain.EnvironmentResponse, error)
+	DeleteEnvironment(ctx context.Context, userID, appID uuid.UUID, name string) error
+	PromoteRelease(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.PromoteReleaseRequest) (*domain.DeployApplicationResponse, error)
+}
+
+type environmentService struct {
+	envRepo     repo.EnvironmentRepository
+	appRepo     repo.ApplicationRepository
+	releaseRepo repo.ReleaseRepository
+	clusterRepo repo.ClusterRepository
+	orgRepo     repo.OrganizationRepository
+	jobRepo     repo.JobRepository
+}
+
+func NewEnvironmentService(
+	envRepo repo.EnvironmentRepository,
+	appRepo repo.ApplicationRepository,
+	releaseRepo repo.ReleaseRepository,
+	clusterRepo repo.ClusterRepository,
+	orgRepo repo.OrganizationRepository,
+	jobRepo repo.JobRepository,
+) EnvironmentService {
+	return &environmentService{
+		envRepo:     envRepo,
+		appRepo:     appRepo,
+		releaseRepo: releaseRepo,
+		clusterRepo: clusterRepo,
+		orgRepo:     orgRepo,
+		jobRepo:     jobRepo,
+	}
+}
+
+func (s *environmentService) GetEnvironments(ctx context.Context, userID, appID uuid.UUID) ([]domain.EnvironmentResponse, error) {
+	if _, _, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID); err != nil {
+		return nil, err
+	}
+
+	envs, err := s.envRepo.GetEnvironmentsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]domain.EnvironmentResponse, 0, len(envs))
+	for i := range envs {
+		response, err := s.toResponse(ctx, &envs[i])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+func (s *environmentService) CreateEnvironment(ctx context.Context, userID, appID uuid.UUID, req *domain.CreateEnvironmentRequest) (*domain.EnvironmentResponse, error) {
+	app, err := s.checkManageAccess(ctx, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := validation.IsDNS1123Label(req.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid environment name %q: %s", req.Name, strings.Join(errs, "; "))
+	}
+	if err := validateEnvironmentEnvVars(req.EnvVars); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.envRepo.GetEnvironmentByName(ctx, appID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("environment already exists")
+	}
+
+	envs, err := s.envRepo.GetEnvironmentsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPositionFree(envs, req.Position, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	// Verify cluster exists and belongs to the same organization
+	clusterID, err := uuid.Parse(req.ClusterID)
+	if err != nil {
+		return nil, errors.New("invalid cluster ID")
+	}
+	cluster, err := s.clusterRepo.GetClusterByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("cluster not found")
+	}
+	if cluster.OrgID != app.OrgID {
+		return nil, errors.New("cluster does not belong to the same organization")
+	}
+
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = domain.ApplicationNamespace(app.OrgID, app.Name+"-"+req.Name)
+	}
+	if err := s.checkNamespaceFree(ctx, app, clusterID, namespace); err != nil {
+		return nil, err
+	}
+
+	created, err := s.envRepo.CreateEnvironment(ctx, &domain.Environment{
+		AppID:     appID,
+		Name:      req.Name,
+		ClusterID: clusterID,
+		Namespace: namespace,
+		Position:  req.Position,
+		Replicas:  req.Replicas,
+		EnvVars:   req.EnvVars,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.EnvironmentResponse{Environment: *created}, nil
+}
+
+func (s *environmentService) UpdateEnvironment(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.UpdateEnvironmentRequest) (*domain.EnvironmentResponse, error) {
+	if _, err := s.checkManageAccess(ctx, userID, appID); err != nil {
+		return nil, err
+	}
+
+	if err := validateEnvironmentEnvVars(req.EnvVars); err != nil {
+		return nil, err
+	}
+
+	env, err := s.getEnvironment(ctx, appID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := s.envRepo.GetEnvironmentsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPositionFree(envs, req.Position, env.ID); err != nil {
+		return nil, err
+	}
+
+	env.Position = req.Position
+	env.Replicas = req.Replicas
+	env.EnvVars = req.EnvVars
+
+	updated, err := s.envRepo.UpdateEnvironment(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("environment not found")
+	}
+
+	return s.toResponse(ctx, updated)
+}
+
+// DeleteEnvironment deletes an environment with its releases and domains. The objects deployed
+// to its namespace are left running in the cluster.
+func (s *environmentService) DeleteEnvironment(ctx context.Context, userID, appID uuid.UUID, name string) error {
+	if _, err := s.checkManageAccess(ctx, userID, appID); err != nil {
+		return err
+	}
+
+	env, err := s.getEnvironment(ctx, appID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.envRepo.DeleteEnvironment(ctx, env.ID)
+}
+
+// PromoteRelease redeploys a succeeded release of an environment to the next environment: the
+// same image and tag, pinned to the same deployment spec version. The target environment's own
+// cluster, namespace, replicas, environment variables and domains apply.
+func (s *environmentService) PromoteRelease(ctx context.Context, userID, appID uuid.UUID, name string, req *domain.PromoteReleaseRequest) (*domain.DeployApplicationResponse, error) {
+	app, _, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.getEnvironment(ctx, appID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := s.envRepo.GetEnvironmentsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	var target *domain.Environment
+	for i := range envs {
+		if envs[i].Position > source.Position {
+			target = &envs[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("environment %s is the last environment and cannot be promoted", source.Name)
+	}
+
+	var release *domain.Release
+	if req.ReleaseID != nil {
+		release, err = s.releaseRepo.GetReleaseByID(ctx, *req.ReleaseID)
+		if err != nil {
+			return nil, err
+		}
+		if release == nil {
+			return nil, errors.New("release not found")
+		}
+		if release.AppID != appID {
+			return nil, errors.New("release does not belong to this application")
+		}
+		if release.EnvironmentID == nil || *release.EnvironmentID != source.ID {
+			return nil, fmt.Errorf("release does not belong to environment %s", source.Name)
+		}
+		if release.Status != domain.ReleaseStatusSucceeded {
+			return nil, fmt.Errorf("only succeeded releases can be promoted (status %s)", release.Status)
+		}
+	} else {
+		release, err = s.releaseRepo.GetLatestReleaseByAppIDAndStatus(ctx, appID, &source.ID, domain.ReleaseStatusSucceeded)
+		if err != nil {
+			return nil, err
+		}
+		if release == nil {
+			return nil, fmt.Errorf("environment %s has no succeeded release to promote", source.Name)
+		}
+	}
+
+	if err := CheckNoCanaryInProgress(ctx, s.releaseRepo, appID, &target.ID); err != nil {
+		return nil, err
+	}
+
+	sourceMeta, err := release.GetMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release metadata: %w", err)
+	}
+	meta := sourceMeta.WithoutRolloutOutcome()
+	meta.PromotedFrom = release.ID.String()
+
+	promoted := &domain.Release{
+		AppID:         appID,
+		EnvironmentID: &target.ID,
+		Image:         release.Image,
+		Tag:           release.Tag,
+		ImageDigest:   release.ImageDigest,
+		CreatedBy:     userID,
+		Status:        domain.ReleaseStatusPending,
+	}
+	if err := promoted.SetMeta(&meta); err != nil {
+		return nil, err
+	}
+
+	createdRelease, err := s.releaseRepo.CreateRelease(ctx, promoted)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := QueueDeployment(ctx, s.jobRepo, s.releaseRepo, app.OrgID, createdRelease); err != nil {
+		return nil, err
+	}
+
+	return &domain.DeployApplicationResponse{
+		ReleaseID:   createdRelease.ID,
+		Environment: target.Name,
+		Status:      string(createdRelease.Status),
+		Message:     "Promotion initiated",
+	}, nil
+}
+
+// checkManageAccess allows only owners and admins to change an application's environments
+func (s *environmentService) checkManageAccess(ctx context.Context, userID, appID uuid.UUID) (*domain.Application, error) {
+	app, role, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireManager(role, "manage application environments"); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+func (s *environmentService) getEnvironment(ctx context.Context, appID uuid.UUID, name string) (*domain.Environment, error) {
+	env, err := s.envRepo.GetEnvironmentByName(ctx, appID, name)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		return nil, errors.New("environment not found")
+	}
+	return env, nil
+}
+
+// checkNamespaceFree rejects a namespace that another application, or an environment, already
+// deploys to in the cluster, and a namespace outside the organization's prefix, which could be
+// another organization's. The application's own namespace may be taken over by one of its
+// environments.
+func (s *environmentService) checkNamespaceFree(ctx context.Context, app *domain.Application, clusterID uuid.UUID, namespace string) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, "; "))
+	}
+	prefix := domain.OrgNamespacePrefix(app.OrgID)
+	if namespace != app.Namespace && !strings.HasPrefix(namespace, prefix) {
+		return fmt.Errorf("invalid namespace %q: must start with the organization's prefix %s", namespace, prefix)
+	}
+
+	existingApp, err := s.appRepo.GetApplicationByNamespaceInCluster(ctx, clusterID, namespace)
+	if err != nil {
+		return err
+	}
+	if existingApp != nil && existingApp.ID != app.ID {
+		return fmt.Errorf("namespace %s is already used by another application in this cluster", namespace)
+	}
+
+	existingEnv, err := s.envRepo.GetEnvironmentByNamespace(ctx, clusterID, namespace)
+	if err != nil {
+		return err
+	}
+	if existingEnv != nil {
+		return fmt.Errorf("namespace %s is already used by another environment in this cluster", namespace)
+	}
+	return nil
+}
+
+func (s *environmentService) toResponse(ctx context.Context, env *domain.Environment) (*domain.EnvironmentResponse, error) {
+	response := &domain.EnvironmentResponse{Environment: *env}
+
+	latest, err := s.releaseRepo.GetLatestReleaseByEnvironment(ctx, env.AppID, &env.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		summary := latest.ToSummary()
+		response.CurrentRelease = &summary
+	}
+
+	return response, nil
+}
+
+// checkPositionFree rejects a position taken by another of the application's environments
+func checkPositionFree(envs []domain.Environment, position int, envID uuid.UUID) error {
+	for _, env := range envs {
+		if env.Position == position && env.ID != envID {
+			return fmt.Errorf("environment %s already has position %d", env.Name, position)
+		}
+	}
+	return nil
+}
+
+func validateEnvironmentEnvVars(envVars map[string]string) error {
+	for key := range envVars {
+		if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid environment variable name %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// resolveDeployEnvironment returns the environment a new release of an application is deployed
+// to. Applications with environments must name one; applications without deploy to their own
+// cluster and namespace, and resolve to nil.
+func resolveDeployEnvironment(ctx context.Context, envRepo repo.EnvironmentRepository, appID uuid.UUID, name string) (*domain.Environment, error) {
+	if name != "" {
+		env, err := envRepo.GetEnvironmentByName(ctx, appID, name)
+		if err != nil {
+			return nil, err
+		}
+		if env == nil {
+			return nil, errors.New("environment not found")
+		}
+		return env, nil
+	}
+
+	envs, err := envRepo.GetEnvironmentsByAppID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if len(envs) > 0 {
+		return nil, errors.New("environment is required for applications with environments")
+	}
+	return nil, nil
+}
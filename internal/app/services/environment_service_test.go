@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockEnvironmentRepository is a mock implementation of EnvironmentRepository
+type MockEnvironmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnvironmentRepository) CreateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	args := m.Called(ctx, env)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByID(ctx context.Context, id uuid.UUID) (*domain.Environment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByName(ctx context.Context, appID uuid.UUID, name string) (*domain.Environment, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Environment, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByNamespace(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Environment, error) {
+	args := m.Called(ctx, clusterID, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) UpdateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	args := m.Called(ctx, env)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) DeleteEnvironment(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestEnvironmentService_CreateEnvironment(t *testing.T) {
+	appID := uuid.New()
+	orgID := uuid.New()
+	clusterID := uuid.New()
+	staging := domain.Environment{ID: uuid.New(), AppID: appID, Name: "staging", Position: 1}
+	apiNamespace := domain.ApplicationNamespace(orgID, "api")
+	webNamespace := domain.ApplicationNamespace(orgID, "web")
+
+	tests := []struct {
+		name            string
+		req             domain.CreateEnvironmentRequest
+		clusterOrgID    uuid.UUID
+		namespaceApp    *domain.Application
+		expectError     string
+		expectNamespace string
+	}{
+		{
+			name:            "namespace defaults to app and environment name",
+			req:             domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Position: 2},
+			clusterOrgID:    orgID,
+			expectNamespace: apiNamespace + "-production",
+		},
+		{
+			name:            "application's own namespace can be taken over",
+			req:             domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Namespace: apiNamespace, Position: 2},
+			clusterOrgID:    orgID,
+			namespaceApp:    &domain.Application{ID: appID, Name: "api", Namespace: apiNamespace},
+			expectNamespace: apiNamespace,
+		},
+		{
+			name:        "invalid name",
+			req:         domain.CreateEnvironmentRequest{Name: "Production", ClusterID: clusterID.String(), Position: 2},
+			expectError: "invalid environment name",
+		},
+		{
+			name:        "invalid environment variable",
+			req:         domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Position: 2, EnvVars: map[string]string{"1BAD": "x"}},
+			expectError: "invalid environment variable name",
+		},
+		{
+			name:        "position taken",
+			req:         domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Position: 1},
+			expectError: "environment staging already has position 1",
+		},
+		{
+			name:         "cluster of another organization",
+			req:          domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Position: 2},
+			clusterOrgID: uuid.New(),
+			expectError:  "cluster does not belong to the same organization",
+		},
+		{
+			name:         "namespace of another application",
+			req:          domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Namespace: webNamespace, Position: 2},
+			clusterOrgID: orgID,
+			namespaceApp: &domain.Application{ID: uuid.New(), Name: "web", Namespace: webNamespace},
+			expectError:  "namespace " + webNamespace + " is already used by another application",
+		},
+		{
+			name:         "namespace outside the organization's prefix",
+			req:          domain.CreateEnvironmentRequest{Name: "production", ClusterID: clusterID.String(), Namespace: "web", Position: 2},
+			clusterOrgID: orgID,
+			expectError:  "must start with the organization's prefix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envRepo := &MockEnvironmentRepository{}
+			appRepo := &MockApplicationRepository{}
+			clusterRepo := &MockClusterRepository{}
+			orgRepo := &MockOrganizationRepository{}
+
+			service := NewEnvironmentService(envRepo, appRepo, nil, clusterRepo, orgRepo, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api", Namespace: apiNamespace}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+			envRepo.On("GetEnvironmentByName", ctx, appID, tt.req.Name).Return(nil, nil)
+			envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{staging}, nil)
+			clusterRepo.On("GetClusterByID", ctx, clusterID).Return(&domain.Cluster{ID: clusterID, OrgID: tt.clusterOrgID}, nil)
+			appRepo.On("GetApplicationByNamespaceInCluster", ctx, clusterID, mock.Anything).Return(tt.namespaceApp, nil)
+			envRepo.On("GetEnvironmentByNamespace", ctx, clusterID, mock.Anything).Return(nil, nil)
+			envRepo.On("CreateEnvironment", ctx, mock.MatchedBy(func(env *domain.Environment) bool {
+				return env.Namespace == tt.expectNamespace && env.ClusterID == clusterID && env.AppID == appID
+			})).Return(&domain.Environment{ID: uuid.New(), AppID: appID, Name: tt.req.Name, ClusterID: clusterID, Namespace: tt.expectNamespace}, nil)
+
+			resp, err := service.CreateEnvironment(ctx, userID, appID, &tt.req)
+
+			if tt.expectError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				envRepo.AssertNotCalled(t, "CreateEnvironment", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectNamespace, resp.Namespace)
+		})
+	}
+}
+
+func TestEnvironmentService_CreateEnvironment_RequiresAdmin(t *testing.T) {
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewEnvironmentService(nil, appRepo, nil, nil, orgRepo, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+
+	_, err := service.CreateEnvironment(ctx, userID, appID, &domain.CreateEnvironmentRequest{Name: "production"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient permissions")
+}
+
+func TestEnvironmentService_PromoteRelease(t *testing.T) {
+	envRepo := &MockEnvironmentRepository{}
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewEnvironmentService(envRepo, appRepo, releaseRepo, nil, orgRepo, jobRepo)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	dev := domain.Environment{ID: uuid.New(), AppID: appID, Name: "dev", Position: 0}
+	staging := domain.Environment{ID: uuid.New(), AppID: appID, Name: "staging", Position: 5}
+	production := domain.Environment{ID: uuid.New(), AppID: appID, Name: "production", Position: 10}
+
+	source := &domain.Release{
+		ID:            uuid.New(),
+		AppID:         appID,
+		EnvironmentID: &staging.ID,
+		Image:         "ghcr.io/acme/api",
+		Tag:           "v1.4.0",
+		Status:        domain.ReleaseStatusSucceeded,
+	}
+	require.NoError(t, source.SetMeta(&domain.ReleaseMeta{SpecVersion: 7, CommitSHA: "abc123", FailureReason: "stale"}))
+	promotedID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api"}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+	envRepo.On("GetEnvironmentByName", ctx, appID, "staging").Return(&staging, nil)
+	envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{dev, staging, production}, nil)
+	releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, appID, &staging.ID, domain.ReleaseStatusSucceeded).Return(source, nil)
+	releaseRepo.On("GetLatestReleaseByEnvironment", ctx, appID, &production.ID).Return(nil, nil)
+	releaseRepo.On("CreateRelease", ctx, mock.MatchedBy(func(release *domain.Release) bool {
+		meta, err := release.GetMeta()
+		return err == nil &&
+			release.EnvironmentID != nil && *release.EnvironmentID == production.ID &&
+			release.Image == source.Image && release.Tag == source.Tag &&
+			release.Status == domain.ReleaseStatusPending &&
+			meta.SpecVersion == 7 && meta.CommitSHA == "abc123" && meta.FailureReason == "" &&
+			meta.PromotedFrom == source.ID.String()
+	})).Return(&domain.Release{ID: promotedID, AppID: appID, EnvironmentID: &production.ID, Status: domain.ReleaseStatusPending}, nil)
+	jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+		return job.Type == domain.JobTypeReleaseDeploy && job.Payload.ReleaseID != nil && *job.Payload.ReleaseID == promotedID
+	})).Return(&domain.Job{ID: uuid.New()}, nil)
+
+	resp, err := service.PromoteRelease(ctx, userID, appID, "staging", &domain.PromoteReleaseRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, promotedID, resp.ReleaseID)
+	assert.Equal(t, "production", resp.Environment)
+	releaseRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestEnvironmentService_PromoteRelease_Rejected(t *testing.T) {
+	appID := uuid.New()
+	staging := domain.Environment{ID: uuid.New(), AppID: appID, Name: "staging", Position: 0}
+	production := domain.Environment{ID: uuid.New(), AppID: appID, Name: "production", Position: 1}
+	releaseID := uuid.New()
+
+	tests := []struct {
+		name        string
+		from        string
+		release     *domain.Release
+		expectError string
+	}{
+		{
+			name:        "last environment",
+			from:        "production",
+			expectError: "environment production is the last environment and cannot be promoted",
+		},
+		{
+			name:        "release of another environment",
+			from:        "staging",
+			release:     &domain.Release{ID: releaseID, AppID: appID, EnvironmentID: &production.ID, Status: domain.ReleaseStatusSucceeded},
+			expectError: "release does not belong to environment staging",
+		},
+		{
+			name:        "failed release",
+			from:        "staging",
+			release:     &domain.Release{ID: releaseID, AppID: appID, EnvironmentID: &staging.ID, Status: domain.ReleaseStatusFailed},
+			expectError: "only succeeded releases can be promoted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envRepo := &MockEnvironmentRepository{}
+			appRepo := &MockApplicationRepository{}
+			releaseRepo := &MockReleaseRepository{}
+			orgRepo := &MockOrganizationRepository{}
+
+			service := NewEnvironmentService(envRepo, appRepo, releaseRepo, nil, orgRepo, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+			envRepo.On("GetEnvironmentByName", ctx, appID, "staging").Return(&staging, nil)
+			envRepo.On("GetEnvironmentByName", ctx, appID, "production").Return(&production, nil)
+			envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{staging, production}, nil)
+			releaseRepo.On("GetReleaseByID", ctx, releaseID).Return(tt.release, nil)
+
+			_, err := service.PromoteRelease(ctx, userID, appID, tt.from, &domain.PromoteReleaseRequest{ReleaseID: &releaseID})
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+			releaseRepo.AssertNotCalled(t, "CreateRelease", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -29,6 +29,7 @@ type RunnerService interface {
 type JobService interface {
 	CreateJob(ctx context.Context, orgID uuid.UUID, jobType domain.JobType, payload domain.JobPayload) (*domain.JobResponse, error)
 	GetJobsByOrg(ctx context.Context, userID, orgID uuid.UUID) ([]domain.JobResponse, error)
+	GetJob(ctx context.Context, userID, orgID, jobID uuid.UUID) (*domain.JobResponse, error)
 	GetPendingJobs(ctx context.Context) ([]domain.JobResponse, error)
 	StartJob(ctx context.Context, jobID uuid.UUID) (*domain.JobResponse, error)
 	CompleteJob(ctx context.Context, jobID uuid.UUID) (*domain.JobResponse, error)
@@ -540,6 +541,31 @@ func (s *jobService) GetJobsByOrg(ctx context.Context, userID, orgID uuid.UUID)
 	return responses, nil
 }
 
+func (s *jobService) GetJob(ctx context.Context, userID, orgID, jobID uuid.UUID) (*domain.JobResponse, error) {
+	// Verify user is a member of the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, orgID)
+	if err != nil {
+		s.logger.Error("Failed to get user role for organization", zap.Error(err), zap.String("orgID", orgID.String()), zap.String("userID", userID.String()))
+		return nil, errors.New("failed to verify organization membership")
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job by ID", zap.Error(err), zap.String("jobID", jobID.String()))
+		return nil, errors.New("failed to retrieve job")
+	}
+	// Jobs of other organizations are reported as missing rather than forbidden
+	if job == nil || job.OrgID != orgID {
+		return nil, errors.New("job not found")
+	}
+
+	response := job.ToResponse()
+	return &response, nil
+}
+
 func (s *jobService) GetPendingJobs(ctx context.Context) ([]domain.JobResponse, error) {
 	jobs, err := s.jobRepo.GetPendingJobs(ctx)
 	if err != nil {
@@ -94,7 +94,7 @@ func (s *infrastructureService) ProvisionServices(ctx context.Context, userID, a
 	}
 
 	// 6. Generate service configurations
-	serviceConfigs, err := s.parser.GenerateServiceConfigs(config, app.Name)
+	serviceConfigs, err := s.parser.GenerateServiceConfigs(config, app.Namespace)
 	if err != nil {
 		s.logger.Error("Failed to generate service configurations", zap.Error(err))
 		return nil, fmt.Errorf("failed to generate service configurations: %w", err)
@@ -236,7 +236,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 	}
 
 	// Get CPU usage for the application namespace
-	cpuQuery := prometheus.BuildPromQLQuery(prometheus.AppCPUUsageQuery, app.Name)
+	cpuQuery := prometheus.BuildPromQLQuery(prometheus.AppCPUUsageQuery, app.Namespace)
 	cpuResp, err := s.prometheusClient.QueryRange(ctx, cpuQuery, startTime, endTime, "1m")
 	if err != nil {
 		s.logger.Error("Failed to query app CPU usage", zap.Error(err))
@@ -246,7 +246,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 	}
 
 	// Get memory usage for the application namespace
-	memQuery := prometheus.BuildPromQLQuery(prometheus.AppMemoryUsageQuery, app.Name)
+	memQuery := prometheus.BuildPromQLQuery(prometheus.AppMemoryUsageQuery, app.Namespace)
 	memResp, err := s.prometheusClient.QueryRange(ctx, memQuery, startTime, endTime, "1m")
 	if err != nil {
 		s.logger.Error("Failed to query app memory usage", zap.Error(err))
@@ -256,7 +256,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 	}
 
 	// Get pod counts
-	podCountQuery := prometheus.BuildPromQLQuery(prometheus.AppPodCountQuery, app.Name)
+	podCountQuery := prometheus.BuildPromQLQuery(prometheus.AppPodCountQuery, app.Namespace)
 	podCountResp, err := s.prometheusClient.Query(ctx, podCountQuery, endTime)
 	if err != nil {
 		s.logger.Error("Failed to query pod count", zap.Error(err))
@@ -265,7 +265,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 		metrics.PodCount = s.extractSingleValue(podCountResp)
 	}
 
-	runningPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppRunningPodsQuery, app.Name)
+	runningPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppRunningPodsQuery, app.Namespace)
 	runningPodsResp, err := s.prometheusClient.Query(ctx, runningPodsQuery, endTime)
 	if err != nil {
 		s.logger.Error("Failed to query running pods", zap.Error(err))
@@ -274,7 +274,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 		metrics.RunningPods = s.extractSingleValue(runningPodsResp)
 	}
 
-	pendingPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppPendingPodsQuery, app.Name)
+	pendingPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppPendingPodsQuery, app.Namespace)
 	pendingPodsResp, err := s.prometheusClient.Query(ctx, pendingPodsQuery, endTime)
 	if err != nil {
 		s.logger.Error("Failed to query pending pods", zap.Error(err))
@@ -283,7 +283,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 		metrics.PendingPods = s.extractSingleValue(pendingPodsResp)
 	}
 
-	failedPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppFailedPodsQuery, app.Name)
+	failedPodsQuery := prometheus.BuildPromQLQuery(prometheus.AppFailedPodsQuery, app.Namespace)
 	failedPodsResp, err := s.prometheusClient.Query(ctx, failedPodsQuery, endTime)
 	if err != nil {
 		s.logger.Error("Failed to query failed pods", zap.Error(err))
@@ -301,7 +301,7 @@ func (s *monitoringService) GetApplicationMetrics(ctx context.Context, userID, a
 		// Filter alerts for this application and limit to top 5
 		var appAlerts []domain.Alert
 		for _, alert := range alerts {
-			if alert.Labels["namespace"] == app.Name {
+			if alert.Labels["namespace"] == app.Namespace {
 				appAlerts = append(appAlerts, alert)
 				if len(appAlerts) >= 5 {
 					break
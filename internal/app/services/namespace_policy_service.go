@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type NamespacePolicyService interface {
+	GetNamespacePolicy(ctx context.Context, userID, orgID uuid.UUID) (*domain.NamespacePolicy, error)
+	UpdateNamespacePolicy(ctx context.Context, userID, orgID uuid.UUID, req *domain.UpdateNamespacePolicyRequest) (*domain.NamespacePolicy, error)
+}
+
+type namespacePolicyService struct {
+	policyRepo repo.NamespacePolicyRepository
+	orgRepo    repo.OrganizationRepository
+}
+
+func NewNamespacePolicyService(policyRepo repo.NamespacePolicyRepository, orgRepo repo.OrganizationRepository) NamespacePolicyService {
+	return &namespacePolicyService{
+		policyRepo: policyRepo,
+		orgRepo:    orgRepo,
+	}
+}
+
+// limitRangeResources are the container resources a LimitRange may constrain
+var limitRangeResources = map[string]bool{"cpu": true, "memory": true, "ephemeral-storage": true}
+
+// GetNamespacePolicy returns the organization's namespace policy, or the default policy if it
+// has not configured one
+func (s *namespacePolicyService) GetNamespacePolicy(ctx context.Context, userID, orgID uuid.UUID) (*domain.NamespacePolicy, error) {
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	policy, err := s.policyRepo.GetNamespacePolicy(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return domain.DefaultNamespacePolicy(orgID), nil
+	}
+	return policy, nil
+}
+
+// UpdateNamespacePolicy replaces the organization's namespace policy. It is applied to each
+// namespace with the next deploy to it.
+func (s *namespacePolicyService) UpdateNamespacePolicy(ctx context.Context, userID, orgID uuid.UUID, req *domain.UpdateNamespacePolicyRequest) (*domain.NamespacePolicy, error) {
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+	if role != domain.RoleOwner && role != domain.RoleAdmin {
+		return nil, errors.New("insufficient permissions to manage the namespace policy")
+	}
+
+	if err := validateResourceQuota(req.ResourceQuota); err != nil {
+		return nil, err
+	}
+	if err := validateLimitRange(req.LimitRange); err != nil {
+		return nil, err
+	}
+	ingressNamespaces := req.IngressNamespaces
+	if len(ingressNamespaces) == 0 {
+		ingressNamespaces = []string{domain.DefaultIngressNamespace}
+	}
+	for _, namespace := range ingressNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid ingress namespace %q: %s", namespace, strings.Join(errs, "; "))
+		}
+	}
+
+	return s.policyRepo.UpsertNamespacePolicy(ctx, &domain.NamespacePolicy{
+		OrgID:             orgID,
+		ResourceQuota:     req.ResourceQuota,
+		LimitRange:        req.LimitRange,
+		IngressNamespaces: ingressNamespaces,
+	})
+}
+
+// validateResourceQuota rejects quota entries that are not a resource name and a quantity
+func validateResourceQuota(quota map[string]string) error {
+	for name, value := range quota {
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			return fmt.Errorf("invalid resource quota name %q: %s", name, strings.Join(errs, "; "))
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("invalid resource quota %s %q: %v", name, value, err)
+		}
+	}
+	return nil
+}
+
+// validateLimitRange rejects limits of unknown resources, values that are not quantities, and
+// bounds that contradict each other, which the cluster would only reject on the next deploy
+func validateLimitRange(limits *domain.LimitRangeSpec) error {
+	if limits == nil {
+		return nil
+	}
+
+	parsed := make(map[string]map[string]resource.Quantity)
+	fields := []struct {
+		name   string
+		values map[string]string
+	}{
+		{"default", limits.Default},
+		{"default_request", limits.DefaultRequest},
+		{"min", limits.Min},
+		{"max", limits.Max},
+	}
+	for _, field := range fields {
+		parsed[field.name] = make(map[string]resource.Quantity, len(field.values))
+		for name, value := range field.values {
+			if !limitRangeResources[name] {
+				return fmt.Errorf("invalid limit range %s resource %q: must be cpu, memory or ephemeral-storage", field.name, name)
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return fmt.Errorf("invalid limit range %s %s %q: %v", field.name, name, value, err)
+			}
+			parsed[field.name][name] = quantity
+		}
+	}
+
+	// Each pair is ordered from the lower bound to the upper one
+	pairs := [][2]string{
+		{"min", "default_request"},
+		{"default_request", "default"},
+		{"default", "max"},
+		{"min", "max"},
+	}
+	for _, pair := range pairs {
+		for name, lower := range parsed[pair[0]] {
+			upper, ok := parsed[pair[1]][name]
+			if ok && lower.Cmp(upper) > 0 {
+				return fmt.Errorf("invalid limit range: %s %s must not exceed %s", pair[0], name, pair[1])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockNamespacePolicyRepository is a mock implementation of NamespacePolicyRepository
+type MockNamespacePolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockNamespacePolicyRepository) GetNamespacePolicy(ctx context.Context, orgID uuid.UUID) (*domain.NamespacePolicy, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.NamespacePolicy), args.Error(1)
+}
+
+func (m *MockNamespacePolicyRepository) UpsertNamespacePolicy(ctx context.Context, policy *domain.NamespacePolicy) (*domain.NamespacePolicy, error) {
+	args := m.Called(ctx, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.NamespacePolicy), args.Error(1)
+}
+
+func TestNamespacePolicyService_GetNamespacePolicy_Default(t *testing.T) {
+	policyRepo := &MockNamespacePolicyRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewNamespacePolicyService(policyRepo, orgRepo)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+	policyRepo.On("GetNamespacePolicy", ctx, orgID).Return((*domain.NamespacePolicy)(nil), nil)
+
+	policy, err := service.GetNamespacePolicy(ctx, userID, orgID)
+
+	require.NoError(t, err)
+	assert.Equal(t, orgID, policy.OrgID)
+	assert.Empty(t, policy.ResourceQuota)
+	assert.Nil(t, policy.LimitRange)
+	assert.Equal(t, []string{domain.DefaultIngressNamespace}, policy.IngressNamespaces)
+}
+
+func TestNamespacePolicyService_UpdateNamespacePolicy(t *testing.T) {
+	policyRepo := &MockNamespacePolicyRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewNamespacePolicyService(policyRepo, orgRepo)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	req := &domain.UpdateNamespacePolicyRequest{
+		ResourceQuota: map[string]string{"requests.cpu": "4", "limits.memory": "8Gi", "pods": "20"},
+		LimitRange: &domain.LimitRangeSpec{
+			Default:        map[string]string{"cpu": "500m", "memory": "512Mi"},
+			DefaultRequest: map[string]string{"cpu": "100m", "memory": "128Mi"},
+			Max:            map[string]string{"memory": "2Gi"},
+		},
+	}
+
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	policyRepo.On("UpsertNamespacePolicy", ctx, mock.MatchedBy(func(policy *domain.NamespacePolicy) bool {
+		return policy.OrgID == orgID &&
+			assert.ObjectsAreEqual(req.ResourceQuota, policy.ResourceQuota) &&
+			policy.LimitRange == req.LimitRange &&
+			assert.ObjectsAreEqual([]string{domain.DefaultIngressNamespace}, policy.IngressNamespaces)
+	})).Return(&domain.NamespacePolicy{OrgID: orgID}, nil)
+
+	_, err := service.UpdateNamespacePolicy(ctx, userID, orgID, req)
+
+	require.NoError(t, err)
+	policyRepo.AssertExpectations(t)
+}
+
+func TestNamespacePolicyService_UpdateNamespacePolicy_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		req         domain.UpdateNamespacePolicyRequest
+		expectError string
+	}{
+		{
+			name:        "member",
+			role:        domain.RoleMember,
+			expectError: "insufficient permissions to manage the namespace policy",
+		},
+		{
+			name:        "quota not a quantity",
+			role:        domain.RoleOwner,
+			req:         domain.UpdateNamespacePolicyRequest{ResourceQuota: map[string]string{"requests.cpu": "lots"}},
+			expectError: `invalid resource quota requests.cpu "lots"`,
+		},
+		{
+			name:        "unknown limit range resource",
+			role:        domain.RoleOwner,
+			req:         domain.UpdateNamespacePolicyRequest{LimitRange: &domain.LimitRangeSpec{Max: map[string]string{"gpu": "1"}}},
+			expectError: `invalid limit range max resource "gpu"`,
+		},
+		{
+			name: "default above max",
+			role: domain.RoleOwner,
+			req: domain.UpdateNamespacePolicyRequest{LimitRange: &domain.LimitRangeSpec{
+				Default: map[string]string{"memory": "4Gi"},
+				Max:     map[string]string{"memory": "2Gi"},
+			}},
+			expectError: "invalid limit range: default memory must not exceed max",
+		},
+		{
+			name:        "invalid ingress namespace",
+			role:        domain.RoleOwner,
+			req:         domain.UpdateNamespacePolicyRequest{IngressNamespaces: []string{"Ingress_Nginx"}},
+			expectError: `invalid ingress namespace "Ingress_Nginx"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policyRepo := &MockNamespacePolicyRepository{}
+			orgRepo := &MockOrganizationRepository{}
+
+			service := NewNamespacePolicyService(policyRepo, orgRepo)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+
+			_, err := service.UpdateNamespacePolicy(ctx, userID, orgID, &tt.req)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+			policyRepo.AssertNotCalled(t, "UpsertNamespacePolicy", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -19,6 +19,7 @@ type PipelineService interface {
 	GetPipelineByID(ctx context.Context, userID, pipelineID uuid.UUID) (*domain.PipelineResponse, error)
 	GetPipelineLogs(ctx context.Context, userID, pipelineID uuid.UUID) (*domain.PipelineLogsResponse, error)
 	TriggerPipeline(ctx context.Context, userID, appID uuid.UUID, req domain.CreatePipelineRequest) (*domain.PipelineResponse, error)
+	TriggerWebhookPipeline(ctx context.Context, app *domain.Application, req domain.CreatePipelineRequest) (*domain.PipelineResponse, error)
 }
 
 // pipelineService implements PipelineService
@@ -75,6 +76,34 @@ func (s *pipelineService) CreatePipeline(ctx context.Context, userID, appID uuid
 		return nil, errors.New("unauthorized: user is not a member of the organization")
 	}
 
+	return s.createPipeline(ctx, app, userID, domain.PipelineTriggerTypeManual, req)
+}
+
+// TriggerWebhookPipeline creates a pipeline for an application in response to a Git webhook.
+// Webhook deliveries carry no OneClick user, so the pipeline is attributed to the organization owner.
+func (s *pipelineService) TriggerWebhookPipeline(ctx context.Context, app *domain.Application, req domain.CreatePipelineRequest) (*domain.PipelineResponse, error) {
+	members, err := s.orgRepo.GetOrganizationMembers(ctx, app.OrgID)
+	if err != nil {
+		s.logger.Error("Failed to get organization members", zap.Error(err), zap.String("orgID", app.OrgID.String()))
+		return nil, fmt.Errorf("failed to get organization members: %w", err)
+	}
+
+	var ownerID uuid.UUID
+	for _, member := range members {
+		if member.Role == domain.RoleOwner {
+			ownerID = member.ID
+			break
+		}
+	}
+	if ownerID == uuid.Nil {
+		return nil, errors.New("organization has no owner to attribute the pipeline to")
+	}
+
+	return s.createPipeline(ctx, app, ownerID, domain.PipelineTriggerTypeWebhook, req)
+}
+
+// createPipeline persists a pipeline for an already authorized application and enqueues its job
+func (s *pipelineService) createPipeline(ctx context.Context, app *domain.Application, userID uuid.UUID, triggerType domain.PipelineTriggerType, req domain.CreatePipelineRequest) (*domain.PipelineResponse, error) {
 	// Get repository details
 	repo, err := s.repoRepo.GetRepositoryByID(ctx, app.RepoID)
 	if err != nil {
@@ -85,20 +114,29 @@ func (s *pipelineService) CreatePipeline(ctx context.Context, userID, appID uuid
 		return nil, errors.New("repository not found")
 	}
 
+	event := req.Event
+	if event == "" {
+		event = domain.PipelineEventPush
+	}
+
 	// Create pipeline
 	pipeline := &domain.Pipeline{
-		AppID:       appID,
+		AppID:       app.ID,
 		RepoID:      app.RepoID,
 		CommitSHA:   req.CommitSHA,
 		Status:      domain.PipelineStatusPending,
 		TriggeredBy: userID,
 		Meta: map[string]interface{}{
 			"ref":          req.Ref,
-			"triggered_by": "manual",
+			"event":        string(event),
+			"triggered_by": string(triggerType),
 			"repository":   repo.URL,
 			"app_name":     app.Name,
 		},
 	}
+	if req.PRNumber > 0 {
+		pipeline.Meta["pr_number"] = req.PRNumber
+	}
 
 	createdPipeline, err := s.pipelineRepo.CreatePipeline(ctx, pipeline)
 	if err != nil {
@@ -110,15 +148,18 @@ func (s *pipelineService) CreatePipeline(ctx context.Context, userID, appID uuid
 	jobPayload := domain.JobPayload{
 		PipelineID: &createdPipeline.ID,
 		Config: map[string]interface{}{
-			"app_id":       appID.String(),
+			"app_id":       app.ID.String(),
 			"repo_id":      app.RepoID.String(),
 			"commit_sha":   req.CommitSHA,
 			"ref":          req.Ref,
+			"event":        string(event),
 			"triggered_by": userID.String(),
+			"trigger_type": string(triggerType),
 		},
 	}
 
 	job := &domain.Job{
+		OrgID:   app.OrgID,
 		Type:    domain.JobTypePipelineRun,
 		Status:  domain.JobStatusPending,
 		Payload: jobPayload,
@@ -112,6 +112,11 @@ func (m *MockRepositoryRepository) GetRepositoryByURL(ctx context.Context, orgID
 	return args.Get(0).(*domain.Repository), args.Error(1)
 }
 
+func (m *MockRepositoryRepository) GetRepositoriesByNormalizedURL(ctx context.Context, normalizedURL string) ([]domain.Repository, error) {
+	args := m.Called(ctx, normalizedURL)
+	return args.Get(0).([]domain.Repository), args.Error(1)
+}
+
 func (m *MockRepositoryRepository) GetRepositoriesByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RepositorySummary, error) {
 	args := m.Called(ctx, orgID)
 	return args.Get(0).([]domain.RepositorySummary), args.Error(1)
@@ -152,6 +157,11 @@ func (m *MockJobRepository) GetPendingJobs(ctx context.Context) ([]domain.Job, e
 	return args.Get(0).([]domain.Job), args.Error(1)
 }
 
+func (m *MockJobRepository) GetPendingJobsByType(ctx context.Context, jobType domain.JobType) ([]domain.Job, error) {
+	args := m.Called(ctx, jobType)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
 func (m *MockJobRepository) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.Job, error) {
 	args := m.Called(ctx, orgID)
 	return args.Get(0).([]domain.Job), args.Error(1)
@@ -172,6 +182,11 @@ func (m *MockJobRepository) CompleteJob(ctx context.Context, id uuid.UUID) (*dom
 	return args.Get(0).(*domain.Job), args.Error(1)
 }
 
+func (m *MockJobRepository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress []domain.JobStep) error {
+	args := m.Called(ctx, id, progress)
+	return args.Error(0)
+}
+
 func (m *MockJobRepository) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -397,3 +412,61 @@ func TestPipelineService_GetPipeline(t *testing.T) {
 	pipelineStepRepo.AssertExpectations(t)
 	appRepo.AssertExpectations(t)
 }
+
+func TestPipelineService_TriggerWebhookPipeline(t *testing.T) {
+	// Setup mocks
+	pipelineRepo := &MockPipelineRepository{}
+	pipelineStepRepo := &MockPipelineStepRepository{}
+	appRepo := &MockApplicationRepository{}
+	repositoryRepo := &MockRepositoryRepository{}
+	jobRepo := &MockJobRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewPipelineService(pipelineRepo, pipelineStepRepo, appRepo, repositoryRepo, orgRepo, jobRepo, nil)
+
+	ctx := context.Background()
+	ownerID := uuid.New()
+	orgID := uuid.New()
+	repoID := uuid.New()
+	app := &domain.Application{
+		ID:     uuid.New(),
+		RepoID: repoID,
+		OrgID:  orgID,
+		Name:   "test-app",
+	}
+
+	// Mock organization members; the owner is attributed as the trigger
+	orgRepo.On("GetOrganizationMembers", ctx, orgID).Return([]domain.OrganizationMember{
+		{ID: uuid.New(), Role: domain.RoleMember},
+		{ID: ownerID, Role: domain.RoleOwner},
+	}, nil)
+
+	repositoryRepo.On("GetRepositoryByID", ctx, repoID).Return(&domain.Repository{ID: repoID, URL: "https://github.com/test/repo"}, nil)
+
+	pipelineID := uuid.New()
+	pipelineRepo.On("CreatePipeline", ctx, mock.MatchedBy(func(p *domain.Pipeline) bool {
+		return p.TriggeredBy == ownerID && p.Meta["triggered_by"] == string(domain.PipelineTriggerTypeWebhook) &&
+			p.Meta["event"] == string(domain.PipelineEventPullRequest) && p.Meta["pr_number"] == 42
+	})).Return(&domain.Pipeline{ID: pipelineID, AppID: app.ID, Status: domain.PipelineStatusPending}, nil)
+	pipelineStepRepo.On("GetPipelineStepsByPipelineID", ctx, pipelineID).Return([]domain.PipelineStep{}, nil)
+	jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(j *domain.Job) bool {
+		return j.OrgID == orgID && j.Type == domain.JobTypePipelineRun
+	})).Return(&domain.Job{ID: uuid.New()}, nil)
+
+	// Test
+	result, err := service.TriggerWebhookPipeline(ctx, app, domain.CreatePipelineRequest{
+		Ref:       "refs/pull/42/head",
+		CommitSHA: "abc123",
+		Event:     domain.PipelineEventPullRequest,
+		PRNumber:  42,
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, pipelineID, result.ID)
+
+	// Verify mocks
+	pipelineRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+	orgRepo.AssertExpectations(t)
+}
@@ -103,7 +103,7 @@ func (s *podService) GetPodsByApp(ctx context.Context, userID, appID uuid.UUID)
 	}
 
 	// Get pods from Kubernetes
-	podInfos, err := kubeClient.GetPodsByApp(ctx, app.Name, app.Name) // Using app name as namespace
+	podInfos, err := kubeClient.GetPodsByApp(ctx, app.Name, app.Namespace)
 	if err != nil {
 		s.logger.Error("Failed to get pods from Kubernetes", zap.Error(err), zap.String("appName", app.Name))
 		return nil, errors.New("failed to retrieve pods from cluster")
@@ -160,9 +160,9 @@ func (s *podService) findApplicationByNamespace(ctx context.Context, userID uuid
 				continue // Continue to next cluster
 			}
 
-			// Look for an application with a name that matches the namespace
+			// Look for the application deployed to the namespace
 			for _, appSummary := range apps {
-				if appSummary.Name == namespace {
+				if appSummary.Namespace == namespace {
 					// Found the application! Now get the full application and cluster details
 					app, err := s.appRepo.GetApplicationByID(ctx, appSummary.ID)
 					if err != nil {
@@ -45,6 +45,19 @@ func (m *MockApplicationRepository) GetApplicationByNameInCluster(ctx context.Co
 	return args.Get(0).(*domain.Application), args.Error(1)
 }
 
+func (m *MockApplicationRepository) GetApplicationByNamespaceInCluster(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Application, error) {
+	args := m.Called(ctx, clusterID, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationsByRepoID(ctx context.Context, repoID uuid.UUID) ([]domain.Application, error) {
+	args := m.Called(ctx, repoID)
+	return args.Get(0).([]domain.Application), args.Error(1)
+}
+
 func (m *MockApplicationRepository) DeleteApplication(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -230,6 +243,22 @@ func (m *MockKubernetesClient) ExecInPod(ctx context.Context, podName, namespace
 	return args.Error(0)
 }
 
+func (m *MockKubernetesClient) GetReplicaStatus(ctx context.Context, appName, namespace string) (*domain.ReplicaStatus, error) {
+	args := m.Called(ctx, appName, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReplicaStatus), args.Error(1)
+}
+
+func (m *MockKubernetesClient) GetVolumeStatuses(ctx context.Context, appName, namespace string) ([]domain.VolumeStatus, error) {
+	args := m.Called(ctx, appName, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.VolumeStatus), args.Error(1)
+}
+
 func TestPodService_GetPodsByApp(t *testing.T) {
 	logger := zap.NewNop()
 
@@ -261,6 +290,7 @@ func TestPodService_GetPodsByApp(t *testing.T) {
 		OrgID:     orgID,
 		ClusterID: clusterID,
 		Name:      "test-app",
+		Namespace: "acme-test-app",
 	}
 
 	cluster := &domain.Cluster{
@@ -273,7 +303,7 @@ func TestPodService_GetPodsByApp(t *testing.T) {
 	mockAppRepo.On("GetApplicationByID", mock.Anything, appID).Return(app, nil)
 	mockOrgRepo.On("GetUserRoleInOrganization", mock.Anything, userID, orgID).Return("member", nil)
 	mockClusterRepo.On("GetClusterByID", mock.Anything, clusterID).Return(cluster, nil)
-	mockKubeClient.On("GetPodsByApp", mock.Anything, "test-app", "test-app").Return([]domain.Pod{}, nil)
+	mockKubeClient.On("GetPodsByApp", mock.Anything, "test-app", "acme-test-app").Return([]domain.Pod{}, nil)
 
 	// Test
 	pods, err := podService.GetPodsByApp(context.Background(), userID, appID)
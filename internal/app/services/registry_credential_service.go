@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/registry"
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type RegistryCredentialService interface {
+	GetRegistryCredentials(ctx context.Context, userID, orgID uuid.UUID) ([]domain.RegistryCredentialResponse, error)
+	CreateRegistryCredential(ctx context.Context, userID, orgID uuid.UUID, req *domain.CreateRegistryCredentialRequest) (*domain.RegistryCredentialResponse, error)
+	UpdateRegistryCredential(ctx context.Context, userID, orgID, credentialID uuid.UUID, req *domain.UpdateRegistryCredentialRequest) (*domain.RegistryCredentialResponse, error)
+	DeleteRegistryCredential(ctx context.Context, userID, orgID, credentialID uuid.UUID) error
+}
+
+type registryCredentialService struct {
+	credRepo repo.RegistryCredentialRepository
+	orgRepo  repo.OrganizationRepository
+	crypto   *crypto.Crypto
+}
+
+func NewRegistryCredentialService(
+	credRepo repo.RegistryCredentialRepository,
+	orgRepo repo.OrganizationRepository,
+	crypto *crypto.Crypto,
+) RegistryCredentialService {
+	return &registryCredentialService{
+		credRepo: credRepo,
+		orgRepo:  orgRepo,
+		crypto:   crypto,
+	}
+}
+
+func (s *registryCredentialService) GetRegistryCredentials(ctx context.Context, userID, orgID uuid.UUID) ([]domain.RegistryCredentialResponse, error) {
+	if err := s.checkManageAccess(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	creds, err := s.credRepo.GetRegistryCredentialsByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]domain.RegistryCredentialResponse, 0, len(creds))
+	for _, cred := range creds {
+		responses = append(responses, cred.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (s *registryCredentialService) CreateRegistryCredential(ctx context.Context, userID, orgID uuid.UUID, req *domain.CreateRegistryCredentialRequest) (*domain.RegistryCredentialResponse, error) {
+	if err := s.checkManageAccess(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	provider, host, err := registryCredentialTarget(req.Provider, req.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.credRepo.GetRegistryCredentialByRegistry(ctx, orgID, host)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("credentials for registry %s already exist", host)
+	}
+
+	passwordEncrypted, err := s.crypto.Encrypt([]byte(req.Password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt registry password: %w", err)
+	}
+
+	created, err := s.credRepo.CreateRegistryCredential(ctx, &domain.RegistryCredential{
+		OrgID:             orgID,
+		Provider:          provider,
+		Registry:          host,
+		Username:          req.Username,
+		PasswordEncrypted: passwordEncrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := created.ToResponse()
+	return &response, nil
+}
+
+func (s *registryCredentialService) UpdateRegistryCredential(ctx context.Context, userID, orgID, credentialID uuid.UUID, req *domain.UpdateRegistryCredentialRequest) (*domain.RegistryCredentialResponse, error) {
+	if err := s.checkManageAccess(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getRegistryCredential(ctx, orgID, credentialID); err != nil {
+		return nil, err
+	}
+
+	passwordEncrypted, err := s.crypto.Encrypt([]byte(req.Password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt registry password: %w", err)
+	}
+
+	updated, err := s.credRepo.UpdateRegistryCredential(ctx, credentialID, req.Username, passwordEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("registry credential not found")
+	}
+
+	response := updated.ToResponse()
+	return &response, nil
+}
+
+func (s *registryCredentialService) DeleteRegistryCredential(ctx context.Context, userID, orgID, credentialID uuid.UUID) error {
+	if err := s.checkManageAccess(ctx, userID, orgID); err != nil {
+		return err
+	}
+
+	if _, err := s.getRegistryCredential(ctx, orgID, credentialID); err != nil {
+		return err
+	}
+
+	return s.credRepo.DeleteRegistryCredential(ctx, credentialID)
+}
+
+// getRegistryCredential returns a registry credential of the organization
+func (s *registryCredentialService) getRegistryCredential(ctx context.Context, orgID, credentialID uuid.UUID) (*domain.RegistryCredential, error) {
+	cred, err := s.credRepo.GetRegistryCredentialByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	// Credentials of other organizations are reported as missing rather than forbidden
+	if cred == nil || cred.OrgID != orgID {
+		return nil, errors.New("registry credential not found")
+	}
+	return cred, nil
+}
+
+// checkManageAccess allows only owners and admins to see and change an organization's
+// registry credentials
+func (s *registryCredentialService) checkManageAccess(ctx context.Context, userID, orgID uuid.UUID) error {
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, orgID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return errors.New("user does not have access to this organization")
+	}
+	if role != domain.RoleOwner && role != domain.RoleAdmin {
+		return errors.New("insufficient permissions to manage registry credentials")
+	}
+	return nil
+}
+
+// registryCredentialTarget returns the provider and registry host credentials are for: the
+// provider's host when no registry is given, and the registry's provider when no provider is
+func registryCredentialTarget(provider domain.RegistryProvider, host string) (domain.RegistryProvider, string, error) {
+	if host == "" {
+		host = provider.DefaultHost()
+		if host == "" {
+			return "", "", errors.New("registry is required for generic registries")
+		}
+	}
+
+	host = registry.NormalizeRegistry(host)
+	if err := validateRegistryHost(host); err != nil {
+		return "", "", err
+	}
+
+	if provider == "" {
+		provider = domain.RegistryProviderForHost(host)
+	}
+	// Only GitLab is self-managed; the other hosted providers have a single registry
+	if provider != domain.RegistryProviderGeneric && provider != domain.RegistryProviderGitLab && host != provider.DefaultHost() {
+		return "", "", fmt.Errorf("invalid registry %q for provider %s, expected %s", host, provider, provider.DefaultHost())
+	}
+
+	return provider, host, nil
+}
+
+// validateRegistryHost checks that a registry is a host name or IP address, with an optional
+// port, as it appears at the start of image names
+func validateRegistryHost(host string) error {
+	name := host
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || len(validation.IsValidPortNum(n)) > 0 {
+			return fmt.Errorf("invalid registry %q: invalid port", host)
+		}
+		name = h
+	}
+
+	if net.ParseIP(name) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid registry %q: %s", host, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockRegistryCredentialRepository is a mock implementation of RegistryCredentialRepository
+type MockRegistryCredentialRepository struct {
+	mock.Mock
+}
+
+func (m *MockRegistryCredentialRepository) CreateRegistryCredential(ctx context.Context, cred *domain.RegistryCredential) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, cred)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialByID(ctx context.Context, id uuid.UUID) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialByRegistry(ctx context.Context, orgID uuid.UUID, registry string) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, orgID, registry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RegistryCredential, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) UpdateRegistryCredential(ctx context.Context, id uuid.UUID, username string, passwordEncrypted []byte) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, id, username, passwordEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) DeleteRegistryCredential(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestRegistryCredentialService_CreateRegistryCredential_EncryptsPassword(t *testing.T) {
+	credRepo := &MockRegistryCredentialRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	cryptoService := newTestCrypto(t)
+
+	service := NewRegistryCredentialService(credRepo, orgRepo, cryptoService)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleOwner, nil)
+	credRepo.On("GetRegistryCredentialByRegistry", ctx, orgID, "docker.io").Return(nil, nil)
+
+	var stored *domain.RegistryCredential
+	credRepo.On("CreateRegistryCredential", ctx, mock.AnythingOfType("*domain.RegistryCredential")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.RegistryCredential)
+		}).
+		Return(&domain.RegistryCredential{ID: uuid.New(), OrgID: orgID, Registry: "docker.io", Username: "acme"}, nil)
+
+	resp, err := service.CreateRegistryCredential(ctx, userID, orgID, &domain.CreateRegistryCredentialRequest{
+		Registry: "index.docker.io",
+		Username: "acme",
+		Password: "dckr_pat_secret",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "docker.io", resp.Registry)
+
+	// Docker Hub is stored under its canonical name, with the password encrypted
+	assert.Equal(t, domain.RegistryProviderDockerHub, stored.Provider)
+	assert.Equal(t, "docker.io", stored.Registry)
+	assert.NotContains(t, string(stored.PasswordEncrypted), "dckr_pat_secret")
+	decrypted, err := cryptoService.Decrypt(stored.PasswordEncrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "dckr_pat_secret", string(decrypted))
+
+	credRepo.AssertExpectations(t)
+}
+
+func TestRegistryCredentialService_CreateRegistryCredential_ProviderDefaults(t *testing.T) {
+	tests := []struct {
+		name             string
+		provider         domain.RegistryProvider
+		registry         string
+		expectedProvider domain.RegistryProvider
+		expectedRegistry string
+	}{
+		{name: "ghcr", provider: domain.RegistryProviderGHCR, expectedProvider: domain.RegistryProviderGHCR, expectedRegistry: "ghcr.io"},
+		{name: "gitlab.com", provider: domain.RegistryProviderGitLab, expectedProvider: domain.RegistryProviderGitLab, expectedRegistry: "registry.gitlab.com"},
+		{name: "self-managed gitlab", provider: domain.RegistryProviderGitLab, registry: "registry.git.example.com", expectedProvider: domain.RegistryProviderGitLab, expectedRegistry: "registry.git.example.com"},
+		{name: "generic", registry: "localhost:5000", expectedProvider: domain.RegistryProviderGeneric, expectedRegistry: "localhost:5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credRepo := &MockRegistryCredentialRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			service := NewRegistryCredentialService(credRepo, orgRepo, newTestCrypto(t))
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+			credRepo.On("GetRegistryCredentialByRegistry", ctx, orgID, tt.expectedRegistry).Return(nil, nil)
+			credRepo.On("CreateRegistryCredential", ctx, mock.MatchedBy(func(cred *domain.RegistryCredential) bool {
+				return cred.Provider == tt.expectedProvider && cred.Registry == tt.expectedRegistry
+			})).Return(&domain.RegistryCredential{ID: uuid.New(), OrgID: orgID}, nil)
+
+			_, err := service.CreateRegistryCredential(ctx, userID, orgID, &domain.CreateRegistryCredentialRequest{
+				Provider: tt.provider,
+				Registry: tt.registry,
+				Username: "acme",
+				Password: "token",
+			})
+
+			assert.NoError(t, err)
+			credRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegistryCredentialService_CreateRegistryCredential_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		provider    domain.RegistryProvider
+		registry    string
+		existing    *domain.RegistryCredential
+		expectError string
+	}{
+		{
+			name:        "member cannot manage credentials",
+			role:        "member",
+			registry:    "ghcr.io",
+			expectError: "insufficient permissions",
+		},
+		{
+			name:        "registry with a scheme",
+			role:        domain.RoleAdmin,
+			registry:    "https://ghcr.io",
+			expectError: "invalid registry",
+		},
+		{
+			name:        "registry with an invalid port",
+			role:        domain.RoleAdmin,
+			registry:    "registry.example.com:99999",
+			expectError: "invalid registry",
+		},
+		{
+			name:        "generic registry without a host",
+			role:        domain.RoleAdmin,
+			provider:    domain.RegistryProviderGeneric,
+			expectError: "registry is required",
+		},
+		{
+			name:        "hosted provider with another host",
+			role:        domain.RoleAdmin,
+			provider:    domain.RegistryProviderGHCR,
+			registry:    "registry.example.com",
+			expectError: "invalid registry",
+		},
+		{
+			name:        "duplicate registry",
+			role:        domain.RoleAdmin,
+			registry:    "GHCR.io",
+			existing:    &domain.RegistryCredential{Registry: "ghcr.io"},
+			expectError: "already exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credRepo := &MockRegistryCredentialRepository{}
+			orgRepo := &MockOrganizationRepository{}
+			service := NewRegistryCredentialService(credRepo, orgRepo, newTestCrypto(t))
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+			credRepo.On("GetRegistryCredentialByRegistry", ctx, orgID, mock.Anything).Return(tt.existing, nil)
+
+			_, err := service.CreateRegistryCredential(ctx, userID, orgID, &domain.CreateRegistryCredentialRequest{
+				Provider: tt.provider,
+				Registry: tt.registry,
+				Username: "acme",
+				Password: "token",
+			})
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+			credRepo.AssertNotCalled(t, "CreateRegistryCredential", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRegistryCredentialService_DeleteRegistryCredential_OtherOrganization(t *testing.T) {
+	credRepo := &MockRegistryCredentialRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	service := NewRegistryCredentialService(credRepo, orgRepo, newTestCrypto(t))
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	credID := uuid.New()
+
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleOwner, nil)
+	credRepo.On("GetRegistryCredentialByID", ctx, credID).Return(&domain.RegistryCredential{ID: credID, OrgID: uuid.New()}, nil)
+
+	err := service.DeleteRegistryCredential(ctx, userID, orgID, credID)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "registry credential not found")
+	credRepo.AssertNotCalled(t, "DeleteRegistryCredential", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+type ReleaseTaskService interface {
+	RunCommand(ctx context.Context, userID, appID uuid.UUID, req *domain.RunCommandRequest) (*domain.ReleaseTask, error)
+	GetReleaseTask(ctx context.Context, userID, appID, taskID uuid.UUID) (*domain.ReleaseTask, error)
+	GetReleaseTasks(ctx context.Context, userID, appID, releaseID uuid.UUID) ([]domain.ReleaseTask, error)
+}
+
+type releaseTaskService struct {
+	taskRepo    repo.ReleaseTaskRepository
+	appRepo     repo.ApplicationRepository
+	releaseRepo repo.ReleaseRepository
+	envRepo     repo.EnvironmentRepository
+	orgRepo     repo.OrganizationRepository
+	jobRepo     repo.JobRepository
+}
+
+func NewReleaseTaskService(
+	taskRepo repo.ReleaseTaskRepository,
+	appRepo repo.ApplicationRepository,
+	releaseRepo repo.ReleaseRepository,
+	envRepo repo.EnvironmentRepository,
+	orgRepo repo.OrganizationRepository,
+	jobRepo repo.JobRepository,
+) ReleaseTaskService {
+	return &releaseTaskService{
+		taskRepo:    taskRepo,
+		appRepo:     appRepo,
+		releaseRepo: releaseRepo,
+		envRepo:     envRepo,
+		orgRepo:     orgRepo,
+		jobRepo:     jobRepo,
+	}
+}
+
+// RunCommand queues an ad-hoc command to run with the image, environment and secrets of the
+// latest succeeded release of the application, or of one of its environments
+func (s *releaseTaskService) RunCommand(ctx context.Context, userID, appID uuid.UUID, req *domain.RunCommandRequest) (*domain.ReleaseTask, error) {
+	app, role, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	// Commands run with the application's secrets
+	if err := requireManager(role, "run commands"); err != nil {
+		return nil, err
+	}
+	if len(req.Command) == 0 || req.Command[0] == "" {
+		return nil, errors.New("command is required")
+	}
+
+	env, err := resolveDeployEnvironment(ctx, s.envRepo, appID, req.Environment)
+	if err != nil {
+		return nil, err
+	}
+	var environmentID *uuid.UUID
+	if env != nil {
+		environmentID = &env.ID
+	}
+
+	release, err := s.releaseRepo.GetLatestReleaseByAppIDAndStatus(ctx, appID, environmentID, domain.ReleaseStatusSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		if env != nil {
+			return nil, fmt.Errorf("environment %s has no succeeded release to run the command with", env.Name)
+		}
+		return nil, errors.New("application has no succeeded release to run the command with")
+	}
+
+	task, err := s.taskRepo.CreateReleaseTask(ctx, &domain.ReleaseTask{
+		AppID:     appID,
+		ReleaseID: release.ID,
+		Kind:      domain.ReleaseTaskRun,
+		Command:   req.Command,
+		Status:    domain.ReleaseTaskStatusPending,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutSeconds := req.TimeoutSeconds
+	if timeoutSeconds == 0 {
+		timeoutSeconds = domain.DefaultReleaseTaskTimeoutSeconds
+	}
+	if _, err := s.jobRepo.CreateJob(ctx, task.NewRunJob(app.OrgID, timeoutSeconds)); err != nil {
+		if _, finishErr := s.taskRepo.FinishReleaseTask(ctx, task.ID, domain.ReleaseTaskStatusFailed, nil, "failed to queue command", ""); finishErr != nil {
+			return nil, fmt.Errorf("failed to queue command: %w (and failed to mark task failed: %v)", err, finishErr)
+		}
+		return nil, fmt.Errorf("failed to queue command: %w", err)
+	}
+
+	return task, nil
+}
+
+func (s *releaseTaskService) GetReleaseTask(ctx context.Context, userID, appID, taskID uuid.UUID) (*domain.ReleaseTask, error) {
+	if err := s.checkTaskOutputAccess(ctx, userID, appID); err != nil {
+		return nil, err
+	}
+
+	task, err := s.taskRepo.GetReleaseTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	// Tasks of other applications are reported as missing rather than forbidden
+	if task == nil || task.AppID != appID {
+		return nil, errors.New("task not found")
+	}
+	return task, nil
+}
+
+func (s *releaseTaskService) GetReleaseTasks(ctx context.Context, userID, appID, releaseID uuid.UUID) ([]domain.ReleaseTask, error) {
+	if err := s.checkTaskOutputAccess(ctx, userID, appID); err != nil {
+		return nil, err
+	}
+
+	release, err := s.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil || release.AppID != appID {
+		return nil, errors.New("release not found")
+	}
+
+	tasks, err := s.taskRepo.GetReleaseTasksByReleaseID(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if tasks == nil {
+		tasks = []domain.ReleaseTask{}
+	}
+	return tasks, nil
+}
+
+// checkTaskOutputAccess rejects users who may not read the output of an application's tasks,
+// which runs with its secrets and may print them
+func (s *releaseTaskService) checkTaskOutputAccess(ctx context.Context, userID, appID uuid.UUID) error {
+	_, role, err := accessibleApplication(ctx, s.appRepo, s.orgRepo, userID, appID)
+	if err != nil {
+		return err
+	}
+	return requireManager(role, "read task output")
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockReleaseTaskRepository is a mock implementation of ReleaseTaskRepository
+type MockReleaseTaskRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseTaskRepository) CreateReleaseTask(ctx context.Context, task *domain.ReleaseTask) (*domain.ReleaseTask, error) {
+	args := m.Called(ctx, task)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseTask), args.Error(1)
+}
+
+func (m *MockReleaseTaskRepository) GetReleaseTaskByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseTask, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseTask), args.Error(1)
+}
+
+func (m *MockReleaseTaskRepository) GetReleaseTasksByReleaseID(ctx context.Context, releaseID uuid.UUID) ([]domain.ReleaseTask, error) {
+	args := m.Called(ctx, releaseID)
+	return args.Get(0).([]domain.ReleaseTask), args.Error(1)
+}
+
+func (m *MockReleaseTaskRepository) StartReleaseTask(ctx context.Context, id uuid.UUID, jobName string) (*domain.ReleaseTask, error) {
+	args := m.Called(ctx, id, jobName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseTask), args.Error(1)
+}
+
+func (m *MockReleaseTaskRepository) UpdateReleaseTaskLogs(ctx context.Context, id uuid.UUID, logs string) error {
+	args := m.Called(ctx, id, logs)
+	return args.Error(0)
+}
+
+func (m *MockReleaseTaskRepository) FinishReleaseTask(ctx context.Context, id uuid.UUID, status domain.ReleaseTaskStatus, exitCode *int32, failureReason, logs string) (*domain.ReleaseTask, error) {
+	args := m.Called(ctx, id, status, exitCode, failureReason, logs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseTask), args.Error(1)
+}
+
+func TestReleaseTaskService_RunCommand(t *testing.T) {
+	taskRepo := &MockReleaseTaskRepository{}
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	envRepo := &MockEnvironmentRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewReleaseTaskService(taskRepo, appRepo, releaseRepo, envRepo, orgRepo, jobRepo)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	production := domain.Environment{ID: uuid.New(), AppID: appID, Name: "production"}
+	release := &domain.Release{ID: uuid.New(), AppID: appID, EnvironmentID: &production.ID, Status: domain.ReleaseStatusSucceeded}
+	taskID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID, Name: "api"}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	envRepo.On("GetEnvironmentByName", ctx, appID, "production").Return(&production, nil)
+	releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, appID, &production.ID, domain.ReleaseStatusSucceeded).Return(release, nil)
+	taskRepo.On("CreateReleaseTask", ctx, mock.MatchedBy(func(task *domain.ReleaseTask) bool {
+		return task.ReleaseID == release.ID &&
+			task.Kind == domain.ReleaseTaskRun &&
+			task.Status == domain.ReleaseTaskStatusPending &&
+			task.CreatedBy == userID &&
+			assert.ObjectsAreEqual([]string{"rails", "db:seed"}, task.Command)
+	})).Return(&domain.ReleaseTask{ID: taskID, AppID: appID, ReleaseID: release.ID, Kind: domain.ReleaseTaskRun, Status: domain.ReleaseTaskStatusPending}, nil)
+	jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+		return job.Type == domain.JobTypeReleaseTask &&
+			job.OrgID == orgID &&
+			job.Payload.ReleaseID != nil && *job.Payload.ReleaseID == release.ID &&
+			job.Payload.Config["task_id"] == taskID.String() &&
+			job.Payload.Config["timeout_seconds"] == int32(domain.DefaultReleaseTaskTimeoutSeconds)
+	})).Return(&domain.Job{ID: uuid.New()}, nil)
+
+	task, err := service.RunCommand(ctx, userID, appID, &domain.RunCommandRequest{
+		Command:     []string{"rails", "db:seed"},
+		Environment: "production",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, taskID, task.ID)
+	taskRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestReleaseTaskService_RunCommand_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		release     *domain.Release
+		expectError string
+	}{
+		{
+			name:        "member",
+			role:        domain.RoleMember,
+			expectError: "insufficient permissions to run commands",
+		},
+		{
+			name:        "no succeeded release",
+			role:        domain.RoleOwner,
+			expectError: "application has no succeeded release to run the command with",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskRepo := &MockReleaseTaskRepository{}
+			appRepo := &MockApplicationRepository{}
+			releaseRepo := &MockReleaseRepository{}
+			envRepo := &MockEnvironmentRepository{}
+			orgRepo := &MockOrganizationRepository{}
+
+			service := NewReleaseTaskService(taskRepo, appRepo, releaseRepo, envRepo, orgRepo, nil)
+
+			ctx := context.Background()
+			userID := uuid.New()
+			orgID := uuid.New()
+			appID := uuid.New()
+
+			appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+			orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(tt.role, nil)
+			envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{}, nil)
+			releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, appID, (*uuid.UUID)(nil), domain.ReleaseStatusSucceeded).Return(tt.release, nil)
+
+			_, err := service.RunCommand(ctx, userID, appID, &domain.RunCommandRequest{Command: []string{"rake", "db:migrate"}})
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+			taskRepo.AssertNotCalled(t, "CreateReleaseTask", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReleaseTaskService_RunCommand_QueueFailure(t *testing.T) {
+	taskRepo := &MockReleaseTaskRepository{}
+	appRepo := &MockApplicationRepository{}
+	releaseRepo := &MockReleaseRepository{}
+	envRepo := &MockEnvironmentRepository{}
+	orgRepo := &MockOrganizationRepository{}
+	jobRepo := &MockJobRepository{}
+
+	service := NewReleaseTaskService(taskRepo, appRepo, releaseRepo, envRepo, orgRepo, jobRepo)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	release := &domain.Release{ID: uuid.New(), AppID: appID, Status: domain.ReleaseStatusSucceeded}
+	task := &domain.ReleaseTask{ID: uuid.New(), AppID: appID, ReleaseID: release.ID, Status: domain.ReleaseTaskStatusPending}
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleOwner, nil)
+	envRepo.On("GetEnvironmentsByAppID", ctx, appID).Return([]domain.Environment{}, nil)
+	releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, appID, (*uuid.UUID)(nil), domain.ReleaseStatusSucceeded).Return(release, nil)
+	taskRepo.On("CreateReleaseTask", ctx, mock.Anything).Return(task, nil)
+	jobRepo.On("CreateJob", ctx, mock.Anything).Return((*domain.Job)(nil), errors.New("connection refused"))
+	taskRepo.On("FinishReleaseTask", ctx, task.ID, domain.ReleaseTaskStatusFailed, (*int32)(nil), "failed to queue command", "").Return(task, nil)
+
+	_, err := service.RunCommand(ctx, userID, appID, &domain.RunCommandRequest{Command: []string{"rake", "db:migrate"}, TimeoutSeconds: 60})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to queue command")
+	taskRepo.AssertExpectations(t)
+}
+
+func TestReleaseTaskService_GetReleaseTask_OtherApplication(t *testing.T) {
+	taskRepo := &MockReleaseTaskRepository{}
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewReleaseTaskService(taskRepo, appRepo, nil, nil, orgRepo, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+	taskID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleAdmin, nil)
+	taskRepo.On("GetReleaseTaskByID", ctx, taskID).Return(&domain.ReleaseTask{ID: taskID, AppID: uuid.New()}, nil)
+
+	_, err := service.GetReleaseTask(ctx, userID, appID, taskID)
+
+	assert.EqualError(t, err, "task not found")
+}
+
+func TestReleaseTaskService_TaskOutput_Member(t *testing.T) {
+	taskRepo := &MockReleaseTaskRepository{}
+	appRepo := &MockApplicationRepository{}
+	orgRepo := &MockOrganizationRepository{}
+
+	service := NewReleaseTaskService(taskRepo, appRepo, nil, nil, orgRepo, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	appID := uuid.New()
+
+	appRepo.On("GetApplicationByID", ctx, appID).Return(&domain.Application{ID: appID, OrgID: orgID}, nil)
+	orgRepo.On("GetUserRoleInOrganization", ctx, userID, orgID).Return(domain.RoleMember, nil)
+
+	_, err := service.GetReleaseTask(ctx, userID, appID, uuid.New())
+	assert.EqualError(t, err, "insufficient permissions to read task output")
+
+	_, err = service.GetReleaseTasks(ctx, userID, appID, uuid.New())
+	assert.EqualError(t, err, "insufficient permissions to read task output")
+
+	taskRepo.AssertNotCalled(t, "GetReleaseTaskByID", mock.Anything, mock.Anything)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/webhook"
 	"github.com/PouryDev/oneclick/internal/domain"
 	"github.com/PouryDev/oneclick/internal/repo"
 )
@@ -19,20 +20,39 @@ type RepositoryService interface {
 	GetRepositoriesByOrg(ctx context.Context, userID, orgID uuid.UUID) ([]domain.RepositorySummary, error)
 	GetRepository(ctx context.Context, userID, repoID uuid.UUID) (*domain.RepositoryResponse, error)
 	DeleteRepository(ctx context.Context, userID, repoID uuid.UUID) error
-	ProcessWebhook(ctx context.Context, provider string, payload []byte, signature string) error
+	RotateWebhookSecret(ctx context.Context, userID, repoID uuid.UUID) (*domain.RepositoryResponse, error)
+	ProcessWebhook(ctx context.Context, req *domain.WebhookRequest) error
+	GetWebhookDeliveries(ctx context.Context, userID, repoID uuid.UUID, limit, offset int) ([]domain.WebhookDeliverySummary, error)
+	GetWebhookDelivery(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error)
+	RedeliverWebhook(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error)
 }
 
 type repositoryService struct {
-	repoRepo repo.RepositoryRepository
-	orgRepo  repo.OrganizationRepository
-	crypto   *crypto.Crypto
+	repoRepo        repo.RepositoryRepository
+	appRepo         repo.ApplicationRepository
+	orgRepo         repo.OrganizationRepository
+	deliveryRepo    repo.WebhookDeliveryRepository
+	pipelineService PipelineService
+	crypto          *crypto.Crypto
+	verifier        *webhook.WebhookVerifier
 }
 
-func NewRepositoryService(repoRepo repo.RepositoryRepository, orgRepo repo.OrganizationRepository, crypto *crypto.Crypto) RepositoryService {
+func NewRepositoryService(
+	repoRepo repo.RepositoryRepository,
+	appRepo repo.ApplicationRepository,
+	orgRepo repo.OrganizationRepository,
+	deliveryRepo repo.WebhookDeliveryRepository,
+	pipelineService PipelineService,
+	crypto *crypto.Crypto,
+) RepositoryService {
 	return &repositoryService{
-		repoRepo: repoRepo,
-		orgRepo:  orgRepo,
-		crypto:   crypto,
+		repoRepo:        repoRepo,
+		appRepo:         appRepo,
+		orgRepo:         orgRepo,
+		deliveryRepo:    deliveryRepo,
+		pipelineService: pipelineService,
+		crypto:          crypto,
+		verifier:        webhook.NewWebhookVerifier(),
 	}
 }
 
@@ -71,6 +91,17 @@ func (s *repositoryService) CreateRepository(ctx context.Context, userID, orgID
 		config.Token = encryptedToken
 	}
 
+	// Generate the webhook secret used to verify deliveries for this repository
+	webhookSecret, err := crypto.GenerateSecret(32)
+	if err != nil {
+		return nil, err
+	}
+	encryptedSecret, err := s.crypto.EncryptString(webhookSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	config.Secret = encryptedSecret
+
 	// Convert config to JSON
 	configBytes, err := json.Marshal(config)
 	if err != nil {
@@ -91,6 +122,7 @@ func (s *repositoryService) CreateRepository(ctx context.Context, userID, orgID
 	}
 
 	response := createdRepo.ToResponse()
+	response.WebhookSecret = webhookSecret
 	return &response, nil
 }
 
@@ -167,52 +199,522 @@ func (s *repositoryService) DeleteRepository(ctx context.Context, userID, repoID
 	return nil
 }
 
-func (s *repositoryService) ProcessWebhook(ctx context.Context, provider string, payload []byte, signature string) error {
-	// Parse the webhook payload to extract repository information
-	var repoURL string
-	var branch string
+func (s *repositoryService) RotateWebhookSecret(ctx context.Context, userID, repoID uuid.UUID) (*domain.RepositoryResponse, error) {
+	// Get repository
+	repository, err := s.repoRepo.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repository == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, repository.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	// Only allow owners and admins to rotate webhook secrets
+	if role != domain.RoleOwner && role != domain.RoleAdmin {
+		return nil, errors.New("insufficient permissions to rotate webhook secret")
+	}
+
+	config, err := repository.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository config: %w", err)
+	}
+
+	webhookSecret, err := crypto.GenerateSecret(32)
+	if err != nil {
+		return nil, err
+	}
+	encryptedSecret, err := s.crypto.EncryptString(webhookSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	config.Secret = encryptedSecret
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	updatedRepo, err := s.repoRepo.UpdateRepositoryConfig(ctx, repoID, configBytes)
+	if err != nil {
+		return nil, err
+	}
+	if updatedRepo == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	response := updatedRepo.ToResponse()
+	response.WebhookSecret = webhookSecret
+	return &response, nil
+}
+
+func (s *repositoryService) ProcessWebhook(ctx context.Context, req *domain.WebhookRequest) error {
+	_, err := s.processWebhook(ctx, req, nil, nil)
+	return err
+}
+
+func (s *repositoryService) GetWebhookDeliveries(ctx context.Context, userID, repoID uuid.UUID, limit, offset int) ([]domain.WebhookDeliverySummary, error) {
+	if _, err := s.getAccessibleRepository(ctx, userID, repoID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepo.GetWebhookDeliveriesByRepoID(ctx, repoID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *repositoryService) GetWebhookDelivery(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error) {
+	if _, err := s.getAccessibleRepository(ctx, userID, repoID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.getRepositoryWebhookDelivery(ctx, repoID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := delivery.ToResponse()
+	return &response, nil
+}
+
+func (s *repositoryService) RedeliverWebhook(ctx context.Context, userID, repoID, deliveryID uuid.UUID) (*domain.WebhookDeliveryResponse, error) {
+	repository, err := s.repoRepo.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repository == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	// Check if user has access to the organization
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, repository.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	// Only allow owners and admins to redeliver webhooks, which trigger pipelines and deploys
+	if role != domain.RoleOwner && role != domain.RoleAdmin {
+		return nil, errors.New("insufficient permissions to redeliver webhook")
+	}
+
+	delivery, err := s.getRepositoryWebhookDelivery(ctx, repoID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stored payload is trusted only if it passed verification when it arrived
+	if !delivery.Verified {
+		return nil, errors.New("cannot redeliver an unverified webhook delivery")
+	}
+
+	deliveries, err := s.processWebhook(ctx, delivery.ToRequest(), repository, &delivery.ID)
+	if len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Pipeline failures are reported through the status of the new delivery
+	response := deliveries[0].ToResponse()
+	return &response, nil
+}
+
+// processWebhook handles a delivery and records its outcome for every matched repository.
+// When target is set, a previously verified delivery is replayed against that repository only.
+func (s *repositoryService) processWebhook(ctx context.Context, req *domain.WebhookRequest, target *domain.Repository, redeliveryOf *uuid.UUID) ([]domain.WebhookDelivery, error) {
+	base := domain.WebhookDelivery{
+		Provider:     req.Provider,
+		Event:        req.Event,
+		DeliveryID:   req.DeliveryID,
+		Headers:      domain.MaskWebhookHeaders(req.Headers),
+		Payload:      req.Payload,
+		RedeliveryOf: redeliveryOf,
+	}
+	if target != nil {
+		base.RepoID = &target.ID
+	}
+
+	event, err := parseWebhookEvent(req.Provider, req.Event, req.Payload)
+	if err != nil {
+		base.Status = domain.WebhookDeliveryStatusRejected
+		base.Message = err.Error()
+		return s.recordWebhookDeliveries(ctx, err, base)
+	}
+
+	var repositories []domain.Repository
+	if target != nil {
+		repositories = []domain.Repository{*target}
+	} else {
+		repositories, err = s.findWebhookRepositories(ctx, event.repoURLs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(repositories) == 0 {
+		base.Status = domain.WebhookDeliveryStatusRejected
+		base.Message = "no repository matches the delivery URLs"
+		return s.recordWebhookDeliveries(ctx, errors.New("repository not found"), base)
+	}
+
+	// Several organizations may connect the same repository; each one verifies the
+	// delivery against its own secret and gets its own delivery record
+	var deliveries []domain.WebhookDelivery
+	var processErr error
+	verified := 0
+	for i := range repositories {
+		repository := &repositories[i]
+		delivery := base
+		delivery.RepoID = &repository.ID
+
+		if target == nil {
+			if err := s.verifyWebhookSignature(req.Provider, req.Payload, req.Signature, repository); err != nil {
+				delivery.Status = domain.WebhookDeliveryStatusRejected
+				delivery.Message = err.Error()
+				deliveries = append(deliveries, delivery)
+				continue
+			}
+		}
+		delivery.Verified = true
+		verified++
+
+		if err := s.dispatchWebhookEvent(ctx, strings.ToLower(req.Provider), repository, event, &delivery); err != nil && processErr == nil {
+			processErr = err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if verified == 0 {
+		processErr = errors.New("invalid webhook signature")
+		if req.Signature == "" {
+			processErr = errors.New("missing webhook signature")
+		}
+	}
+
+	return s.recordWebhookDeliveries(ctx, processErr, deliveries...)
+}
+
+// recordWebhookDeliveries persists delivery records and passes through the processing error
+func (s *repositoryService) recordWebhookDeliveries(ctx context.Context, processErr error, deliveries ...domain.WebhookDelivery) ([]domain.WebhookDelivery, error) {
+	recorded := make([]domain.WebhookDelivery, 0, len(deliveries))
+	for i := range deliveries {
+		// Rejected deliveries are unauthenticated, so only what identifies them is kept
+		if deliveries[i].Status == domain.WebhookDeliveryStatusRejected {
+			deliveries[i].Headers = map[string]string{}
+			deliveries[i].Payload = []byte{}
+		}
+		created, err := s.deliveryRepo.CreateWebhookDelivery(ctx, &deliveries[i])
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record webhook delivery: %w", err)
+		}
+		recorded = append(recorded, *created)
+	}
+	return recorded, processErr
+}
+
+// webhookEvent holds the fields of a provider payload needed to route and trigger pipelines
+type webhookEvent struct {
+	kind         domain.PipelineEvent // Empty for events that never trigger pipelines
+	name         string               // Event type header as sent by the provider
+	repoURLs     []string
+	ref          string // Pushed ref, or the provider's head ref of a pull request
+	commitSHA    string
+	action       string // Pull request action
+	targetBranch string // Pull request base branch
+	prNumber     int
+}
+
+// pullRequestActions lists the pull/merge request actions that introduce new commits, per provider
+var pullRequestActions = map[string][]string{
+	"github": {"opened", "reopened", "synchronize"},
+	"gitlab": {"open", "reopen", "update"},
+	"gitea":  {"opened", "reopened", "synchronized"},
+}
+
+// parseWebhookEvent extracts repository URLs and event details from a provider payload.
+// Deliveries without an event type header are treated as pushes.
+func parseWebhookEvent(provider, eventName string, payload []byte) (*webhookEvent, error) {
+	event := webhookEvent{name: eventName}
 
 	switch strings.ToLower(provider) {
 	case "github":
-		var githubPayload domain.WebhookPayload
-		if err := json.Unmarshal(payload, &githubPayload); err != nil {
-			return fmt.Errorf("failed to parse GitHub webhook payload: %w", err)
+		switch eventName {
+		case "pull_request":
+			var prPayload domain.GitHubPullRequestPayload
+			if err := json.Unmarshal(payload, &prPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse GitHub pull request payload: %w", err)
+			}
+			event.kind = domain.PipelineEventPullRequest
+			event.repoURLs = []string{prPayload.Repository.CloneURL, prPayload.Repository.SSHURL}
+			event.prNumber = prPayload.Number
+			event.ref = fmt.Sprintf("refs/pull/%d/head", prPayload.Number)
+			event.commitSHA = prPayload.PullRequest.Head.SHA
+			event.action = prPayload.Action
+			event.targetBranch = prPayload.PullRequest.Base.Ref
+		default:
+			var githubPayload domain.WebhookPayload
+			if err := json.Unmarshal(payload, &githubPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse GitHub webhook payload: %w", err)
+			}
+			event.repoURLs = []string{githubPayload.Repository.CloneURL, githubPayload.Repository.SSHURL}
+			if eventName == "" || eventName == "push" {
+				event.kind = domain.PipelineEventPush
+				event.ref = githubPayload.Ref
+				event.commitSHA = githubPayload.After
+				if event.commitSHA == "" {
+					event.commitSHA = githubPayload.HeadCommit.ID
+				}
+			}
 		}
-		repoURL = githubPayload.Repository.CloneURL
-		branch = strings.TrimPrefix(githubPayload.Ref, "refs/heads/")
 	case "gitlab":
-		var gitlabPayload domain.GitLabWebhookPayload
-		if err := json.Unmarshal(payload, &gitlabPayload); err != nil {
-			return fmt.Errorf("failed to parse GitLab webhook payload: %w", err)
+		switch eventName {
+		case "Merge Request Hook":
+			var mrPayload domain.GitLabMergeRequestPayload
+			if err := json.Unmarshal(payload, &mrPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse GitLab merge request payload: %w", err)
+			}
+			event.kind = domain.PipelineEventPullRequest
+			event.repoURLs = []string{mrPayload.Project.GitHTTPURL, mrPayload.Project.GitSSHURL}
+			event.prNumber = mrPayload.ObjectAttributes.IID
+			event.ref = fmt.Sprintf("refs/merge-requests/%d/head", mrPayload.ObjectAttributes.IID)
+			event.commitSHA = mrPayload.ObjectAttributes.LastCommit.ID
+			event.action = mrPayload.ObjectAttributes.Action
+			event.targetBranch = mrPayload.ObjectAttributes.TargetBranch
+		default:
+			var gitlabPayload domain.GitLabWebhookPayload
+			if err := json.Unmarshal(payload, &gitlabPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse GitLab webhook payload: %w", err)
+			}
+			event.repoURLs = []string{
+				gitlabPayload.Project.GitHTTPURL,
+				gitlabPayload.Project.GitSSHURL,
+				gitlabPayload.Repository.GitHTTPURL,
+				gitlabPayload.Repository.URL,
+			}
+			if eventName == "" || eventName == "Push Hook" || eventName == "Tag Push Hook" {
+				event.kind = domain.PipelineEventPush
+				event.ref = gitlabPayload.Ref
+				event.commitSHA = gitlabPayload.CheckoutSHA
+				if event.commitSHA == "" {
+					event.commitSHA = gitlabPayload.After
+				}
+			}
 		}
-		repoURL = gitlabPayload.Repository.URL
-		branch = strings.TrimPrefix(gitlabPayload.Ref, "refs/heads/")
 	case "gitea":
-		var giteaPayload domain.GiteaWebhookPayload
-		if err := json.Unmarshal(payload, &giteaPayload); err != nil {
-			return fmt.Errorf("failed to parse Gitea webhook payload: %w", err)
+		switch eventName {
+		case "pull_request":
+			var prPayload domain.GiteaPullRequestPayload
+			if err := json.Unmarshal(payload, &prPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse Gitea pull request payload: %w", err)
+			}
+			event.kind = domain.PipelineEventPullRequest
+			event.repoURLs = []string{prPayload.Repository.CloneURL, prPayload.Repository.SSHURL}
+			event.prNumber = prPayload.Number
+			event.ref = fmt.Sprintf("refs/pull/%d/head", prPayload.Number)
+			event.commitSHA = prPayload.PullRequest.Head.SHA
+			event.action = prPayload.Action
+			event.targetBranch = prPayload.PullRequest.Base.Ref
+		default:
+			var giteaPayload domain.GiteaWebhookPayload
+			if err := json.Unmarshal(payload, &giteaPayload); err != nil {
+				return nil, fmt.Errorf("failed to parse Gitea webhook payload: %w", err)
+			}
+			event.repoURLs = []string{giteaPayload.Repository.CloneURL, giteaPayload.Repository.SSHURL}
+			if eventName == "" || eventName == "push" {
+				event.kind = domain.PipelineEventPush
+				event.ref = giteaPayload.Ref
+				event.commitSHA = giteaPayload.After
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	// Tag pushes arrive as push events
+	if event.kind == domain.PipelineEventPush && strings.HasPrefix(event.ref, "refs/tags/") {
+		event.kind = domain.PipelineEventTag
+	}
+
+	return &event, nil
+}
+
+// dispatchWebhookEvent triggers pipelines for a verified delivery and records the outcome on it
+func (s *repositoryService) dispatchWebhookEvent(ctx context.Context, provider string, repository *domain.Repository, event *webhookEvent, delivery *domain.WebhookDelivery) error {
+	delivery.Status = domain.WebhookDeliveryStatusIgnored
+
+	// Select which of the repository's applications the event applies to:
+	// branch pushes and pull requests match the application's default branch,
+	// tags release every application built from the repository
+	var matches func(app *domain.Application) bool
+	switch event.kind {
+	case domain.PipelineEventPush:
+		if !strings.HasPrefix(event.ref, "refs/heads/") {
+			delivery.Message = fmt.Sprintf("ref %q is not a branch or tag", event.ref)
+			return nil
+		}
+		branch := strings.TrimPrefix(event.ref, "refs/heads/")
+		if isDeletedCommit(event.commitSHA) {
+			delivery.Message = fmt.Sprintf("branch %s was deleted", branch)
+			return nil
+		}
+		matches = func(app *domain.Application) bool { return app.DefaultBranch == branch }
+		delivery.Message = fmt.Sprintf("no application tracks branch %s", branch)
+	case domain.PipelineEventTag:
+		if isDeletedCommit(event.commitSHA) {
+			delivery.Message = fmt.Sprintf("tag %s was deleted", strings.TrimPrefix(event.ref, "refs/tags/"))
+			return nil
 		}
-		repoURL = giteaPayload.Repository.CloneURL
-		branch = strings.TrimPrefix(giteaPayload.Ref, "refs/heads/")
+		matches = func(app *domain.Application) bool { return true }
+		delivery.Message = "no application uses this repository"
+	case domain.PipelineEventPullRequest:
+		if !containsString(pullRequestActions[provider], event.action) || event.commitSHA == "" {
+			delivery.Message = fmt.Sprintf("pull request action %q does not trigger pipelines", event.action)
+			return nil
+		}
+		matches = func(app *domain.Application) bool { return app.DefaultBranch == event.targetBranch }
+		delivery.Message = fmt.Sprintf("no application tracks branch %s", event.targetBranch)
 	default:
-		return fmt.Errorf("unsupported provider: %s", provider)
+		delivery.Message = fmt.Sprintf("event %q is not handled", event.name)
+		return nil
 	}
 
-	// Find the repository by URL
-	// Note: This is a simplified approach. In a real implementation, you might need
-	// to search across all organizations or maintain a mapping
-	// For now, we'll assume we can find it by searching through organizations
+	apps, err := s.appRepo.GetApplicationsByRepoID(ctx, repository.ID)
+	if err != nil {
+		delivery.Status = domain.WebhookDeliveryStatusFailed
+		delivery.Message = "failed to get applications for repository"
+		return fmt.Errorf("failed to get applications for repository: %w", err)
+	}
 
-	// TODO: Implement repository lookup by URL
-	// This would require either:
-	// 1. Adding a URL index to the repositories table
-	// 2. Searching through organizations
-	// 3. Maintaining a separate mapping table
+	for i := range apps {
+		app := &apps[i]
+		if !matches(app) {
+			continue
+		}
 
-	fmt.Printf("Webhook received for %s repository: %s, branch: %s\n", provider, repoURL, branch)
+		pipeline, err := s.pipelineService.TriggerWebhookPipeline(ctx, app, domain.CreatePipelineRequest{
+			Ref:       event.ref,
+			CommitSHA: event.commitSHA,
+			Event:     event.kind,
+			PRNumber:  event.prNumber,
+		})
+		if err != nil {
+			delivery.Status = domain.WebhookDeliveryStatusFailed
+			delivery.Message = fmt.Sprintf("failed to trigger pipeline for application %s: %v", app.Name, err)
+			return fmt.Errorf("failed to trigger pipeline for application %s: %w", app.Name, err)
+		}
+		delivery.PipelineIDs = append(delivery.PipelineIDs, pipeline.ID)
+	}
 
-	// TODO: Create pipeline record when applications are implemented
-	// For now, just log the webhook event
+	if len(delivery.PipelineIDs) == 0 {
+		return nil
+	}
 
+	delivery.Status = domain.WebhookDeliveryStatusProcessed
+	delivery.Message = ""
 	return nil
 }
+
+// isDeletedCommit reports whether a pushed SHA denotes a deleted ref; providers send all zeros
+func isDeletedCommit(commitSHA string) bool {
+	return commitSHA == "" || strings.Trim(commitSHA, "0") == ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// findWebhookRepositories resolves the repositories registered for any of the URLs a provider reports
+func (s *repositoryService) findWebhookRepositories(ctx context.Context, repoURLs []string) ([]domain.Repository, error) {
+	seen := make(map[string]bool)
+
+	for _, repoURL := range repoURLs {
+		normalizedURL := domain.NormalizeRepositoryURL(repoURL)
+		if normalizedURL == "" || seen[normalizedURL] {
+			continue
+		}
+		seen[normalizedURL] = true
+
+		repos, err := s.repoRepo.GetRepositoriesByNormalizedURL(ctx, normalizedURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up repository: %w", err)
+		}
+		if len(repos) > 0 {
+			// HTTPS and SSH URLs normalize identically, so the first match is complete
+			return repos, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// verifyWebhookSignature checks the delivery signature against the repository's webhook secret
+func (s *repositoryService) verifyWebhookSignature(provider string, payload []byte, signature string, repository *domain.Repository) error {
+	if signature == "" {
+		return errors.New("missing webhook signature")
+	}
+
+	config, err := repository.GetConfig()
+	if err != nil || config.Secret == "" {
+		return errors.New("repository has no webhook secret")
+	}
+
+	secret, err := s.crypto.DecryptString(config.Secret)
+	if err != nil {
+		return errors.New("failed to decrypt webhook secret")
+	}
+
+	if err := s.verifier.VerifySignature(provider, payload, signature, secret); err != nil {
+		return errors.New("invalid webhook signature")
+	}
+
+	return nil
+}
+
+// getAccessibleRepository loads a repository the user is a member of
+func (s *repositoryService) getAccessibleRepository(ctx context.Context, userID, repoID uuid.UUID) (*domain.Repository, error) {
+	repository, err := s.repoRepo.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repository == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	role, err := s.orgRepo.GetUserRoleInOrganization(ctx, userID, repository.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user does not have access to this organization")
+	}
+
+	return repository, nil
+}
+
+// getRepositoryWebhookDelivery loads a delivery recorded for the given repository
+func (s *repositoryService) getRepositoryWebhookDelivery(ctx context.Context, repoID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepo.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.RepoID == nil || *delivery.RepoID != repoID {
+		return nil, errors.New("webhook delivery not found")
+	}
+	return delivery, nil
+}
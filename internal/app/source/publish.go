@@ -0,0 +1,170 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// publishAttempts is how often a publication is tried when its push is rejected because another
+// commit was pushed to the branch in the meantime
+const publishAttempts = 3
+
+// Publisher commits manifests to repositories with the git CLI
+type Publisher struct {
+	git string
+}
+
+// NewPublisher creates a new publisher that runs the git CLI found on the PATH
+func NewPublisher() *Publisher {
+	return &Publisher{git: "git"}
+}
+
+// Publication is a set of files committed to a directory of a repository
+type Publication struct {
+	Repository Repository
+	Branch     string
+	Path       string // Directory whose contents the files replace
+	Files      []domain.ManifestFile
+	Message    string
+}
+
+// Publish commits the files to the publication's directory, replacing what it held before, and
+// pushes the commit to the branch, which is created if it does not exist. It returns the commit,
+// which is the head of the branch when the directory already held the same files. A push that is
+// rejected as not fast-forward is retried on the branch's new head.
+func (p *Publisher) Publish(ctx context.Context, pub *Publication) (string, error) {
+	path, err := localPath(pub.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+	if path == "." {
+		return "", errors.New("invalid path: the files cannot replace the root of the repository")
+	}
+	if pub.Branch == "" || strings.HasPrefix(pub.Branch, "-") {
+		return "", fmt.Errorf("invalid branch %q", pub.Branch)
+	}
+	if len(pub.Files) == 0 {
+		return "", errors.New("no files to publish")
+	}
+	for _, file := range pub.Files {
+		if file.Name != filepath.Base(file.Name) || !filepath.IsLocal(file.Name) {
+			return "", fmt.Errorf("invalid file name %q", file.Name)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		commit, err := p.publish(ctx, path, pub)
+		var rejected *pushRejected
+		if errors.As(err, &rejected) && attempt < publishAttempts {
+			continue
+		}
+		return commit, err
+	}
+}
+
+// pushRejected is a push of a publication that the repository rejected because the branch moved
+type pushRejected struct {
+	err error
+}
+
+func (e *pushRejected) Error() string {
+	return fmt.Sprintf("failed to push: %v", e.err)
+}
+
+// publish checks the head of the publication's branch out into a temporary directory, which is
+// removed afterwards, and commits and pushes the files on top of it
+func (p *Publisher) publish(ctx context.Context, path string, pub *Publication) (string, error) {
+	dir, err := os.MkdirTemp("", "oneclick-publish-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	env := append(gitEnv(pub.Repository),
+		"GIT_AUTHOR_NAME=OneClick",
+		"GIT_AUTHOR_EMAIL=oneclick@localhost",
+		"GIT_COMMITTER_NAME=OneClick",
+		"GIT_COMMITTER_EMAIL=oneclick@localhost",
+	)
+	ref := "refs/heads/" + pub.Branch
+
+	if _, err := run(ctx, dir, env, p.git, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	heads, err := run(ctx, dir, env, p.git, "ls-remote", "--heads", "--", pub.Repository.URL, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to list branches: %w", err)
+	}
+	if len(strings.TrimSpace(string(heads))) > 0 {
+		if _, err := run(ctx, dir, env, p.git, "fetch", "--quiet", "--depth", "1", "--", pub.Repository.URL, ref); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", pub.Branch, err)
+		}
+		if _, err := run(ctx, dir, env, p.git, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+		// The files are written through the checkout's directories, which must not lead out of it
+		if err := checkSymlinks(dir); err != nil {
+			return "", err
+		}
+	}
+
+	target := filepath.Join(dir, path)
+	if err := os.RemoveAll(target); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return "", err
+	}
+	for _, file := range pub.Files {
+		if err := os.WriteFile(filepath.Join(target, file.Name), file.Content, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := run(ctx, dir, env, p.git, "add", "--all", "--", path); err != nil {
+		return "", err
+	}
+	changes, err := run(ctx, dir, env, p.git, "status", "--porcelain", "--", path)
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(changes))) == 0 {
+		head, err := run(ctx, dir, env, p.git, "rev-parse", "HEAD")
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(head)), nil
+	}
+
+	if _, err := run(ctx, dir, env, p.git, "commit", "--quiet", "--message", pub.Message); err != nil {
+		return "", err
+	}
+	if _, err := run(ctx, dir, env, p.git, "push", "--quiet", "--", pub.Repository.URL, "HEAD:"+ref); err != nil {
+		if isNonFastForward(err) {
+			return "", &pushRejected{err: err}
+		}
+		return "", fmt.Errorf("failed to push: %w", err)
+	}
+	head, err := run(ctx, dir, env, p.git, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(head)), nil
+}
+
+// isNonFastForward reports whether a failed push was rejected because the remote branch has
+// commits the pushed one is not based on, as opposed to hooks, permissions or the network
+func isNonFastForward(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "(non-fast-forward)") || strings.Contains(message, "(fetch first)")
+}
@@ -0,0 +1,145 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// newBareRepository creates an empty bare repository to publish to and returns its URL and a
+// function that runs git in it
+func newBareRepository(t *testing.T) (string, func(args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet", "--bare")
+	return "file://" + dir, git
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	url, git := newBareRepository(t)
+	publisher := NewPublisher()
+	ctx := context.Background()
+
+	pub := &Publication{
+		Repository: Repository{URL: url},
+		Branch:     "deploy",
+		Path:       "/apps/api/production",
+		Files: []domain.ManifestFile{
+			{Name: "configmap-api-config.yaml", Content: []byte("kind: ConfigMap\n")},
+			{Name: "deployment-api.yaml", Content: []byte("kind: Deployment\n")},
+		},
+		Message: "Deploy api v1",
+	}
+
+	first, err := publisher.Publish(ctx, pub)
+	require.NoError(t, err)
+	assert.Equal(t, first, git("rev-parse", "deploy"), "the branch is created")
+	assert.Equal(t, "apps/api/production/configmap-api-config.yaml\napps/api/production/deployment-api.yaml", git("ls-tree", "-r", "--name-only", "deploy"))
+	assert.Equal(t, "Deploy api v1", git("log", "-1", "--format=%s", "deploy"))
+
+	unchanged, err := publisher.Publish(ctx, pub)
+	require.NoError(t, err)
+	assert.Equal(t, first, unchanged, "publishing the same files does not commit")
+
+	pub.Files = []domain.ManifestFile{{Name: "deployment-api.yaml", Content: []byte("kind: Deployment\nspec: {}\n")}}
+	pub.Message = "Deploy api v2"
+	second, err := publisher.Publish(ctx, pub)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, git("rev-parse", "deploy~1"), "the commit is made on top of the branch")
+	assert.Equal(t, "apps/api/production/deployment-api.yaml", git("ls-tree", "-r", "--name-only", "deploy"), "files that are no longer published are removed")
+	assert.Equal(t, "kind: Deployment\nspec: {}", git("show", "deploy:apps/api/production/deployment-api.yaml"))
+}
+
+func TestPublisher_Publish_DeclinedPushIsNotRetried(t *testing.T) {
+	url, _ := newBareRepository(t)
+	dir := strings.TrimPrefix(url, "file://")
+	attempts := filepath.Join(t.TempDir(), "attempts")
+	hook := "#!/bin/sh\necho push >> " + attempts + "\necho declined by policy >&2\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hooks", "pre-receive"), []byte(hook), 0o755))
+
+	_, err := NewPublisher().Publish(context.Background(), &Publication{
+		Repository: Repository{URL: url},
+		Branch:     "deploy",
+		Path:       "apps/api",
+		Files:      []domain.ManifestFile{{Name: "service-api.yaml", Content: []byte("kind: Service\n")}},
+		Message:    "Deploy api v1",
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "declined by policy")
+	var rejected *pushRejected
+	assert.False(t, errors.As(err, &rejected))
+
+	pushes, err := os.ReadFile(attempts)
+	require.NoError(t, err)
+	assert.Equal(t, "push\n", string(pushes), "a push declined by the repository is not retried")
+}
+
+func TestIsNonFastForward(t *testing.T) {
+	assert.True(t, isNonFastForward(errors.New("git push failed: ! [rejected] HEAD -> deploy (fetch first)")))
+	assert.True(t, isNonFastForward(errors.New("git push failed: ! [rejected] HEAD -> deploy (non-fast-forward)")))
+	assert.False(t, isNonFastForward(errors.New("git push failed: ! [remote rejected] HEAD -> deploy (pre-receive hook declined)")))
+	assert.False(t, isNonFastForward(errors.New("git push failed: fatal: could not read from remote repository")))
+}
+
+func TestPublisher_Publish_Rejects(t *testing.T) {
+	publisher := NewPublisher()
+	files := []domain.ManifestFile{{Name: "service-api.yaml", Content: []byte("kind: Service\n")}}
+
+	tests := []struct {
+		name        string
+		pub         *Publication
+		expectError string
+	}{
+		{
+			name:        "root of the repository",
+			pub:         &Publication{Branch: "main", Path: "/", Files: files},
+			expectError: "cannot replace the root of the repository",
+		},
+		{
+			name:        "path outside the repository",
+			pub:         &Publication{Branch: "main", Path: "../deploy", Files: files},
+			expectError: "outside the repository",
+		},
+		{
+			name:        "option as the branch",
+			pub:         &Publication{Branch: "--force", Path: "deploy", Files: files},
+			expectError: "invalid branch",
+		},
+		{
+			name:        "file in a subdirectory",
+			pub:         &Publication{Branch: "main", Path: "deploy", Files: []domain.ManifestFile{{Name: filepath.Join("..", "service.yaml")}}},
+			expectError: "invalid file name",
+		},
+		{
+			name:        "no files",
+			pub:         &Publication{Branch: "main", Path: "deploy"},
+			expectError: "no files to publish",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := publisher.Publish(context.Background(), tt.pub)
+			assert.ErrorContains(t, err, tt.expectError)
+		})
+	}
+}
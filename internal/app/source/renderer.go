@@ -0,0 +1,343 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// Renderer checks deploy sources out of application repositories and renders their objects with
+// the git, kustomize and helm CLIs
+type Renderer struct {
+	git       string
+	kustomize string
+	helm      string
+}
+
+// NewRenderer creates a new renderer that runs the CLIs found on the PATH
+func NewRenderer() *Renderer {
+	return &Renderer{
+		git:       "git",
+		kustomize: "kustomize",
+		helm:      "helm",
+	}
+}
+
+// Repository is the repository a deploy source is checked out of
+type Repository struct {
+	URL   string
+	Type  string // github, gitlab or gitea
+	Token string // Decrypted access token, empty for public repositories
+}
+
+// Request is a deploy source to render for a release
+type Request struct {
+	Repository  Repository
+	Ref         string // Commit the release was built from, or the branch whose head is deployed
+	Source      *deployment.SourceConfig
+	ReleaseName string // Helm release name
+	Namespace   string
+}
+
+// Result is a rendered deploy source
+type Result struct {
+	Commit  string // Commit the source was rendered at
+	Objects []*unstructured.Unstructured
+}
+
+// Render checks the repository out at the requested ref into a temporary directory, which is
+// removed afterwards, and renders the deploy source at its path. Helm hooks are not rendered.
+func (r *Renderer) Render(ctx context.Context, req *Request) (*Result, error) {
+	path, err := localPath(req.Source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if req.Ref == "" || strings.HasPrefix(req.Ref, "-") {
+		return nil, fmt.Errorf("invalid ref %q", req.Ref)
+	}
+
+	dir, err := os.MkdirTemp("", "oneclick-source-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The temporary directory may itself be behind a symlink, which would make every link in the
+	// checkout look like it leads out of it
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	repoDir := filepath.Join(dir, "repo")
+	if err := os.Mkdir(repoDir, 0o700); err != nil {
+		return nil, err
+	}
+	commit, err := r.checkout(ctx, repoDir, req.Repository, req.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSymlinks(repoDir); err != nil {
+		return nil, err
+	}
+
+	sourceDir := filepath.Join(repoDir, path)
+	if info, err := os.Stat(sourceDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory %s not found in the repository at commit %s", req.Source.Path, commit)
+	}
+
+	var objects []*unstructured.Unstructured
+	switch req.Source.Type {
+	case domain.SourceManifests:
+		objects, err = readManifests(sourceDir)
+	case domain.SourceKustomize:
+		var out []byte
+		out, err = run(ctx, "", nil, r.kustomize, "build", sourceDir)
+		if err == nil {
+			objects, err = decodeManifests(out)
+		}
+	case domain.SourceHelm:
+		objects, err = r.renderChart(ctx, dir, sourceDir, req)
+	default:
+		return nil, fmt.Errorf("unknown deploy source %q", req.Source.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("deploy source %s rendered no objects", req.Source.Path)
+	}
+
+	return &Result{Commit: commit, Objects: objects}, nil
+}
+
+// checkout fetches a single commit of a repository into an empty directory and returns it
+func (r *Renderer) checkout(ctx context.Context, dir string, repo Repository, ref string) (string, error) {
+	env := gitEnv(repo)
+	if _, err := run(ctx, dir, env, r.git, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	if _, err := run(ctx, dir, env, r.git, "fetch", "--quiet", "--depth", "1", "--", repo.URL, ref); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	if _, err := run(ctx, dir, env, r.git, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	out, err := run(ctx, dir, env, r.git, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// renderChart renders a Helm chart with the source's values files and values. Dependencies are not
+// downloaded, so that rendering only runs what is in the repository: they must be in charts/
+func (r *Renderer) renderChart(ctx context.Context, dir, chartDir string, req *Request) ([]*unstructured.Unstructured, error) {
+	args := []string{"template", req.ReleaseName, chartDir, "--namespace", req.Namespace, "--no-hooks"}
+	for _, file := range req.Source.ValuesFiles {
+		path, err := localPath(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid values file: %w", err)
+		}
+		args = append(args, "--values", filepath.Join(chartDir, path))
+	}
+
+	if len(req.Source.Values) > 0 {
+		values, err := yaml.Marshal(req.Source.Values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal values: %w", err)
+		}
+		valuesFile := filepath.Join(dir, "values.yaml")
+		if err := os.WriteFile(valuesFile, values, 0o600); err != nil {
+			return nil, err
+		}
+		args = append(args, "--values", valuesFile)
+	}
+
+	out, err := run(ctx, "", nil, r.helm, args...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifests(out)
+}
+
+// run runs a CLI and returns its output. A failure is reported with what the CLI wrote to stderr.
+func run(ctx context.Context, dir string, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s %s failed: %s", name, args[0], message)
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", name, args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// gitEnv returns the environment git runs in. The access token is passed in an HTTP header set
+// through the environment, so that it is neither stored in the checkout nor visible in the
+// process list.
+func gitEnv(repo Repository) []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if repo.Token == "" {
+		return env
+	}
+
+	var credentials string
+	switch repo.Type {
+	case "github":
+		credentials = "x-access-token:" + repo.Token
+	case "gitlab":
+		credentials = "oauth2:" + repo.Token
+	default:
+		credentials = repo.Token + ":x-oauth-basic"
+	}
+	return append(env,
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)),
+	)
+}
+
+// localPath cleans a path in the repository, which may start with a slash, and rejects paths
+// that lead out of it
+func localPath(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(path, "/")))
+	if !filepath.IsLocal(cleaned) {
+		return "", fmt.Errorf("%q is outside the repository", path)
+	}
+	return cleaned, nil
+}
+
+// checkSymlinks rejects symlinks in a checkout that lead out of it, so that rendering a source
+// cannot read files of the host; the kustomize and helm CLIs follow symlinks
+func checkSymlinks(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("symlink %s cannot be resolved: %w", filepath.ToSlash(rel), err)
+		}
+		if targetRel, err := filepath.Rel(dir, target); err != nil || !filepath.IsLocal(targetRel) {
+			return fmt.Errorf("symlink %s leads out of the repository", filepath.ToSlash(rel))
+		}
+		return nil
+	})
+}
+
+// readManifests reads the objects of the YAML and JSON files in a directory and its
+// subdirectories, in lexical order. Hidden directories are skipped, and symlinks are not followed.
+func readManifests(dir string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoded, err := decodeManifests(data)
+		if err != nil {
+			rel, _ := filepath.Rel(dir, path)
+			return fmt.Errorf("failed to parse %s: %w", filepath.ToSlash(rel), err)
+		}
+		objects = append(objects, decoded...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// decodeManifests decodes a stream of YAML documents, or a JSON object, into objects. Empty
+// documents are skipped and lists are expanded into their items.
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	var objects []*unstructured.Unstructured
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := yaml.YAMLToJSON(document)
+		if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(content); len(trimmed) == 0 || string(trimmed) == "null" {
+			continue
+		}
+
+		decoded, err := runtime.Decode(unstructured.UnstructuredJSONScheme, content)
+		if err != nil {
+			return nil, err
+		}
+		switch obj := decoded.(type) {
+		case *unstructured.UnstructuredList:
+			for i := range obj.Items {
+				objects = append(objects, &obj.Items[i])
+			}
+		case *unstructured.Unstructured:
+			objects = append(objects, obj)
+		}
+	}
+
+	for _, obj := range objects {
+		if obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("manifest must set kind and metadata.name")
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,174 @@
+package source
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// newTestRepository creates a git repository with the given files committed and returns its
+// directory and commit
+func newTestRepository(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, name), content)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add manifests")
+	return dir, strings.TrimSpace(git("rev-parse", "HEAD"))
+}
+
+func TestDecodeManifests(t *testing.T) {
+	objects, err := decodeManifests([]byte(`# Source: api/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+    - port: 80
+---
+---
+# Only a comment
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: api-flags
+  - apiVersion: v1
+    kind: ServiceAccount
+    metadata:
+      name: api
+`))
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+	assert.Equal(t, "Service", objects[0].GetKind())
+	assert.Equal(t, "ConfigMap", objects[1].GetKind())
+	assert.Equal(t, "ServiceAccount", objects[2].GetKind())
+
+	ports, _, _ := unstructured.NestedSlice(objects[0].Object, "spec", "ports")
+	assert.Equal(t, int64(80), ports[0].(map[string]interface{})["port"], "numbers are decoded as integers")
+
+	_, err = decodeManifests([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.ErrorContains(t, err, "must set kind and metadata.name")
+}
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "service.yaml"), "apiVersion: v1\nkind: Service\nmetadata:\n  name: api\n")
+	writeFile(t, filepath.Join(dir, "apps", "deployment.yml"), "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n")
+	writeFile(t, filepath.Join(dir, "config.json"), `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "api"}}`)
+	writeFile(t, filepath.Join(dir, "README.md"), "# Manifests\n")
+	writeFile(t, filepath.Join(dir, ".github", "workflow.yaml"), "on: push\n")
+
+	objects, err := readManifests(dir)
+	require.NoError(t, err)
+	kinds := make([]string, len(objects))
+	for i, obj := range objects {
+		kinds[i] = obj.GetKind()
+	}
+	assert.Equal(t, []string{"Deployment", "ConfigMap", "Service"}, kinds, "files are read in lexical order")
+
+	writeFile(t, filepath.Join(dir, "broken.yaml"), "kind: [\n")
+	_, err = readManifests(dir)
+	assert.ErrorContains(t, err, "failed to parse broken.yaml")
+}
+
+func TestLocalPath(t *testing.T) {
+	for path, expected := range map[string]string{"": ".", "/": ".", "deploy/chart": "deploy/chart", "/deploy/./k8s/": "deploy/k8s"} {
+		local, err := localPath(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, filepath.FromSlash(expected), local, path)
+	}
+	for _, path := range []string{"..", "deploy/../../etc"} {
+		_, err := localPath(path)
+		assert.ErrorContains(t, err, "outside the repository", path)
+	}
+}
+
+func TestRenderer_Render_Manifests(t *testing.T) {
+	repoDir, commit := newTestRepository(t, map[string]string{
+		"deploy/k8s/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n",
+		"deploy/k8s/service.yaml":    "apiVersion: v1\nkind: Service\nmetadata:\n  name: api\n",
+		"main.go":                    "package main\n",
+	})
+
+	renderer := NewRenderer()
+	request := &Request{
+		Repository:  Repository{URL: "file://" + repoDir, Type: "gitea"},
+		Ref:         "main",
+		Source:      &deployment.SourceConfig{Type: domain.SourceManifests, Path: "/deploy/k8s"},
+		ReleaseName: "api",
+		Namespace:   "acme-api",
+	}
+
+	result, err := renderer.Render(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, commit, result.Commit, "the branch is resolved to its head commit")
+	require.Len(t, result.Objects, 2)
+	assert.Equal(t, "Deployment", result.Objects[0].GetKind())
+
+	request.Ref = commit
+	result, err = renderer.Render(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, commit, result.Commit)
+
+	request.Source = &deployment.SourceConfig{Type: domain.SourceManifests, Path: "charts/api"}
+	_, err = renderer.Render(context.Background(), request)
+	assert.ErrorContains(t, err, "directory charts/api not found")
+
+	request.Ref = "--upload-pack=touch /tmp/pwned"
+	_, err = renderer.Render(context.Background(), request)
+	assert.ErrorContains(t, err, "invalid ref")
+}
+
+func TestRenderer_Render_RejectsSymlinksOutOfRepository(t *testing.T) {
+	repoDir, _ := newTestRepository(t, map[string]string{
+		"deploy/service.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: api\n",
+	})
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(repoDir, "deploy", "passwd")))
+	for _, args := range [][]string{{"add", "."}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Link passwd"}} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	_, err := NewRenderer().Render(context.Background(), &Request{
+		Repository: Repository{URL: "file://" + repoDir},
+		Ref:        "main",
+		Source:     &deployment.SourceConfig{Type: domain.SourceManifests, Path: "deploy"},
+	})
+	assert.ErrorContains(t, err, "symlink deploy/passwd leads out of the repository")
+}
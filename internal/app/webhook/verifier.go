@@ -59,17 +59,26 @@ func (w *WebhookVerifier) VerifyGitLabSignature(payload []byte, signature, secre
 	return nil
 }
 
+// VerifyGitLabToken verifies the plain secret token GitLab sends in the X-Gitlab-Token header
+func (w *WebhookVerifier) VerifyGitLabToken(token, secret string) error {
+	if token == "" {
+		return fmt.Errorf("missing signature")
+	}
+
+	if !hmac.Equal([]byte(token), []byte(secret)) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	return nil
+}
+
 // VerifyGiteaSignature verifies Gitea webhook signature using HMAC-SHA256
 func (w *WebhookVerifier) VerifyGiteaSignature(payload []byte, signature, secret string) error {
 	if signature == "" {
 		return fmt.Errorf("missing signature")
 	}
 
-	// Gitea sends signature as "sha256=<hash>"
-	if !strings.HasPrefix(signature, "sha256=") {
-		return fmt.Errorf("invalid signature format")
-	}
-
+	// Gitea sends the bare hex digest; accept the "sha256=<hash>" form as well
 	expectedSignature := strings.TrimPrefix(signature, "sha256=")
 	actualSignature := w.computeHMACSHA256(payload, secret)
 
@@ -113,7 +122,11 @@ func (w *WebhookVerifier) VerifySignature(provider string, payload []byte, signa
 		}
 		return nil
 	case "gitlab":
-		return w.VerifyGitLabSignature(payload, signature, secret)
+		// GitLab sends the configured secret token verbatim unless an HMAC signature is present
+		if strings.HasPrefix(signature, "sha256=") {
+			return w.VerifyGitLabSignature(payload, signature, secret)
+		}
+		return w.VerifyGitLabToken(signature, secret)
 	case "gitea":
 		return w.VerifyGiteaSignature(payload, signature, secret)
 	default:
@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,6 +81,24 @@ func TestWebhookVerifier_VerifyGitLabSignature(t *testing.T) {
 	assert.Contains(t, err.Error(), "signature verification failed")
 }
 
+func TestWebhookVerifier_VerifyGitLabToken(t *testing.T) {
+	verifier := NewWebhookVerifier()
+	secret := "test-secret"
+
+	// Test valid token
+	err := verifier.VerifyGitLabToken(secret, secret)
+	assert.NoError(t, err)
+
+	// Test invalid token
+	err = verifier.VerifyGitLabToken("wrong-secret", secret)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "signature verification failed")
+
+	// Test token through provider dispatch
+	err = verifier.VerifySignature("gitlab", []byte("test payload"), secret, secret)
+	assert.NoError(t, err)
+}
+
 func TestWebhookVerifier_VerifyGiteaSignature(t *testing.T) {
 	verifier := NewWebhookVerifier()
 	payload := []byte("test payload")
@@ -94,6 +113,10 @@ func TestWebhookVerifier_VerifyGiteaSignature(t *testing.T) {
 	err := verifier.VerifyGiteaSignature(payload, expectedSignature, secret)
 	assert.NoError(t, err)
 
+	// Test bare hex signature as sent by Gitea
+	err = verifier.VerifyGiteaSignature(payload, strings.TrimPrefix(expectedSignature, "sha256="), secret)
+	assert.NoError(t, err)
+
 	// Test invalid signature
 	err = verifier.VerifyGiteaSignature(payload, "sha256=invalid", secret)
 	assert.Error(t, err)
@@ -2,51 +2,101 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/PouryDev/oneclick/internal/app/crypto"
 	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/rollout"
+	"github.com/PouryDev/oneclick/internal/app/source"
 	"github.com/PouryDev/oneclick/internal/domain"
 	"github.com/PouryDev/oneclick/internal/repo"
 )
 
-// DeploymentWorker handles background deployment jobs
+// DeploymentWorker rolls releases out, runs their tasks, tears applications down and checks drift
 type DeploymentWorker struct {
-	appRepo     repo.ApplicationRepository
-	releaseRepo repo.ReleaseRepository
-	clusterRepo repo.ClusterRepository
-	crypto      *crypto.Crypto
-	logger      *zap.Logger
-	deployer    *deployment.DeploymentGenerator
+	jobRepo            repo.JobRepository
+	appRepo            repo.ApplicationRepository
+	releaseRepo        repo.ReleaseRepository
+	clusterRepo        repo.ClusterRepository
+	domainRepo         repo.DomainRepository
+	envRepo            repo.EnvironmentRepository
+	taskRepo           repo.ReleaseTaskRepository
+	serviceRepo        repo.ServiceRepository
+	driftRepo          repo.ReleaseDriftRepository
+	desired            *rollout.Resolver
+	crypto             *crypto.Crypto
+	progress           *deployment.ProgressBroker
+	logger             *zap.Logger
+	deployer           *deployment.DeploymentGenerator
+	publisher          *source.Publisher
+	stopChan           chan struct{}
+	processingInterval time.Duration
+	pollInterval       time.Duration
+	driftInterval      time.Duration
+	driftCheckTimeout  time.Duration
+	driftRenders       map[uuid.UUID]*driftRender // Deploy sources rendered by drift checks, by release; only used by the reconciler
+	applyMu            sync.Mutex                 // Held while a job runs or drift is healed, so that healing never races a rollout or teardown
+	connect            func(kubeconfig []byte) (kubernetes.Interface, *deployment.Applier, error)
 }
 
 // NewDeploymentWorker creates a new deployment worker
 func NewDeploymentWorker(
+	jobRepo repo.JobRepository,
 	appRepo repo.ApplicationRepository,
 	releaseRepo repo.ReleaseRepository,
 	clusterRepo repo.ClusterRepository,
+	domainRepo repo.DomainRepository,
+	envRepo repo.EnvironmentRepository,
+	taskRepo repo.ReleaseTaskRepository,
+	serviceRepo repo.ServiceRepository,
+	driftRepo repo.ReleaseDriftRepository,
+	desired *rollout.Resolver,
 	crypto *crypto.Crypto,
+	progress *deployment.ProgressBroker,
 	logger *zap.Logger,
 ) *DeploymentWorker {
 	return &DeploymentWorker{
-		appRepo:     appRepo,
-		releaseRepo: releaseRepo,
-		clusterRepo: clusterRepo,
-		crypto:      crypto,
-		logger:      logger,
-		deployer:    deployment.NewDeploymentGenerator(),
+		jobRepo:            jobRepo,
+		appRepo:            appRepo,
+		releaseRepo:        releaseRepo,
+		clusterRepo:        clusterRepo,
+		domainRepo:         domainRepo,
+		envRepo:            envRepo,
+		taskRepo:           taskRepo,
+		serviceRepo:        serviceRepo,
+		driftRepo:          driftRepo,
+		desired:            desired,
+		crypto:             crypto,
+		progress:           progress,
+		logger:             logger,
+		deployer:           deployment.NewDeploymentGenerator(),
+		publisher:          source.NewPublisher(),
+		stopChan:           make(chan struct{}),
+		processingInterval: 5 * time.Second,
+		pollInterval:       5 * time.Second,
+		driftInterval:      5 * time.Minute,
+		driftCheckTimeout:  2 * time.Minute,
+		driftRenders:       make(map[uuid.UUID]*driftRender),
+		connect:            connectCluster,
 	}
 }
 
@@ -59,32 +109,64 @@ type DeploymentJob struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// ProcessDeployment processes a deployment job
-func (w *DeploymentWorker) ProcessDeployment(ctx context.Context, job *DeploymentJob) error {
-	w.logger.Info("Processing deployment job",
-		zap.String("release_id", job.ReleaseID.String()),
-		zap.String("app_id", job.AppID.String()),
-		zap.String("image", job.Image),
-		zap.String("tag", job.Tag),
-	)
+// NewDeploymentJobFromJob converts a queued release job to a DeploymentJob
+func NewDeploymentJobFromJob(job *domain.Job) (*DeploymentJob, error) {
+	if job.Payload.ReleaseID == nil {
+		return nil, fmt.Errorf("release ID is required for release job")
+	}
 
-	// Get application details
-	app, err := w.appRepo.GetApplicationByID(ctx, job.AppID)
+	appIDStr, _ := job.Payload.Config["app_id"].(string)
+	appID, err := uuid.Parse(appIDStr)
 	if err != nil {
-		return fmt.Errorf("failed to get application: %w", err)
+		return nil, fmt.Errorf("invalid application ID in release job: %w", err)
 	}
-	if app == nil {
-		return fmt.Errorf("application not found")
+
+	image, _ := job.Payload.Config["image"].(string)
+	tag, _ := job.Payload.Config["tag"].(string)
+
+	return &DeploymentJob{
+		ReleaseID: *job.Payload.ReleaseID,
+		AppID:     appID,
+		Image:     image,
+		Tag:       tag,
+		CreatedAt: job.CreatedAt,
+	}, nil
+}
+
+// ProcessJob processes a queued release job
+func (w *DeploymentWorker) ProcessJob(ctx context.Context, job *domain.Job) error {
+	if !domain.IsReleaseJobType(job.Type) {
+		return fmt.Errorf("unknown job type: %s", job.Type)
+	}
+	if job.Type == domain.JobTypeApplicationDelete {
+		return w.ProcessTeardown(ctx, job)
 	}
 
-	// Get cluster details
-	cluster, err := w.clusterRepo.GetClusterByID(ctx, app.ClusterID)
+	deploymentJob, err := NewDeploymentJobFromJob(job)
 	if err != nil {
-		return fmt.Errorf("failed to get cluster: %w", err)
+		return err
 	}
-	if cluster == nil {
-		return fmt.Errorf("cluster not found")
+
+	switch job.Type {
+	case domain.JobTypeReleaseTask:
+		return w.ProcessTask(ctx, job)
+	case domain.JobTypeReleasePromote:
+		return w.ProcessPromotion(ctx, deploymentJob)
+	case domain.JobTypeReleaseAbort:
+		return w.ProcessAbort(ctx, deploymentJob)
+	default:
+		return w.ProcessDeployment(ctx, deploymentJob)
 	}
+}
+
+// ProcessDeployment processes a deployment job
+func (w *DeploymentWorker) ProcessDeployment(ctx context.Context, job *DeploymentJob) error {
+	w.logger.Info("Processing deployment job",
+		zap.String("release_id", job.ReleaseID.String()),
+		zap.String("app_id", job.AppID.String()),
+		zap.String("image", job.Image),
+		zap.String("tag", job.Tag),
+	)
 
 	// Get release details
 	release, err := w.releaseRepo.GetReleaseByID(ctx, job.ReleaseID)
@@ -94,6 +176,13 @@ func (w *DeploymentWorker) ProcessDeployment(ctx context.Context, job *Deploymen
 	if release == nil {
 		return fmt.Errorf("release not found")
 	}
+	if release.IsCompleted() {
+		w.logger.Warn("Skipping deployment of completed release",
+			zap.String("release_id", release.ID.String()),
+			zap.String("status", string(release.Status)),
+		)
+		return nil
+	}
 
 	// Update release status to running
 	now := time.Now()
@@ -101,27 +190,369 @@ func (w *DeploymentWorker) ProcessDeployment(ctx context.Context, job *Deploymen
 	if err != nil {
 		return fmt.Errorf("failed to update release status to running: %w", err)
 	}
+	w.recordPhase(ctx, job.ReleaseID, domain.ReleasePhaseRollingOut)
+	w.publish(job.ReleaseID, domain.ReleaseProgressEvent{
+		Type:   domain.ReleaseProgressStatus,
+		Status: domain.ReleaseStatusRunning,
+		Phase:  domain.ReleasePhaseRollingOut,
+	})
 
-	// Decrypt kubeconfig
-	kubeconfigBytes, err := w.crypto.Decrypt(cluster.KubeconfigEncrypted)
+	target, err := w.prepareRollout(ctx, release)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt kubeconfig: %w", err)
+		return w.failRollout(ctx, release, nil, err)
+	}
+	if err := w.pinImageDigest(ctx, release, target); err != nil {
+		return w.failRollout(ctx, release, target, err)
+	}
+	if err := w.pinSnapshot(ctx, target); err != nil {
+		return w.failRollout(ctx, release, target, err)
 	}
 
-	// Create Kubernetes client
-	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+	// A release that is only committed is rolled out by the GitOps tool syncing the commit
+	if target.gitops != nil && !target.gitops.AppliesRelease() {
+		if err := w.commitRelease(ctx, release, target); err != nil {
+			return w.failRollout(ctx, release, target, err)
+		}
+		if err := w.finishRelease(ctx, job.ReleaseID, domain.ReleaseStatusSucceeded, domain.ReleasePhaseCompleted); err != nil {
+			return err
+		}
+		w.logger.Info("Release committed to GitOps repository",
+			zap.String("release_id", job.ReleaseID.String()),
+			zap.String("app_name", target.app.Name),
+			zap.String("commit", target.meta.GitOpsCommit),
+		)
+		return nil
+	}
+
+	// The pre-deploy task runs before anything of the release is rolled out; if it fails, the
+	// running release is left untouched
+	if target.preDeploy != nil {
+		w.setPhase(ctx, release.ID, domain.ReleasePhasePreDeploy)
+		if err := w.runPreDeploy(ctx, release, target); err != nil {
+			return w.failRollout(ctx, release, target, err)
+		}
+		w.setPhase(ctx, release.ID, domain.ReleasePhaseRollingOut)
+	}
+
+	strategy := strategyType(target.config)
+	if strategy == domain.StrategyCanary && target.meta.RollbackOf != "" {
+		// A rollback restores the stable Deployment directly rather than starting a canary
+		strategy = domain.StrategyRolling
+	}
+
+	switch strategy {
+	case domain.StrategyBlueGreen:
+		err = w.deployBlueGreen(ctx, release.ID, target)
+	case domain.StrategyCanary:
+		err = w.deployCanary(ctx, target)
+	default:
+		err = w.deployToKubernetes(ctx, target)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create kubeconfig: %w", err)
+		return w.failRollout(ctx, release, target, fmt.Errorf("failed to deploy to kubernetes: %w", err))
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	if strategy == domain.StrategyCanary {
+		w.setPhase(ctx, job.ReleaseID, domain.ReleasePhaseCanary)
+		w.logger.Info("Canary deployed, awaiting promotion",
+			zap.String("release_id", job.ReleaseID.String()),
+			zap.String("app_name", target.app.Name),
+		)
+		return nil
+	}
+
+	w.exportRelease(ctx, release, target)
+	w.runPostDeploy(ctx, release, target)
+
+	if err := w.finishRelease(ctx, job.ReleaseID, domain.ReleaseStatusSucceeded, domain.ReleasePhaseCompleted); err != nil {
+		return err
+	}
+
+	w.logger.Info("Deployment completed successfully",
+		zap.String("release_id", job.ReleaseID.String()),
+		zap.String("app_name", target.app.Name),
+	)
+
+	return nil
+}
+
+// ProcessPromotion rolls a canary release out to the application's stable Deployment
+func (w *DeploymentWorker) ProcessPromotion(ctx context.Context, job *DeploymentJob) error {
+	release, err := w.getCanaryRelease(ctx, job.ReleaseID, domain.ReleasePhasePromoting)
 	if err != nil {
-		return fmt.Errorf("failed to create kubernetes client: %w", err)
+		return err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
+	target, err := w.prepareRollout(ctx, release)
 	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+		return w.failRollout(ctx, release, nil, fmt.Errorf("failed to promote canary: %w", err))
+	}
+	if err := w.pinSnapshot(ctx, target); err != nil {
+		return w.failRollout(ctx, release, target, fmt.Errorf("failed to promote canary: %w", err))
+	}
+
+	if err := w.deployToKubernetes(ctx, target); err != nil {
+		return w.failRollout(ctx, release, target, fmt.Errorf("failed to promote canary: %w", err))
+	}
+
+	w.runPostDeploy(ctx, release, target)
+
+	if err := w.finishRelease(ctx, job.ReleaseID, domain.ReleaseStatusSucceeded, domain.ReleasePhaseCompleted); err != nil {
+		return err
+	}
+
+	w.logger.Info("Canary promoted", zap.String("release_id", job.ReleaseID.String()))
+	return nil
+}
+
+// ProcessAbort removes a canary release's Deployment, Service and Ingress
+func (w *DeploymentWorker) ProcessAbort(ctx context.Context, job *DeploymentJob) error {
+	release, err := w.getCanaryRelease(ctx, job.ReleaseID, domain.ReleasePhaseAborting)
+	if err != nil {
+		return err
+	}
+
+	target, err := w.prepareRollout(ctx, release)
+	if err == nil {
+		err = w.removeCanary(ctx, target)
+	}
+	if err != nil {
+		// The canary may still be serving; return it to the canary phase so the abort can be retried
+		w.setPhase(ctx, job.ReleaseID, domain.ReleasePhaseCanary)
+		return fmt.Errorf("failed to abort canary: %w", err)
+	}
+
+	if err := w.finishRelease(ctx, job.ReleaseID, domain.ReleaseStatusFailed, domain.ReleasePhaseAborted); err != nil {
+		return err
+	}
+
+	w.logger.Info("Canary aborted", zap.String("release_id", job.ReleaseID.String()))
+	return nil
+}
+
+// rolloutFailure is a rollout that was applied but whose pods did not become healthy
+type rolloutFailure struct {
+	reason string
+}
+
+func (e *rolloutFailure) Error() string {
+	return e.reason
+}
+
+// failRollout records a failed rollout, reverting it if its pods did not become healthy, and returns err
+func (w *DeploymentWorker) failRollout(ctx context.Context, release *domain.Release, target *rolloutTarget, err error) error {
+	w.publish(release.ID, domain.ReleaseProgressEvent{
+		Type:    domain.ReleaseProgressRolloutFailed,
+		Message: err.Error(),
+	})
+
+	// A rollback would fail like a rollout that never reached healthy pods, and is not rolled back itself
+	var failure *rolloutFailure
+	if target == nil || !errors.As(err, &failure) || !*target.rollout.AutoRollback || target.meta.RollbackOf != "" {
+		w.finishRelease(ctx, release.ID, domain.ReleaseStatusFailed, domain.ReleasePhaseFailed)
+		return err
+	}
+
+	meta := *target.meta
+	meta.FailureReason = failure.reason
+	if target.config.Canary {
+		if removeErr := w.removeCanary(ctx, target); removeErr != nil {
+			w.finishRelease(ctx, release.ID, domain.ReleaseStatusFailed, domain.ReleasePhaseFailed)
+			return fmt.Errorf("%w (and failed to remove the canary: %v)", err, removeErr)
+		}
+	} else {
+		revert, revertErr := w.queueRevert(ctx, release, target.app)
+		if revertErr != nil {
+			w.finishRelease(ctx, release.ID, domain.ReleaseStatusFailed, domain.ReleasePhaseFailed)
+			return fmt.Errorf("%w (and failed to roll back: %v)", err, revertErr)
+		}
+		if revert == nil {
+			w.logger.Warn("No succeeded release to roll back to", zap.String("release_id", release.ID.String()))
+			w.finishRelease(ctx, release.ID, domain.ReleaseStatusFailed, domain.ReleasePhaseFailed)
+			return err
+		}
+		meta.RolledBackTo = revert.ID.String()
+	}
+
+	// The reason is informational; the status change below is what matters
+	if metaErr := release.SetMeta(&meta); metaErr == nil {
+		if _, metaErr = w.releaseRepo.UpdateReleaseMeta(ctx, release.ID, release.Meta); metaErr != nil {
+			w.logger.Error("Failed to record rollback reason", zap.Error(metaErr), zap.String("release_id", release.ID.String()))
+		}
+	}
+	if finishErr := w.finishRelease(ctx, release.ID, domain.ReleaseStatusRolledBack, domain.ReleasePhaseFailed); finishErr != nil {
+		return finishErr
+	}
+
+	w.logger.Warn("Rolled back failed rollout",
+		zap.String("release_id", release.ID.String()),
+		zap.String("app_name", target.app.Name),
+		zap.String("reason", failure.reason),
+		zap.String("rolled_back_to", meta.RolledBackTo),
+	)
+	return err
+}
+
+// queueRevert queues a new release of the last release that succeeded before the failed one
+func (w *DeploymentWorker) queueRevert(ctx context.Context, failed *domain.Release, app *domain.Application) (*domain.Release, error) {
+	previous, err := w.releaseRepo.GetLatestReleaseByAppIDAndStatus(ctx, app.ID, failed.EnvironmentID, domain.ReleaseStatusSucceeded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last succeeded release: %w", err)
+	}
+	if previous == nil {
+		return nil, nil
+	}
+
+	previousMeta, err := previous.GetMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of release %s: %w", previous.ID, err)
+	}
+	meta := previousMeta.WithoutRolloutOutcome()
+	meta.RollbackOf = failed.ID.String()
+
+	revert := &domain.Release{
+		AppID:         app.ID,
+		EnvironmentID: failed.EnvironmentID,
+		Image:         previous.Image,
+		Tag:           previous.Tag,
+		ImageDigest:   previous.ImageDigest,
+		CreatedBy:     failed.CreatedBy,
+		Status:        domain.ReleaseStatusPending,
+	}
+	if err := revert.SetMeta(&meta); err != nil {
+		return nil, err
+	}
+
+	created, err := w.releaseRepo.CreateRelease(ctx, revert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rollback release: %w", err)
+	}
+
+	// A release that cannot be queued would never leave pending
+	if _, err := w.jobRepo.CreateJob(ctx, created.NewDeployJob(app.OrgID)); err != nil {
+		w.finishRelease(ctx, created.ID, domain.ReleaseStatusFailed, domain.ReleasePhaseFailed)
+		return nil, fmt.Errorf("failed to queue rollback release: %w", err)
+	}
+
+	return created, nil
+}
+
+// getCanaryRelease returns a canary release that is in the given phase
+func (w *DeploymentWorker) getCanaryRelease(ctx context.Context, releaseID uuid.UUID, phase domain.ReleasePhase) (*domain.Release, error) {
+	release, err := w.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+	if release == nil {
+		return nil, fmt.Errorf("release not found")
+	}
+	if release.Status != domain.ReleaseStatusRunning || release.Phase != phase {
+		return nil, fmt.Errorf("release is %s in phase %s, expected running in phase %s", release.Status, release.Phase, phase)
+	}
+	return release, nil
+}
+
+// setPhase records the phase a release is in and publishes it to the release's progress
+func (w *DeploymentWorker) setPhase(ctx context.Context, releaseID uuid.UUID, phase domain.ReleasePhase) {
+	w.recordPhase(ctx, releaseID, phase)
+	w.publish(releaseID, domain.ReleaseProgressEvent{Type: domain.ReleaseProgressStatus, Phase: phase})
+}
+
+// recordPhase records the phase a release is in, logging failures
+func (w *DeploymentWorker) recordPhase(ctx context.Context, releaseID uuid.UUID, phase domain.ReleasePhase) {
+	if _, err := w.releaseRepo.UpdateReleasePhase(ctx, releaseID, phase); err != nil {
+		w.logger.Error("Failed to update release phase", zap.Error(err),
+			zap.String("release_id", releaseID.String()),
+			zap.String("phase", string(phase)),
+		)
+	}
+}
+
+// finishRelease records the final status and phase of a release
+func (w *DeploymentWorker) finishRelease(ctx context.Context, releaseID uuid.UUID, status domain.ReleaseStatus, phase domain.ReleasePhase) error {
+	finishedAt := time.Now()
+	if _, err := w.releaseRepo.UpdateReleaseStatus(ctx, releaseID, status, nil, &finishedAt); err != nil {
+		w.logger.Error("Failed to update release status", zap.Error(err),
+			zap.String("release_id", releaseID.String()),
+			zap.String("status", string(status)),
+		)
+		return fmt.Errorf("failed to update release status to %s: %w", status, err)
+	}
+	w.recordPhase(ctx, releaseID, phase)
+	w.publish(releaseID, domain.ReleaseProgressEvent{Type: domain.ReleaseProgressStatus, Status: status, Phase: phase})
+	return nil
+}
+
+// publish sends a progress event of a release's rollout to the release's subscribers
+func (w *DeploymentWorker) publish(releaseID uuid.UUID, event domain.ReleaseProgressEvent) {
+	event.ReleaseID = releaseID
+	w.progress.Publish(event)
+}
+
+// rolloutTarget is a release's deployment configuration and the clients of the cluster it is
+// deployed to
+type rolloutTarget struct {
+	releaseID uuid.UUID
+	app       *domain.Application
+	clientset kubernetes.Interface
+	applier   *deployment.Applier
+	config    *deployment.DeploymentConfig
+	domains   []string
+	meta      *domain.ReleaseMeta
+	rollout   domain.RolloutSpec
+
+	preDeploy  *domain.ReleaseTaskSpec
+	postDeploy *domain.ReleaseTaskSpec
+
+	rendered []*unstructured.Unstructured // Objects rendered from the deploy source, once rendered
+
+	gitops      *domain.GitOpsSpec
+	environment string // Name of the environment the release is deployed to, if any
+
+	pinned bool // Configured as the release was last rolled out, to check it; nothing is recorded on it
+}
+
+// prepareRollout connects to the cluster of a release's application, or of its environment, and
+// generates the release's deployment configuration
+func (w *DeploymentWorker) prepareRollout(ctx context.Context, release *domain.Release) (*rolloutTarget, error) {
+	return w.prepareTarget(ctx, release, false)
+}
+
+// prepareCheck connects to the cluster a release runs in and generates the deployment
+// configuration it was last rolled out with, which later changes to the application do not affect
+func (w *DeploymentWorker) prepareCheck(ctx context.Context, release *domain.Release) (*rolloutTarget, error) {
+	return w.prepareTarget(ctx, release, true)
+}
+
+// prepareTarget connects to the cluster of a release and generates its deployment configuration,
+// as it was last rolled out if pinned is set
+func (w *DeploymentWorker) prepareTarget(ctx context.Context, release *domain.Release, pinned bool) (*rolloutTarget, error) {
+	// Get application details
+	app, err := w.appRepo.GetApplicationByID(ctx, release.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application: %w", err)
+	}
+	if app == nil {
+		return nil, fmt.Errorf("application not found")
+	}
+
+	// Releases deployed to an environment run in its cluster and namespace
+	clusterID := app.ClusterID
+	var env *domain.Environment
+	if release.EnvironmentID != nil {
+		env, err = w.envRepo.GetEnvironmentByID(ctx, *release.EnvironmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get environment: %w", err)
+		}
+		if env == nil {
+			return nil, fmt.Errorf("environment not found")
+		}
+		clusterID = env.ClusterID
+	}
+
+	clientset, applier, err := w.clusterClients(ctx, clusterID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get release metadata
@@ -131,79 +562,432 @@ func (w *DeploymentWorker) ProcessDeployment(ctx context.Context, job *Deploymen
 		meta = &domain.ReleaseMeta{}
 	}
 
-	// Generate deployment configuration
-	deployConfig := w.deployer.GenerateFromApplication(app, release, meta)
+	var state *rollout.State
+	if pinned {
+		state, err = w.desired.ResolvePinned(ctx, app, release, env, meta)
+	} else {
+		state, err = w.desired.Resolve(ctx, app, release, env, meta, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	spec := state.Spec
+
+	target := &rolloutTarget{
+		releaseID: release.ID,
+		app:       app,
+		clientset: clientset,
+		applier:   applier,
+		config:    state.Config,
+		domains:   state.Domains,
+		meta:      meta,
+		rollout:   spec.RolloutSettings(),
+
+		preDeploy:  spec.PreDeploy,
+		postDeploy: spec.PostDeploy,
+
+		gitops: spec.GitOps,
 
-	// Deploy to Kubernetes
-	err = w.deployToKubernetes(ctx, clientset, dynamicClient, deployConfig)
+		pinned: pinned,
+	}
+	if env != nil {
+		target.environment = env.Name
+	}
+	return target, nil
+}
+
+// clusterClients connects to a cluster and returns its client and an applier for it
+func (w *DeploymentWorker) clusterClients(ctx context.Context, clusterID uuid.UUID) (kubernetes.Interface, *deployment.Applier, error) {
+	// Get cluster details
+	cluster, err := w.clusterRepo.GetClusterByID(ctx, clusterID)
 	if err != nil {
-		// Update release status to failed
-		finishedAt := time.Now()
-		w.releaseRepo.UpdateReleaseStatus(ctx, job.ReleaseID, domain.ReleaseStatusFailed, nil, &finishedAt)
-		return fmt.Errorf("failed to deploy to kubernetes: %w", err)
+		return nil, nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	if cluster == nil {
+		return nil, nil, fmt.Errorf("cluster not found")
 	}
 
-	// Update release status to succeeded
-	finishedAt := time.Now()
-	_, err = w.releaseRepo.UpdateReleaseStatus(ctx, job.ReleaseID, domain.ReleaseStatusSucceeded, nil, &finishedAt)
+	// Decrypt kubeconfig
+	kubeconfigBytes, err := w.crypto.Decrypt(cluster.KubeconfigEncrypted)
 	if err != nil {
-		return fmt.Errorf("failed to update release status to succeeded: %w", err)
+		return nil, nil, fmt.Errorf("failed to decrypt kubeconfig: %w", err)
 	}
 
-	w.logger.Info("Deployment completed successfully",
-		zap.String("release_id", job.ReleaseID.String()),
-		zap.String("app_name", app.Name),
-	)
+	return w.connect(kubeconfigBytes)
+}
 
+// connectCluster creates a client of the cluster a kubeconfig points to and an applier for it
+func connectCluster(kubeconfigBytes []byte) (kubernetes.Interface, *deployment.Applier, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	return clientset, deployment.NewApplier(dynamicClient, deployment.NewDiscoveryRESTMapper(clientset.Discovery())), nil
+}
+
+// pinImageDigest records the digest the release's tag points to on its first rollout
+func (w *DeploymentWorker) pinImageDigest(ctx context.Context, release *domain.Release, target *rolloutTarget) error {
+	if release.ImageDigest == "" {
+		digest, err := w.desired.ImageDigest(ctx, target.app.OrgID, release)
+		if err != nil {
+			return err
+		}
+		if _, err := w.releaseRepo.UpdateReleaseImageDigest(ctx, release.ID, digest); err != nil {
+			return fmt.Errorf("failed to update release image digest: %w", err)
+		}
+		release.ImageDigest = digest
+
+		w.logger.Info("Pinned release to image digest",
+			zap.String("release_id", release.ID.String()),
+			zap.String("image", release.Image),
+			zap.String("tag", release.Tag),
+			zap.String("digest", digest),
+		)
+	}
+
+	target.config.ImageDigest = release.ImageDigest
 	return nil
 }
 
-// deployToKubernetes deploys the application to Kubernetes
-func (w *DeploymentWorker) deployToKubernetes(ctx context.Context, clientset *kubernetes.Clientset, dynamicClient dynamic.Interface, config *deployment.DeploymentConfig) error {
-	// Generate deployment manifests
-	manifests, err := w.deployer.GenerateAllManifests(config, []string{})
+// deployToKubernetes rolls a release out with a rolling update, pruning objects no longer generated
+func (w *DeploymentWorker) deployToKubernetes(ctx context.Context, target *rolloutTarget) error {
+	config := target.config
+
+	objects, err := w.prepareObjects(ctx, target)
 	if err != nil {
-		return fmt.Errorf("failed to generate manifests: %w", err)
+		return err
 	}
 
 	// Create namespace if it doesn't exist
-	err = w.ensureNamespace(ctx, clientset, config.Namespace)
-	if err != nil {
+	if err := w.ensureNamespace(ctx, target, config.Namespace); err != nil {
 		return fmt.Errorf("failed to ensure namespace: %w", err)
 	}
 
 	// Apply each manifest
-	for filename, manifest := range manifests {
-		err = w.applyManifest(ctx, dynamicClient, manifest)
+	for _, obj := range objects {
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+
+	// Prune objects from earlier releases that are no longer part of the application
+	if err := w.pruneObjects(ctx, target, objects); err != nil {
+		return err
+	}
+
+	if config.Source != nil {
+		return w.waitForSourceWorkloads(ctx, target, objects)
+	}
+
+	// Wait for the web process, which runs as a StatefulSet when its replicas have volumes of
+	// their own, and the deployments of the other processes to be ready
+	if deployment.Stateful(config) {
+		if err := w.waitForStatefulSet(ctx, target, deployment.DeploymentName(config)); err != nil {
+			return fmt.Errorf("failed to wait for statefulset: %w", err)
+		}
+	}
+	return w.waitForDeployments(ctx, target, rolloutDeploymentNames(config))
+}
+
+// deployBlueGreen rolls a release out to the idle blue/green slot and switches the Service to it
+func (w *DeploymentWorker) deployBlueGreen(ctx context.Context, releaseID uuid.UUID, target *rolloutTarget) error {
+	config := target.config
+
+	activeSlot, err := w.activeSlot(ctx, target)
+	if err != nil {
+		return err
+	}
+	config.Slot = deployment.NextSlot(activeSlot)
+
+	objects, err := w.prepareObjects(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ensureNamespace(ctx, target, config.Namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+
+	previous := *config
+	previous.Slot = activeSlot
+	var replicas int32
+	if deployment.Autoscaled(config) {
+		replicas, err = w.deploymentReplicas(ctx, target, deployment.DeploymentName(&previous), config.Autoscaling.MinReplicas)
 		if err != nil {
-			return fmt.Errorf("failed to apply %s: %w", filename, err)
+			return err
+		}
+	}
+
+	// Start the new slot without routing traffic to it
+	var routing []*unstructured.Unstructured
+	for _, obj := range objects {
+		switch {
+		case obj.GetKind() == "Service" || obj.GetKind() == "Ingress" || obj.GetKind() == "HorizontalPodAutoscaler":
+			routing = append(routing, obj)
+			continue
+		case deployment.AutoscaledDeployment(config, obj):
+			// The autoscaler does not scale the slot until traffic is switched to it
+			obj = deployment.WithReplicas(obj, int64(replicas))
+		}
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+
+	// The processes roll out in place next to the slot, and must be ready before the switch too
+	if err := w.waitForDeployments(ctx, target, rolloutDeploymentNames(config)); err != nil {
+		return err
+	}
+
+	// Switch traffic to the new slot
+	w.setPhase(ctx, releaseID, domain.ReleasePhaseSwitching)
+	for _, obj := range routing {
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
 		}
-		w.logger.Info("Applied manifest", zap.String("filename", filename))
 	}
+	w.logger.Info("Switched traffic to slot",
+		zap.String("app_name", target.app.Name),
+		zap.String("slot", config.Slot),
+		zap.String("previous_slot", activeSlot),
+	)
 
-	// Wait for deployment to be ready
-	err = w.waitForDeployment(ctx, clientset, config.Namespace, config.AppName)
+	// Keep the previous slot; anything else that is no longer generated is pruned
+	keep := objects
+	if activeSlot != "" {
+		keep = append(keep, newObjectRef("apps/v1", "Deployment", config.Namespace, deployment.DeploymentName(&previous)))
+	}
+	return w.pruneObjects(ctx, target, keep)
+}
+
+// deployCanary starts a release next to the running one and routes a share of traffic to it
+func (w *DeploymentWorker) deployCanary(ctx context.Context, target *rolloutTarget) error {
+	config := target.config
+	if len(target.domains) == 0 {
+		return fmt.Errorf("canary deployments route traffic through the Ingress and require at least one domain")
+	}
+	config.Canary = true
+
+	objects, err := w.prepareObjects(ctx, target)
 	if err != nil {
+		return err
+	}
+
+	if err := w.ensureNamespace(ctx, target, config.Namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+
+	for _, obj := range objects {
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+
+	if err := w.waitForDeployment(ctx, target, deployment.DeploymentName(config)); err != nil {
 		return fmt.Errorf("failed to wait for deployment: %w", err)
 	}
 
 	return nil
 }
 
-// ensureNamespace creates a namespace if it doesn't exist
-func (w *DeploymentWorker) ensureNamespace(ctx context.Context, clientset *kubernetes.Clientset, namespace string) error {
-	_, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+// removeCanary deletes the canary's Deployment, Service and Ingress
+func (w *DeploymentWorker) removeCanary(ctx context.Context, target *rolloutTarget) error {
+	target.config.Canary = true
+
+	objects, err := w.prepareObjects(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		switch obj.GetKind() {
+		case "Deployment", "Service", "Ingress":
+		default:
+			continue
+		}
+		if err := target.applier.Delete(ctx, obj); err != nil {
+			return err
+		}
+		w.publish(target.releaseID, domain.ReleaseProgressEvent{Type: domain.ReleaseProgressResourceDeleted, Kind: obj.GetKind(), Name: obj.GetName()})
+		w.logger.Info("Deleted canary resource",
+			zap.String("kind", obj.GetKind()),
+			zap.String("namespace", obj.GetNamespace()),
+			zap.String("name", obj.GetName()),
+		)
+	}
+
+	return nil
+}
+
+// prepareObjects generates and parses the manifests of a rollout target, or renders its deploy
+// source, labelled as belonging to its application and sorted in apply order
+func (w *DeploymentWorker) prepareObjects(ctx context.Context, target *rolloutTarget) ([]*unstructured.Unstructured, error) {
+	if target.config.Source == nil {
+		return w.deployer.GenerateObjects(target.config, target.domains, target.app.ID)
+	}
+
+	if target.rendered == nil {
+		if err := w.renderSource(ctx, target); err != nil {
+			return nil, err
+		}
+	}
+	return w.deployer.GenerateSourceObjects(target.config, target.rendered, target.app.ID)
+}
+
+// renderSource renders the deploy source of a rollout target, pinning the commit it rendered
+func (w *DeploymentWorker) renderSource(ctx context.Context, target *rolloutTarget) error {
+	if target.pinned && target.meta.CommitSHA == "" {
+		return errors.New("release was not rolled out from a commit of its deploy source")
+	}
+
+	result, err := w.desired.RenderSource(ctx, target.app, target.meta, target.config)
+	if err != nil {
+		return err
+	}
+	if err := target.applier.CheckNamespaced(result.Objects); err != nil {
+		return err
+	}
+
+	if target.meta.CommitSHA == "" {
+		target.meta.CommitSHA = result.Commit
+		if err := w.updateMeta(ctx, target); err != nil {
+			return err
+		}
+		w.logger.Info("Pinned release to commit",
+			zap.String("release_id", target.releaseID.String()),
+			zap.String("commit", result.Commit),
+		)
+	}
+
+	target.rendered = result.Objects
+	w.logger.Info("Rendered deploy source",
+		zap.String("app_name", target.app.Name),
+		zap.String("source", target.config.Source.Type),
+		zap.String("commit", result.Commit),
+		zap.Int("objects", len(result.Objects)),
+	)
+	return nil
+}
+
+// pinSnapshot records the secrets and other inputs a release is rolled out with besides its spec
+func (w *DeploymentWorker) pinSnapshot(ctx context.Context, target *rolloutTarget) error {
+	pinned, err := w.desired.Record(ctx, target.app, target.meta, target.config, target.domains)
+	if err != nil || !pinned {
+		return err
+	}
+	return w.updateMeta(ctx, target)
+}
+
+// updateMeta records a rollout target's release metadata
+func (w *DeploymentWorker) updateMeta(ctx context.Context, target *rolloutTarget) error {
+	meta, err := json.Marshal(target.meta)
+	if err != nil {
+		return err
+	}
+	if _, err := w.releaseRepo.UpdateReleaseMeta(ctx, target.releaseID, meta); err != nil {
+		return fmt.Errorf("failed to update release metadata: %w", err)
+	}
+	return nil
+}
+
+// pruneObjects deletes the application's objects that are not among the objects to keep
+func (w *DeploymentWorker) pruneObjects(ctx context.Context, target *rolloutTarget, keep []*unstructured.Unstructured) error {
+	namespace := target.config.Namespace
+	pruned, err := target.applier.Prune(ctx, namespace, deployment.ManagedLabels(target.app.ID), keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune resources: %w", err)
+	}
+	for _, name := range pruned {
+		w.logger.Info("Pruned resource", zap.String("namespace", namespace), zap.String("resource", name))
+		kind, objName, _ := strings.Cut(name, "/")
+		w.publish(target.releaseID, domain.ReleaseProgressEvent{Type: domain.ReleaseProgressResourceDeleted, Kind: kind, Name: objName})
+	}
+	return nil
+}
+
+// activeSlot returns the blue/green slot the application's Service routes to, or "" if the
+// Service does not exist yet or does not route to a slot
+func (w *DeploymentWorker) activeSlot(ctx context.Context, target *rolloutTarget) (string, error) {
+	service, err := target.clientset.CoreV1().Services(target.config.Namespace).Get(ctx, deployment.ServiceName(target.config), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get service: %w", err)
+	}
+	return deployment.ActiveSlot(service), nil
+}
+
+// strategyType returns the deployment strategy of a configuration, defaulting to a rolling update
+func strategyType(config *deployment.DeploymentConfig) string {
+	if config.Strategy == nil {
+		return domain.StrategyRolling
+	}
+	return config.Strategy.Type
+}
+
+// newObjectRef returns an object holding only its identity, to refer to an object without its content
+func newObjectRef(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// ensureNamespace creates a namespace if it doesn't exist, labelled for the rollout's application
+func (w *DeploymentWorker) ensureNamespace(ctx context.Context, target *rolloutTarget, namespace string) error {
+	app := target.app
+	existing, err := target.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get namespace: %w", err)
+	}
 	if err == nil {
-		return nil // Namespace already exists
+		orgID, appID := existing.Labels[deployment.LabelOrgID], existing.Labels[deployment.LabelAppID]
+		if orgID != "" && orgID != app.OrgID.String() {
+			return fmt.Errorf("namespace %s belongs to another organization", namespace)
+		}
+		if appID != "" && appID != app.ID.String() {
+			return fmt.Errorf("namespace %s belongs to another application", namespace)
+		}
+		if orgID != "" && appID != "" {
+			return nil
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]string{
+					deployment.LabelOrgID: app.OrgID.String(),
+					deployment.LabelAppID: app.ID.String(),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := target.clientset.CoreV1().Namespaces().Patch(ctx, namespace, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to label namespace: %w", err)
+		}
+		return nil
 	}
 
 	// Create namespace
-	_, err = clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+	_, err = target.clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: namespace,
-			Labels: map[string]string{
-				"name": namespace,
-			},
+			Name:   namespace,
+			Labels: deployment.NamespaceLabels(namespace, app.OrgID, app.ID),
 		},
 	}, metav1.CreateOptions{})
 	if err != nil {
@@ -214,61 +998,99 @@ func (w *DeploymentWorker) ensureNamespace(ctx context.Context, clientset *kuber
 	return nil
 }
 
-// applyManifest applies a Kubernetes manifest
-func (w *DeploymentWorker) applyManifest(ctx context.Context, dynamicClient dynamic.Interface, manifest string) error {
-	// Parse YAML
-	var obj unstructured.Unstructured
-	err := yaml.Unmarshal([]byte(manifest), &obj)
+// deploymentReplicas returns the replicas of a Deployment, or the given default if it does not exist
+func (w *DeploymentWorker) deploymentReplicas(ctx context.Context, target *rolloutTarget, name string, defaultReplicas int32) (int32, error) {
+	live, err := target.clientset.AppsV1().Deployments(target.config.Namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to parse manifest: %w", err)
+		if apierrors.IsNotFound(err) {
+			return defaultReplicas, nil
+		}
+		return 0, fmt.Errorf("failed to get deployment: %w", err)
 	}
-
-	// Get GVR
-	gvr := schema.GroupVersionResource{
-		Group:    obj.GroupVersionKind().Group,
-		Version:  obj.GroupVersionKind().Version,
-		Resource: w.getResourceFromKind(obj.GetKind()),
+	if live.Spec.Replicas == nil {
+		return defaultReplicas, nil
 	}
+	return *live.Spec.Replicas, nil
+}
 
-	// Apply the manifest
-	_, err = dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, &obj, metav1.CreateOptions{})
+// applyManifest server-side applies a Kubernetes object under the oneclick field manager
+func (w *DeploymentWorker) applyManifest(ctx context.Context, target *rolloutTarget, obj *unstructured.Unstructured) error {
+	var err error
+	if deployment.AutoscaledDeployment(target.config, obj) {
+		_, err = target.applier.ApplyAutoscaled(ctx, obj, target.config.Autoscaling.MinReplicas)
+	} else {
+		_, err = target.applier.Apply(ctx, obj)
+	}
 	if err != nil {
-		// Try to update if create fails
-		_, err = dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Update(ctx, &obj, metav1.UpdateOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to create/update resource: %w", err)
-		}
+		return err
 	}
+	w.publish(target.releaseID, domain.ReleaseProgressEvent{
+		Type: domain.ReleaseProgressManifestApplied,
+		Kind: obj.GetKind(),
+		Name: obj.GetName(),
+	})
 
+	w.logger.Info("Applied manifest",
+		zap.String("kind", obj.GetKind()),
+		zap.String("namespace", obj.GetNamespace()),
+		zap.String("name", obj.GetName()),
+	)
 	return nil
 }
 
-// getResourceFromKind converts a Kubernetes kind to a resource name
-func (w *DeploymentWorker) getResourceFromKind(kind string) string {
-	switch kind {
-	case "Deployment":
-		return "deployments"
-	case "Service":
-		return "services"
-	case "ConfigMap":
-		return "configmaps"
-	case "Ingress":
-		return "ingresses"
-	default:
-		return fmt.Sprintf("%ss", kind) // Simple pluralization
+// rolloutDeploymentNames returns the names of the Deployments a release rolls out
+func rolloutDeploymentNames(config *deployment.DeploymentConfig) []string {
+	if deployment.Stateful(config) {
+		return deployment.ProcessDeploymentNames(config)
+	}
+	return append([]string{deployment.DeploymentName(config)}, deployment.ProcessDeploymentNames(config)...)
+}
+
+// waitForSourceWorkloads waits for the rollout of the Deployments and StatefulSets rendered from a
+// deploy source, in turn
+func (w *DeploymentWorker) waitForSourceWorkloads(ctx context.Context, target *rolloutTarget, objects []*unstructured.Unstructured) error {
+	var deployments []string
+	for _, obj := range objects {
+		switch obj.GetKind() {
+		case "StatefulSet":
+			if err := w.waitForStatefulSet(ctx, target, obj.GetName()); err != nil {
+				return fmt.Errorf("failed to wait for statefulset: %w", err)
+			}
+		case "Deployment":
+			deployments = append(deployments, obj.GetName())
+		}
 	}
+	return w.waitForDeployments(ctx, target, deployments)
 }
 
-// waitForDeployment waits for a deployment to be ready
-func (w *DeploymentWorker) waitForDeployment(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) error {
-	timeout := time.After(5 * time.Minute)
-	ticker := time.NewTicker(10 * time.Second)
+// waitForDeployments waits for the rollout of each deployment in turn
+func (w *DeploymentWorker) waitForDeployments(ctx context.Context, target *rolloutTarget, names []string) error {
+	for _, name := range names {
+		if err := w.waitForDeployment(ctx, target, name); err != nil {
+			return fmt.Errorf("failed to wait for deployment: %w", err)
+		}
+	}
+	return nil
+}
+
+// waitForDeployment waits for the rollout of a deployment to finish, publishing its progress
+func (w *DeploymentWorker) waitForDeployment(ctx context.Context, target *rolloutTarget, name string) error {
+	namespace := target.config.Namespace
+	clientset := target.clientset
+	rolloutTimeout := time.Duration(target.rollout.TimeoutSeconds) * time.Second
+
+	timeout := time.After(rolloutTimeout)
+	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
+	watch := newRolloutWatch(time.Now())
+
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-timeout:
-			return fmt.Errorf("timeout waiting for deployment to be ready")
+			return &rolloutFailure{reason: fmt.Sprintf("deployment %q was not ready after %s", name, rolloutTimeout)}
 		case <-ticker.C:
 			deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 			if err != nil {
@@ -276,47 +1098,463 @@ func (w *DeploymentWorker) waitForDeployment(ctx context.Context, clientset *kub
 				continue
 			}
 
-			if deployment.Status.ReadyReplicas == *deployment.Spec.Replicas {
+			counts := replicaCounts(deployment)
+			if counts != watch.replicas {
+				watch.replicas = counts
+				w.publish(target.releaseID, domain.ReleaseProgressEvent{
+					Type:     domain.ReleaseProgressReplicas,
+					Kind:     "Deployment",
+					Name:     name,
+					Replicas: &counts,
+				})
+			}
+
+			done, err := deploymentRolloutStatus(deployment)
+			if err != nil {
+				return err
+			}
+
+			if done {
 				w.logger.Info("Deployment is ready",
 					zap.String("namespace", namespace),
 					zap.String("name", name),
-					zap.Int32("ready_replicas", deployment.Status.ReadyReplicas),
-					zap.Int32("desired_replicas", *deployment.Spec.Replicas),
+					zap.Int32("ready_replicas", counts.Ready),
+					zap.Int32("desired_replicas", counts.Desired),
 				)
 				return nil
 			}
 
+			reason, err := w.inspectNewPods(ctx, target, deployment, watch)
+			if err != nil {
+				w.logger.Warn("Failed to check pods of deployment", zap.Error(err))
+			} else if reason != "" {
+				return &rolloutFailure{reason: reason}
+			}
+
+			if err := w.publishWarnings(ctx, target, watch); err != nil {
+				w.logger.Warn("Failed to list namespace events", zap.Error(err))
+			}
+
 			w.logger.Info("Waiting for deployment to be ready",
 				zap.String("namespace", namespace),
 				zap.String("name", name),
-				zap.Int32("ready_replicas", deployment.Status.ReadyReplicas),
-				zap.Int32("desired_replicas", *deployment.Spec.Replicas),
+				zap.Int32("updated_replicas", counts.Updated),
+				zap.Int32("available_replicas", counts.Available),
+				zap.Int32("desired_replicas", counts.Desired),
 			)
 		}
 	}
 }
 
-// Start starts the deployment worker
-func (w *DeploymentWorker) Start(ctx context.Context) {
+// rolloutWatch is what has been published about a rollout so far, so that each poll only
+// publishes what changed
+type rolloutWatch struct {
+	since         time.Time
+	replicas      domain.ReplicaCounts
+	replicaSet    string
+	scheduledPods map[string]bool
+	warnings      map[types.UID]int32 // Count of each warning event when it was last published
+}
+
+func newRolloutWatch(since time.Time) *rolloutWatch {
+	return &rolloutWatch{
+		since:         since,
+		scheduledPods: make(map[string]bool),
+		warnings:      make(map[types.UID]int32),
+	}
+}
+
+// replicaCounts returns the replica counts of a deployment
+func replicaCounts(deployment *appsv1.Deployment) domain.ReplicaCounts {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return domain.ReplicaCounts{
+		Desired:   desired,
+		Updated:   deployment.Status.UpdatedReplicas,
+		Ready:     deployment.Status.ReadyReplicas,
+		Available: deployment.Status.AvailableReplicas,
+	}
+}
+
+// deploymentRolloutStatus reports whether the rollout of a deployment has completed
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (bool, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, &rolloutFailure{reason: fmt.Sprintf("deployment %q exceeded its progress deadline: %s", deployment.Name, condition.Message)}
+		}
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	if status.UpdatedReplicas < desired {
+		return false, nil
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return false, nil // Old replicas are still terminating
+	}
+	if status.AvailableReplicas < status.UpdatedReplicas {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// waitForStatefulSet waits for the rollout of a stateful set to finish, publishing its progress
+func (w *DeploymentWorker) waitForStatefulSet(ctx context.Context, target *rolloutTarget, name string) error {
+	namespace := target.config.Namespace
+	clientset := target.clientset
+	rolloutTimeout := time.Duration(target.rollout.TimeoutSeconds) * time.Second
+
+	timeout := time.After(rolloutTimeout)
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	watch := newRolloutWatch(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return &rolloutFailure{reason: fmt.Sprintf("statefulset %q was not ready after %s", name, rolloutTimeout)}
+		case <-ticker.C:
+			statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				w.logger.Warn("Failed to get statefulset status", zap.Error(err))
+				continue
+			}
+
+			counts := statefulSetReplicaCounts(statefulSet)
+			if counts != watch.replicas {
+				watch.replicas = counts
+				w.publish(target.releaseID, domain.ReleaseProgressEvent{
+					Type:     domain.ReleaseProgressReplicas,
+					Kind:     "StatefulSet",
+					Name:     name,
+					Replicas: &counts,
+				})
+			}
+
+			if statefulSetRolloutStatus(statefulSet) {
+				w.logger.Info("StatefulSet is ready",
+					zap.String("namespace", namespace),
+					zap.String("name", name),
+					zap.Int32("ready_replicas", counts.Ready),
+					zap.Int32("desired_replicas", counts.Desired),
+				)
+				return nil
+			}
+
+			reason, err := w.inspectStatefulSetPods(ctx, target, statefulSet, watch)
+			if err != nil {
+				w.logger.Warn("Failed to check pods of statefulset", zap.Error(err))
+			} else if reason != "" {
+				return &rolloutFailure{reason: reason}
+			}
+
+			if err := w.publishWarnings(ctx, target, watch); err != nil {
+				w.logger.Warn("Failed to list namespace events", zap.Error(err))
+			}
+
+			w.logger.Info("Waiting for statefulset to be ready",
+				zap.String("namespace", namespace),
+				zap.String("name", name),
+				zap.Int32("updated_replicas", counts.Updated),
+				zap.Int32("available_replicas", counts.Available),
+				zap.Int32("desired_replicas", counts.Desired),
+			)
+		}
+	}
+}
+
+// statefulSetReplicaCounts returns the replica counts of a stateful set
+func statefulSetReplicaCounts(statefulSet *appsv1.StatefulSet) domain.ReplicaCounts {
+	desired := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+	return domain.ReplicaCounts{
+		Desired:   desired,
+		Updated:   statefulSet.Status.UpdatedReplicas,
+		Ready:     statefulSet.Status.ReadyReplicas,
+		Available: statefulSet.Status.AvailableReplicas,
+	}
+}
+
+// statefulSetRolloutStatus reports whether the rolling update of a stateful set has completed
+func statefulSetRolloutStatus(statefulSet *appsv1.StatefulSet) bool {
+	if statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		return false
+	}
+
+	desired := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+
+	status := statefulSet.Status
+	if status.UpdatedReplicas < desired || status.AvailableReplicas < desired {
+		return false
+	}
+	return status.UpdateRevision == "" || status.CurrentRevision == status.UpdateRevision
+}
+
+// inspectStatefulSetPods returns why a pod of the update revision will not become healthy, or ""
+func (w *DeploymentWorker) inspectStatefulSetPods(ctx context.Context, target *rolloutTarget, statefulSet *appsv1.StatefulSet, watch *rolloutWatch) (string, error) {
+	revision := statefulSet.Status.UpdateRevision
+	if revision == "" {
+		return "", nil // Not observed yet
+	}
+
+	podSelector := labels.SelectorFromSet(labels.Set{appsv1.StatefulSetRevisionLabel: revision})
+	pods, err := target.clientset.CoreV1().Pods(statefulSet.Namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != "" && !watch.scheduledPods[pod.Name] {
+			watch.scheduledPods[pod.Name] = true
+			w.publish(target.releaseID, domain.ReleaseProgressEvent{
+				Type:    domain.ReleaseProgressPodScheduled,
+				Kind:    "Pod",
+				Name:    pod.Name,
+				Message: fmt.Sprintf("scheduled to node %s", pod.Spec.NodeName),
+			})
+		}
+		if reason := podFailure(pod, target.rollout.MaxRestarts); reason != "" {
+			return reason, nil
+		}
+	}
+	return "", nil
+}
+
+// inspectNewPods returns why a pod of the current ReplicaSet will not become healthy, or ""
+func (w *DeploymentWorker) inspectNewPods(ctx context.Context, target *rolloutTarget, deployment *appsv1.Deployment, watch *rolloutWatch) (string, error) {
+	clientset := target.clientset
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid deployment selector: %w", err)
+	}
+
+	replicaSets, err := clientset.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to list replica sets: %w", err)
+	}
+	current := currentReplicaSet(deployment, replicaSets.Items)
+	if current == nil {
+		return "", nil // Not created yet
+	}
+	if current.Name != watch.replicaSet {
+		watch.replicaSet = current.Name
+		w.publish(target.releaseID, domain.ReleaseProgressEvent{
+			Type:    domain.ReleaseProgressReplicaSetCreated,
+			Kind:    "ReplicaSet",
+			Name:    current.Name,
+			Message: fmt.Sprintf("revision %s", current.Annotations[annotationRevision]),
+		})
+	}
+
+	podSelector := labels.SelectorFromSet(labels.Set{appsv1.DefaultDeploymentUniqueLabelKey: current.Labels[appsv1.DefaultDeploymentUniqueLabelKey]})
+	pods, err := clientset.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != "" && !watch.scheduledPods[pod.Name] {
+			watch.scheduledPods[pod.Name] = true
+			w.publish(target.releaseID, domain.ReleaseProgressEvent{
+				Type:    domain.ReleaseProgressPodScheduled,
+				Kind:    "Pod",
+				Name:    pod.Name,
+				Message: fmt.Sprintf("scheduled to node %s", pod.Spec.NodeName),
+			})
+		}
+		if reason := podFailure(pod, target.rollout.MaxRestarts); reason != "" {
+			return reason, nil
+		}
+	}
+	return "", nil
+}
+
+// publishWarnings publishes the warning events of the application's namespace that occurred
+// since the rollout started and were not published yet, or have recurred since
+func (w *DeploymentWorker) publishWarnings(ctx context.Context, target *rolloutTarget, watch *rolloutWatch) error {
+	events, err := target.clientset.CoreV1().Events(target.config.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "type=" + corev1.EventTypeWarning,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events.Items {
+		count := event.Count
+		if event.Series != nil {
+			count = event.Series.Count
+		}
+		if eventTime(&event).Before(watch.since) {
+			continue
+		}
+		if published, ok := watch.warnings[event.UID]; ok && published == count {
+			continue
+		}
+		watch.warnings[event.UID] = count
+		w.publish(target.releaseID, domain.ReleaseProgressEvent{
+			Type:    domain.ReleaseProgressWarning,
+			Kind:    event.InvolvedObject.Kind,
+			Name:    event.InvolvedObject.Name,
+			Reason:  event.Reason,
+			Message: event.Message,
+		})
+	}
+	return nil
+}
+
+// eventTime returns when a Kubernetes event last occurred
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// annotationRevision is the annotation holding a deployment's revision and the revision of each
+// of its ReplicaSets
+const annotationRevision = "deployment.kubernetes.io/revision"
+
+// currentReplicaSet returns the ReplicaSet the deployment is rolling out, identified by the
+// revision the deployment controller annotates both with
+func currentReplicaSet(deployment *appsv1.Deployment, replicaSets []appsv1.ReplicaSet) *appsv1.ReplicaSet {
+	revision := deployment.Annotations[annotationRevision]
+	if revision == "" {
+		return nil
+	}
+	for i := range replicaSets {
+		rs := &replicaSets[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		if rs.Annotations[annotationRevision] == revision {
+			return rs
+		}
+	}
+	return nil
+}
+
+// fatalWaitingReasons are the reasons a container waits for that will not resolve on their own
+var fatalWaitingReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"ErrImageNeverPull":          true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// podFailure returns why a pod will not become healthy, or "" if it still may
+func podFailure(pod *corev1.Pod, maxRestarts int32) string {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.RestartCount >= maxRestarts {
+			reason := "crash-looping"
+			if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason != "" {
+				reason = fmt.Sprintf("last terminated with %s, exit code %d", terminated.Reason, terminated.ExitCode)
+			}
+			return fmt.Sprintf("container %q of pod %q restarted %d times (%s)", status.Name, pod.Name, status.RestartCount, reason)
+		}
+		if waiting := status.State.Waiting; waiting != nil && fatalWaitingReasons[waiting.Reason] {
+			return fmt.Sprintf("container %q of pod %q cannot start: %s: %s", status.Name, pod.Name, waiting.Reason, waiting.Message)
+		}
+	}
+	return ""
+}
+
+// Start starts the deployment worker and its drift reconciler
+func (w *DeploymentWorker) Start(ctx context.Context) error {
 	w.logger.Info("Starting deployment worker")
 
-	// In a real implementation, this would:
-	// 1. Connect to a message queue (Redis, RabbitMQ, etc.)
-	// 2. Listen for deployment jobs
-	// 3. Process jobs concurrently
-	// 4. Handle retries and error recovery
+	go w.runDriftReconciler(ctx)
 
-	// For now, this is a stub that demonstrates the interface
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(w.processingInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
+			w.logger.Info("Deployment worker stopped due to context cancellation")
+			return ctx.Err()
+		case <-w.stopChan:
 			w.logger.Info("Deployment worker stopped")
-			return
+			return nil
 		case <-ticker.C:
-			w.logger.Info("Deployment worker is running")
+			if err := w.processPendingJobs(ctx); err != nil {
+				w.logger.Error("Failed to process pending deployment jobs", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Stop stops the deployment worker
+func (w *DeploymentWorker) Stop() error {
+	w.logger.Info("Stopping deployment worker")
+	close(w.stopChan)
+	return nil
+}
+
+// processPendingJobs processes all pending release jobs in the order they were queued
+func (w *DeploymentWorker) processPendingJobs(ctx context.Context) error {
+	var jobs []domain.Job
+	for _, jobType := range domain.ReleaseJobTypes() {
+		pending, err := w.jobRepo.GetPendingJobsByType(ctx, jobType)
+		if err != nil {
+			return fmt.Errorf("failed to get pending jobs: %w", err)
 		}
+		jobs = append(jobs, pending...)
 	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	for _, job := range jobs {
+		// Try to start the job (atomic operation)
+		startedJob, err := w.jobRepo.StartJob(ctx, job.ID)
+		if err != nil {
+			w.logger.Error("Failed to start job", zap.Error(err), zap.String("jobID", job.ID.String()))
+			continue
+		}
+
+		w.applyMu.Lock()
+		err = w.ProcessJob(ctx, startedJob)
+		w.applyMu.Unlock()
+		if err != nil {
+			w.logger.Error("Failed to process deployment job", zap.Error(err), zap.String("jobID", job.ID.String()))
+			if _, failErr := w.jobRepo.FailJob(ctx, job.ID, err.Error()); failErr != nil {
+				w.logger.Error("Failed to mark job as failed", zap.Error(failErr), zap.String("jobID", job.ID.String()))
+			}
+		} else {
+			if _, completeErr := w.jobRepo.CompleteJob(ctx, job.ID); completeErr != nil {
+				w.logger.Error("Failed to mark job as completed", zap.Error(completeErr), zap.String("jobID", job.ID.String()))
+			}
+		}
+	}
+
+	return nil
 }
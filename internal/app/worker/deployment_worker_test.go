@@ -0,0 +1,1171 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/PouryDev/oneclick/internal/app/crypto"
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/registry"
+	"github.com/PouryDev/oneclick/internal/app/rollout"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// MockReleaseRepository is a mock implementation of ReleaseRepository
+type MockReleaseRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseRepository) CreateRelease(ctx context.Context, release *domain.Release) (*domain.Release, error) {
+	args := m.Called(ctx, release)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetReleaseByID(ctx context.Context, id uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetReleasesByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseSummary, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.ReleaseSummary), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByAppID(ctx context.Context, appID uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByEnvironment(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID) (*domain.Release, error) {
+	args := m.Called(ctx, appID, environmentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetLatestReleaseByAppIDAndStatus(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID, status domain.ReleaseStatus) (*domain.Release, error) {
+	args := m.Called(ctx, appID, environmentID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) GetCurrentReleases(ctx context.Context) ([]domain.Release, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseStatus(ctx context.Context, id uuid.UUID, status domain.ReleaseStatus, startedAt, finishedAt *time.Time) (*domain.Release, error) {
+	args := m.Called(ctx, id, status, startedAt, finishedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleasePhase(ctx context.Context, id uuid.UUID, phase domain.ReleasePhase) (*domain.Release, error) {
+	args := m.Called(ctx, id, phase)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) TransitionReleasePhase(ctx context.Context, id uuid.UUID, from, to domain.ReleasePhase) (*domain.Release, error) {
+	args := m.Called(ctx, id, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseMeta(ctx context.Context, id uuid.UUID, meta []byte) (*domain.Release, error) {
+	args := m.Called(ctx, id, meta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) UpdateReleaseImageDigest(ctx context.Context, id uuid.UUID, digest string) (*domain.Release, error) {
+	args := m.Called(ctx, id, digest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Release), args.Error(1)
+}
+
+func (m *MockReleaseRepository) DeleteRelease(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockApplicationRepository is a mock implementation of ApplicationRepository
+type MockApplicationRepository struct {
+	mock.Mock
+}
+
+func (m *MockApplicationRepository) CreateApplication(ctx context.Context, app *domain.Application) (*domain.Application, error) {
+	args := m.Called(ctx, app)
+	return args.Get(0).(*domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationByID(ctx context.Context, id uuid.UUID) (*domain.Application, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationsByClusterID(ctx context.Context, clusterID uuid.UUID) ([]domain.ApplicationSummary, error) {
+	args := m.Called(ctx, clusterID)
+	return args.Get(0).([]domain.ApplicationSummary), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationByNameInCluster(ctx context.Context, clusterID uuid.UUID, name string) (*domain.Application, error) {
+	args := m.Called(ctx, clusterID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationByNamespaceInCluster(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Application, error) {
+	args := m.Called(ctx, clusterID, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) GetApplicationsByRepoID(ctx context.Context, repoID uuid.UUID) ([]domain.Application, error) {
+	args := m.Called(ctx, repoID)
+	return args.Get(0).([]domain.Application), args.Error(1)
+}
+
+func (m *MockApplicationRepository) DeleteApplication(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockClusterRepository is a mock implementation of ClusterRepository
+type MockClusterRepository struct {
+	mock.Mock
+}
+
+func (m *MockClusterRepository) CreateCluster(ctx context.Context, cluster *domain.Cluster) (*domain.Cluster, error) {
+	args := m.Called(ctx, cluster)
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) GetClusterByID(ctx context.Context, id uuid.UUID) (*domain.Cluster, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) GetClustersByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.ClusterSummary, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]domain.ClusterSummary), args.Error(1)
+}
+
+func (m *MockClusterRepository) UpdateClusterStatus(ctx context.Context, id uuid.UUID, status string) (*domain.Cluster, error) {
+	args := m.Called(ctx, id, status)
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) UpdateClusterKubeconfig(ctx context.Context, id uuid.UUID, kubeconfigEncrypted []byte, status string) (*domain.Cluster, error) {
+	args := m.Called(ctx, id, kubeconfigEncrypted, status)
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) UpdateClusterHealth(ctx context.Context, id uuid.UUID, kubeVersion string) (*domain.Cluster, error) {
+	args := m.Called(ctx, id, kubeVersion)
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) UpdateClusterNodeCount(ctx context.Context, id uuid.UUID, nodeCount int) (*domain.Cluster, error) {
+	args := m.Called(ctx, id, nodeCount)
+	return args.Get(0).(*domain.Cluster), args.Error(1)
+}
+
+func (m *MockClusterRepository) DeleteCluster(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockJobRepository is a mock implementation of JobRepository
+type MockJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockJobRepository) UpdateJobStatus(ctx context.Context, id uuid.UUID, status domain.JobStatus) (*domain.Job, error) {
+	args := m.Called(ctx, id, status)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) StartJob(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) GetPendingJobsByType(ctx context.Context, jobType domain.JobType) ([]domain.Job, error) {
+	args := m.Called(ctx, jobType)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.Job, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) CreateJob(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	args := m.Called(ctx, job)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) CompleteJob(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress []domain.JobStep) error {
+	args := m.Called(ctx, id, progress)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) DeleteJob(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) FailJob(ctx context.Context, id uuid.UUID, reason string) (*domain.Job, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+// MockEnvironmentRepository is a mock implementation of EnvironmentRepository
+type MockEnvironmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnvironmentRepository) CreateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	args := m.Called(ctx, env)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByID(ctx context.Context, id uuid.UUID) (*domain.Environment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByName(ctx context.Context, appID uuid.UUID, name string) (*domain.Environment, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Environment, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) GetEnvironmentByNamespace(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Environment, error) {
+	args := m.Called(ctx, clusterID, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) UpdateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	args := m.Called(ctx, env)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Environment), args.Error(1)
+}
+
+func (m *MockEnvironmentRepository) DeleteEnvironment(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockReleaseDriftRepository is a mock implementation of ReleaseDriftRepository
+type MockReleaseDriftRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseDriftRepository) UpsertReleaseDrift(ctx context.Context, drift *domain.ReleaseDrift) error {
+	args := m.Called(ctx, drift)
+	return args.Error(0)
+}
+
+func (m *MockReleaseDriftRepository) GetReleaseDriftByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseDrift, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.ReleaseDrift), args.Error(1)
+}
+
+// MockApplicationSpecRepository is a mock implementation of ApplicationSpecRepository
+type MockApplicationSpecRepository struct {
+	mock.Mock
+}
+
+func (m *MockApplicationSpecRepository) CreateApplicationSpec(ctx context.Context, appID, createdBy uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, createdBy, spec)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetLatestApplicationSpec(ctx context.Context, appID uuid.UUID) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+func (m *MockApplicationSpecRepository) GetApplicationSpecByVersion(ctx context.Context, appID uuid.UUID, version int) (*domain.ApplicationSpec, error) {
+	args := m.Called(ctx, appID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApplicationSpec), args.Error(1)
+}
+
+// MockAppSecretRepository is a mock implementation of AppSecretRepository
+type MockAppSecretRepository struct {
+	mock.Mock
+}
+
+func (m *MockAppSecretRepository) CreateAppSecret(ctx context.Context, secret *domain.AppSecret) (*domain.AppSecret, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretByName(ctx context.Context, appID uuid.UUID, name string) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) GetAppSecretsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.AppSecret, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) UpdateAppSecretValue(ctx context.Context, appID uuid.UUID, name string, valueEncrypted []byte) (*domain.AppSecret, error) {
+	args := m.Called(ctx, appID, name, valueEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AppSecret), args.Error(1)
+}
+
+func (m *MockAppSecretRepository) DeleteAppSecret(ctx context.Context, appID uuid.UUID, name string) error {
+	args := m.Called(ctx, appID, name)
+	return args.Error(0)
+}
+
+// MockNamespacePolicyRepository is a mock implementation of NamespacePolicyRepository
+type MockNamespacePolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockNamespacePolicyRepository) GetNamespacePolicy(ctx context.Context, orgID uuid.UUID) (*domain.NamespacePolicy, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.NamespacePolicy), args.Error(1)
+}
+
+func (m *MockNamespacePolicyRepository) UpsertNamespacePolicy(ctx context.Context, policy *domain.NamespacePolicy) (*domain.NamespacePolicy, error) {
+	args := m.Called(ctx, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.NamespacePolicy), args.Error(1)
+}
+
+// MockRegistryCredentialRepository is a mock implementation of RegistryCredentialRepository
+type MockRegistryCredentialRepository struct {
+	mock.Mock
+}
+
+func (m *MockRegistryCredentialRepository) CreateRegistryCredential(ctx context.Context, cred *domain.RegistryCredential) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, cred)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialByID(ctx context.Context, id uuid.UUID) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialByRegistry(ctx context.Context, orgID uuid.UUID, registry string) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, orgID, registry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) GetRegistryCredentialsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RegistryCredential, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) UpdateRegistryCredential(ctx context.Context, id uuid.UUID, username string, passwordEncrypted []byte) (*domain.RegistryCredential, error) {
+	args := m.Called(ctx, id, username, passwordEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RegistryCredential), args.Error(1)
+}
+
+func (m *MockRegistryCredentialRepository) DeleteRegistryCredential(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockReleaseSnapshotRepository is a mock implementation of ReleaseSnapshotRepository
+type MockReleaseSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *MockReleaseSnapshotRepository) CreateReleaseSnapshot(ctx context.Context, appID uuid.UUID, dataEncrypted []byte) (*domain.ReleaseSnapshot, error) {
+	args := m.Called(ctx, appID, dataEncrypted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseSnapshot), args.Error(1)
+}
+
+func (m *MockReleaseSnapshotRepository) GetReleaseSnapshotByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseSnapshot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReleaseSnapshot), args.Error(1)
+}
+
+// MockDomainRepository is a mock implementation of DomainRepository
+type MockDomainRepository struct {
+	mock.Mock
+}
+
+func (m *MockDomainRepository) CreateDomain(ctx context.Context, d *domain.Domain) (*domain.Domain, error) {
+	args := m.Called(ctx, d)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) GetDomainByID(ctx context.Context, id uuid.UUID) (*domain.Domain, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) GetDomainsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Domain, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) GetDomainByDomainInApp(ctx context.Context, appID uuid.UUID, domainName string) (*domain.Domain, error) {
+	args := m.Called(ctx, appID, domainName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) UpdateDomainCertStatus(ctx context.Context, id uuid.UUID, status domain.CertificateStatus) (*domain.Domain, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) UpdateDomainCertSecret(ctx context.Context, id uuid.UUID, secretName string) (*domain.Domain, error) {
+	args := m.Called(ctx, id, secretName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) UpdateDomainProviderConfig(ctx context.Context, id uuid.UUID, config domain.ProviderConfig) (*domain.Domain, error) {
+	args := m.Called(ctx, id, config)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Domain), args.Error(1)
+}
+
+func (m *MockDomainRepository) DeleteDomain(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockServiceRepository is a mock implementation of ServiceRepository
+type MockServiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockServiceRepository) CreateService(ctx context.Context, service *domain.Service) (*domain.Service, error) {
+	args := m.Called(ctx, service)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Service), args.Error(1)
+}
+
+func (m *MockServiceRepository) GetServiceByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Service), args.Error(1)
+}
+
+func (m *MockServiceRepository) GetServicesByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ServiceSummary, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).([]domain.ServiceSummary), args.Error(1)
+}
+
+func (m *MockServiceRepository) GetServiceByNameInApp(ctx context.Context, appID uuid.UUID, name string) (*domain.Service, error) {
+	args := m.Called(ctx, appID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Service), args.Error(1)
+}
+
+func (m *MockServiceRepository) UpdateServiceStatus(ctx context.Context, id uuid.UUID, status domain.ServiceStatus) (*domain.Service, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Service), args.Error(1)
+}
+
+func (m *MockServiceRepository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// newTestCrypto creates a Crypto instance backed by a fixed test master key
+func newTestCrypto(t *testing.T) *crypto.Crypto {
+	t.Setenv("ONECLICK_MASTER_KEY", "!@#$%^&*()_+-=[]{}|;':\",./<>?123")
+	cryptoService, err := crypto.NewCrypto()
+	require.NoError(t, err)
+	return cryptoService
+}
+
+// testCluster is a fake cluster. Objects that are server-side applied are stored as sent, as the
+// API server does for objects only OneClick manages. The fake client drops apply options, so a
+// dry-run apply stores the object too.
+type testCluster struct {
+	clientset *kubefake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+	applier   *deployment.Applier
+}
+
+// newTestCluster creates a fake cluster serving the kinds OneClick applies. Unstructured objects
+// are served to the applier, typed objects to the clientset.
+func newTestCluster(objects ...runtime.Object) *testCluster {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, gvk := range append(deployment.ManagedKinds(), deployment.PersistentVolumeClaimKind) {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		listKinds[gvr] = gvk.Kind + "List"
+	}
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	var typed, live []runtime.Object
+	for _, obj := range objects {
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			live = append(live, obj)
+		} else {
+			typed = append(typed, obj)
+		}
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, live...)
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchActionImpl)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := client.Tracker()
+		if _, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName()); apierrors.IsNotFound(err) {
+			return true, obj, tracker.Create(patch.GetResource(), obj, patch.GetNamespace())
+		}
+		return true, obj, tracker.Update(patch.GetResource(), obj, patch.GetNamespace())
+	})
+
+	return &testCluster{
+		clientset: kubefake.NewClientset(typed...),
+		dynamic:   client,
+		applier:   deployment.NewApplier(client, mapper),
+	}
+}
+
+// get returns a live object served to the applier, or nil if it does not exist
+func (c *testCluster) get(t *testing.T, apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	live, err := c.applier.Get(context.Background(), newObjectRef(apiVersion, kind, namespace, name))
+	require.NoError(t, err)
+	return live
+}
+
+// testWorker is a deployment worker backed by mock repositories and a fake cluster
+type testWorker struct {
+	*DeploymentWorker
+	jobRepo      *MockJobRepository
+	appRepo      *MockApplicationRepository
+	releaseRepo  *MockReleaseRepository
+	clusterRepo  *MockClusterRepository
+	domainRepo   *MockDomainRepository
+	envRepo      *MockEnvironmentRepository
+	serviceRepo  *MockServiceRepository
+	driftRepo    *MockReleaseDriftRepository
+	specRepo     *MockApplicationSpecRepository
+	secretRepo   *MockAppSecretRepository
+	policyRepo   *MockNamespacePolicyRepository
+	credRepo     *MockRegistryCredentialRepository
+	snapshotRepo *MockReleaseSnapshotRepository
+}
+
+// newTestWorker creates a deployment worker whose clusters all connect to the given fake cluster
+func newTestWorker(t *testing.T, cluster *testCluster) *testWorker {
+	cryptoService := newTestCrypto(t)
+	w := &testWorker{
+		jobRepo:      &MockJobRepository{},
+		appRepo:      &MockApplicationRepository{},
+		releaseRepo:  &MockReleaseRepository{},
+		clusterRepo:  &MockClusterRepository{},
+		domainRepo:   &MockDomainRepository{},
+		envRepo:      &MockEnvironmentRepository{},
+		serviceRepo:  &MockServiceRepository{},
+		driftRepo:    &MockReleaseDriftRepository{},
+		specRepo:     &MockApplicationSpecRepository{},
+		secretRepo:   &MockAppSecretRepository{},
+		policyRepo:   &MockNamespacePolicyRepository{},
+		credRepo:     &MockRegistryCredentialRepository{},
+		snapshotRepo: &MockReleaseSnapshotRepository{},
+	}
+
+	desired := rollout.NewResolver(w.specRepo, w.secretRepo, w.domainRepo, w.policyRepo, nil, w.snapshotRepo,
+		registry.NewResolver(nil, w.credRepo, cryptoService), cryptoService)
+	w.DeploymentWorker = NewDeploymentWorker(w.jobRepo, w.appRepo, w.releaseRepo, w.clusterRepo, w.domainRepo, w.envRepo,
+		nil, w.serviceRepo, w.driftRepo, desired, cryptoService, nil, zap.NewNop())
+	w.pollInterval = 10 * time.Millisecond
+	w.connect = func([]byte) (kubernetes.Interface, *deployment.Applier, error) {
+		return cluster.clientset, cluster.applier, nil
+	}
+	return w
+}
+
+// withApplication serves an application with the given domains and its cluster to the worker. The
+// application has no spec, secrets, registry credentials or namespace policy of its own.
+func (w *testWorker) withApplication(t *testing.T, app *domain.Application, domains ...string) {
+	kubeconfig, err := w.crypto.Encrypt([]byte("kubeconfig"))
+	require.NoError(t, err)
+
+	w.appRepo.On("GetApplicationByID", mock.Anything, app.ID).Return(app, nil)
+	w.clusterRepo.On("GetClusterByID", mock.Anything, app.ClusterID).Return(&domain.Cluster{ID: app.ClusterID, KubeconfigEncrypted: kubeconfig}, nil)
+	w.specRepo.On("GetLatestApplicationSpec", mock.Anything, app.ID).Return(nil, nil).Maybe()
+	w.secretRepo.On("GetAppSecretsByAppID", mock.Anything, app.ID).Return([]domain.AppSecret{}, nil).Maybe()
+	appDomains := []domain.Domain{}
+	for _, name := range domains {
+		appDomains = append(appDomains, domain.Domain{ID: uuid.New(), AppID: app.ID, Domain: name})
+	}
+	w.domainRepo.On("GetDomainsByAppID", mock.Anything, app.ID).Return(appDomains, nil).Maybe()
+	w.credRepo.On("GetRegistryCredentialByRegistry", mock.Anything, app.OrgID, mock.Anything).Return(nil, nil).Maybe()
+	w.policyRepo.On("GetNamespacePolicy", mock.Anything, app.OrgID).Return(nil, nil).Maybe()
+}
+
+// readyDeployment returns a Deployment whose rollout has completed
+func readyDeployment(namespace, name string) *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1},
+	}
+}
+
+// newTestApplication returns an application in its own namespace
+func newTestApplication() *domain.Application {
+	return &domain.Application{ID: uuid.New(), OrgID: uuid.New(), ClusterID: uuid.New(), Name: "api", Namespace: "acme-api"}
+}
+
+// managedObject returns an object labelled as applied for an application
+func managedObject(app *domain.Application, apiVersion, kind, name string) *unstructured.Unstructured {
+	obj := newObjectRef(apiVersion, kind, app.Namespace, name)
+	obj.SetLabels(deployment.ManagedLabels(app.ID))
+	return obj
+}
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+
+	newDeployment := func(generation, observed int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		status.ObservedGeneration = observed
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     status,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		wantDone   bool
+		wantErr    bool
+	}{
+		{
+			name:       "spec not yet observed",
+			deployment: newDeployment(2, 1, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}),
+		},
+		{
+			name:       "replicas still updating",
+			deployment: newDeployment(2, 2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 3}),
+		},
+		{
+			name:       "old replicas terminating",
+			deployment: newDeployment(2, 2, appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3}),
+		},
+		{
+			name:       "updated replicas not available",
+			deployment: newDeployment(2, 2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2}),
+		},
+		{
+			name:       "rollout complete",
+			deployment: newDeployment(2, 2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3, ReadyReplicas: 3}),
+			wantDone:   true,
+		},
+		{
+			name: "progress deadline exceeded",
+			deployment: newDeployment(2, 2, appsv1.DeploymentStatus{
+				Replicas:        3,
+				UpdatedReplicas: 1,
+				Conditions: []appsv1.DeploymentCondition{{
+					Type:    appsv1.DeploymentProgressing,
+					Reason:  "ProgressDeadlineExceeded",
+					Message: `ReplicaSet "api-7d9f" has timed out progressing.`,
+				}},
+			}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := deploymentRolloutStatus(tt.deployment)
+			if tt.wantErr {
+				var failure *rolloutFailure
+				assert.True(t, errors.As(err, &failure), "a failed rollout is rolled back")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDone, done)
+		})
+	}
+}
+
+func TestStatefulSetRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+
+	newStatefulSet := func(generation, observed int64, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		status.ObservedGeneration = observed
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Generation: generation},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status:     status,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		statefulSet *appsv1.StatefulSet
+		wantDone    bool
+	}{
+		{
+			name:        "spec not yet observed",
+			statefulSet: newStatefulSet(2, 1, appsv1.StatefulSetStatus{UpdatedReplicas: 3, AvailableReplicas: 3}),
+		},
+		{
+			name: "replicas still updating",
+			statefulSet: newStatefulSet(2, 2, appsv1.StatefulSetStatus{
+				UpdatedReplicas: 1, AvailableReplicas: 3, CurrentRevision: "api-6b8c", UpdateRevision: "api-7d9f",
+			}),
+		},
+		{
+			name: "updated replicas not available",
+			statefulSet: newStatefulSet(2, 2, appsv1.StatefulSetStatus{
+				UpdatedReplicas: 3, AvailableReplicas: 2, CurrentRevision: "api-6b8c", UpdateRevision: "api-7d9f",
+			}),
+		},
+		{
+			name: "rollout complete",
+			statefulSet: newStatefulSet(2, 2, appsv1.StatefulSetStatus{
+				UpdatedReplicas: 3, AvailableReplicas: 3, ReadyReplicas: 3, CurrentRevision: "api-7d9f", UpdateRevision: "api-7d9f",
+			}),
+			wantDone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDone, statefulSetRolloutStatus(tt.statefulSet))
+		})
+	}
+}
+
+func TestNewDeploymentJobFromJob(t *testing.T) {
+	release := &domain.Release{ID: uuid.New(), AppID: uuid.New(), Image: "ghcr.io/acme/api", Tag: "v1.2.0"}
+
+	job, err := NewDeploymentJobFromJob(release.NewDeployJob(uuid.New()))
+	assert.NoError(t, err)
+	assert.Equal(t, release.ID, job.ReleaseID)
+	assert.Equal(t, release.AppID, job.AppID)
+	assert.Equal(t, "ghcr.io/acme/api", job.Image)
+	assert.Equal(t, "v1.2.0", job.Tag)
+
+	_, err = NewDeploymentJobFromJob(&domain.Job{Type: domain.JobTypeReleaseDeploy})
+	assert.Error(t, err)
+}
+
+func TestPodFailure(t *testing.T) {
+	newPod := func(statuses ...corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f-x2k4"},
+			Status:     corev1.PodStatus{ContainerStatuses: statuses},
+		}
+	}
+	waiting := func(reason string) corev1.ContainerState {
+		return corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}
+	}
+
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		wantReason string
+	}{
+		{
+			name: "starting",
+			pod:  newPod(corev1.ContainerStatus{Name: "api", State: waiting("ContainerCreating")}),
+		},
+		{
+			name: "restarted fewer times than allowed",
+			pod:  newPod(corev1.ContainerStatus{Name: "api", RestartCount: 2, State: waiting("CrashLoopBackOff")}),
+		},
+		{
+			name: "crash-looping",
+			pod: newPod(corev1.ContainerStatus{
+				Name:                 "api",
+				RestartCount:         3,
+				State:                waiting("CrashLoopBackOff"),
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+			}),
+			wantReason: `container "api" of pod "api-7d9f-x2k4" restarted 3 times (last terminated with Error, exit code 1)`,
+		},
+		{
+			name:       "image cannot be pulled",
+			pod:        newPod(corev1.ContainerStatus{Name: "api", State: waiting("ImagePullBackOff")}),
+			wantReason: "ImagePullBackOff",
+		},
+		{
+			name:       "missing secret or config map",
+			pod:        newPod(corev1.ContainerStatus{Name: "api", State: waiting("CreateContainerConfigError")}),
+			wantReason: "CreateContainerConfigError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := podFailure(tt.pod, 3)
+			if tt.wantReason == "" {
+				assert.Empty(t, reason)
+				return
+			}
+			assert.Contains(t, reason, tt.wantReason)
+		})
+	}
+}
+
+func TestCurrentReplicaSet(t *testing.T) {
+	controller := true
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			UID:         types.UID("deployment-uid"),
+			Annotations: map[string]string{annotationRevision: "3"},
+		},
+	}
+	newReplicaSet := func(name, revision string, ownerUID types.UID) appsv1.ReplicaSet {
+		return appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Annotations:     map[string]string{annotationRevision: revision},
+			OwnerReferences: []metav1.OwnerReference{{UID: ownerUID, Controller: &controller}},
+		}}
+	}
+
+	replicaSets := []appsv1.ReplicaSet{
+		newReplicaSet("api-old", "2", deployment.UID),
+		newReplicaSet("other-new", "3", types.UID("other-uid")),
+		newReplicaSet("api-new", "3", deployment.UID),
+	}
+
+	current := currentReplicaSet(deployment, replicaSets)
+	if assert.NotNil(t, current) {
+		assert.Equal(t, "api-new", current.Name)
+	}
+
+	assert.Nil(t, currentReplicaSet(deployment, replicaSets[:2]))
+}
+
+func TestFailRollout_QueuesRevert(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, newTestCluster())
+	app := newTestApplication()
+
+	previous := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v1", ImageDigest: "sha256:aaa", Status: domain.ReleaseStatusSucceeded}
+	require.NoError(t, previous.SetMeta(&domain.ReleaseMeta{CommitSHA: "4f7a9c2", SpecVersion: 3, SnapshotID: uuid.NewString(), GitOpsCommit: "9b1e0d4"}))
+	failed := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v2", CreatedBy: uuid.New(), Status: domain.ReleaseStatusRunning}
+
+	revert := &domain.Release{}
+	w.releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, app.ID, (*uuid.UUID)(nil), domain.ReleaseStatusSucceeded).Return(previous, nil)
+	w.releaseRepo.On("CreateRelease", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		*revert = *args.Get(1).(*domain.Release)
+		revert.ID = uuid.New()
+	}).Return(revert, nil)
+	w.jobRepo.On("CreateJob", ctx, mock.MatchedBy(func(job *domain.Job) bool {
+		return job.Type == domain.JobTypeReleaseDeploy && *job.Payload.ReleaseID == revert.ID
+	})).Return(&domain.Job{}, nil)
+
+	var failedMeta domain.ReleaseMeta
+	w.releaseRepo.On("UpdateReleaseMeta", ctx, failed.ID, mock.Anything).Run(func(args mock.Arguments) {
+		require.NoError(t, json.Unmarshal(args.Get(2).([]byte), &failedMeta))
+	}).Return(failed, nil)
+	w.releaseRepo.On("UpdateReleaseStatus", ctx, failed.ID, domain.ReleaseStatusRolledBack, (*time.Time)(nil), mock.Anything).Return(failed, nil)
+	w.releaseRepo.On("UpdateReleasePhase", ctx, failed.ID, domain.ReleasePhaseFailed).Return(failed, nil)
+
+	target := &rolloutTarget{releaseID: failed.ID, app: app, config: &deployment.DeploymentConfig{}, meta: &domain.ReleaseMeta{}, rollout: domain.DefaultDeploymentSpec().RolloutSettings()}
+	failure := &rolloutFailure{reason: `deployment "api" was not ready after 5m0s`}
+	err := w.failRollout(ctx, failed, target, fmt.Errorf("failed to deploy: %w", failure))
+	assert.ErrorIs(t, err, failure)
+
+	assert.Equal(t, domain.ReleaseStatusPending, revert.Status)
+	assert.Equal(t, "sha256:aaa", revert.ImageDigest, "the revert runs the image the last succeeded release ran")
+	revertMeta, err := revert.GetMeta()
+	require.NoError(t, err)
+	previousMeta, _ := previous.GetMeta()
+	assert.Equal(t, failed.ID.String(), revertMeta.RollbackOf)
+	assert.Equal(t, previousMeta.SnapshotID, revertMeta.SnapshotID, "the revert deploys the secrets the last succeeded release ran with")
+	assert.Equal(t, previousMeta.SpecVersion, revertMeta.SpecVersion)
+	assert.Empty(t, revertMeta.GitOpsCommit)
+
+	assert.Equal(t, revert.ID.String(), failedMeta.RolledBackTo)
+	assert.Equal(t, failure.reason, failedMeta.FailureReason)
+	w.releaseRepo.AssertExpectations(t)
+	w.jobRepo.AssertExpectations(t)
+}
+
+func TestFailRollout_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	failure := &rolloutFailure{reason: "pod api-7d9f-x2k4 is crash-looping"}
+
+	tests := []struct {
+		name   string
+		err    error
+		meta   *domain.ReleaseMeta
+		revert *domain.Release
+	}{
+		{name: "cluster unreachable", err: errors.New("failed to get cluster: connection refused"), meta: &domain.ReleaseMeta{}},
+		{name: "rollback of a rollback", err: failure, meta: &domain.ReleaseMeta{RollbackOf: uuid.NewString()}},
+		{name: "nothing to roll back to", err: failure, meta: &domain.ReleaseMeta{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorker(t, newTestCluster())
+			release := &domain.Release{ID: uuid.New(), AppID: app.ID, Status: domain.ReleaseStatusRunning}
+
+			w.releaseRepo.On("GetLatestReleaseByAppIDAndStatus", ctx, app.ID, (*uuid.UUID)(nil), domain.ReleaseStatusSucceeded).Return(nil, nil).Maybe()
+			w.releaseRepo.On("UpdateReleaseStatus", ctx, release.ID, domain.ReleaseStatusFailed, (*time.Time)(nil), mock.Anything).Return(release, nil).Once()
+			w.releaseRepo.On("UpdateReleasePhase", ctx, release.ID, domain.ReleasePhaseFailed).Return(release, nil).Once()
+
+			target := &rolloutTarget{releaseID: release.ID, app: app, config: &deployment.DeploymentConfig{}, meta: tt.meta, rollout: domain.DefaultDeploymentSpec().RolloutSettings()}
+			err := w.failRollout(ctx, release, target, tt.err)
+			assert.Equal(t, tt.err, err)
+
+			w.releaseRepo.AssertExpectations(t)
+			w.releaseRepo.AssertNotCalled(t, "CreateRelease", mock.Anything, mock.Anything)
+			w.releaseRepo.AssertNotCalled(t, "UpdateReleaseMeta", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProcessPromotion(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	canary := managedObject(app, "apps/v1", "Deployment", "api-canary")
+	cluster := newTestCluster(canary, readyDeployment(app.Namespace, "api"))
+	w := newTestWorker(t, cluster)
+	w.withApplication(t, app)
+
+	release := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v2", ImageDigest: "sha256:bbb", Status: domain.ReleaseStatusRunning, Phase: domain.ReleasePhasePromoting}
+	require.NoError(t, release.SetMeta(&domain.ReleaseMeta{}))
+
+	snapshot := &domain.ReleaseSnapshot{ID: uuid.New(), AppID: app.ID}
+	w.releaseRepo.On("GetReleaseByID", ctx, release.ID).Return(release, nil)
+	w.snapshotRepo.On("CreateReleaseSnapshot", ctx, app.ID, mock.Anything).Return(snapshot, nil).Once()
+	var meta domain.ReleaseMeta
+	w.releaseRepo.On("UpdateReleaseMeta", ctx, release.ID, mock.Anything).Run(func(args mock.Arguments) {
+		require.NoError(t, json.Unmarshal(args.Get(2).([]byte), &meta))
+	}).Return(release, nil)
+	w.releaseRepo.On("UpdateReleaseStatus", ctx, release.ID, domain.ReleaseStatusSucceeded, (*time.Time)(nil), mock.Anything).Return(release, nil)
+	w.releaseRepo.On("UpdateReleasePhase", ctx, release.ID, domain.ReleasePhaseCompleted).Return(release, nil)
+
+	require.NoError(t, w.ProcessPromotion(ctx, &DeploymentJob{ReleaseID: release.ID, AppID: app.ID}))
+
+	assert.Equal(t, snapshot.ID.String(), meta.SnapshotID, "the promoted release is pinned to what it was rolled out with")
+	stable := cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api")
+	require.NotNil(t, stable)
+	containers, _, _ := unstructured.NestedSlice(stable.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "ghcr.io/acme/api@sha256:bbb", containers[0].(map[string]interface{})["image"])
+	assert.Nil(t, cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api-canary"), "the canary is pruned")
+	w.releaseRepo.AssertExpectations(t)
+}
+
+func TestProcessAbort(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	stable := managedObject(app, "apps/v1", "Deployment", "api")
+	cluster := newTestCluster(
+		stable,
+		managedObject(app, "apps/v1", "Deployment", "api-canary"),
+		managedObject(app, "v1", "Service", "api-canary-service"),
+		managedObject(app, "networking.k8s.io/v1", "Ingress", "api-canary-ingress"),
+	)
+	w := newTestWorker(t, cluster)
+	w.withApplication(t, app, "api.example.com")
+
+	release := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v2", Status: domain.ReleaseStatusRunning, Phase: domain.ReleasePhaseAborting}
+	require.NoError(t, release.SetMeta(&domain.ReleaseMeta{}))
+
+	w.releaseRepo.On("GetReleaseByID", ctx, release.ID).Return(release, nil)
+	w.releaseRepo.On("UpdateReleaseStatus", ctx, release.ID, domain.ReleaseStatusFailed, (*time.Time)(nil), mock.Anything).Return(release, nil)
+	w.releaseRepo.On("UpdateReleasePhase", ctx, release.ID, domain.ReleasePhaseAborted).Return(release, nil)
+
+	require.NoError(t, w.ProcessAbort(ctx, &DeploymentJob{ReleaseID: release.ID, AppID: app.ID}))
+
+	assert.Nil(t, cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api-canary"))
+	assert.Nil(t, cluster.get(t, "v1", "Service", app.Namespace, "api-canary-service"))
+	assert.Nil(t, cluster.get(t, "networking.k8s.io/v1", "Ingress", app.Namespace, "api-canary-ingress"))
+	assert.NotNil(t, cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api"), "the stable release keeps serving")
+	w.releaseRepo.AssertExpectations(t)
+	w.snapshotRepo.AssertNotCalled(t, "CreateReleaseSnapshot", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessAbort_ClusterUnreachable(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	w := newTestWorker(t, newTestCluster())
+
+	release := &domain.Release{ID: uuid.New(), AppID: app.ID, Status: domain.ReleaseStatusRunning, Phase: domain.ReleasePhaseAborting}
+	w.releaseRepo.On("GetReleaseByID", ctx, release.ID).Return(release, nil)
+	w.appRepo.On("GetApplicationByID", ctx, app.ID).Return(app, nil)
+	w.clusterRepo.On("GetClusterByID", ctx, app.ClusterID).Return(nil, errors.New("connection refused"))
+	w.releaseRepo.On("UpdateReleasePhase", ctx, release.ID, domain.ReleasePhaseCanary).Return(release, nil)
+
+	err := w.ProcessAbort(ctx, &DeploymentJob{ReleaseID: release.ID, AppID: app.ID})
+	assert.ErrorContains(t, err, "failed to abort canary")
+
+	// The canary may still be serving, so the abort can be retried
+	w.releaseRepo.AssertExpectations(t)
+	w.releaseRepo.AssertNotCalled(t, "UpdateReleaseStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,237 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// driftRender is the deploy source a drift check rendered for a release
+type driftRender struct {
+	commit      string
+	specVersion int
+	objects     []*unstructured.Unstructured
+}
+
+// runDriftReconciler reconciles drift every driftInterval until the worker stops
+func (w *DeploymentWorker) runDriftReconciler(ctx context.Context) {
+	ticker := time.NewTicker(w.driftInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			if err := w.reconcileDrift(ctx); err != nil {
+				w.logger.Error("Failed to reconcile release drift", zap.Error(err))
+			}
+		}
+	}
+}
+
+// reconcileDrift checks the current release of each environment for drift and records it
+func (w *DeploymentWorker) reconcileDrift(ctx context.Context) error {
+	releases, err := w.releaseRepo.GetCurrentReleases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current releases: %w", err)
+	}
+
+	current := make(map[uuid.UUID]bool, len(releases))
+	for i := range releases {
+		current[releases[i].ID] = true
+
+		checkCtx, cancel := context.WithTimeout(ctx, w.driftCheckTimeout)
+		drift := w.checkDrift(checkCtx, &releases[i])
+		cancel()
+		if drift == nil {
+			continue
+		}
+		if err := w.driftRepo.UpsertReleaseDrift(ctx, drift); err != nil {
+			w.logger.Error("Failed to record release drift", zap.Error(err), zap.String("release_id", drift.ReleaseID.String()))
+		}
+	}
+
+	for releaseID := range w.driftRenders {
+		if !current[releaseID] {
+			delete(w.driftRenders, releaseID)
+		}
+	}
+	return nil
+}
+
+// checkDrift compares a release as it was last rolled out with the live objects, or returns nil
+func (w *DeploymentWorker) checkDrift(ctx context.Context, release *domain.Release) *domain.ReleaseDrift {
+	drift := &domain.ReleaseDrift{
+		ReleaseID:     release.ID,
+		AppID:         release.AppID,
+		EnvironmentID: release.EnvironmentID,
+		Status:        domain.DriftStatusInSync,
+	}
+	unknown := func(err error) *domain.ReleaseDrift {
+		w.logger.Warn("Failed to check release drift", zap.Error(err), zap.String("release_id", release.ID.String()))
+		drift.Status = domain.DriftStatusUnknown
+		drift.Error = err.Error()
+		return drift
+	}
+
+	target, err := w.prepareCheck(ctx, release)
+	if err != nil {
+		return unknown(err)
+	}
+	if target.gitops != nil && !target.gitops.AppliesRelease() {
+		return nil
+	}
+	target.config.ImageDigest = release.ImageDigest
+
+	if strategyType(target.config) == domain.StrategyBlueGreen {
+		target.config.Slot, err = w.servingSlot(ctx, target)
+		if err != nil {
+			return unknown(err)
+		}
+	}
+
+	cached := w.driftRenders[release.ID]
+	if cached != nil && cached.commit == target.meta.CommitSHA && cached.specVersion == target.meta.SpecVersion {
+		target.rendered = cached.objects
+	}
+	objects, err := w.prepareObjects(ctx, target)
+	if err != nil {
+		return unknown(err)
+	}
+	if target.rendered != nil {
+		w.driftRenders[release.ID] = &driftRender{commit: target.meta.CommitSHA, specVersion: target.meta.SpecVersion, objects: target.rendered}
+	}
+
+	for _, obj := range objects {
+		var objectDrift *domain.ObjectDrift
+		if deployment.AutoscaledDeployment(target.config, obj) {
+			objectDrift, err = target.applier.DetectAutoscaledDrift(ctx, obj)
+		} else {
+			objectDrift, err = target.applier.DetectDrift(ctx, obj)
+		}
+		if err != nil {
+			return unknown(err)
+		}
+		if objectDrift != nil {
+			drift.Objects = append(drift.Objects, *objectDrift)
+		}
+	}
+	if len(drift.Objects) == 0 {
+		return drift
+	}
+
+	now := time.Now()
+	drift.Status = domain.DriftStatusDrifted
+	drift.DriftedSince = &now
+	w.logger.Warn("Release drifted from its cluster",
+		zap.String("release_id", release.ID.String()),
+		zap.String("app_name", target.app.Name),
+		zap.String("namespace", target.config.Namespace),
+		zap.Int("objects", len(drift.Objects)),
+	)
+
+	if target.rollout.AutoHeal {
+		w.applyMu.Lock()
+		defer w.applyMu.Unlock()
+
+		// A rollout or teardown may have run since the check started, which healing would undo
+		latest, err := w.releaseRepo.GetLatestReleaseByEnvironment(ctx, release.AppID, release.EnvironmentID)
+		if err != nil {
+			return unknown(fmt.Errorf("failed to get latest release: %w", err))
+		}
+		if latest == nil || latest.ID != release.ID || latest.Status != domain.ReleaseStatusSucceeded {
+			return nil
+		}
+
+		if err := w.healDrift(ctx, target, driftedObjects(objects, drift.Objects)); err != nil {
+			w.logger.Error("Failed to heal release drift", zap.Error(err), zap.String("release_id", release.ID.String()))
+			drift.Error = fmt.Sprintf("failed to heal drift: %v", err)
+			return drift
+		}
+		drift.Status = domain.DriftStatusInSync
+		drift.Healed = drift.Objects
+		drift.HealedAt = &now
+		drift.Objects = nil
+		drift.DriftedSince = nil
+	}
+	return drift
+}
+
+// healDrift re-applies the objects of a release that drifted, recreating the namespace first if
+// it was deleted
+func (w *DeploymentWorker) healDrift(ctx context.Context, target *rolloutTarget, objects []*unstructured.Unstructured) error {
+	if err := w.ensureNamespace(ctx, target, target.config.Namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+	for _, obj := range objects {
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+
+	w.logger.Info("Healed release drift",
+		zap.String("release_id", target.releaseID.String()),
+		zap.String("app_name", target.app.Name),
+		zap.Int("objects", len(objects)),
+	)
+	return nil
+}
+
+// servingSlot returns the blue/green slot a release runs in
+func (w *DeploymentWorker) servingSlot(ctx context.Context, target *rolloutTarget) (string, error) {
+	slot, err := w.activeSlot(ctx, target)
+	if err != nil || slot != "" {
+		return slot, err
+	}
+
+	for _, candidate := range []string{deployment.SlotBlue, deployment.SlotGreen} {
+		config := *target.config
+		config.Slot = candidate
+		rendered, err := w.deployer.BuildDeployment(&config)
+		if err != nil {
+			return "", err
+		}
+
+		live, err := target.clientset.AppsV1().Deployments(config.Namespace).Get(ctx, rendered.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", fmt.Errorf("failed to get deployment: %w", err)
+		}
+		containers := live.Spec.Template.Spec.Containers
+		if len(containers) > 0 && containers[0].Image == rendered.Spec.Template.Spec.Containers[0].Image {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("cannot tell which blue/green slot runs the release: its Service does not route to a slot and no slot runs its image")
+}
+
+// driftedObjects returns the objects of a release that drifted
+func driftedObjects(objects []*unstructured.Unstructured, drifted []domain.ObjectDrift) []*unstructured.Unstructured {
+	isDrifted := make(map[string]bool, len(drifted))
+	for _, d := range drifted {
+		isDrifted[d.Kind+"/"+d.Name] = true
+	}
+
+	var result []*unstructured.Unstructured
+	for _, obj := range objects {
+		if isDrifted[obj.GetKind()+"/"+obj.GetName()] {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// pinnedRelease returns a succeeded release of an application pinned to the given spec version and
+// to a snapshot of what it was rolled out with
+func (w *testWorker) pinnedRelease(t *testing.T, app *domain.Application, specVersion int) *domain.Release {
+	ctx := context.Background()
+	stored := &domain.ReleaseSnapshot{ID: uuid.New(), AppID: app.ID}
+	w.snapshotRepo.On("CreateReleaseSnapshot", ctx, app.ID, mock.Anything).Run(func(args mock.Arguments) {
+		stored.DataEncrypted = args.Get(2).([]byte)
+	}).Return(stored, nil).Once()
+	w.snapshotRepo.On("GetReleaseSnapshotByID", mock.Anything, stored.ID).Return(stored, nil)
+
+	meta := &domain.ReleaseMeta{SpecVersion: specVersion}
+	_, err := w.desired.Record(ctx, app, meta, &deployment.DeploymentConfig{}, nil)
+	require.NoError(t, err)
+
+	release := &domain.Release{ID: uuid.New(), AppID: app.ID, Image: "ghcr.io/acme/api", Tag: "v1", Status: domain.ReleaseStatusSucceeded}
+	require.NoError(t, release.SetMeta(meta))
+	return release
+}
+
+func TestDriftedObjects(t *testing.T) {
+	deploymentObj := newObjectRef("apps/v1", "Deployment", "api", "api")
+	serviceObj := newObjectRef("v1", "Service", "api", "api")
+	configMapObj := newObjectRef("v1", "ConfigMap", "api", "api-config")
+
+	drifted := driftedObjects([]*unstructured.Unstructured{deploymentObj, serviceObj, configMapObj}, []domain.ObjectDrift{
+		{Kind: "Service", Name: "api", Missing: true},
+		{Kind: "Deployment", Name: "api", Fields: []domain.FieldDrift{{Path: "spec.replicas", Live: int64(5), Desired: int64(3)}}},
+	})
+
+	assert.Equal(t, []*unstructured.Unstructured{deploymentObj, serviceObj}, drifted, "objects are kept in apply order")
+}
+
+func TestCheckDrift(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	cluster := newTestCluster()
+	w := newTestWorker(t, cluster)
+	w.withApplication(t, app)
+	w.specRepo.On("GetApplicationSpecByVersion", ctx, app.ID, 1).Return(&domain.ApplicationSpec{Version: 1, Spec: domain.DefaultDeploymentSpec()}, nil)
+	release := w.pinnedRelease(t, app, 1)
+
+	drift := w.checkDrift(ctx, release)
+	require.NotNil(t, drift)
+	assert.Equal(t, domain.DriftStatusDrifted, drift.Status)
+	assert.NotNil(t, drift.DriftedSince)
+	require.NotEmpty(t, drift.Objects)
+	for _, object := range drift.Objects {
+		assert.True(t, object.Missing, "%s/%s was never applied", object.Kind, object.Name)
+	}
+	assert.Contains(t, drift.Objects, domain.ObjectDrift{Kind: "Deployment", Name: "api", Namespace: app.Namespace, Missing: true})
+	assert.Nil(t, cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api"), "drift is not healed without auto_heal")
+	w.releaseRepo.AssertNotCalled(t, "GetLatestReleaseByEnvironment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckDrift_AutoHeal(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApplication()
+	cluster := newTestCluster()
+	w := newTestWorker(t, cluster)
+	w.withApplication(t, app)
+
+	spec := domain.DefaultDeploymentSpec()
+	spec.Rollout = &domain.RolloutSpec{AutoHeal: true}
+	w.specRepo.On("GetApplicationSpecByVersion", ctx, app.ID, 2).Return(&domain.ApplicationSpec{Version: 2, Spec: spec}, nil)
+	release := w.pinnedRelease(t, app, 2)
+	latest := w.releaseRepo.On("GetLatestReleaseByEnvironment", ctx, app.ID, (*uuid.UUID)(nil)).Return(release, nil)
+
+	drift := w.checkDrift(ctx, release)
+	require.NotNil(t, drift)
+	assert.Equal(t, domain.DriftStatusInSync, drift.Status)
+	assert.Empty(t, drift.Objects)
+	assert.NotEmpty(t, drift.Healed)
+	assert.NotNil(t, drift.HealedAt)
+	live := cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api")
+	require.NotNil(t, live, "the deleted Deployment is applied again")
+	_, err := cluster.clientset.CoreV1().Namespaces().Get(ctx, app.Namespace, metav1.GetOptions{})
+	assert.NoError(t, err, "the deleted namespace is created again")
+
+	drift = w.checkDrift(ctx, release)
+	require.NotNil(t, drift)
+	assert.Equal(t, domain.DriftStatusInSync, drift.Status)
+	assert.Empty(t, drift.Healed, "a release in sync is not applied again")
+
+	deployments := cluster.dynamic.Resource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).Namespace(app.Namespace)
+	require.NoError(t, unstructured.SetNestedField(live.Object, int64(5), "spec", "replicas"))
+	_, err = deployments.Update(ctx, live, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	drift = w.checkDrift(ctx, release)
+	require.NotNil(t, drift)
+	assert.Equal(t, domain.DriftStatusInSync, drift.Status)
+	require.Len(t, drift.Healed, 1)
+	assert.Equal(t, "Deployment", drift.Healed[0].Kind)
+	assert.Equal(t, []domain.FieldDrift{{Path: "spec.replicas", Live: int64(5), Desired: int64(1)}}, drift.Healed[0].Fields)
+	replicas, _, _ := unstructured.NestedInt64(cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api").Object, "spec", "replicas")
+	assert.Equal(t, int64(1), replicas, "the scaled Deployment is reverted")
+
+	// A release rolled out since the check started is not undone
+	latest.Unset()
+	w.releaseRepo.On("GetLatestReleaseByEnvironment", ctx, app.ID, (*uuid.UUID)(nil)).Return(&domain.Release{ID: uuid.New(), Status: domain.ReleaseStatusRunning}, nil)
+	_, err = deployments.Update(ctx, live, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Nil(t, w.checkDrift(ctx, release))
+	w.releaseRepo.AssertExpectations(t)
+}
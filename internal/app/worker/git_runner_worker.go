@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/PouryDev/oneclick/internal/app/crypto"
 	"github.com/PouryDev/oneclick/internal/app/provisioner"
+	"github.com/PouryDev/oneclick/internal/app/services"
 	"github.com/PouryDev/oneclick/internal/domain"
 	"github.com/PouryDev/oneclick/internal/repo"
 )
@@ -29,6 +31,9 @@ type GitRunnerWorker struct {
 	domainRepo         repo.DomainRepository
 	pipelineRepo       repo.PipelineRepository
 	pipelineStepRepo   repo.PipelineStepRepository
+	releaseRepo        repo.ReleaseRepository
+	envRepo            repo.EnvironmentRepository
+	specRepo           repo.ApplicationSpecRepository
 	provisioner        provisioner.Provisioner
 	crypto             *crypto.Crypto
 	logger             *zap.Logger
@@ -45,6 +50,9 @@ func NewGitRunnerWorker(
 	domainRepo repo.DomainRepository,
 	pipelineRepo repo.PipelineRepository,
 	pipelineStepRepo repo.PipelineStepRepository,
+	releaseRepo repo.ReleaseRepository,
+	envRepo repo.EnvironmentRepository,
+	specRepo repo.ApplicationSpecRepository,
 	provisioner provisioner.Provisioner,
 	crypto *crypto.Crypto,
 	logger *zap.Logger,
@@ -57,6 +65,9 @@ func NewGitRunnerWorker(
 		domainRepo:         domainRepo,
 		pipelineRepo:       pipelineRepo,
 		pipelineStepRepo:   pipelineStepRepo,
+		releaseRepo:        releaseRepo,
+		envRepo:            envRepo,
+		specRepo:           specRepo,
 		provisioner:        provisioner,
 		crypto:             crypto,
 		logger:             logger,
@@ -111,6 +122,11 @@ func (w *GitRunnerWorker) processPendingJobs(ctx context.Context) error {
 	w.logger.Info("Processing pending jobs", zap.Int("count", len(jobs)))
 
 	for _, job := range jobs {
+		// Release rollouts are consumed by the DeploymentWorker
+		if domain.IsReleaseJobType(job.Type) {
+			continue
+		}
+
 		// Try to start the job (atomic operation)
 		startedJob, err := w.jobRepo.StartJob(ctx, job.ID)
 		if err != nil {
@@ -718,10 +734,14 @@ func (w *GitRunnerWorker) processPipelineRun(ctx context.Context, job *domain.Jo
 	w.logger.Info("Processing pipeline run", zap.String("pipelineID", pipelineID.String()))
 
 	// Get pipeline from database
-	_, err := w.pipelineRepo.GetPipelineByID(ctx, pipelineID)
+	pipeline, err := w.pipelineRepo.GetPipelineByID(ctx, pipelineID)
 	if err != nil {
 		return fmt.Errorf("failed to get pipeline: %w", err)
 	}
+	if pipeline == nil {
+		return fmt.Errorf("pipeline not found: %s", pipelineID.String())
+	}
+	event, _ := pipeline.Meta["event"].(string)
 
 	// Update pipeline status to running
 	now := time.Now()
@@ -730,8 +750,12 @@ func (w *GitRunnerWorker) processPipelineRun(ctx context.Context, job *domain.Jo
 		return fmt.Errorf("failed to update pipeline status: %w", err)
 	}
 
-	// Create pipeline steps (simulated for MVP)
+	// Create pipeline steps (simulated for MVP). Pull request pipelines only validate
+	// the change, and tag pipelines hand off to a release instead of deploying directly.
 	steps := []string{"checkout", "build", "test", "deploy"}
+	if event == string(domain.PipelineEventPullRequest) || event == string(domain.PipelineEventTag) {
+		steps = []string{"checkout", "build", "test"}
+	}
 	var stepIDs []uuid.UUID
 
 	for _, stepName := range steps {
@@ -811,6 +835,13 @@ func (w *GitRunnerWorker) processPipelineRun(ctx context.Context, job *domain.Jo
 		pipelineStatus = domain.PipelineStatusFailed
 	}
 
+	if pipelineStatus == domain.PipelineStatusSuccess && event == string(domain.PipelineEventTag) {
+		if err := w.createTagRelease(ctx, job.OrgID, pipeline); err != nil {
+			w.logger.Error("Failed to create release for tag pipeline", zap.Error(err), zap.String("pipelineID", pipelineID.String()))
+			pipelineStatus = domain.PipelineStatusFailed
+		}
+	}
+
 	// Update pipeline status to finished
 	finished := time.Now()
 	_, err = w.pipelineRepo.UpdatePipelineFinished(ctx, pipelineID, pipelineStatus, &finished)
@@ -835,3 +866,73 @@ func (w *GitRunnerWorker) processPipelineRun(ctx context.Context, job *domain.Jo
 
 	return nil
 }
+
+// createTagRelease creates a release of the pipeline's application for the built tag
+func (w *GitRunnerWorker) createTagRelease(ctx context.Context, orgID uuid.UUID, pipeline *domain.Pipeline) error {
+	ref, _ := pipeline.Meta["ref"].(string)
+	tag := strings.TrimPrefix(ref, "refs/tags/")
+
+	envs, err := w.envRepo.GetEnvironmentsByAppID(ctx, pipeline.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to get environments: %w", err)
+	}
+	var environmentID *uuid.UUID
+	if len(envs) > 0 {
+		environmentID = &envs[0].ID
+	}
+
+	latestRelease, err := w.releaseRepo.GetLatestReleaseByAppIDAndStatus(ctx, pipeline.AppID, environmentID, domain.ReleaseStatusSucceeded)
+	if err != nil {
+		return fmt.Errorf("failed to get latest succeeded release: %w", err)
+	}
+	if latestRelease == nil {
+		w.logger.Warn("Skipping tag release, application has no succeeded release to take the image from",
+			zap.String("pipelineID", pipeline.ID.String()),
+			zap.String("appID", pipeline.AppID.String()),
+			zap.String("tag", tag))
+		return nil
+	}
+
+	if err := services.CheckNoCanaryInProgress(ctx, w.releaseRepo, pipeline.AppID, environmentID); err != nil {
+		return err
+	}
+
+	spec, err := w.specRepo.GetLatestApplicationSpec(ctx, pipeline.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to get application spec: %w", err)
+	}
+	meta := &domain.ReleaseMeta{
+		CommitSHA:  pipeline.CommitSHA,
+		PipelineID: pipeline.ID.String(),
+	}
+	if spec != nil {
+		meta.SpecVersion = spec.Version
+	}
+
+	release := &domain.Release{
+		AppID:         pipeline.AppID,
+		EnvironmentID: environmentID,
+		Image:         latestRelease.Image,
+		Tag:           tag,
+		CreatedBy:     pipeline.TriggeredBy,
+		Status:        domain.ReleaseStatusPending,
+	}
+	if err := release.SetMeta(meta); err != nil {
+		return fmt.Errorf("failed to set release meta: %w", err)
+	}
+
+	createdRelease, err := w.releaseRepo.CreateRelease(ctx, release)
+	if err != nil {
+		return fmt.Errorf("failed to create release: %w", err)
+	}
+
+	if err := services.QueueDeployment(ctx, w.jobRepo, w.releaseRepo, orgID, createdRelease); err != nil {
+		return err
+	}
+
+	w.logger.Info("Created release for tag",
+		zap.String("pipelineID", pipeline.ID.String()),
+		zap.String("releaseID", createdRelease.ID.String()),
+		zap.String("tag", tag))
+	return nil
+}
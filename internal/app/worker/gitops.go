@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/source"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// commitRelease rolls a release out through its GitOps repository
+func (w *DeploymentWorker) commitRelease(ctx context.Context, release *domain.Release, target *rolloutTarget) error {
+	objects, err := w.prepareObjects(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ensureNamespace(ctx, target, target.config.Namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+	for _, obj := range objects {
+		if deployment.Exported(obj) {
+			continue
+		}
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+
+	return w.publishManifests(ctx, release, target, objects)
+}
+
+// exportRelease commits the manifests of a rolled out release to its GitOps repository, if any
+func (w *DeploymentWorker) exportRelease(ctx context.Context, release *domain.Release, target *rolloutTarget) {
+	if target.gitops == nil {
+		return
+	}
+
+	objects, err := w.prepareObjects(ctx, target)
+	if err == nil {
+		err = w.publishManifests(ctx, release, target, objects)
+	}
+	if err == nil {
+		return
+	}
+
+	w.logger.Warn("Failed to commit release to GitOps repository", zap.Error(err),
+		zap.String("release_id", release.ID.String()),
+	)
+	w.publish(release.ID, domain.ReleaseProgressEvent{
+		Type:    domain.ReleaseProgressWarning,
+		Reason:  "GitOpsCommitFailed",
+		Message: err.Error(),
+	})
+}
+
+// publishManifests commits the manifests of a release and records the commit on it
+func (w *DeploymentWorker) publishManifests(ctx context.Context, release *domain.Release, target *rolloutTarget, objects []*unstructured.Unstructured) error {
+	w.setPhase(ctx, release.ID, domain.ReleasePhasePublishing)
+
+	files, err := deployment.ExportManifests(objects)
+	if err != nil {
+		return err
+	}
+
+	repository, access, err := w.desired.RepositoryAccess(ctx, target.gitops.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get gitops repository: %w", err)
+	}
+	if repository.OrgID != target.app.OrgID {
+		return errors.New("gitops repository does not belong to the application's organization")
+	}
+	branch := target.gitops.Branch
+	if branch == "" {
+		branch = repository.DefaultBranch
+	}
+
+	commit, err := w.publisher.Publish(ctx, &source.Publication{
+		Repository: access,
+		Branch:     branch,
+		Path:       gitOpsPath(target.gitops, target.environment),
+		Files:      files,
+		Message:    gitOpsCommitMessage(release, target),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit manifests: %w", err)
+	}
+
+	target.meta.GitOpsCommit = commit
+	if err := w.updateMeta(ctx, target); err != nil {
+		return err
+	}
+	w.publish(release.ID, domain.ReleaseProgressEvent{Type: domain.ReleaseProgressGitOpsCommit, Message: commit})
+	w.logger.Info("Committed release manifests",
+		zap.String("release_id", release.ID.String()),
+		zap.String("repository", repository.URL),
+		zap.String("branch", branch),
+		zap.String("commit", commit),
+		zap.Int("files", len(files)),
+	)
+	return nil
+}
+
+// gitOpsPath returns the directory of a GitOps repository a release's manifests are committed to
+func gitOpsPath(spec *domain.GitOpsSpec, environment string) string {
+	return path.Join(spec.Path, environment)
+}
+
+// gitOpsCommitMessage describes the release a GitOps commit publishes
+func gitOpsCommitMessage(release *domain.Release, target *rolloutTarget) string {
+	var message strings.Builder
+	fmt.Fprintf(&message, "Deploy %s %s", target.app.Name, release.Tag)
+	if target.environment != "" {
+		fmt.Fprintf(&message, " to %s", target.environment)
+	}
+	fmt.Fprintf(&message, "\n\nRelease: %s\nImage: %s", release.ID, release.Image)
+	if release.ImageDigest != "" {
+		fmt.Fprintf(&message, "@%s", release.ImageDigest)
+	}
+	message.WriteString("\n")
+	if target.meta.CommitSHA != "" {
+		fmt.Fprintf(&message, "Commit: %s\n", target.meta.CommitSHA)
+	}
+	return message.String()
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestGitOpsPath(t *testing.T) {
+	spec := &domain.GitOpsSpec{Path: "clusters/prod/api/"}
+	assert.Equal(t, "clusters/prod/api", gitOpsPath(spec, ""))
+	assert.Equal(t, "clusters/prod/api/staging", gitOpsPath(spec, "staging"))
+}
+
+func TestGitOpsCommitMessage(t *testing.T) {
+	releaseID := uuid.MustParse("7d3f0c1e-5b2a-4c6d-9e8f-0a1b2c3d4e5f")
+	release := &domain.Release{ID: releaseID, Image: "ghcr.io/acme/api", Tag: "v2", ImageDigest: "sha256:abc"}
+	target := &rolloutTarget{
+		app:         &domain.Application{Name: "api"},
+		meta:        &domain.ReleaseMeta{CommitSHA: "4f7a9c2"},
+		environment: "production",
+	}
+
+	assert.Equal(t, "Deploy api v2 to production\n\n"+
+		"Release: 7d3f0c1e-5b2a-4c6d-9e8f-0a1b2c3d4e5f\n"+
+		"Image: ghcr.io/acme/api@sha256:abc\n"+
+		"Commit: 4f7a9c2\n", gitOpsCommitMessage(release, target))
+
+	target.meta.CommitSHA = ""
+	target.environment = ""
+	assert.Equal(t, "Deploy api v2\n\n"+
+		"Release: 7d3f0c1e-5b2a-4c6d-9e8f-0a1b2c3d4e5f\n"+
+		"Image: ghcr.io/acme/api@sha256:abc\n", gitOpsCommitMessage(release, target))
+
+	release.ImageDigest = ""
+	assert.Equal(t, "Deploy api v2\n\n"+
+		"Release: 7d3f0c1e-5b2a-4c6d-9e8f-0a1b2c3d4e5f\n"+
+		"Image: ghcr.io/acme/api\n", gitOpsCommitMessage(release, target), "a release without a digest names only the image")
+}
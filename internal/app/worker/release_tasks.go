@@ -0,0 +1,404 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/app/rollout"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+// maxTaskLogBytes is how much of a task's output is captured; output beyond it is dropped
+const maxTaskLogBytes = 1 << 20
+
+// taskTimeoutSlack is how long past its timeout a task's Job is waited for, so that Kubernetes,
+// which enforces the timeout, gets to report it
+const taskTimeoutSlack = time.Minute
+
+// ProcessTask runs an ad-hoc command queued with POST /apps/:appId/run, with the image,
+// environment and secrets of the release it was queued against
+func (w *DeploymentWorker) ProcessTask(ctx context.Context, job *domain.Job) error {
+	taskIDStr, _ := job.Payload.Config["task_id"].(string)
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid task ID in release task job: %w", err)
+	}
+
+	task, err := w.taskRepo.GetReleaseTaskByID(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to get release task: %w", err)
+	}
+	if task == nil {
+		return fmt.Errorf("release task not found")
+	}
+	if task.IsCompleted() {
+		w.logger.Warn("Skipping completed release task",
+			zap.String("task_id", task.ID.String()),
+			zap.String("status", string(task.Status)),
+		)
+		return nil
+	}
+
+	// Job payloads are decoded from JSON, so numbers are float64
+	timeoutSeconds := int64(domain.DefaultReleaseTaskTimeoutSeconds)
+	if seconds, ok := job.Payload.Config["timeout_seconds"].(float64); ok && seconds > 0 {
+		timeoutSeconds = int64(seconds)
+	}
+
+	target, err := w.prepareTaskTarget(ctx, task.ReleaseID)
+	if err != nil {
+		if _, finishErr := w.taskRepo.FinishReleaseTask(ctx, task.ID, domain.ReleaseTaskStatusFailed, nil, err.Error(), ""); finishErr != nil {
+			w.logger.Error("Failed to update release task", zap.Error(finishErr), zap.String("task_id", task.ID.String()))
+		}
+		return err
+	}
+
+	task, err = w.executeTask(ctx, target, task, &deployment.TaskConfig{
+		Name:           taskName(task),
+		Command:        task.Command,
+		TimeoutSeconds: timeoutSeconds,
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	w.logger.Info("Release task finished",
+		zap.String("task_id", task.ID.String()),
+		zap.String("status", string(task.Status)),
+	)
+	return nil
+}
+
+// prepareTaskTarget connects to the cluster of the release an ad-hoc task runs with
+func (w *DeploymentWorker) prepareTaskTarget(ctx context.Context, releaseID uuid.UUID) (*rolloutTarget, error) {
+	release, err := w.releaseRepo.GetReleaseByID(ctx, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+	if release == nil {
+		return nil, fmt.Errorf("release not found")
+	}
+
+	target, err := w.prepareCheck(ctx, release)
+	if errors.Is(err, rollout.ErrNotRecorded) {
+		return w.prepareRollout(ctx, release)
+	}
+	return target, err
+}
+
+// runPreDeploy runs a release's pre-deploy task
+func (w *DeploymentWorker) runPreDeploy(ctx context.Context, release *domain.Release, target *rolloutTarget) error {
+	task, err := w.runReleaseTask(ctx, release, target, domain.ReleaseTaskPreDeploy, target.preDeploy)
+	if err != nil {
+		return fmt.Errorf("failed to run pre-deploy task: %w", err)
+	}
+	if task.Status != domain.ReleaseTaskStatusSucceeded {
+		return fmt.Errorf("pre-deploy task failed: %s", task.FailureReason)
+	}
+	return nil
+}
+
+// runPostDeploy runs a release's post-deploy task, if it has one
+func (w *DeploymentWorker) runPostDeploy(ctx context.Context, release *domain.Release, target *rolloutTarget) {
+	if target.postDeploy == nil {
+		return
+	}
+
+	w.setPhase(ctx, release.ID, domain.ReleasePhasePostDeploy)
+	task, err := w.runReleaseTask(ctx, release, target, domain.ReleaseTaskPostDeploy, target.postDeploy)
+	reason := ""
+	switch {
+	case err != nil:
+		reason = fmt.Sprintf("failed to run post-deploy task: %v", err)
+	case task.Status != domain.ReleaseTaskStatusSucceeded:
+		reason = fmt.Sprintf("post-deploy task failed: %s", task.FailureReason)
+	default:
+		return
+	}
+
+	w.logger.Warn("Post-deploy task failed",
+		zap.String("release_id", release.ID.String()),
+		zap.String("reason", reason),
+	)
+	w.publish(release.ID, domain.ReleaseProgressEvent{
+		Type:    domain.ReleaseProgressWarning,
+		Kind:    "Job",
+		Reason:  "PostDeployFailed",
+		Message: reason,
+	})
+}
+
+// runReleaseTask records a pre- or post-deploy task of a release and runs it
+func (w *DeploymentWorker) runReleaseTask(ctx context.Context, release *domain.Release, target *rolloutTarget, kind domain.ReleaseTaskKind, spec *domain.ReleaseTaskSpec) (*domain.ReleaseTask, error) {
+	task, err := w.taskRepo.CreateReleaseTask(ctx, &domain.ReleaseTask{
+		AppID:     release.AppID,
+		ReleaseID: release.ID,
+		Kind:      kind,
+		Command:   append(append([]string{}, spec.Command...), spec.Args...),
+		Status:    domain.ReleaseTaskStatusPending,
+		CreatedBy: release.CreatedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release task: %w", err)
+	}
+
+	return w.executeTask(ctx, target, task, &deployment.TaskConfig{
+		Name:           taskName(task),
+		Command:        spec.Command,
+		Args:           spec.Args,
+		TimeoutSeconds: int64(spec.Timeout()),
+	}, true)
+}
+
+// taskName returns the name a task's Job is named after: its kind and the start of its ID
+func taskName(task *domain.ReleaseTask) string {
+	return fmt.Sprintf("%s-%s", strings.ReplaceAll(string(task.Kind), "_", "-"), task.ID.String()[:8])
+}
+
+// taskResult is the outcome and output of a task's Job
+type taskResult struct {
+	status   domain.ReleaseTaskStatus
+	exitCode *int32
+	reason   string
+	logs     string
+}
+
+// executeTask runs a task's Job to completion and records its outcome and output
+func (w *DeploymentWorker) executeTask(ctx context.Context, target *rolloutTarget, task *domain.ReleaseTask, config *deployment.TaskConfig, publish bool) (*domain.ReleaseTask, error) {
+	result := &taskResult{}
+	if err := w.runTaskJob(ctx, target, task, config, publish, result); err != nil {
+		result.status = domain.ReleaseTaskStatusFailed
+		result.reason = err.Error()
+	}
+
+	finished, err := w.taskRepo.FinishReleaseTask(ctx, task.ID, result.status, result.exitCode, result.reason, result.logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release task: %w", err)
+	}
+	if finished == nil {
+		return nil, fmt.Errorf("release task not found")
+	}
+
+	if publish {
+		w.publish(task.ReleaseID, domain.ReleaseProgressEvent{
+			Type:    domain.ReleaseProgressTaskFinished,
+			Kind:    "Job",
+			Name:    finished.JobName,
+			Reason:  string(finished.Status),
+			Message: finished.FailureReason,
+		})
+	}
+	return finished, nil
+}
+
+// runTaskJob creates a task's Job and waits for it to finish, capturing its output into result
+func (w *DeploymentWorker) runTaskJob(ctx context.Context, target *rolloutTarget, task *domain.ReleaseTask, config *deployment.TaskConfig, publish bool, result *taskResult) error {
+	job, err := w.deployer.BuildTaskJob(target.config, config)
+	if err != nil {
+		return fmt.Errorf("failed to build task job: %w", err)
+	}
+
+	if err := w.ensureNamespace(ctx, target, job.Namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+	// Ad-hoc tasks run against a release that is already rolled out, and use its Secrets as applied
+	if task.Kind != domain.ReleaseTaskRun {
+		if err := w.applyTaskSecrets(ctx, target); err != nil {
+			return err
+		}
+	}
+
+	if _, err := target.clientset.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create task job: %w", err)
+	}
+	if _, err := w.taskRepo.StartReleaseTask(ctx, task.ID, job.Name); err != nil {
+		w.logger.Error("Failed to update release task", zap.Error(err), zap.String("task_id", task.ID.String()))
+	}
+	if publish {
+		w.publish(task.ReleaseID, domain.ReleaseProgressEvent{
+			Type:    domain.ReleaseProgressTaskStarted,
+			Kind:    "Job",
+			Name:    job.Name,
+			Message: strings.Join(task.Command, " "),
+		})
+	}
+	w.logger.Info("Started release task",
+		zap.String("task_id", task.ID.String()),
+		zap.String("namespace", job.Namespace),
+		zap.String("job", job.Name),
+	)
+
+	err = w.waitForTaskJob(ctx, target, task, job, publish, result)
+	if err != nil {
+		// Stop the Job rather than leave it running until its deadline
+		w.deleteTaskJob(ctx, target, job)
+	}
+	return err
+}
+
+// applyTaskSecrets applies the Secrets a task's pod reads its secrets and pulls its image with
+func (w *DeploymentWorker) applyTaskSecrets(ctx context.Context, target *rolloutTarget) error {
+	objects, err := w.prepareObjects(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if obj.GetKind() != "Secret" {
+			continue
+		}
+		if err := w.applyManifest(ctx, target, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForTaskJob waits for a task's Job to finish, storing and publishing its output
+func (w *DeploymentWorker) waitForTaskJob(ctx context.Context, target *rolloutTarget, task *domain.ReleaseTask, job *batchv1.Job, publish bool, result *taskResult) error {
+	jobs := target.clientset.BatchV1().Jobs(job.Namespace)
+	taskTimeout := time.Duration(*job.Spec.ActiveDeadlineSeconds) * time.Second
+
+	timeout := time.After(taskTimeout + taskTimeoutSlack)
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("task did not finish after %s", taskTimeout)
+		case <-ticker.C:
+			live, err := jobs.Get(ctx, job.Name, metav1.GetOptions{})
+			if err != nil {
+				w.logger.Warn("Failed to get task job status", zap.Error(err))
+				continue
+			}
+
+			// The Job is read before the logs, so a finished Job's output is complete
+			pod, err := w.taskPod(ctx, target, live)
+			if err != nil {
+				w.logger.Warn("Failed to get task pod", zap.Error(err))
+			}
+			if pod != nil {
+				w.captureTaskLogs(ctx, target, task, pod, publish, result)
+				result.exitCode = taskExitCode(pod)
+			}
+
+			if done, failure := jobOutcome(live); done {
+				if failure == "" {
+					result.status = domain.ReleaseTaskStatusSucceeded
+					return nil
+				}
+				result.status = domain.ReleaseTaskStatusFailed
+				result.reason = failure
+				if result.exitCode != nil && *result.exitCode != 0 {
+					result.reason = fmt.Sprintf("command exited with code %d", *result.exitCode)
+				}
+				return nil
+			}
+
+			// Task pods are never restarted, so only containers that cannot start fail them early
+			if pod != nil {
+				if reason := podFailure(pod, 1); reason != "" {
+					return fmt.Errorf("%s", reason)
+				}
+			}
+		}
+	}
+}
+
+// taskPod returns the pod of a task's Job, or nil if it has not been created yet
+func (w *DeploymentWorker) taskPod(ctx context.Context, target *rolloutTarget, job *batchv1.Job) (*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job selector: %w", err)
+	}
+
+	pods, err := target.clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	return &pods.Items[0], nil
+}
+
+// captureTaskLogs stores and publishes the output of a task's pod when it has grown
+func (w *DeploymentWorker) captureTaskLogs(ctx context.Context, target *rolloutTarget, task *domain.ReleaseTask, pod *corev1.Pod, publish bool, result *taskResult) {
+	limit := int64(maxTaskLogBytes)
+	raw, err := target.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{LimitBytes: &limit}).DoRaw(ctx)
+	if err != nil {
+		return
+	}
+
+	logs := string(raw)
+	if len(logs) <= len(result.logs) {
+		return
+	}
+	added := strings.TrimPrefix(logs, result.logs)
+	result.logs = logs
+
+	if err := w.taskRepo.UpdateReleaseTaskLogs(ctx, task.ID, logs); err != nil {
+		w.logger.Warn("Failed to update release task logs", zap.Error(err), zap.String("task_id", task.ID.String()))
+	}
+	if publish {
+		w.publish(task.ReleaseID, domain.ReleaseProgressEvent{
+			Type:    domain.ReleaseProgressTaskLog,
+			Kind:    "Pod",
+			Name:    pod.Name,
+			Message: added,
+		})
+	}
+}
+
+// deleteTaskJob deletes a task's Job and its pod
+func (w *DeploymentWorker) deleteTaskJob(ctx context.Context, target *rolloutTarget, job *batchv1.Job) {
+	propagation := metav1.DeletePropagationBackground
+	err := target.clientset.BatchV1().Jobs(job.Namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		w.logger.Warn("Failed to delete task job", zap.Error(err), zap.String("job", job.Name))
+	}
+}
+
+// jobOutcome returns whether a Job has finished and, if it failed, why
+func jobOutcome(job *batchv1.Job) (bool, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, ""
+		case batchv1.JobFailed:
+			if condition.Message == "" {
+				return true, condition.Reason
+			}
+			return true, fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return false, ""
+}
+
+// taskExitCode returns the exit code of a task pod's container, or nil if it has not terminated
+func taskExitCode(pod *corev1.Pod) *int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			exitCode := terminated.ExitCode
+			return &exitCode
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestJobOutcome(t *testing.T) {
+	newJob := func(conditions ...batchv1.JobCondition) *batchv1.Job {
+		return &batchv1.Job{Status: batchv1.JobStatus{Conditions: conditions}}
+	}
+
+	tests := []struct {
+		name        string
+		job         *batchv1.Job
+		wantDone    bool
+		wantFailure string
+	}{
+		{
+			name: "running",
+			job:  newJob(),
+		},
+		{
+			name:     "complete",
+			job:      newJob(batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+			wantDone: true,
+		},
+		{
+			name: "deadline exceeded",
+			job: newJob(batchv1.JobCondition{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "DeadlineExceeded",
+				Message: "Job was active longer than specified deadline",
+			}),
+			wantDone:    true,
+			wantFailure: "DeadlineExceeded: Job was active longer than specified deadline",
+		},
+		{
+			name:        "failed without message",
+			job:         newJob(batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"}),
+			wantDone:    true,
+			wantFailure: "BackoffLimitExceeded",
+		},
+		{
+			name: "condition not true",
+			job:  newJob(batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionFalse, Reason: "BackoffLimitExceeded"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, failure := jobOutcome(tt.job)
+			assert.Equal(t, tt.wantDone, done)
+			assert.Equal(t, tt.wantFailure, failure)
+		})
+	}
+}
+
+func TestTaskExitCode(t *testing.T) {
+	running := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "api",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}}}
+	assert.Nil(t, taskExitCode(running))
+
+	terminated := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "api",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3}},
+	}}}}
+	if exitCode := taskExitCode(terminated); assert.NotNil(t, exitCode) {
+		assert.Equal(t, int32(3), *exitCode)
+	}
+}
+
+func TestTaskName(t *testing.T) {
+	task := &domain.ReleaseTask{ID: uuid.MustParse("3f2a9c1e-0000-4000-8000-000000000000"), Kind: domain.ReleaseTaskPreDeploy}
+	assert.Equal(t, "pre-deploy-3f2a9c1e", taskName(task))
+
+	task.Kind = domain.ReleaseTaskRun
+	assert.Equal(t, "run-3f2a9c1e", taskName(task))
+}
@@ -0,0 +1,430 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+	"github.com/PouryDev/oneclick/internal/repo"
+)
+
+// protectedNamespaces are never deleted with an application, even if only it runs in them
+var protectedNamespaces = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// teardownTarget is a namespace an application runs in: its own or one of its environments'
+type teardownTarget struct {
+	namespace string
+	clientset kubernetes.Interface
+	applier   *deployment.Applier
+}
+
+// teardownPlan is what tearing an application down deletes
+type teardownPlan struct {
+	app      *domain.Application
+	keepData bool
+	targets  []*teardownTarget
+	// byEnvironment maps environment IDs to their target, and uuid.Nil to the application's own
+	byEnvironment map[uuid.UUID]*teardownTarget
+}
+
+// ProcessTeardown tears an application down in every cluster it runs in, then deletes it
+func (w *DeploymentWorker) ProcessTeardown(ctx context.Context, job *domain.Job) error {
+	appIDStr, _ := job.Payload.Config["app_id"].(string)
+	appID, err := uuid.Parse(appIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid application ID in delete job: %w", err)
+	}
+	keepData, _ := job.Payload.Config["keep_data"].(bool)
+
+	progress := &jobProgress{jobRepo: w.jobRepo, logger: w.logger, jobID: job.ID, steps: job.Progress}
+
+	app, err := w.appRepo.GetApplicationByID(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("failed to get application: %w", err)
+	}
+	if app == nil {
+		w.logger.Warn("Application not found, assuming already deleted", zap.String("app_id", appID.String()))
+		progress.skipPending(ctx, "application already deleted")
+		return nil
+	}
+
+	w.logger.Info("Tearing down application",
+		zap.String("app_id", app.ID.String()),
+		zap.String("name", app.Name),
+		zap.Bool("keep_data", keepData),
+	)
+
+	plan, err := w.planTeardown(ctx, app, keepData)
+	if err != nil {
+		progress.set(ctx, domain.TeardownStepDomains, domain.JobStepFailed, err.Error())
+		return err
+	}
+
+	steps := []struct {
+		name string
+		run  func(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error)
+	}{
+		{domain.TeardownStepDomains, w.teardownDomains},
+		{domain.TeardownStepWorkload, w.teardownWorkload},
+		{domain.TeardownStepServices, w.teardownServices},
+		{domain.TeardownStepNamespace, w.teardownNamespaces},
+		{domain.TeardownStepApplication, w.deleteApplication},
+	}
+	for _, step := range steps {
+		if err := progress.run(ctx, step.name, func() (domain.JobStepStatus, string, error) {
+			return step.run(ctx, plan)
+		}); err != nil {
+			return fmt.Errorf("failed to tear down %s: %w", step.name, err)
+		}
+	}
+
+	w.logger.Info("Application deleted", zap.String("app_id", app.ID.String()), zap.String("name", app.Name))
+	return nil
+}
+
+// planTeardown connects to the clusters of the application and of its environments
+func (w *DeploymentWorker) planTeardown(ctx context.Context, app *domain.Application, keepData bool) (*teardownPlan, error) {
+	environments, err := w.envRepo.GetEnvironmentsByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environments: %w", err)
+	}
+
+	plan := &teardownPlan{
+		app:           app,
+		keepData:      keepData,
+		byEnvironment: make(map[uuid.UUID]*teardownTarget),
+	}
+	targets := make(map[string]*teardownTarget)
+	clients := make(map[uuid.UUID]*teardownTarget)
+
+	add := func(environmentID, clusterID uuid.UUID, namespace string) error {
+		key := clusterID.String() + "/" + namespace
+		if target, ok := targets[key]; ok {
+			plan.byEnvironment[environmentID] = target
+			return nil
+		}
+
+		connected, ok := clients[clusterID]
+		if !ok {
+			clientset, applier, err := w.clusterClients(ctx, clusterID)
+			if err != nil {
+				return err
+			}
+			connected = &teardownTarget{clientset: clientset, applier: applier}
+			clients[clusterID] = connected
+		}
+
+		target := &teardownTarget{namespace: namespace, clientset: connected.clientset, applier: connected.applier}
+		targets[key] = target
+		plan.targets = append(plan.targets, target)
+		plan.byEnvironment[environmentID] = target
+		return nil
+	}
+
+	// Releases deployed without an environment run in the application's cluster, in its own namespace
+	if err := add(uuid.Nil, app.ClusterID, app.Namespace); err != nil {
+		return nil, err
+	}
+	for _, env := range environments {
+		if err := add(env.ID, env.ClusterID, env.Namespace); err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env.Name, err)
+		}
+	}
+
+	return plan, nil
+}
+
+// teardownDomains deletes the application's Ingresses, the certificates of its domains and its
+// domains, so that nothing routes to the application while the rest of it is torn down
+func (w *DeploymentWorker) teardownDomains(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error) {
+	ingresses := 0
+	for _, target := range plan.targets {
+		deleted, err := target.applier.DeleteMatching(ctx, target.namespace, deployment.ManagedLabels(plan.app.ID), []schema.GroupVersionKind{deployment.IngressKind})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to delete ingresses in %s: %w", target.namespace, err)
+		}
+		ingresses += len(deleted)
+	}
+
+	domains, err := w.domainRepo.GetDomainsByAppID(ctx, plan.app.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get domains: %w", err)
+	}
+	for _, d := range domains {
+		environmentID := uuid.Nil
+		if d.EnvironmentID != nil {
+			environmentID = *d.EnvironmentID
+		}
+		if target := plan.byEnvironment[environmentID]; target != nil {
+			if err := deleteCertificate(ctx, target, &d); err != nil {
+				return "", "", err
+			}
+		}
+		if err := w.domainRepo.DeleteDomain(ctx, d.ID); err != nil {
+			return "", "", fmt.Errorf("failed to delete domain %s: %w", d.Domain, err)
+		}
+	}
+
+	return domain.JobStepCompleted, fmt.Sprintf("deleted %d ingresses and %d domains", ingresses, len(domains)), nil
+}
+
+// deleteCertificate deletes the cert-manager Certificate of a domain and the Secret it is stored in
+func deleteCertificate(ctx context.Context, target *teardownTarget, d *domain.Domain) error {
+	certificate := newObjectRef("cert-manager.io/v1", "Certificate", target.namespace, d.Domain+"-cert")
+	if err := target.applier.Delete(ctx, certificate); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+
+	secretName := d.CertSecretName
+	if secretName == "" {
+		secretName = d.Domain + "-tls"
+	}
+	return target.applier.Delete(ctx, newObjectRef("v1", "Secret", target.namespace, secretName))
+}
+
+// teardownWorkload deletes everything the application's releases applied, and its persistent
+// volume claims unless its data is kept
+func (w *DeploymentWorker) teardownWorkload(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error) {
+	objects, claims := 0, 0
+	for _, target := range plan.targets {
+		deleted, err := target.applier.DeleteMatching(ctx, target.namespace, deployment.ManagedLabels(plan.app.ID), deployment.ManagedKinds())
+		if err != nil {
+			return "", "", fmt.Errorf("failed to delete workload in %s: %w", target.namespace, err)
+		}
+		objects += len(deleted)
+		w.logger.Info("Deleted workload", zap.String("namespace", target.namespace), zap.Strings("resources", deleted))
+
+		if plan.keepData {
+			continue
+		}
+		deleted, err = target.applier.DeleteMatching(ctx, target.namespace, deployment.VolumeClaimLabels(plan.app.Name), []schema.GroupVersionKind{deployment.PersistentVolumeClaimKind})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to delete volumes in %s: %w", target.namespace, err)
+		}
+		claims += len(deleted)
+	}
+
+	if plan.keepData {
+		return domain.JobStepCompleted, fmt.Sprintf("deleted %d objects, kept persistent volume claims", objects), nil
+	}
+	return domain.JobStepCompleted, fmt.Sprintf("deleted %d objects and %d persistent volume claims", objects, claims), nil
+}
+
+// teardownServices deletes the application's infrastructure services
+func (w *DeploymentWorker) teardownServices(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error) {
+	services, err := w.serviceRepo.GetServicesByAppID(ctx, plan.app.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get services: %w", err)
+	}
+	if len(services) == 0 {
+		return domain.JobStepSkipped, "no infrastructure services", nil
+	}
+
+	target := plan.byEnvironment[uuid.Nil]
+	for _, service := range services {
+		namespace := service.Namespace
+		if namespace == "" {
+			namespace = target.namespace
+		}
+
+		release := helmReleaseName(service.Chart)
+		releaseLabels := map[string]string{"app.kubernetes.io/instance": release}
+		if _, err := target.applier.DeleteMatching(ctx, namespace, releaseLabels, deployment.ManagedKinds()); err != nil {
+			return "", "", fmt.Errorf("failed to delete service %s: %w", service.Name, err)
+		}
+		if _, err := target.applier.DeleteMatching(ctx, namespace, map[string]string{"owner": "helm", "name": release}, []schema.GroupVersionKind{deployment.SecretKind}); err != nil {
+			return "", "", fmt.Errorf("failed to delete helm release of service %s: %w", service.Name, err)
+		}
+		if err := target.applier.Delete(ctx, newObjectRef("v1", "Secret", namespace, service.Name+"-secrets")); err != nil {
+			return "", "", fmt.Errorf("failed to delete secrets of service %s: %w", service.Name, err)
+		}
+		if !plan.keepData {
+			if _, err := target.applier.DeleteMatching(ctx, namespace, releaseLabels, []schema.GroupVersionKind{deployment.PersistentVolumeClaimKind}); err != nil {
+				return "", "", fmt.Errorf("failed to delete volumes of service %s: %w", service.Name, err)
+			}
+		}
+
+		if err := w.serviceRepo.DeleteService(ctx, service.ID); err != nil {
+			return "", "", fmt.Errorf("failed to delete service %s: %w", service.Name, err)
+		}
+		w.logger.Info("Deleted infrastructure service", zap.String("service", service.Name), zap.String("namespace", namespace))
+	}
+
+	return domain.JobStepCompleted, fmt.Sprintf("deleted %d infrastructure services", len(services)), nil
+}
+
+// helmReleaseName returns the name services are installed under
+func helmReleaseName(chart string) string {
+	return chart[strings.LastIndex(chart, "/")+1:]
+}
+
+// teardownNamespaces deletes the namespaces the application ran in, unless they are still in use
+func (w *DeploymentWorker) teardownNamespaces(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error) {
+	var deleted, kept []string
+	for _, target := range plan.targets {
+		reason, err := namespaceInUse(ctx, target, plan)
+		if err != nil {
+			return "", "", err
+		}
+		if reason != "" {
+			kept = append(kept, fmt.Sprintf("%s (%s)", target.namespace, reason))
+			continue
+		}
+
+		err = target.clientset.CoreV1().Namespaces().Delete(ctx, target.namespace, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", "", fmt.Errorf("failed to delete namespace %s: %w", target.namespace, err)
+		}
+		deleted = append(deleted, target.namespace)
+	}
+
+	return namespaceStepResult(deleted, kept)
+}
+
+// namespaceStepResult reports the namespaces deleted and kept
+func namespaceStepResult(deleted, kept []string) (domain.JobStepStatus, string, error) {
+	var parts []string
+	if len(deleted) > 0 {
+		parts = append(parts, "deleted "+strings.Join(deleted, ", "))
+	}
+	if len(kept) > 0 {
+		parts = append(parts, "kept "+strings.Join(kept, ", "))
+	}
+
+	status := domain.JobStepCompleted
+	if len(deleted) == 0 {
+		status = domain.JobStepSkipped
+	}
+	return status, strings.Join(parts, "; "), nil
+}
+
+// namespaceInUse returns why a namespace must be kept, or "" if it can be deleted
+func namespaceInUse(ctx context.Context, target *teardownTarget, plan *teardownPlan) (string, error) {
+	if protectedNamespaces[target.namespace] {
+		return "system namespace", nil
+	}
+
+	namespace, err := target.clientset.CoreV1().Namespaces().Get(ctx, target.namespace, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get namespace %s: %w", target.namespace, err)
+	}
+	if namespace.Labels[deployment.LabelManagedBy] != deployment.ManagedByOneClick {
+		return "not created by OneClick", nil
+	}
+	if appID := namespace.Labels[deployment.LabelAppID]; appID != "" && appID != plan.app.ID.String() {
+		return "namespace of another application", nil
+	}
+
+	// Objects of other applications
+	others := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s!=%s", deployment.LabelManagedBy, deployment.ManagedByOneClick, deployment.LabelAppID, plan.app.ID),
+		Limit:         1,
+	}
+	deployments, err := target.clientset.AppsV1().Deployments(target.namespace).List(ctx, others)
+	if err != nil {
+		return "", fmt.Errorf("failed to list deployments in %s: %w", target.namespace, err)
+	}
+	statefulSets, err := target.clientset.AppsV1().StatefulSets(target.namespace).List(ctx, others)
+	if err != nil {
+		return "", fmt.Errorf("failed to list stateful sets in %s: %w", target.namespace, err)
+	}
+	if len(deployments.Items) > 0 || len(statefulSets.Items) > 0 {
+		return "used by other applications", nil
+	}
+
+	// Deleting a namespace deletes the persistent volume claims in it
+	if plan.keepData {
+		claims, err := target.clientset.CoreV1().PersistentVolumeClaims(target.namespace).List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return "", fmt.Errorf("failed to list persistent volume claims in %s: %w", target.namespace, err)
+		}
+		if len(claims.Items) > 0 {
+			return "holds persistent volume claims", nil
+		}
+	}
+
+	return "", nil
+}
+
+// deleteApplication deletes the application's record, with its releases, environments, spec and
+// secrets
+func (w *DeploymentWorker) deleteApplication(ctx context.Context, plan *teardownPlan) (domain.JobStepStatus, string, error) {
+	if err := w.appRepo.DeleteApplication(ctx, plan.app.ID); err != nil {
+		return "", "", fmt.Errorf("failed to delete application: %w", err)
+	}
+	return domain.JobStepCompleted, "", nil
+}
+
+// jobProgress records the progress of a job's steps on the job
+type jobProgress struct {
+	jobRepo repo.JobRepository
+	logger  *zap.Logger
+	jobID   uuid.UUID
+	steps   []domain.JobStep
+}
+
+// run runs a step, recording it as running and then with its outcome
+func (p *jobProgress) run(ctx context.Context, name string, step func() (domain.JobStepStatus, string, error)) error {
+	p.set(ctx, name, domain.JobStepRunning, "")
+	status, message, err := step()
+	if err != nil {
+		p.set(ctx, name, domain.JobStepFailed, err.Error())
+		return err
+	}
+	p.set(ctx, name, status, message)
+	return nil
+}
+
+// skipPending records every step that has not run as skipped
+func (p *jobProgress) skipPending(ctx context.Context, message string) {
+	for i := range p.steps {
+		if p.steps[i].Status == domain.JobStepPending {
+			p.steps[i].Status = domain.JobStepSkipped
+			p.steps[i].Message = message
+		}
+	}
+	p.save(ctx)
+}
+
+// set records the status of a step, adding it if the job did not list it
+func (p *jobProgress) set(ctx context.Context, name string, status domain.JobStepStatus, message string) {
+	updateJobStep(&p.steps, name, status, message)
+	p.save(ctx)
+}
+
+// save records the steps on the job, logging failures
+func (p *jobProgress) save(ctx context.Context) {
+	if err := p.jobRepo.UpdateJobProgress(ctx, p.jobID, p.steps); err != nil {
+		p.logger.Warn("Failed to record job progress", zap.Error(err), zap.String("jobID", p.jobID.String()))
+	}
+}
+
+// updateJobStep sets the status and message of the named step, appending it if it is missing
+func updateJobStep(steps *[]domain.JobStep, name string, status domain.JobStepStatus, message string) {
+	for i := range *steps {
+		if (*steps)[i].Name == name {
+			(*steps)[i].Status = status
+			(*steps)[i].Message = message
+			return
+		}
+	}
+	*steps = append(*steps, domain.JobStep{Name: name, Status: status, Message: message})
+}
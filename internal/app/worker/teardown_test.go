@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PouryDev/oneclick/internal/app/deployment"
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+func TestUpdateJobStep(t *testing.T) {
+	steps := domain.NewJobSteps(domain.TeardownStepDomains, domain.TeardownStepWorkload)
+
+	updateJobStep(&steps, domain.TeardownStepWorkload, domain.JobStepRunning, "")
+	updateJobStep(&steps, domain.TeardownStepNamespace, domain.JobStepSkipped, "kept api (system namespace)")
+
+	assert.Equal(t, []domain.JobStep{
+		{Name: domain.TeardownStepDomains, Status: domain.JobStepPending},
+		{Name: domain.TeardownStepWorkload, Status: domain.JobStepRunning},
+		{Name: domain.TeardownStepNamespace, Status: domain.JobStepSkipped, Message: "kept api (system namespace)"},
+	}, steps)
+}
+
+func TestNamespaceStepResult(t *testing.T) {
+	status, message, err := namespaceStepResult([]string{"api", "api-staging"}, []string{"default (system namespace)"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.JobStepCompleted, status)
+	assert.Equal(t, "deleted api, api-staging; kept default (system namespace)", message)
+
+	status, message, _ = namespaceStepResult(nil, []string{"api (holds persistent volume claims)"})
+	assert.Equal(t, domain.JobStepSkipped, status)
+	assert.Equal(t, "kept api (holds persistent volume claims)", message)
+}
+
+func TestHelmReleaseName(t *testing.T) {
+	assert.Equal(t, "postgresql", helmReleaseName("bitnami/postgresql"))
+	assert.Equal(t, "redis", helmReleaseName("redis"))
+}
+
+func TestProcessTeardown(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepData  bool
+		workload  string
+		namespace domain.JobStep
+		kept      []string
+	}{
+		{
+			name:      "delete data",
+			workload:  "deleted 2 objects and 1 persistent volume claims",
+			namespace: domain.JobStep{Name: domain.TeardownStepNamespace, Status: domain.JobStepCompleted, Message: "deleted acme-api, acme-api-staging"},
+		},
+		{
+			name:      "keep data",
+			keepData:  true,
+			workload:  "deleted 2 objects, kept persistent volume claims",
+			namespace: domain.JobStep{Name: domain.TeardownStepNamespace, Status: domain.JobStepCompleted, Message: "deleted acme-api-staging; kept acme-api (holds persistent volume claims)"},
+			kept:      []string{"acme-api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			app := newTestApplication()
+			env := domain.Environment{ID: uuid.New(), AppID: app.ID, ClusterID: app.ClusterID, Name: "staging", Namespace: "acme-api-staging"}
+
+			claim := newObjectRef("v1", "PersistentVolumeClaim", app.Namespace, "data-api-0")
+			claim.SetLabels(deployment.VolumeClaimLabels(app.Name))
+			staging := newObjectRef("apps/v1", "Deployment", env.Namespace, "api")
+			staging.SetLabels(deployment.ManagedLabels(app.ID))
+			cluster := newTestCluster(
+				managedObject(app, "apps/v1", "Deployment", "api"),
+				managedObject(app, "networking.k8s.io/v1", "Ingress", "api-ingress"),
+				claim,
+				staging,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: app.Namespace, Labels: deployment.NamespaceLabels(app.Namespace, app.OrgID, app.ID)}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: env.Namespace, Labels: deployment.NamespaceLabels(env.Namespace, app.OrgID, app.ID)}},
+				&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: app.Namespace, Name: "data-api-0"}},
+			)
+			w := newTestWorker(t, cluster)
+			w.withApplication(t, app, "api.example.com")
+
+			job := app.NewDeleteJob(tt.keepData)
+			job.ID = uuid.New()
+
+			var started []string
+			w.jobRepo.On("UpdateJobProgress", ctx, job.ID, mock.Anything).Run(func(args mock.Arguments) {
+				for _, step := range args.Get(2).([]domain.JobStep) {
+					if step.Status == domain.JobStepRunning && (len(started) == 0 || started[len(started)-1] != step.Name) {
+						started = append(started, step.Name)
+					}
+				}
+			}).Return(nil)
+			w.envRepo.On("GetEnvironmentsByAppID", ctx, app.ID).Return([]domain.Environment{env}, nil)
+			w.domainRepo.On("DeleteDomain", ctx, mock.Anything).Return(nil)
+			w.serviceRepo.On("GetServicesByAppID", ctx, app.ID).Return([]domain.ServiceSummary{}, nil)
+			w.appRepo.On("DeleteApplication", ctx, app.ID).Return(nil)
+
+			require.NoError(t, w.ProcessTeardown(ctx, job))
+
+			// Nothing routes to the application while the rest of it is torn down, and its
+			// record goes last, so that a failed teardown can be retried
+			assert.Equal(t, []string{
+				domain.TeardownStepDomains,
+				domain.TeardownStepWorkload,
+				domain.TeardownStepServices,
+				domain.TeardownStepNamespace,
+				domain.TeardownStepApplication,
+			}, started)
+			assert.Equal(t, []domain.JobStep{
+				{Name: domain.TeardownStepDomains, Status: domain.JobStepCompleted, Message: "deleted 1 ingresses and 1 domains"},
+				{Name: domain.TeardownStepWorkload, Status: domain.JobStepCompleted, Message: tt.workload},
+				{Name: domain.TeardownStepServices, Status: domain.JobStepSkipped, Message: "no infrastructure services"},
+				tt.namespace,
+				{Name: domain.TeardownStepApplication, Status: domain.JobStepCompleted},
+			}, job.Progress)
+
+			assert.Nil(t, cluster.get(t, "networking.k8s.io/v1", "Ingress", app.Namespace, "api-ingress"))
+			assert.Nil(t, cluster.get(t, "apps/v1", "Deployment", app.Namespace, "api"))
+			assert.Nil(t, cluster.get(t, "apps/v1", "Deployment", env.Namespace, "api"))
+			assert.Equal(t, tt.keepData, cluster.get(t, "v1", "PersistentVolumeClaim", app.Namespace, "data-api-0") != nil)
+
+			for _, namespace := range []string{app.Namespace, env.Namespace} {
+				_, err := cluster.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+				if slices.Contains(tt.kept, namespace) {
+					assert.NoError(t, err, "namespace %s is kept", namespace)
+				} else {
+					assert.True(t, apierrors.IsNotFound(err), "namespace %s is deleted", namespace)
+				}
+			}
+			w.domainRepo.AssertNumberOfCalls(t, "DeleteDomain", 1)
+			w.appRepo.AssertExpectations(t)
+		})
+	}
+}
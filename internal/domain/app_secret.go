@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppSecret is an encrypted, write-only environment variable of an application
+type AppSecret struct {
+	ID             uuid.UUID `json:"id"`
+	AppID          uuid.UUID `json:"app_id"`
+	Name           string    `json:"name"`
+	ValueEncrypted []byte    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// AppSecretResponse represents an application secret without its value
+type AppSecretResponse struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateAppSecretRequest represents a request to create an application secret
+type CreateAppSecretRequest struct {
+	Name  string `json:"name" validate:"required,max=253"`
+	Value string `json:"value" validate:"max=65536"`
+}
+
+// UpdateAppSecretRequest represents a request to replace the value of an application secret
+type UpdateAppSecretRequest struct {
+	Value string `json:"value" validate:"max=65536"`
+}
+
+// ToResponse converts an AppSecret to AppSecretResponse
+func (s *AppSecret) ToResponse() AppSecretResponse {
+	return AppSecretResponse{
+		Name:      s.Name,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -13,6 +13,7 @@ type Application struct {
 	OrgID         uuid.UUID `json:"org_id"`
 	ClusterID     uuid.UUID `json:"cluster_id"`
 	Name          string    `json:"name"`
+	Namespace     string    `json:"namespace"`
 	RepoID        uuid.UUID `json:"repo_id"`
 	Path          *string   `json:"path"`
 	DefaultBranch string    `json:"default_branch"`
@@ -24,6 +25,7 @@ type Application struct {
 type ApplicationSummary struct {
 	ID            uuid.UUID `json:"id"`
 	Name          string    `json:"name"`
+	Namespace     string    `json:"namespace"`
 	RepoID        uuid.UUID `json:"repo_id"`
 	Path          *string   `json:"path"`
 	DefaultBranch string    `json:"default_branch"`
@@ -37,6 +39,37 @@ type ApplicationDetail struct {
 	CurrentRelease *ReleaseSummary `json:"current_release,omitempty"`
 	ReleaseCount   int             `json:"release_count"`
 	Status         string          `json:"status"`
+	Replicas       []ReplicaStatus `json:"replicas,omitempty"` // One per environment; omitted for clusters that cannot be reached
+	Volumes        []VolumeStatus  `json:"volumes,omitempty"`  // Persistent volume claims of every environment
+	Drift          []ReleaseDrift  `json:"drift,omitempty"`    // Drift of the release each environment runs, once checked
+}
+
+// ReplicaStatus represents the replicas of the Deployment, or StatefulSet, serving an application
+// in a namespace
+type ReplicaStatus struct {
+	Environment     string `json:"environment,omitempty"`
+	Namespace       string `json:"namespace"`
+	Kind            string `json:"kind"`       // Deployment or StatefulSet
+	Deployment      string `json:"deployment"` // Name of the Deployment or StatefulSet
+	CurrentReplicas int32  `json:"current_replicas"`
+	DesiredReplicas int32  `json:"desired_replicas"`
+	ReadyReplicas   int32  `json:"ready_replicas"`
+	Autoscaled      bool   `json:"autoscaled"`
+	MinReplicas     int32  `json:"min_replicas,omitempty"` // Autoscaled only
+	MaxReplicas     int32  `json:"max_replicas,omitempty"` // Autoscaled only
+}
+
+// VolumeStatus represents a persistent volume claim of an application and how much of it is used
+type VolumeStatus struct {
+	Environment    string `json:"environment,omitempty"`
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`  // Name of the PersistentVolumeClaim
+	Phase          string `json:"phase"` // Pending, Bound or Lost
+	StorageClass   string `json:"storage_class,omitempty"`
+	AccessMode     string `json:"access_mode,omitempty"`
+	Capacity       string `json:"capacity,omitempty"` // Provisioned size, once bound
+	UsedBytes      *int64 `json:"used_bytes,omitempty"`
+	AvailableBytes *int64 `json:"available_bytes,omitempty"`
 }
 
 // ReleaseStatus represents the status of a release
@@ -47,34 +80,93 @@ const (
 	ReleaseStatusRunning   ReleaseStatus = "running"
 	ReleaseStatusSucceeded ReleaseStatus = "succeeded"
 	ReleaseStatusFailed    ReleaseStatus = "failed"
+	// ReleaseStatusRolledBack marks a release whose rollout failed and was reverted to the last
+	// succeeded release
+	ReleaseStatusRolledBack ReleaseStatus = "rolled_back"
+)
+
+// ReleasePhase represents the step of its deployment strategy a release is in
+type ReleasePhase string
+
+const (
+	ReleasePhasePending    ReleasePhase = "pending"
+	ReleasePhasePreDeploy  ReleasePhase = "pre_deploy"  // The pre-deploy task is running
+	ReleasePhaseRollingOut ReleasePhase = "rolling_out" // New pods are starting
+	ReleasePhaseSwitching  ReleasePhase = "switching"   // Blue/green: the Service is being switched to the new slot
+	ReleasePhaseCanary     ReleasePhase = "canary"      // Canary: serving its share of traffic until promoted or aborted
+	ReleasePhasePromoting  ReleasePhase = "promoting"   // Canary: being rolled out to the stable Deployment
+	ReleasePhaseAborting   ReleasePhase = "aborting"    // Canary: being removed
+	ReleasePhasePostDeploy ReleasePhase = "post_deploy" // The post-deploy task is running
+	ReleasePhasePublishing ReleasePhase = "publishing"  // The release's manifests are being committed to its GitOps repository
+	ReleasePhaseCompleted  ReleasePhase = "completed"
+	ReleasePhaseAborted    ReleasePhase = "aborted"
+	ReleasePhaseFailed     ReleasePhase = "failed"
+)
+
+// JobTypes for rolling out releases
+const (
+	JobTypeReleaseDeploy  JobType = "release_deploy"
+	JobTypeReleasePromote JobType = "release_promote"
+	JobTypeReleaseAbort   JobType = "release_abort"
+	JobTypeReleaseTask    JobType = "release_task" // Ad-hoc command run with a release's image
 )
 
+// JobTypeApplicationDelete tears an application down in its clusters, then deletes it
+const JobTypeApplicationDelete JobType = "app_delete"
+
+// ReleaseJobTypes returns the job types consumed by the deployment worker
+func ReleaseJobTypes() []JobType {
+	return []JobType{
+		JobTypeReleaseDeploy,
+		JobTypeReleasePromote,
+		JobTypeReleaseAbort,
+		JobTypeReleaseTask,
+		JobTypeApplicationDelete,
+	}
+}
+
+// IsReleaseJobType checks if a job type is consumed by the deployment worker
+func IsReleaseJobType(jobType JobType) bool {
+	for _, releaseJobType := range ReleaseJobTypes() {
+		if jobType == releaseJobType {
+			return true
+		}
+	}
+	return false
+}
+
 // Release represents a deployment release
 type Release struct {
-	ID         uuid.UUID       `json:"id"`
-	AppID      uuid.UUID       `json:"app_id"`
-	Image      string          `json:"image"`
-	Tag        string          `json:"tag"`
-	CreatedBy  uuid.UUID       `json:"created_by"`
-	Status     ReleaseStatus   `json:"status"`
-	StartedAt  *time.Time      `json:"started_at"`
-	FinishedAt *time.Time      `json:"finished_at"`
-	Meta       json.RawMessage `json:"meta"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
+	ID            uuid.UUID       `json:"id"`
+	AppID         uuid.UUID       `json:"app_id"`
+	EnvironmentID *uuid.UUID      `json:"environment_id,omitempty"` // Nil for the application's own cluster and namespace
+	Image         string          `json:"image"`
+	Tag           string          `json:"tag"`
+	ImageDigest   string          `json:"image_digest,omitempty"` // Digest the tag resolved to when the release was first rolled out
+	CreatedBy     uuid.UUID       `json:"created_by"`
+	Status        ReleaseStatus   `json:"status"`
+	Phase         ReleasePhase    `json:"phase"`
+	StartedAt     *time.Time      `json:"started_at"`
+	FinishedAt    *time.Time      `json:"finished_at"`
+	Meta          json.RawMessage `json:"meta"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 // ReleaseSummary represents a release in list views
 type ReleaseSummary struct {
-	ID         uuid.UUID     `json:"id"`
-	Image      string        `json:"image"`
-	Tag        string        `json:"tag"`
-	CreatedBy  uuid.UUID     `json:"created_by"`
-	Status     ReleaseStatus `json:"status"`
-	StartedAt  *time.Time    `json:"started_at"`
-	FinishedAt *time.Time    `json:"finished_at"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	ID            uuid.UUID     `json:"id"`
+	EnvironmentID *uuid.UUID    `json:"environment_id,omitempty"`
+	Image         string        `json:"image"`
+	Tag           string        `json:"tag"`
+	ImageDigest   string        `json:"image_digest,omitempty"`
+	CreatedBy     uuid.UUID     `json:"created_by"`
+	Status        ReleaseStatus `json:"status"`
+	Phase         ReleasePhase  `json:"phase"`
+	StartedAt     *time.Time    `json:"started_at"`
+	FinishedAt    *time.Time    `json:"finished_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
 }
 
 // ReleaseMeta represents metadata for a release
@@ -82,8 +174,16 @@ type ReleaseMeta struct {
 	CommitSHA     string            `json:"commit_sha,omitempty"`
 	CommitMessage string            `json:"commit_message,omitempty"`
 	Branch        string            `json:"branch,omitempty"`
+	PipelineID    string            `json:"pipeline_id,omitempty"`  // Pipeline that built the release, if any
+	SpecVersion   int               `json:"spec_version,omitempty"` // Deployment spec version, 0 uses the latest at rollout
 	Environment   map[string]string `json:"environment,omitempty"`
 	Config        map[string]string `json:"config,omitempty"`
+	FailureReason string            `json:"failure_reason,omitempty"` // Why the rollout was rolled back
+	RolledBackTo  string            `json:"rolled_back_to,omitempty"` // Release created to revert a failed rollout
+	RollbackOf    string            `json:"rollback_of,omitempty"`    // Failed release that this release automatically reverts
+	PromotedFrom  string            `json:"promoted_from,omitempty"`  // Release in the previous environment that this release promotes
+	GitOpsCommit  string            `json:"gitops_commit,omitempty"`  // Commit of the GitOps repository the release's manifests were published in
+	SnapshotID    string            `json:"snapshot_id,omitempty"`    // Snapshot of what the release was last rolled out with besides its spec
 }
 
 // Request/Response DTOs
@@ -99,6 +199,7 @@ type ApplicationResponse struct {
 	OrgID         uuid.UUID `json:"org_id"`
 	ClusterID     uuid.UUID `json:"cluster_id"`
 	Name          string    `json:"name"`
+	Namespace     string    `json:"namespace"`
 	RepoID        uuid.UUID `json:"repo_id"`
 	Path          *string   `json:"path"`
 	DefaultBranch string    `json:"default_branch"`
@@ -106,29 +207,70 @@ type ApplicationResponse struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// DeleteApplicationRequest holds the options of deleting an application
+type DeleteApplicationRequest struct {
+	KeepData bool `json:"keep_data" form:"keep_data"` // Keep the application's persistent volume claims
+}
+
+// Steps of tearing an application down, in the order they run
+const (
+	TeardownStepDomains     = "domains"     // Ingresses, certificates and domains
+	TeardownStepWorkload    = "workload"    // Workloads, Services, config, Secrets and volumes
+	TeardownStepServices    = "services"    // Infrastructure services
+	TeardownStepNamespace   = "namespace"   // Namespaces no other application uses
+	TeardownStepApplication = "application" // The application's record and everything stored with it
+)
+
+// NewDeleteJob returns the queued job that tears the application down and deletes it
+func (a *Application) NewDeleteJob(keepData bool) *Job {
+	return &Job{
+		OrgID:  a.OrgID,
+		Type:   JobTypeApplicationDelete,
+		Status: JobStatusPending,
+		Payload: JobPayload{
+			Config: map[string]interface{}{
+				"app_id":    a.ID.String(),
+				"keep_data": keepData,
+			},
+		},
+		Progress: NewJobSteps(
+			TeardownStepDomains,
+			TeardownStepWorkload,
+			TeardownStepServices,
+			TeardownStepNamespace,
+			TeardownStepApplication,
+		),
+	}
+}
+
 type DeployApplicationRequest struct {
-	Image string `json:"image,omitempty"`
-	Tag   string `json:"tag,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Environment string `json:"environment,omitempty"` // Required when the application has environments
 }
 
 type DeployApplicationResponse struct {
-	ReleaseID uuid.UUID `json:"release_id"`
-	Status    string    `json:"status"`
-	Message   string    `json:"message"`
+	ReleaseID   uuid.UUID `json:"release_id"`
+	Environment string    `json:"environment,omitempty"`
+	Status      string    `json:"status"`
+	Message     string    `json:"message"`
 }
 
 type ReleaseResponse struct {
-	ID         uuid.UUID       `json:"id"`
-	AppID      uuid.UUID       `json:"app_id"`
-	Image      string          `json:"image"`
-	Tag        string          `json:"tag"`
-	CreatedBy  uuid.UUID       `json:"created_by"`
-	Status     ReleaseStatus   `json:"status"`
-	StartedAt  *time.Time      `json:"started_at"`
-	FinishedAt *time.Time      `json:"finished_at"`
-	Meta       json.RawMessage `json:"meta"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
+	ID            uuid.UUID       `json:"id"`
+	AppID         uuid.UUID       `json:"app_id"`
+	EnvironmentID *uuid.UUID      `json:"environment_id,omitempty"`
+	Image         string          `json:"image"`
+	Tag           string          `json:"tag"`
+	ImageDigest   string          `json:"image_digest,omitempty"`
+	CreatedBy     uuid.UUID       `json:"created_by"`
+	Status        ReleaseStatus   `json:"status"`
+	Phase         ReleasePhase    `json:"phase"`
+	StartedAt     *time.Time      `json:"started_at"`
+	FinishedAt    *time.Time      `json:"finished_at"`
+	Meta          json.RawMessage `json:"meta"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 // ValidReleaseStatuses returns a list of valid release statuses
@@ -138,6 +280,7 @@ func ValidReleaseStatuses() []ReleaseStatus {
 		ReleaseStatusRunning,
 		ReleaseStatusSucceeded,
 		ReleaseStatusFailed,
+		ReleaseStatusRolledBack,
 	}
 }
 
@@ -158,6 +301,7 @@ func (a *Application) ToResponse() ApplicationResponse {
 		OrgID:         a.OrgID,
 		ClusterID:     a.ClusterID,
 		Name:          a.Name,
+		Namespace:     a.Namespace,
 		RepoID:        a.RepoID,
 		Path:          a.Path,
 		DefaultBranch: a.DefaultBranch,
@@ -171,6 +315,7 @@ func (a *Application) ToSummary() ApplicationSummary {
 	return ApplicationSummary{
 		ID:            a.ID,
 		Name:          a.Name,
+		Namespace:     a.Namespace,
 		RepoID:        a.RepoID,
 		Path:          a.Path,
 		DefaultBranch: a.DefaultBranch,
@@ -182,32 +327,38 @@ func (a *Application) ToSummary() ApplicationSummary {
 // ToResponse converts a Release to ReleaseResponse
 func (r *Release) ToResponse() ReleaseResponse {
 	return ReleaseResponse{
-		ID:         r.ID,
-		AppID:      r.AppID,
-		Image:      r.Image,
-		Tag:        r.Tag,
-		CreatedBy:  r.CreatedBy,
-		Status:     r.Status,
-		StartedAt:  r.StartedAt,
-		FinishedAt: r.FinishedAt,
-		Meta:       r.Meta,
-		CreatedAt:  r.CreatedAt,
-		UpdatedAt:  r.UpdatedAt,
+		ID:            r.ID,
+		AppID:         r.AppID,
+		EnvironmentID: r.EnvironmentID,
+		Image:         r.Image,
+		Tag:           r.Tag,
+		ImageDigest:   r.ImageDigest,
+		CreatedBy:     r.CreatedBy,
+		Status:        r.Status,
+		Phase:         r.Phase,
+		StartedAt:     r.StartedAt,
+		FinishedAt:    r.FinishedAt,
+		Meta:          r.Meta,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
 	}
 }
 
 // ToSummary converts a Release to ReleaseSummary
 func (r *Release) ToSummary() ReleaseSummary {
 	return ReleaseSummary{
-		ID:         r.ID,
-		Image:      r.Image,
-		Tag:        r.Tag,
-		CreatedBy:  r.CreatedBy,
-		Status:     r.Status,
-		StartedAt:  r.StartedAt,
-		FinishedAt: r.FinishedAt,
-		CreatedAt:  r.CreatedAt,
-		UpdatedAt:  r.UpdatedAt,
+		ID:            r.ID,
+		EnvironmentID: r.EnvironmentID,
+		Image:         r.Image,
+		Tag:           r.Tag,
+		ImageDigest:   r.ImageDigest,
+		CreatedBy:     r.CreatedBy,
+		Status:        r.Status,
+		Phase:         r.Phase,
+		StartedAt:     r.StartedAt,
+		FinishedAt:    r.FinishedAt,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
 	}
 }
 
@@ -233,12 +384,74 @@ func (r *Release) SetMeta(meta *ReleaseMeta) error {
 	return nil
 }
 
+// WithoutRolloutOutcome returns a copy of the metadata without the fields that describe how the
+// release's own rollout ended, for a new release that deploys the same version
+func (m ReleaseMeta) WithoutRolloutOutcome() ReleaseMeta {
+	m.FailureReason = ""
+	m.RolledBackTo = ""
+	m.RollbackOf = ""
+	m.GitOpsCommit = ""
+	return m
+}
+
 // IsActive returns true if the release is currently active
 func (r *Release) IsActive() bool {
 	return r.Status == ReleaseStatusRunning || r.Status == ReleaseStatusSucceeded
 }
 
-// IsCompleted returns true if the release has finished (succeeded or failed)
+// IsCompleted returns true if the release has finished (succeeded, failed or rolled back)
 func (r *Release) IsCompleted() bool {
-	return r.Status == ReleaseStatusSucceeded || r.Status == ReleaseStatusFailed
+	return r.Status == ReleaseStatusSucceeded || r.Status == ReleaseStatusFailed || r.Status == ReleaseStatusRolledBack
+}
+
+// IsRollingOut returns true if the release is queued or a rollout of it is running
+func (r *Release) IsRollingOut() bool {
+	return !r.IsCompleted() && !r.IsAwaitingPromotion()
+}
+
+// IsAwaitingPromotion returns true if the release is a canary waiting to be promoted or aborted
+func (r *Release) IsAwaitingPromotion() bool {
+	return r.Status == ReleaseStatusRunning && r.Phase == ReleasePhaseCanary
+}
+
+// HasCanaryInProgress returns true if the release is a canary that has not been promoted or
+// aborted yet
+func (r *Release) HasCanaryInProgress() bool {
+	if r.Status != ReleaseStatusRunning {
+		return false
+	}
+	return r.Phase == ReleasePhaseCanary || r.Phase == ReleasePhasePromoting || r.Phase == ReleasePhaseAborting
+}
+
+// NewDeployJob returns the queued job that rolls the release out to its application's cluster
+func (r *Release) NewDeployJob(orgID uuid.UUID) *Job {
+	return r.newJob(orgID, JobTypeReleaseDeploy)
+}
+
+// NewPromoteJob returns the queued job that promotes the release from canary to stable
+func (r *Release) NewPromoteJob(orgID uuid.UUID) *Job {
+	return r.newJob(orgID, JobTypeReleasePromote)
+}
+
+// NewAbortJob returns the queued job that removes the release's canary
+func (r *Release) NewAbortJob(orgID uuid.UUID) *Job {
+	return r.newJob(orgID, JobTypeReleaseAbort)
+}
+
+// newJob returns a queued release job of the given type
+func (r *Release) newJob(orgID uuid.UUID, jobType JobType) *Job {
+	releaseID := r.ID
+	return &Job{
+		OrgID:  orgID,
+		Type:   jobType,
+		Status: JobStatusPending,
+		Payload: JobPayload{
+			ReleaseID: &releaseID,
+			Config: map[string]interface{}{
+				"app_id": r.AppID.String(),
+				"image":  r.Image,
+				"tag":    r.Tag,
+			},
+		},
+	}
 }
@@ -0,0 +1,349 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service types an application can be exposed with
+const (
+	ServiceTypeClusterIP    = "ClusterIP"
+	ServiceTypeNodePort     = "NodePort"
+	ServiceTypeLoadBalancer = "LoadBalancer"
+)
+
+// Deployment strategies a new release can be rolled out with
+const (
+	StrategyRolling   = "rolling"
+	StrategyBlueGreen = "blue_green"
+	StrategyCanary    = "canary"
+)
+
+// Process types an application can run besides its main web process
+const (
+	ProcessTypeWeb    = "web"
+	ProcessTypeWorker = "worker"
+	ProcessTypeCron   = "cron"
+)
+
+// Deploy sources an application's objects can be rendered from
+const (
+	SourceGenerated = "generated"
+	SourceManifests = "manifests"
+	SourceKustomize = "kustomize"
+	SourceHelm      = "helm"
+)
+
+// Access modes a volume can be mounted with
+const (
+	VolumeAccessReadWriteOnce = "ReadWriteOnce"
+	VolumeAccessReadWriteMany = "ReadWriteMany"
+)
+
+// DefaultCanaryWeight is the percentage of traffic sent to a canary when the spec sets none
+const DefaultCanaryWeight = 10
+
+// Rollout settings used when the spec sets none
+const (
+	DefaultRolloutTimeoutSeconds = 300
+	DefaultRolloutMaxRestarts    = 3
+)
+
+// DefaultReleaseTaskTimeoutSeconds is how long a release task may run when it sets no timeout
+const DefaultReleaseTaskTimeoutSeconds = 600
+
+// DeploymentSpec describes how an application's container is run and exposed
+type DeploymentSpec struct {
+	Replicas       int32             `json:"replicas" validate:"min=0,max=100"` // 0 uses the default of 1; ignored when autoscaling
+	Source         *SourceSpec       `json:"source,omitempty"`                  // Defaults to the generated source
+	Ports          []PortSpec        `json:"ports" validate:"required_without=Source,max=10,dive"`
+	Command        []string          `json:"command,omitempty"`
+	Args           []string          `json:"args,omitempty"`
+	LivenessProbe  *ProbeSpec        `json:"liveness_probe,omitempty"`
+	ReadinessProbe *ProbeSpec        `json:"readiness_probe,omitempty"`
+	Resources      *ResourceSpec     `json:"resources,omitempty"`
+	ServiceType    string            `json:"service_type,omitempty" validate:"omitempty,oneof=ClusterIP NodePort LoadBalancer"`
+	NodeSelector   map[string]string `json:"node_selector,omitempty"`
+	Strategy       *StrategySpec     `json:"strategy,omitempty"` // Defaults to a rolling update
+	Rollout        *RolloutSpec      `json:"rollout,omitempty"`
+	Autoscaling    *AutoscalingSpec  `json:"autoscaling,omitempty"`
+	Processes      []ProcessSpec     `json:"processes,omitempty" validate:"max=20,dive"`
+	PreDeploy      *ReleaseTaskSpec  `json:"pre_deploy,omitempty"`  // Runs before the rollout, which is aborted if it fails
+	PostDeploy     *ReleaseTaskSpec  `json:"post_deploy,omitempty"` // Runs once the rollout succeeded
+	Volumes        []VolumeSpec      `json:"volumes,omitempty" validate:"max=10,dive"`
+	GitOps         *GitOpsSpec       `json:"gitops,omitempty"` // Publishes each release's manifests to a Git repository
+}
+
+// GitOpsSpec publishes the manifests of each release as a commit to a connected repository
+type GitOpsSpec struct {
+	RepositoryID uuid.UUID `json:"repository_id" validate:"required"`
+	Branch       string    `json:"branch,omitempty" validate:"max=255"` // Defaults to the repository's default branch
+	Path         string    `json:"path" validate:"required,max=255"`    // Releases of an environment are written to a subdirectory named after it
+	Apply        *bool     `json:"apply,omitempty"`                     // Defaults to true
+}
+
+// AppliesRelease reports whether OneClick applies releases to the cluster as well as committing them
+func (g GitOpsSpec) AppliesRelease() bool {
+	return g.Apply == nil || *g.Apply
+}
+
+// SourceSpec selects what an application's objects are rendered from
+type SourceSpec struct {
+	Type        string                 `json:"type" validate:"required,oneof=generated manifests kustomize helm"`
+	Containers  []string               `json:"containers,omitempty" validate:"max=20"`   // Containers that run the release image whatever image they reference
+	Values      map[string]interface{} `json:"values,omitempty"`                         // Helm only: values passed to the chart
+	ValuesFiles []string               `json:"values_files,omitempty" validate:"max=10"` // Helm only: values files in the chart, applied before values
+}
+
+// SourceType returns the deploy source of the spec, defaulting to the generated source
+func (s DeploymentSpec) SourceType() string {
+	if s.Source == nil {
+		return SourceGenerated
+	}
+	return s.Source.Type
+}
+
+// VolumeSpec is a persistent volume mounted into the application's main process
+type VolumeSpec struct {
+	Name         string `json:"name" validate:"required,max=40"`
+	Size         string `json:"size" validate:"required"`                                                     // Kubernetes quantity, such as 10Gi
+	StorageClass string `json:"storage_class,omitempty"`                                                      // Defaults to the cluster's default storage class
+	AccessMode   string `json:"access_mode,omitempty" validate:"omitempty,oneof=ReadWriteOnce ReadWriteMany"` // Defaults to ReadWriteOnce
+	MountPath    string `json:"mount_path" validate:"required,startswith=/"`
+}
+
+// ReleaseTaskSpec is a command run to completion with the release image, such as a migration
+type ReleaseTaskSpec struct {
+	Command        []string `json:"command" validate:"required,min=1"`
+	Args           []string `json:"args,omitempty"`
+	TimeoutSeconds int32    `json:"timeout_seconds,omitempty" validate:"omitempty,min=10,max=3600"` // Defaults to 600
+}
+
+// Timeout returns how long the task may run
+func (t ReleaseTaskSpec) Timeout() int32 {
+	if t.TimeoutSeconds == 0 {
+		return DefaultReleaseTaskTimeoutSeconds
+	}
+	return t.TimeoutSeconds
+}
+
+// ProcessSpec is an additional process of an application, run from the release image
+type ProcessSpec struct {
+	Name      string        `json:"name" validate:"required,max=20"` // Names its objects <app>-<name>
+	Type      string        `json:"type" validate:"required,oneof=web worker cron"`
+	Command   []string      `json:"command" validate:"required,min=1"`
+	Args      []string      `json:"args,omitempty"`
+	Replicas  int32         `json:"replicas,omitempty" validate:"min=0,max=100"` // Web and worker only, 0 uses the default of 1
+	Resources *ResourceSpec `json:"resources,omitempty"`
+	Ports     []PortSpec    `json:"ports,omitempty" validate:"max=10,dive"` // Web only, at least one
+	Schedule  string        `json:"schedule,omitempty"`                     // Cron only: a cron expression, in UTC
+}
+
+// AutoscalingSpec scales an application with a HorizontalPodAutoscaler
+type AutoscalingSpec struct {
+	MinReplicas             int32              `json:"min_replicas" validate:"required,min=1,max=100"`
+	MaxReplicas             int32              `json:"max_replicas" validate:"required,min=1,max=100"`
+	TargetCPUUtilization    int32              `json:"target_cpu_utilization,omitempty" validate:"min=0,max=1000"`    // Percentage of the CPU request
+	TargetMemoryUtilization int32              `json:"target_memory_utilization,omitempty" validate:"min=0,max=1000"` // Percentage of the memory request
+	Metrics                 []CustomMetricSpec `json:"metrics,omitempty" validate:"max=10,dive"`
+}
+
+// CustomMetricSpec is a per-pod metric an application is scaled on
+type CustomMetricSpec struct {
+	Name               string `json:"name" validate:"required,max=253"`
+	TargetAverageValue string `json:"target_average_value" validate:"required"` // Kubernetes quantity, such as 100 or 500m
+}
+
+// StrategySpec selects how a new release replaces the running one
+type StrategySpec struct {
+	Type           string `json:"type" validate:"required,oneof=rolling blue_green canary"`
+	MaxSurge       string `json:"max_surge,omitempty"`                                       // Rolling only: a pod count or percentage
+	MaxUnavailable string `json:"max_unavailable,omitempty"`                                 // Rolling only: a pod count or percentage
+	CanaryWeight   int32  `json:"canary_weight,omitempty" validate:"omitempty,min=1,max=99"` // Canary only: percentage of traffic
+}
+
+// RolloutSpec sets when a rollout counts as healthy and what happens when it does not
+type RolloutSpec struct {
+	TimeoutSeconds  int32 `json:"timeout_seconds,omitempty" validate:"omitempty,min=30,max=3600"` // Defaults to 300
+	MinReadySeconds int32 `json:"min_ready_seconds,omitempty" validate:"min=0,max=600"`           // Time a pod must stay ready to count as available
+	MaxRestarts     int32 `json:"max_restarts,omitempty" validate:"omitempty,min=1,max=100"`      // Defaults to 3
+	AutoRollback    *bool `json:"auto_rollback,omitempty"`                                        // Defaults to true
+	AutoHeal        bool  `json:"auto_heal,omitempty"`                                            // Re-apply the release when drift is detected
+}
+
+// PortSpec is a port the container listens on
+type PortSpec struct {
+	Name        string `json:"name,omitempty" validate:"omitempty,max=15"` // Required when there is more than one port
+	Port        int32  `json:"port" validate:"required,min=1,max=65535"`
+	Protocol    string `json:"protocol,omitempty" validate:"omitempty,oneof=TCP UDP SCTP"`
+	ServicePort int32  `json:"service_port,omitempty" validate:"omitempty,min=1,max=65535"` // Port exposed by the Service, defaults to Port
+}
+
+// ProbeSpec is an HTTP GET health probe. Zero timings use the Kubernetes defaults.
+type ProbeSpec struct {
+	Path                string `json:"path" validate:"required,startswith=/"`
+	Port                int32  `json:"port,omitempty" validate:"omitempty,min=1,max=65535"` // Defaults to the first port
+	InitialDelaySeconds int32  `json:"initial_delay_seconds,omitempty" validate:"min=0"`
+	PeriodSeconds       int32  `json:"period_seconds,omitempty" validate:"min=0"`
+	TimeoutSeconds      int32  `json:"timeout_seconds,omitempty" validate:"min=0"`
+	FailureThreshold    int32  `json:"failure_threshold,omitempty" validate:"min=0"`
+}
+
+// ResourceSpec holds container resource requests and limits as Kubernetes quantities
+type ResourceSpec struct {
+	CPURequest    string `json:"cpu_request,omitempty"`
+	CPULimit      string `json:"cpu_limit,omitempty"`
+	MemoryRequest string `json:"memory_request,omitempty"`
+	MemoryLimit   string `json:"memory_limit,omitempty"`
+}
+
+// ApplicationSpec is a stored version of an application's deployment spec
+type ApplicationSpec struct {
+	ID        uuid.UUID      `json:"id"`
+	AppID     uuid.UUID      `json:"app_id"`
+	Version   int            `json:"version"`
+	Spec      DeploymentSpec `json:"spec"`
+	CreatedBy uuid.UUID      `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ApplicationSpecResponse represents an application's deployment spec; version 0 is the default
+type ApplicationSpecResponse struct {
+	AppID     uuid.UUID      `json:"app_id"`
+	Version   int            `json:"version"`
+	Spec      DeploymentSpec `json:"spec"`
+	CreatedBy *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt *time.Time     `json:"created_at,omitempty"`
+}
+
+// DefaultDeploymentSpec returns the spec used for applications that never had one saved
+func DefaultDeploymentSpec() DeploymentSpec {
+	return DeploymentSpec{
+		Replicas: 1,
+		Ports: []PortSpec{
+			{Name: "http", Port: 8080, Protocol: "TCP", ServicePort: 80},
+		},
+		LivenessProbe: &ProbeSpec{
+			Path:                "/health",
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       10,
+		},
+		ReadinessProbe: &ProbeSpec{
+			Path:                "/ready",
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       5,
+		},
+		Resources: &ResourceSpec{
+			CPURequest:    "100m",
+			CPULimit:      "500m",
+			MemoryRequest: "128Mi",
+			MemoryLimit:   "512Mi",
+		},
+		ServiceType: ServiceTypeClusterIP,
+	}
+}
+
+// WithDefaults returns a copy of the spec with unset fields filled in
+func (s DeploymentSpec) WithDefaults() DeploymentSpec {
+	if s.Replicas == 0 {
+		s.Replicas = 1
+	}
+	if s.ServiceType == "" {
+		s.ServiceType = ServiceTypeClusterIP
+	}
+
+	s.Ports = withPortDefaults(s.Ports)
+
+	if s.Strategy != nil {
+		strategy := *s.Strategy
+		if strategy.Type == StrategyCanary && strategy.CanaryWeight == 0 {
+			strategy.CanaryWeight = DefaultCanaryWeight
+		}
+		s.Strategy = &strategy
+	}
+
+	if s.Rollout != nil {
+		rollout := s.Rollout.withDefaults()
+		s.Rollout = &rollout
+	}
+
+	if len(s.Processes) > 0 {
+		processes := make([]ProcessSpec, len(s.Processes))
+		for i, process := range s.Processes {
+			if process.Type != ProcessTypeCron && process.Replicas == 0 {
+				process.Replicas = 1
+			}
+			process.Ports = withPortDefaults(process.Ports)
+			processes[i] = process
+		}
+		s.Processes = processes
+	}
+
+	if len(s.Volumes) > 0 {
+		volumes := make([]VolumeSpec, len(s.Volumes))
+		for i, volume := range s.Volumes {
+			if volume.AccessMode == "" {
+				volume.AccessMode = VolumeAccessReadWriteOnce
+			}
+			volumes[i] = volume
+		}
+		s.Volumes = volumes
+	}
+
+	return s
+}
+
+// withPortDefaults returns a copy of ports with unset fields filled in
+func withPortDefaults(ports []PortSpec) []PortSpec {
+	if ports == nil {
+		return nil
+	}
+	withDefaults := make([]PortSpec, len(ports))
+	for i, port := range ports {
+		if port.Protocol == "" {
+			port.Protocol = "TCP"
+		}
+		if port.ServicePort == 0 {
+			port.ServicePort = port.Port
+		}
+		withDefaults[i] = port
+	}
+	return withDefaults
+}
+
+// RolloutSettings returns the spec's rollout settings with unset fields filled in
+func (s DeploymentSpec) RolloutSettings() RolloutSpec {
+	if s.Rollout == nil {
+		return RolloutSpec{}.withDefaults()
+	}
+	return s.Rollout.withDefaults()
+}
+
+// withDefaults returns a copy of the rollout settings with unset fields filled in
+func (r RolloutSpec) withDefaults() RolloutSpec {
+	if r.TimeoutSeconds == 0 {
+		r.TimeoutSeconds = DefaultRolloutTimeoutSeconds
+	}
+	if r.MaxRestarts == 0 {
+		r.MaxRestarts = DefaultRolloutMaxRestarts
+	}
+	if r.AutoRollback == nil {
+		autoRollback := true
+		r.AutoRollback = &autoRollback
+	}
+	return r
+}
+
+// ToResponse converts an ApplicationSpec to ApplicationSpecResponse
+func (s *ApplicationSpec) ToResponse() ApplicationSpecResponse {
+	createdBy := s.CreatedBy
+	createdAt := s.CreatedAt
+	return ApplicationSpecResponse{
+		AppID:     s.AppID,
+		Version:   s.Version,
+		Spec:      s.Spec,
+		CreatedBy: &createdBy,
+		CreatedAt: &createdAt,
+	}
+}
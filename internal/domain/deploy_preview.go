@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// ObjectAction represents the change a deploy makes to a Kubernetes object
+type ObjectAction string
+
+const (
+	ObjectActionCreate    ObjectAction = "create"
+	ObjectActionUpdate    ObjectAction = "update"
+	ObjectActionUnchanged ObjectAction = "unchanged"
+	ObjectActionDelete    ObjectAction = "delete"
+)
+
+// DeployPreviewRequest is a proposed deploy of a new image or a rollback
+type DeployPreviewRequest struct {
+	Image       string          `json:"image,omitempty"`
+	Tag         string          `json:"tag,omitempty"`
+	Environment string          `json:"environment,omitempty"` // Required for a deploy when the application has environments
+	ReleaseID   *uuid.UUID      `json:"release_id,omitempty"`  // Preview a rollback to this release; Image, Tag and Environment must be empty
+	Spec        *DeploymentSpec `json:"spec,omitempty"`
+}
+
+// DeployPreviewResponse is what a proposed deploy would change in the cluster
+type DeployPreviewResponse struct {
+	AppID       uuid.UUID       `json:"app_id"`
+	Image       string          `json:"image"`
+	Tag         string          `json:"tag"`
+	ImageDigest string          `json:"image_digest"` // Digest the deploy pins the image to
+	Environment string          `json:"environment,omitempty"`
+	SpecVersion int             `json:"spec_version"` // 0 for the default spec or a spec given in the request
+	Strategy    string          `json:"strategy"`
+	Source      string          `json:"source"`           // Deploy source the objects are rendered from
+	Commit      string          `json:"commit,omitempty"` // Commit the deploy source is rendered at
+	Namespace   string          `json:"namespace"`
+	Objects     []ObjectPreview `json:"objects"`
+}
+
+// ObjectPreview is the change a deploy makes to one Kubernetes object
+type ObjectPreview struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Action    ObjectAction `json:"action"`
+	Manifest  string       `json:"manifest"`        // Rendered manifest, or the live object for a delete
+	Diff      string       `json:"diff,omitempty"`  // Unified diff from the live object to the object after the deploy
+	Error     string       `json:"error,omitempty"` // Why the cluster rejected the dry-run of the object
+}
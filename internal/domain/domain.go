@@ -44,6 +44,7 @@ const (
 type Domain struct {
 	ID             uuid.UUID         `json:"id"`
 	AppID          uuid.UUID         `json:"app_id"`
+	EnvironmentID  *uuid.UUID        `json:"environment_id,omitempty"` // Nil for the application's own cluster and namespace
 	Domain         string            `json:"domain"`
 	Provider       DomainProvider    `json:"provider"`
 	ProviderConfig ProviderConfig    `json:"provider_config"`
@@ -98,12 +99,14 @@ type CreateDomainRequest struct {
 	Provider       DomainProvider `json:"provider" validate:"required,oneof=cloudflare route53 manual"`
 	ProviderConfig ProviderConfig `json:"provider_config,omitempty"`
 	ChallengeType  ChallengeType  `json:"challenge_type" validate:"required,oneof=http-01 dns-01"`
+	Environment    string         `json:"environment,omitempty"` // Name of the environment whose Ingress routes the domain
 }
 
 // DomainResponse is the response body for domain details
 type DomainResponse struct {
 	ID              uuid.UUID         `json:"id"`
 	AppID           uuid.UUID         `json:"app_id"`
+	EnvironmentID   *uuid.UUID        `json:"environment_id,omitempty"`
 	Domain          string            `json:"domain"`
 	Provider        DomainProvider    `json:"provider"`
 	ProviderConfig  ProviderConfig    `json:"provider_config"`
@@ -142,6 +145,7 @@ func (d *Domain) ToResponse(certInfo *CertificateInfo) DomainResponse {
 	return DomainResponse{
 		ID:              d.ID,
 		AppID:           d.AppID,
+		EnvironmentID:   d.EnvironmentID,
 		Domain:          d.Domain,
 		Provider:        d.Provider,
 		ProviderConfig:  config,
@@ -189,6 +193,15 @@ func IsValidChallengeType(t string) bool {
 
 // Helper methods
 
+// InEnvironment checks if the domain is routed by an environment, or by the application's own
+// Ingress if environmentID is nil
+func (d *Domain) InEnvironment(environmentID *uuid.UUID) bool {
+	if d.EnvironmentID == nil || environmentID == nil {
+		return d.EnvironmentID == nil && environmentID == nil
+	}
+	return *d.EnvironmentID == *environmentID
+}
+
 // RequiresDNSProvider checks if the domain requires DNS provider configuration
 func (d *Domain) RequiresDNSProvider() bool {
 	return d.Provider != DomainProviderManual && d.ChallengeType == ChallengeTypeDNS01
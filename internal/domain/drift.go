@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DriftStatus represents whether the live objects of a release still match what it deployed
+type DriftStatus string
+
+const (
+	DriftStatusInSync  DriftStatus = "in_sync"
+	DriftStatusDrifted DriftStatus = "drifted"
+	DriftStatusUnknown DriftStatus = "unknown" // The last check failed, e.g. because the cluster could not be reached
+)
+
+// ReleaseDrift is the result of the last check of the release an application, or one of its
+// environments, is running against the live objects in its cluster
+type ReleaseDrift struct {
+	ReleaseID     uuid.UUID     `json:"release_id"`
+	AppID         uuid.UUID     `json:"app_id"`
+	EnvironmentID *uuid.UUID    `json:"environment_id,omitempty"`
+	Environment   string        `json:"environment,omitempty"`
+	Status        DriftStatus   `json:"status"`
+	Objects       []ObjectDrift `json:"objects"`                 // Objects that differ from the release
+	Error         string        `json:"error,omitempty"`         // Why the check, or healing the drift, failed
+	DriftedSince  *time.Time    `json:"drifted_since,omitempty"` // When the drift was first detected
+	Healed        []ObjectDrift `json:"healed,omitempty"`        // Objects whose drift was last reverted
+	HealedAt      *time.Time    `json:"healed_at,omitempty"`     // When drift was last reverted by re-applying the release
+	CheckedAt     time.Time     `json:"checked_at"`
+}
+
+// ObjectDrift is a Kubernetes object of a release that was deleted, or whose fields were changed
+// in the cluster
+type ObjectDrift struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Missing   bool         `json:"missing,omitempty"` // The object was deleted
+	Fields    []FieldDrift `json:"fields,omitempty"`
+}
+
+// FieldDrift is a field whose live value differs from the value the release sets
+type FieldDrift struct {
+	Path    string      `json:"path"` // e.g. spec.template.spec.containers[0].image
+	Live    interface{} `json:"live"`
+	Desired interface{} `json:"desired"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Environment is a stage of an application, such as staging or production
+type Environment struct {
+	ID        uuid.UUID         `json:"id"`
+	AppID     uuid.UUID         `json:"app_id"`
+	Name      string            `json:"name"`
+	ClusterID uuid.UUID         `json:"cluster_id"`
+	Namespace string            `json:"namespace"`
+	Position  int               `json:"position"`           // Order in the promotion chain, lowest first
+	Replicas  *int32            `json:"replicas,omitempty"` // Overrides the deployment spec's replicas
+	EnvVars   map[string]string `json:"env_vars"`           // Take precedence over the release's environment variables
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// EnvironmentResponse represents an environment and the release last deployed to it
+type EnvironmentResponse struct {
+	Environment
+	CurrentRelease *ReleaseSummary `json:"current_release,omitempty"`
+}
+
+// CreateEnvironmentRequest is the request body for creating an environment
+type CreateEnvironmentRequest struct {
+	Name      string            `json:"name" validate:"required,max=63"`
+	ClusterID string            `json:"cluster_id" validate:"required,uuid"`
+	Namespace string            `json:"namespace,omitempty" validate:"omitempty,max=63"` // Defaults to <app name>-<environment name>
+	Position  int               `json:"position" validate:"min=0"`
+	Replicas  *int32            `json:"replicas,omitempty" validate:"omitempty,min=0,max=100"`
+	EnvVars   map[string]string `json:"env_vars,omitempty"`
+}
+
+// UpdateEnvironmentRequest is the request body for updating an environment
+type UpdateEnvironmentRequest struct {
+	Position int               `json:"position" validate:"min=0"`
+	Replicas *int32            `json:"replicas,omitempty" validate:"omitempty,min=0,max=100"`
+	EnvVars  map[string]string `json:"env_vars,omitempty"`
+}
+
+// PromoteReleaseRequest selects the release to promote out of an environment
+type PromoteReleaseRequest struct {
+	ReleaseID *uuid.UUID `json:"release_id,omitempty"`
+}
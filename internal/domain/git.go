@@ -124,17 +124,46 @@ type Job struct {
 	Status       JobStatus  `json:"status"`
 	Payload      JobPayload `json:"payload"`
 	ErrorMessage string     `json:"error_message,omitempty"`
+	Progress     []JobStep  `json:"progress,omitempty"` // Steps of jobs that report their progress
 	CreatedAt    time.Time  `json:"created_at"`
 	StartedAt    *time.Time `json:"started_at,omitempty"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
 }
 
+// JobStepStatus defines the status of a step of a job
+type JobStepStatus string
+
+const (
+	JobStepPending   JobStepStatus = "pending"
+	JobStepRunning   JobStepStatus = "running"
+	JobStepCompleted JobStepStatus = "completed"
+	JobStepSkipped   JobStepStatus = "skipped"
+	JobStepFailed    JobStepStatus = "failed"
+)
+
+// JobStep is a step of a job, run in the order the job lists its steps
+type JobStep struct {
+	Name    string        `json:"name"`
+	Status  JobStepStatus `json:"status"`
+	Message string        `json:"message,omitempty"` // What the step did, or why it was skipped or failed
+}
+
+// NewJobSteps returns the named steps, all pending
+func NewJobSteps(names ...string) []JobStep {
+	steps := make([]JobStep, len(names))
+	for i, name := range names {
+		steps[i] = JobStep{Name: name, Status: JobStepPending}
+	}
+	return steps
+}
+
 // JobPayload contains the data for a background job
 type JobPayload struct {
 	GitServerID *uuid.UUID             `json:"git_server_id,omitempty"`
 	RunnerID    *uuid.UUID             `json:"runner_id,omitempty"`
 	DomainID    *uuid.UUID             `json:"domain_id,omitempty"`
 	PipelineID  *uuid.UUID             `json:"pipeline_id,omitempty"`
+	ReleaseID   *uuid.UUID             `json:"release_id,omitempty"`
 	Config      map[string]interface{} `json:"config,omitempty"`
 }
 
@@ -191,6 +220,7 @@ type JobResponse struct {
 	Status       JobStatus  `json:"status"`
 	Payload      JobPayload `json:"payload"`
 	ErrorMessage string     `json:"error_message,omitempty"`
+	Progress     []JobStep  `json:"progress,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
 	StartedAt    *time.Time `json:"started_at,omitempty"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
@@ -248,6 +278,7 @@ func (j *Job) ToResponse() JobResponse {
 		Status:       j.Status,
 		Payload:      j.Payload,
 		ErrorMessage: j.ErrorMessage,
+		Progress:     j.Progress,
 		CreatedAt:    j.CreatedAt,
 		StartedAt:    j.StartedAt,
 		CompletedAt:  j.CompletedAt,
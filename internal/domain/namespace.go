@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxNamespaceLength is the longest name Kubernetes accepts for a namespace
+const maxNamespaceLength = 63
+
+// DefaultIngressNamespace is the namespace of the ingress controller allowed to reach
+// applications when an organization has not configured its own
+const DefaultIngressNamespace = "ingress-nginx"
+
+// OrgNamespacePrefix returns the prefix of the namespaces of an organization's applications
+func OrgNamespacePrefix(orgID uuid.UUID) string {
+	return orgID.String()[:8] + "-"
+}
+
+// ApplicationNamespace returns the namespace of a new application
+func ApplicationNamespace(orgID uuid.UUID, name string) string {
+	namespace := OrgNamespacePrefix(orgID) + name
+	if len(namespace) <= maxNamespaceLength {
+		return namespace
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(namespace[:maxNamespaceLength-len(suffix)], "-") + suffix
+}
+
+// NamespacePolicy represents the isolation of an organization's application namespaces
+type NamespacePolicy struct {
+	OrgID             uuid.UUID         `json:"org_id"`
+	ResourceQuota     map[string]string `json:"resource_quota"`        // Hard limits by resource name, e.g. requests.cpu; no ResourceQuota when empty
+	LimitRange        *LimitRangeSpec   `json:"limit_range,omitempty"` // No LimitRange when nil
+	IngressNamespaces []string          `json:"ingress_namespaces"`    // Namespaces of the ingress controllers
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// LimitRangeSpec represents the defaults and bounds of the resources of each container, by
+// resource name (cpu, memory, ephemeral-storage)
+type LimitRangeSpec struct {
+	Default        map[string]string `json:"default,omitempty"`         // Limits of containers that set none
+	DefaultRequest map[string]string `json:"default_request,omitempty"` // Requests of containers that set none
+	Min            map[string]string `json:"min,omitempty"`
+	Max            map[string]string `json:"max,omitempty"`
+}
+
+// UpdateNamespacePolicyRequest replaces an organization's namespace policy
+type UpdateNamespacePolicyRequest struct {
+	ResourceQuota     map[string]string `json:"resource_quota,omitempty"`
+	LimitRange        *LimitRangeSpec   `json:"limit_range,omitempty"`
+	IngressNamespaces []string          `json:"ingress_namespaces,omitempty"`
+}
+
+// DefaultNamespacePolicy returns the policy of an organization that has not configured one
+func DefaultNamespacePolicy(orgID uuid.UUID) *NamespacePolicy {
+	return &NamespacePolicy{
+		OrgID:             orgID,
+		ResourceQuota:     map[string]string{},
+		IngressNamespaces: []string{DefaultIngressNamespace},
+	}
+}
@@ -38,6 +38,15 @@ const (
 	PipelineTriggerTypeSchedule PipelineTriggerType = "schedule"
 )
 
+// PipelineEvent represents the Git event a pipeline was created for
+type PipelineEvent string
+
+const (
+	PipelineEventPush        PipelineEvent = "push"
+	PipelineEventTag         PipelineEvent = "tag"
+	PipelineEventPullRequest PipelineEvent = "pull_request" // Also used for GitLab merge requests
+)
+
 // Pipeline represents a CI/CD pipeline
 type Pipeline struct {
 	ID          uuid.UUID              `json:"id"`
@@ -71,6 +80,10 @@ type PipelineStep struct {
 type CreatePipelineRequest struct {
 	Ref       string `json:"ref" binding:"required"`        // Branch or tag reference
 	CommitSHA string `json:"commit_sha" binding:"required"` // Git commit SHA
+
+	// Set by webhook triggers only
+	Event    PipelineEvent `json:"-"` // Defaults to push
+	PRNumber int           `json:"-"` // Pull or merge request number for pull_request pipelines
 }
 
 // PipelineResponse represents the response for pipeline details
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRegistry is the registry of images whose name does not start with a registry host
+const DefaultRegistry = "docker.io"
+
+// RegistryProvider defines the kind of container registry credentials are for
+type RegistryProvider string
+
+const (
+	RegistryProviderDockerHub RegistryProvider = "dockerhub"
+	RegistryProviderGHCR      RegistryProvider = "ghcr"
+	RegistryProviderGitLab    RegistryProvider = "gitlab"
+	RegistryProviderGeneric   RegistryProvider = "generic"
+)
+
+// DefaultHost returns the registry host of a hosted provider, or "" for generic registries
+func (p RegistryProvider) DefaultHost() string {
+	switch p {
+	case RegistryProviderDockerHub:
+		return DefaultRegistry
+	case RegistryProviderGHCR:
+		return "ghcr.io"
+	case RegistryProviderGitLab:
+		return "registry.gitlab.com"
+	default:
+		return ""
+	}
+}
+
+// RegistryProviderForHost returns the provider of a registry host, generic for hosts that are not
+// a hosted provider's
+func RegistryProviderForHost(host string) RegistryProvider {
+	for _, p := range []RegistryProvider{RegistryProviderDockerHub, RegistryProviderGHCR, RegistryProviderGitLab} {
+		if p.DefaultHost() == host {
+			return p
+		}
+	}
+	return RegistryProviderGeneric
+}
+
+// RegistryCredential authenticates an organization to a container registry
+type RegistryCredential struct {
+	ID                uuid.UUID        `json:"id"`
+	OrgID             uuid.UUID        `json:"org_id"`
+	Provider          RegistryProvider `json:"provider"`
+	Registry          string           `json:"registry"` // Host, with port if any, e.g. ghcr.io or registry.example.com:5000
+	Username          string           `json:"username"`
+	PasswordEncrypted []byte           `json:"-"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// RegistryCredentialResponse represents a registry credential without its password
+type RegistryCredentialResponse struct {
+	ID        uuid.UUID        `json:"id"`
+	OrgID     uuid.UUID        `json:"org_id"`
+	Provider  RegistryProvider `json:"provider"`
+	Registry  string           `json:"registry"`
+	Username  string           `json:"username"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// CreateRegistryCredentialRequest represents a request to add credentials for a registry
+type CreateRegistryCredentialRequest struct {
+	Provider RegistryProvider `json:"provider,omitempty" validate:"omitempty,oneof=dockerhub ghcr gitlab generic"`
+	Registry string           `json:"registry,omitempty" validate:"max=253"`
+	Username string           `json:"username" validate:"required,max=255"`
+	Password string           `json:"password" validate:"required,max=4096"`
+}
+
+// UpdateRegistryCredentialRequest represents a request to replace the credentials for a registry
+type UpdateRegistryCredentialRequest struct {
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=4096"`
+}
+
+// ToResponse converts a RegistryCredential to RegistryCredentialResponse
+func (c *RegistryCredential) ToResponse() RegistryCredentialResponse {
+	return RegistryCredentialResponse{
+		ID:        c.ID,
+		OrgID:     c.OrgID,
+		Provider:  c.Provider,
+		Registry:  c.Registry,
+		Username:  c.Username,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
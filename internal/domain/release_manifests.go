@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// ManifestFile is a file of a release's exported manifests
+type ManifestFile struct {
+	Name    string // File name, without a directory
+	Content []byte
+}
+
+// ReleaseManifests are the manifests of a release's objects, without its Secrets
+type ReleaseManifests struct {
+	AppName   string
+	ReleaseID uuid.UUID
+	Files     []ManifestFile
+}
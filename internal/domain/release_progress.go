@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReleaseProgressType is the kind of step a rollout progress event reports
+type ReleaseProgressType string
+
+const (
+	ReleaseProgressStatus            ReleaseProgressType = "status"             // Release status or phase changed
+	ReleaseProgressManifestApplied   ReleaseProgressType = "manifest_applied"   // An object was server-side applied
+	ReleaseProgressResourceDeleted   ReleaseProgressType = "resource_deleted"   // An object that is no longer part of the release was deleted
+	ReleaseProgressReplicaSetCreated ReleaseProgressType = "replicaset_created" // The Deployment created the ReplicaSet of the release
+	ReleaseProgressPodScheduled      ReleaseProgressType = "pod_scheduled"      // A pod of the release was scheduled to a node
+	ReleaseProgressReplicas          ReleaseProgressType = "replicas"           // Replica counts of the Deployment changed
+	ReleaseProgressWarning           ReleaseProgressType = "warning"            // A warning Kubernetes event in the application's namespace
+	ReleaseProgressRolloutFailed     ReleaseProgressType = "rollout_failed"     // The rollout failed; Message holds the reason
+	ReleaseProgressTaskStarted       ReleaseProgressType = "task_started"       // The Job of a pre- or post-deploy task was created
+	ReleaseProgressTaskLog           ReleaseProgressType = "task_log"           // Output of a release task; Message holds the new lines
+	ReleaseProgressTaskFinished      ReleaseProgressType = "task_finished"      // A release task finished; Reason is its status, Message why it failed
+	ReleaseProgressGitOpsCommit      ReleaseProgressType = "gitops_commit"      // The release's manifests were committed to its GitOps repository; Message holds the commit
+)
+
+// ReleaseProgressEvent is a step of a release's rollout, streamed by
+// GET /apps/:appId/releases/:releaseId/stream
+type ReleaseProgressEvent struct {
+	ReleaseID uuid.UUID           `json:"release_id"`
+	Type      ReleaseProgressType `json:"type"`
+	Status    ReleaseStatus       `json:"status,omitempty"`
+	Phase     ReleasePhase        `json:"phase,omitempty"`
+	Kind      string              `json:"kind,omitempty"` // Kind of the object the event is about
+	Name      string              `json:"name,omitempty"` // Name of the object the event is about
+	Reason    string              `json:"reason,omitempty"`
+	Message   string              `json:"message,omitempty"`
+	Replicas  *ReplicaCounts      `json:"replicas,omitempty"`
+	Time      time.Time           `json:"time"`
+}
+
+// ReplicaCounts are the replica counts of a Deployment
+type ReplicaCounts struct {
+	Desired   int32 `json:"desired"`
+	Updated   int32 `json:"updated"`
+	Ready     int32 `json:"ready"`
+	Available int32 `json:"available"`
+}
+
+// EndsRollout returns true if the event is the last of a rollout
+func (e ReleaseProgressEvent) EndsRollout() bool {
+	if e.Type != ReleaseProgressStatus {
+		return false
+	}
+	switch e.Status {
+	case ReleaseStatusSucceeded, ReleaseStatusFailed, ReleaseStatusRolledBack:
+		return true
+	}
+	return e.Phase == ReleasePhaseCanary
+}
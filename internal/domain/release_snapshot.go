@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReleaseSnapshot is an encrypted record of what a release was rolled out with besides its spec,
+// referenced from the metadata of the releases that deploy it
+type ReleaseSnapshot struct {
+	ID            uuid.UUID `json:"id"`
+	AppID         uuid.UUID `json:"app_id"`
+	DataEncrypted []byte    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+}
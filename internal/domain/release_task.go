@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReleaseTaskKind is when a release task runs
+type ReleaseTaskKind string
+
+const (
+	ReleaseTaskPreDeploy  ReleaseTaskKind = "pre_deploy"  // Before the release is rolled out
+	ReleaseTaskPostDeploy ReleaseTaskKind = "post_deploy" // After the release was rolled out
+	ReleaseTaskRun        ReleaseTaskKind = "run"         // Ad-hoc, with POST /apps/:appId/run
+)
+
+// ReleaseTaskStatus represents the status of a release task
+type ReleaseTaskStatus string
+
+const (
+	ReleaseTaskStatusPending   ReleaseTaskStatus = "pending"
+	ReleaseTaskStatusRunning   ReleaseTaskStatus = "running"
+	ReleaseTaskStatusSucceeded ReleaseTaskStatus = "succeeded"
+	ReleaseTaskStatusFailed    ReleaseTaskStatus = "failed"
+)
+
+// ReleaseTask is a one-off command run to completion with the image of a release
+type ReleaseTask struct {
+	ID            uuid.UUID         `json:"id"`
+	AppID         uuid.UUID         `json:"app_id"`
+	ReleaseID     uuid.UUID         `json:"release_id"`
+	Kind          ReleaseTaskKind   `json:"kind"`
+	Command       []string          `json:"command"` // Command followed by its arguments
+	Status        ReleaseTaskStatus `json:"status"`
+	JobName       string            `json:"job_name,omitempty"`
+	ExitCode      *int32            `json:"exit_code,omitempty"`
+	FailureReason string            `json:"failure_reason,omitempty"`
+	Logs          string            `json:"logs"`
+	CreatedBy     uuid.UUID         `json:"created_by"`
+	StartedAt     *time.Time        `json:"started_at"`
+	FinishedAt    *time.Time        `json:"finished_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// RunCommandRequest is the request body of POST /apps/:appId/run
+type RunCommandRequest struct {
+	Command        []string `json:"command" validate:"required,min=1"`
+	Environment    string   `json:"environment,omitempty"`                                          // Required when the application has environments
+	TimeoutSeconds int32    `json:"timeout_seconds,omitempty" validate:"omitempty,min=10,max=3600"` // Defaults to 600
+}
+
+// IsCompleted returns true if the task has finished
+func (t *ReleaseTask) IsCompleted() bool {
+	return t.Status == ReleaseTaskStatusSucceeded || t.Status == ReleaseTaskStatusFailed
+}
+
+// NewRunJob returns the queued job that runs an ad-hoc task
+func (t *ReleaseTask) NewRunJob(orgID uuid.UUID, timeoutSeconds int32) *Job {
+	releaseID := t.ReleaseID
+	return &Job{
+		OrgID:  orgID,
+		Type:   JobTypeReleaseTask,
+		Status: JobStatusPending,
+		Payload: JobPayload{
+			ReleaseID: &releaseID,
+			Config: map[string]interface{}{
+				"app_id":          t.AppID.String(),
+				"task_id":         t.ID.String(),
+				"timeout_seconds": timeoutSeconds,
+			},
+		},
+	}
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"encoding/json"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -50,6 +52,7 @@ type RepositoryResponse struct {
 	URL           string          `json:"url"`
 	DefaultBranch string          `json:"default_branch"`
 	Config        json.RawMessage `json:"config"`
+	WebhookSecret string          `json:"webhook_secret,omitempty"` // Plaintext secret, only returned when generated
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
@@ -57,6 +60,7 @@ type RepositoryResponse struct {
 // Webhook payload structures
 type WebhookPayload struct {
 	Ref        string `json:"ref"`
+	After      string `json:"after"`
 	Repository struct {
 		FullName string `json:"full_name"`
 		CloneURL string `json:"clone_url"`
@@ -82,11 +86,18 @@ type WebhookPayload struct {
 
 // GitLab webhook payload structure
 type GitLabWebhookPayload struct {
-	Ref        string `json:"ref"`
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSHA string `json:"checkout_sha"`
+	Project     struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+	} `json:"project"`
 	Repository struct {
-		Name     string `json:"name"`
-		URL      string `json:"url"`
-		Homepage string `json:"homepage"`
+		Name       string `json:"name"`
+		URL        string `json:"url"`
+		Homepage   string `json:"homepage"`
+		GitHTTPURL string `json:"git_http_url"`
 	} `json:"repository"`
 	Commits []struct {
 		ID      string `json:"id"`
@@ -101,6 +112,7 @@ type GitLabWebhookPayload struct {
 // Gitea webhook payload structure
 type GiteaWebhookPayload struct {
 	Ref        string `json:"ref"`
+	After      string `json:"after"`
 	Repository struct {
 		FullName string `json:"full_name"`
 		CloneURL string `json:"clone_url"`
@@ -116,6 +128,64 @@ type GiteaWebhookPayload struct {
 	} `json:"commits"`
 }
 
+// GitHubPullRequestPayload is the payload of a GitHub pull_request event
+type GitHubPullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+	} `json:"repository"`
+}
+
+// GitLabMergeRequestPayload is the payload of a GitLab merge request event
+type GitLabMergeRequestPayload struct {
+	ObjectKind       string `json:"object_kind"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+	} `json:"project"`
+}
+
+// GiteaPullRequestPayload is the payload of a Gitea pull_request event
+type GiteaPullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+	} `json:"repository"`
+}
+
 // Valid repository types
 const (
 	RepoTypeGitHub = "github"
@@ -138,14 +208,47 @@ func IsValidRepositoryType(repoType string) bool {
 	return false
 }
 
+var (
+	repoURLSchemeRe   = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://`)
+	repoURLUserInfoRe = regexp.MustCompile(`^[^@/]*@`)
+	repoURLPortRe     = regexp.MustCompile(`^([^/:]+):[0-9]+/`)
+	repoURLSCPRe      = regexp.MustCompile(`^([^/:]+):`)
+	repoURLSuffixRe   = regexp.MustCompile(`(\.git)?/*$`)
+)
+
+// NormalizeRepositoryURL reduces a clone URL to "host/owner/name", as migration 0013 does
+func NormalizeRepositoryURL(rawURL string) string {
+	normalized := strings.ToLower(strings.TrimSpace(rawURL))
+	normalized = repoURLSchemeRe.ReplaceAllString(normalized, "")
+	normalized = repoURLUserInfoRe.ReplaceAllString(normalized, "")
+	normalized = repoURLPortRe.ReplaceAllString(normalized, "$1/")
+	normalized = repoURLSCPRe.ReplaceAllString(normalized, "$1/")
+	normalized = repoURLSuffixRe.ReplaceAllString(normalized, "")
+	return normalized
+}
+
 // ToResponse converts a Repository to RepositoryResponse
 func (r *Repository) ToResponse() RepositoryResponse {
+	// Mask sensitive information
+	config := r.Config
+	if repoConfig, err := r.GetConfig(); err == nil {
+		if repoConfig.Token != "" {
+			repoConfig.Token = "***MASKED***"
+		}
+		if repoConfig.Secret != "" {
+			repoConfig.Secret = "***MASKED***"
+		}
+		if masked, err := json.Marshal(repoConfig); err == nil {
+			config = masked
+		}
+	}
+
 	return RepositoryResponse{
 		ID:            r.ID,
 		Type:          r.Type,
 		URL:           r.URL,
 		DefaultBranch: r.DefaultBranch,
-		Config:        r.Config,
+		Config:        config,
 		CreatedAt:     r.CreatedAt,
 		UpdatedAt:     r.UpdatedAt,
 	}
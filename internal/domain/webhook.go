@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRequest represents an inbound Git webhook delivery as received by /hooks/git
+type WebhookRequest struct {
+	Provider   string
+	Event      string            // Event type header, e.g. X-GitHub-Event
+	DeliveryID string            // Provider-assigned delivery identifier
+	Signature  string            // Signature or secret token header
+	Headers    map[string]string // All request headers, first value per key
+	Payload    []byte
+}
+
+// WebhookDeliveryStatus represents the outcome of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusProcessed WebhookDeliveryStatus = "processed" // At least one pipeline was triggered
+	WebhookDeliveryStatusIgnored   WebhookDeliveryStatus = "ignored"   // Verified, but nothing to trigger
+	WebhookDeliveryStatusRejected  WebhookDeliveryStatus = "rejected"  // Unparseable, unmatched or failed verification
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"    // Triggering a pipeline failed
+)
+
+// WebhookDelivery is the persisted record of a webhook delivery for one repository
+type WebhookDelivery struct {
+	ID           uuid.UUID             `json:"id"`
+	RepoID       *uuid.UUID            `json:"repo_id,omitempty"`
+	Provider     string                `json:"provider"`
+	Event        string                `json:"event"`
+	DeliveryID   string                `json:"delivery_id"`
+	Headers      map[string]string     `json:"headers"`
+	Payload      []byte                `json:"payload"`
+	Verified     bool                  `json:"verified"`
+	Status       WebhookDeliveryStatus `json:"status"`
+	Message      string                `json:"message"`
+	PipelineIDs  []uuid.UUID           `json:"pipeline_ids"`
+	RedeliveryOf *uuid.UUID            `json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// WebhookDeliverySummary represents a webhook delivery in list views
+type WebhookDeliverySummary struct {
+	ID           uuid.UUID             `json:"id"`
+	Provider     string                `json:"provider"`
+	Event        string                `json:"event"`
+	DeliveryID   string                `json:"delivery_id"`
+	Verified     bool                  `json:"verified"`
+	Status       WebhookDeliveryStatus `json:"status"`
+	Message      string                `json:"message"`
+	PipelineIDs  []uuid.UUID           `json:"pipeline_ids"`
+	RedeliveryOf *uuid.UUID            `json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// WebhookDeliveryResponse represents a webhook delivery with its headers and payload
+type WebhookDeliveryResponse struct {
+	WebhookDeliverySummary
+	Headers map[string]string `json:"headers"`
+	Payload json.RawMessage   `json:"payload"`
+}
+
+// sensitiveWebhookHeaders are replaced before a delivery is persisted
+var sensitiveWebhookHeaders = []string{"X-Gitlab-Token", "Authorization", "Cookie"}
+
+// MaskWebhookHeaders returns a copy of the headers with secret values masked
+func MaskWebhookHeaders(headers map[string]string) map[string]string {
+	masked := make(map[string]string, len(headers))
+	for key, value := range headers {
+		masked[key] = value
+	}
+	for _, key := range sensitiveWebhookHeaders {
+		if _, ok := masked[http.CanonicalHeaderKey(key)]; ok {
+			masked[http.CanonicalHeaderKey(key)] = "***MASKED***"
+		}
+	}
+	return masked
+}
+
+// ToSummary converts a WebhookDelivery to WebhookDeliverySummary
+func (d *WebhookDelivery) ToSummary() WebhookDeliverySummary {
+	pipelineIDs := d.PipelineIDs
+	if pipelineIDs == nil {
+		pipelineIDs = []uuid.UUID{}
+	}
+
+	return WebhookDeliverySummary{
+		ID:           d.ID,
+		Provider:     d.Provider,
+		Event:        d.Event,
+		DeliveryID:   d.DeliveryID,
+		Verified:     d.Verified,
+		Status:       d.Status,
+		Message:      d.Message,
+		PipelineIDs:  pipelineIDs,
+		RedeliveryOf: d.RedeliveryOf,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+// ToResponse converts a WebhookDelivery to WebhookDeliveryResponse
+func (d *WebhookDelivery) ToResponse() WebhookDeliveryResponse {
+	// Payloads that are not valid JSON are returned as a JSON string, and dropped ones as null
+	var payload json.RawMessage
+	if json.Valid(d.Payload) {
+		payload = json.RawMessage(d.Payload)
+	} else if len(d.Payload) > 0 {
+		payload, _ = json.Marshal(string(d.Payload))
+	}
+
+	return WebhookDeliveryResponse{
+		WebhookDeliverySummary: d.ToSummary(),
+		Headers:                d.Headers,
+		Payload:                payload,
+	}
+}
+
+// ToRequest rebuilds the inbound request from a stored delivery for redelivery
+func (d *WebhookDelivery) ToRequest() *WebhookRequest {
+	return &WebhookRequest{
+		Provider:   d.Provider,
+		Event:      d.Event,
+		DeliveryID: d.DeliveryID,
+		Headers:    d.Headers,
+		Payload:    d.Payload,
+	}
+}
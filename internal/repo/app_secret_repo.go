@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type AppSecretRepository interface {
+	CreateAppSecret(ctx context.Context, secret *domain.AppSecret) (*domain.AppSecret, error)
+	GetAppSecretByName(ctx context.Context, appID uuid.UUID, name string) (*domain.AppSecret, error)
+	GetAppSecretsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.AppSecret, error)
+	UpdateAppSecretValue(ctx context.Context, appID uuid.UUID, name string, valueEncrypted []byte) (*domain.AppSecret, error)
+	DeleteAppSecret(ctx context.Context, appID uuid.UUID, name string) error
+}
+
+type appSecretRepository struct {
+	db *sql.DB
+}
+
+func NewAppSecretRepository(db *sql.DB) AppSecretRepository {
+	return &appSecretRepository{db: db}
+}
+
+func (r *appSecretRepository) CreateAppSecret(ctx context.Context, secret *domain.AppSecret) (*domain.AppSecret, error) {
+	query := `
+		INSERT INTO app_secrets (app_id, name, value_encrypted)
+		VALUES ($1, $2, $3)
+		RETURNING id, app_id, name, value_encrypted, created_at, updated_at
+	`
+
+	var created domain.AppSecret
+	err := r.db.QueryRowContext(ctx, query, secret.AppID, secret.Name, secret.ValueEncrypted).Scan(
+		&created.ID,
+		&created.AppID,
+		&created.Name,
+		&created.ValueEncrypted,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *appSecretRepository) GetAppSecretByName(ctx context.Context, appID uuid.UUID, name string) (*domain.AppSecret, error) {
+	query := `
+		SELECT id, app_id, name, value_encrypted, created_at, updated_at
+		FROM app_secrets
+		WHERE app_id = $1 AND name = $2
+	`
+
+	var secret domain.AppSecret
+	err := r.db.QueryRowContext(ctx, query, appID, name).Scan(
+		&secret.ID,
+		&secret.AppID,
+		&secret.Name,
+		&secret.ValueEncrypted,
+		&secret.CreatedAt,
+		&secret.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func (r *appSecretRepository) GetAppSecretsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.AppSecret, error) {
+	query := `
+		SELECT id, app_id, name, value_encrypted, created_at, updated_at
+		FROM app_secrets
+		WHERE app_id = $1
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []domain.AppSecret
+	for rows.Next() {
+		var secret domain.AppSecret
+		err := rows.Scan(
+			&secret.ID,
+			&secret.AppID,
+			&secret.Name,
+			&secret.ValueEncrypted,
+			&secret.CreatedAt,
+			&secret.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+func (r *appSecretRepository) UpdateAppSecretValue(ctx context.Context, appID uuid.UUID, name string, valueEncrypted []byte) (*domain.AppSecret, error) {
+	query := `
+		UPDATE app_secrets
+		SET value_encrypted = $3, updated_at = NOW()
+		WHERE app_id = $1 AND name = $2
+		RETURNING id, app_id, name, value_encrypted, created_at, updated_at
+	`
+
+	var secret domain.AppSecret
+	err := r.db.QueryRowContext(ctx, query, appID, name, valueEncrypted).Scan(
+		&secret.ID,
+		&secret.AppID,
+		&secret.Name,
+		&secret.ValueEncrypted,
+		&secret.CreatedAt,
+		&secret.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func (r *appSecretRepository) DeleteAppSecret(ctx context.Context, appID uuid.UUID, name string) error {
+	query := `DELETE FROM app_secrets WHERE app_id = $1 AND name = $2`
+
+	_, err := r.db.ExecContext(ctx, query, appID, name)
+	return err
+}
@@ -15,6 +15,8 @@ type ApplicationRepository interface {
 	GetApplicationByID(ctx context.Context, id uuid.UUID) (*domain.Application, error)
 	GetApplicationsByClusterID(ctx context.Context, clusterID uuid.UUID) ([]domain.ApplicationSummary, error)
 	GetApplicationByNameInCluster(ctx context.Context, clusterID uuid.UUID, name string) (*domain.Application, error)
+	GetApplicationByNamespaceInCluster(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Application, error)
+	GetApplicationsByRepoID(ctx context.Context, repoID uuid.UUID) ([]domain.Application, error)
 	DeleteApplication(ctx context.Context, id uuid.UUID) error
 }
 
@@ -23,8 +25,14 @@ type ReleaseRepository interface {
 	GetReleaseByID(ctx context.Context, id uuid.UUID) (*domain.Release, error)
 	GetReleasesByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseSummary, error)
 	GetLatestReleaseByAppID(ctx context.Context, appID uuid.UUID) (*domain.Release, error)
+	GetLatestReleaseByEnvironment(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID) (*domain.Release, error)
+	GetLatestReleaseByAppIDAndStatus(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID, status domain.ReleaseStatus) (*domain.Release, error)
+	GetCurrentReleases(ctx context.Context) ([]domain.Release, error)
 	UpdateReleaseStatus(ctx context.Context, id uuid.UUID, status domain.ReleaseStatus, startedAt, finishedAt *time.Time) (*domain.Release, error)
+	UpdateReleasePhase(ctx context.Context, id uuid.UUID, phase domain.ReleasePhase) (*domain.Release, error)
+	TransitionReleasePhase(ctx context.Context, id uuid.UUID, from, to domain.ReleasePhase) (*domain.Release, error)
 	UpdateReleaseMeta(ctx context.Context, id uuid.UUID, meta []byte) (*domain.Release, error)
+	UpdateReleaseImageDigest(ctx context.Context, id uuid.UUID, digest string) (*domain.Release, error)
 	DeleteRelease(ctx context.Context, id uuid.UUID) error
 }
 
@@ -48,9 +56,9 @@ func NewReleaseRepository(db *sql.DB) ReleaseRepository {
 
 func (r *applicationRepository) CreateApplication(ctx context.Context, app *domain.Application) (*domain.Application, error) {
 	query := `
-		INSERT INTO applications (org_id, cluster_id, name, repo_id, path, default_branch)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, org_id, cluster_id, name, repo_id, path, default_branch, created_at, updated_at
+		INSERT INTO applications (org_id, cluster_id, name, namespace, repo_id, path, default_branch)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
 	`
 
 	var createdApp domain.Application
@@ -58,6 +66,7 @@ func (r *applicationRepository) CreateApplication(ctx context.Context, app *doma
 		app.OrgID,
 		app.ClusterID,
 		app.Name,
+		app.Namespace,
 		app.RepoID,
 		app.Path,
 		app.DefaultBranch,
@@ -66,6 +75,7 @@ func (r *applicationRepository) CreateApplication(ctx context.Context, app *doma
 		&createdApp.OrgID,
 		&createdApp.ClusterID,
 		&createdApp.Name,
+		&createdApp.Namespace,
 		&createdApp.RepoID,
 		&createdApp.Path,
 		&createdApp.DefaultBranch,
@@ -82,7 +92,7 @@ func (r *applicationRepository) CreateApplication(ctx context.Context, app *doma
 
 func (r *applicationRepository) GetApplicationByID(ctx context.Context, id uuid.UUID) (*domain.Application, error) {
 	query := `
-		SELECT id, org_id, cluster_id, name, repo_id, path, default_branch, created_at, updated_at
+		SELECT id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
 		FROM applications
 		WHERE id = $1
 	`
@@ -93,6 +103,7 @@ func (r *applicationRepository) GetApplicationByID(ctx context.Context, id uuid.
 		&app.OrgID,
 		&app.ClusterID,
 		&app.Name,
+		&app.Namespace,
 		&app.RepoID,
 		&app.Path,
 		&app.DefaultBranch,
@@ -112,7 +123,7 @@ func (r *applicationRepository) GetApplicationByID(ctx context.Context, id uuid.
 
 func (r *applicationRepository) GetApplicationsByClusterID(ctx context.Context, clusterID uuid.UUID) ([]domain.ApplicationSummary, error) {
 	query := `
-		SELECT id, org_id, cluster_id, name, repo_id, path, default_branch, created_at, updated_at
+		SELECT id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
 		FROM applications
 		WHERE cluster_id = $1
 		ORDER BY created_at DESC
@@ -134,6 +145,7 @@ func (r *applicationRepository) GetApplicationsByClusterID(ctx context.Context,
 			&orgID,
 			&clusterID,
 			&app.Name,
+			&app.Namespace,
 			&app.RepoID,
 			&app.Path,
 			&app.DefaultBranch,
@@ -152,7 +164,7 @@ func (r *applicationRepository) GetApplicationsByClusterID(ctx context.Context,
 
 func (r *applicationRepository) GetApplicationByNameInCluster(ctx context.Context, clusterID uuid.UUID, name string) (*domain.Application, error) {
 	query := `
-		SELECT id, org_id, cluster_id, name, repo_id, path, default_branch, created_at, updated_at
+		SELECT id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
 		FROM applications
 		WHERE cluster_id = $1 AND name = $2
 	`
@@ -163,6 +175,7 @@ func (r *applicationRepository) GetApplicationByNameInCluster(ctx context.Contex
 		&app.OrgID,
 		&app.ClusterID,
 		&app.Name,
+		&app.Namespace,
 		&app.RepoID,
 		&app.Path,
 		&app.DefaultBranch,
@@ -180,6 +193,78 @@ func (r *applicationRepository) GetApplicationByNameInCluster(ctx context.Contex
 	return &app, nil
 }
 
+// GetApplicationByNamespaceInCluster returns the application deployed to a namespace of a cluster
+func (r *applicationRepository) GetApplicationByNamespaceInCluster(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Application, error) {
+	query := `
+		SELECT id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
+		FROM applications
+		WHERE cluster_id = $1 AND namespace = $2
+	`
+
+	var app domain.Application
+	err := r.db.QueryRowContext(ctx, query, clusterID, namespace).Scan(
+		&app.ID,
+		&app.OrgID,
+		&app.ClusterID,
+		&app.Name,
+		&app.Namespace,
+		&app.RepoID,
+		&app.Path,
+		&app.DefaultBranch,
+		&app.CreatedAt,
+		&app.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &app, nil
+}
+
+func (r *applicationRepository) GetApplicationsByRepoID(ctx context.Context, repoID uuid.UUID) ([]domain.Application, error) {
+	query := `
+		SELECT id, org_id, cluster_id, name, namespace, repo_id, path, default_branch, created_at, updated_at
+		FROM applications
+		WHERE repo_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []domain.Application
+	for rows.Next() {
+		var app domain.Application
+
+		err := rows.Scan(
+			&app.ID,
+			&app.OrgID,
+			&app.ClusterID,
+			&app.Name,
+			&app.Namespace,
+			&app.RepoID,
+			&app.Path,
+			&app.DefaultBranch,
+			&app.CreatedAt,
+			&app.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
 func (r *applicationRepository) DeleteApplication(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM applications WHERE id = $1`
 
@@ -204,26 +289,31 @@ func (r *applicationRepository) DeleteApplication(ctx context.Context, id uuid.U
 
 func (r *releaseRepository) CreateRelease(ctx context.Context, release *domain.Release) (*domain.Release, error) {
 	query := `
-		INSERT INTO releases (app_id, image, tag, created_by, status, meta)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		INSERT INTO releases (app_id, environment_id, image, tag, image_digest, created_by, status, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 	`
 
 	var createdRelease domain.Release
 	err := r.db.QueryRowContext(ctx, query,
 		release.AppID,
+		release.EnvironmentID,
 		release.Image,
 		release.Tag,
+		release.ImageDigest,
 		release.CreatedBy,
 		release.Status,
 		release.Meta,
 	).Scan(
 		&createdRelease.ID,
 		&createdRelease.AppID,
+		&createdRelease.EnvironmentID,
 		&createdRelease.Image,
 		&createdRelease.Tag,
+		&createdRelease.ImageDigest,
 		&createdRelease.CreatedBy,
 		&createdRelease.Status,
+		&createdRelease.Phase,
 		&createdRelease.StartedAt,
 		&createdRelease.FinishedAt,
 		&createdRelease.Meta,
@@ -240,7 +330,7 @@ func (r *releaseRepository) CreateRelease(ctx context.Context, release *domain.R
 
 func (r *releaseRepository) GetReleaseByID(ctx context.Context, id uuid.UUID) (*domain.Release, error) {
 	query := `
-		SELECT id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 		FROM releases
 		WHERE id = $1
 	`
@@ -249,10 +339,13 @@ func (r *releaseRepository) GetReleaseByID(ctx context.Context, id uuid.UUID) (*
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&release.ID,
 		&release.AppID,
+		&release.EnvironmentID,
 		&release.Image,
 		&release.Tag,
+		&release.ImageDigest,
 		&release.CreatedBy,
 		&release.Status,
+		&release.Phase,
 		&release.StartedAt,
 		&release.FinishedAt,
 		&release.Meta,
@@ -272,7 +365,7 @@ func (r *releaseRepository) GetReleaseByID(ctx context.Context, id uuid.UUID) (*
 
 func (r *releaseRepository) GetReleasesByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseSummary, error) {
 	query := `
-		SELECT id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 		FROM releases
 		WHERE app_id = $1
 		ORDER BY created_at DESC
@@ -293,10 +386,13 @@ func (r *releaseRepository) GetReleasesByAppID(ctx context.Context, appID uuid.U
 		err := rows.Scan(
 			&release.ID,
 			&appID,
+			&release.EnvironmentID,
 			&release.Image,
 			&release.Tag,
+			&release.ImageDigest,
 			&release.CreatedBy,
 			&release.Status,
+			&release.Phase,
 			&release.StartedAt,
 			&release.FinishedAt,
 			&meta,
@@ -315,7 +411,7 @@ func (r *releaseRepository) GetReleasesByAppID(ctx context.Context, appID uuid.U
 
 func (r *releaseRepository) GetLatestReleaseByAppID(ctx context.Context, appID uuid.UUID) (*domain.Release, error) {
 	query := `
-		SELECT id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 		FROM releases
 		WHERE app_id = $1
 		ORDER BY created_at DESC
@@ -326,10 +422,91 @@ func (r *releaseRepository) GetLatestReleaseByAppID(ctx context.Context, appID u
 	err := r.db.QueryRowContext(ctx, query, appID).Scan(
 		&release.ID,
 		&release.AppID,
+		&release.EnvironmentID,
+		&release.Image,
+		&release.Tag,
+		&release.ImageDigest,
+		&release.CreatedBy,
+		&release.Status,
+		&release.Phase,
+		&release.StartedAt,
+		&release.FinishedAt,
+		&release.Meta,
+		&release.CreatedAt,
+		&release.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// GetLatestReleaseByEnvironment returns the most recent release deployed to an environment of
+// the application, or to the application's own cluster and namespace if environmentID is nil
+func (r *releaseRepository) GetLatestReleaseByEnvironment(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID) (*domain.Release, error) {
+	query := `
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+		FROM releases
+		WHERE app_id = $1 AND environment_id IS NOT DISTINCT FROM $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var release domain.Release
+	err := r.db.QueryRowContext(ctx, query, appID, environmentID).Scan(
+		&release.ID,
+		&release.AppID,
+		&release.EnvironmentID,
+		&release.Image,
+		&release.Tag,
+		&release.ImageDigest,
+		&release.CreatedBy,
+		&release.Status,
+		&release.Phase,
+		&release.StartedAt,
+		&release.FinishedAt,
+		&release.Meta,
+		&release.CreatedAt,
+		&release.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// GetLatestReleaseByAppIDAndStatus returns the most recent release with the given status deployed
+// to an environment of the application, or to its own cluster and namespace if environmentID is nil
+func (r *releaseRepository) GetLatestReleaseByAppIDAndStatus(ctx context.Context, appID uuid.UUID, environmentID *uuid.UUID, status domain.ReleaseStatus) (*domain.Release, error) {
+	query := `
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+		FROM releases
+		WHERE app_id = $1 AND environment_id IS NOT DISTINCT FROM $2 AND status = $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var release domain.Release
+	err := r.db.QueryRowContext(ctx, query, appID, environmentID, status).Scan(
+		&release.ID,
+		&release.AppID,
+		&release.EnvironmentID,
 		&release.Image,
 		&release.Tag,
+		&release.ImageDigest,
 		&release.CreatedBy,
 		&release.Status,
+		&release.Phase,
 		&release.StartedAt,
 		&release.FinishedAt,
 		&release.Meta,
@@ -347,22 +524,150 @@ func (r *releaseRepository) GetLatestReleaseByAppID(ctx context.Context, appID u
 	return &release, nil
 }
 
+// GetCurrentReleases returns the release each environment of every application, and every
+// application without environments, is running: its latest release, if that release succeeded.
+// Environments whose latest release is still rolling out or failed are left out.
+func (r *releaseRepository) GetCurrentReleases(ctx context.Context) ([]domain.Release, error) {
+	query := `
+		SELECT id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+		FROM (
+			SELECT DISTINCT ON (r.app_id, r.environment_id) r.*
+			FROM releases r
+			WHERE r.environment_id IS NOT NULL
+				OR NOT EXISTS (SELECT 1 FROM app_environments e WHERE e.app_id = r.app_id)
+			ORDER BY r.app_id, r.environment_id, r.created_at DESC
+		) latest
+		WHERE status = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.ReleaseStatusSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []domain.Release
+	for rows.Next() {
+		var release domain.Release
+		err := rows.Scan(
+			&release.ID,
+			&release.AppID,
+			&release.EnvironmentID,
+			&release.Image,
+			&release.Tag,
+			&release.ImageDigest,
+			&release.CreatedBy,
+			&release.Status,
+			&release.Phase,
+			&release.StartedAt,
+			&release.FinishedAt,
+			&release.Meta,
+			&release.CreatedAt,
+			&release.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, rows.Err()
+}
+
 func (r *releaseRepository) UpdateReleaseStatus(ctx context.Context, id uuid.UUID, status domain.ReleaseStatus, startedAt, finishedAt *time.Time) (*domain.Release, error) {
 	query := `
 		UPDATE releases
 		SET status = $2, started_at = $3, finished_at = $4, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 	`
 
 	var release domain.Release
 	err := r.db.QueryRowContext(ctx, query, id, status, startedAt, finishedAt).Scan(
 		&release.ID,
 		&release.AppID,
+		&release.EnvironmentID,
+		&release.Image,
+		&release.Tag,
+		&release.ImageDigest,
+		&release.CreatedBy,
+		&release.Status,
+		&release.Phase,
+		&release.StartedAt,
+		&release.FinishedAt,
+		&release.Meta,
+		&release.CreatedAt,
+		&release.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+func (r *releaseRepository) UpdateReleasePhase(ctx context.Context, id uuid.UUID, phase domain.ReleasePhase) (*domain.Release, error) {
+	query := `
+		UPDATE releases
+		SET phase = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+	`
+
+	var release domain.Release
+	err := r.db.QueryRowContext(ctx, query, id, phase).Scan(
+		&release.ID,
+		&release.AppID,
+		&release.EnvironmentID,
 		&release.Image,
 		&release.Tag,
+		&release.ImageDigest,
 		&release.CreatedBy,
 		&release.Status,
+		&release.Phase,
+		&release.StartedAt,
+		&release.FinishedAt,
+		&release.Meta,
+		&release.CreatedAt,
+		&release.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// TransitionReleasePhase moves a running release from one phase to another. It returns nil if the
+// release is not running in the from phase, so concurrent transitions cannot both succeed.
+func (r *releaseRepository) TransitionReleasePhase(ctx context.Context, id uuid.UUID, from, to domain.ReleasePhase) (*domain.Release, error) {
+	query := `
+		UPDATE releases
+		SET phase = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND phase = $2
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+	`
+
+	var release domain.Release
+	err := r.db.QueryRowContext(ctx, query, id, from, to).Scan(
+		&release.ID,
+		&release.AppID,
+		&release.EnvironmentID,
+		&release.Image,
+		&release.Tag,
+		&release.ImageDigest,
+		&release.CreatedBy,
+		&release.Status,
+		&release.Phase,
 		&release.StartedAt,
 		&release.FinishedAt,
 		&release.Meta,
@@ -385,17 +690,57 @@ func (r *releaseRepository) UpdateReleaseMeta(ctx context.Context, id uuid.UUID,
 		UPDATE releases
 		SET meta = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, app_id, image, tag, created_by, status, started_at, finished_at, meta, created_at, updated_at
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
 	`
 
 	var release domain.Release
 	err := r.db.QueryRowContext(ctx, query, id, meta).Scan(
 		&release.ID,
 		&release.AppID,
+		&release.EnvironmentID,
+		&release.Image,
+		&release.Tag,
+		&release.ImageDigest,
+		&release.CreatedBy,
+		&release.Status,
+		&release.Phase,
+		&release.StartedAt,
+		&release.FinishedAt,
+		&release.Meta,
+		&release.CreatedAt,
+		&release.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// UpdateReleaseImageDigest pins a release to the digest its tag resolved to
+func (r *releaseRepository) UpdateReleaseImageDigest(ctx context.Context, id uuid.UUID, digest string) (*domain.Release, error) {
+	query := `
+		UPDATE releases
+		SET image_digest = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, app_id, environment_id, image, tag, image_digest, created_by, status, phase, started_at, finished_at, meta, created_at, updated_at
+	`
+
+	var release domain.Release
+	err := r.db.QueryRowContext(ctx, query, id, digest).Scan(
+		&release.ID,
+		&release.AppID,
+		&release.EnvironmentID,
 		&release.Image,
 		&release.Tag,
+		&release.ImageDigest,
 		&release.CreatedBy,
 		&release.Status,
+		&release.Phase,
 		&release.StartedAt,
 		&release.FinishedAt,
 		&release.Meta,
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type ApplicationSpecRepository interface {
+	CreateApplicationSpec(ctx context.Context, appID, createdBy uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpec, error)
+	GetLatestApplicationSpec(ctx context.Context, appID uuid.UUID) (*domain.ApplicationSpec, error)
+	GetApplicationSpecByVersion(ctx context.Context, appID uuid.UUID, version int) (*domain.ApplicationSpec, error)
+}
+
+type applicationSpecRepository struct {
+	db *sql.DB
+}
+
+func NewApplicationSpecRepository(db *sql.DB) ApplicationSpecRepository {
+	return &applicationSpecRepository{db: db}
+}
+
+// CreateApplicationSpec stores a spec as the application's next version. Concurrent writers
+// racing for the same version are rejected by the (app_id, version) unique constraint.
+func (r *applicationSpecRepository) CreateApplicationSpec(ctx context.Context, appID, createdBy uuid.UUID, spec *domain.DeploymentSpec) (*domain.ApplicationSpec, error) {
+	query := `
+		INSERT INTO application_specs (app_id, version, spec, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3
+		FROM application_specs
+		WHERE app_id = $1
+		RETURNING id, app_id, version, spec, created_by, created_at
+	`
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanApplicationSpec(r.db.QueryRowContext(ctx, query, appID, specJSON, createdBy))
+}
+
+func (r *applicationSpecRepository) GetLatestApplicationSpec(ctx context.Context, appID uuid.UUID) (*domain.ApplicationSpec, error) {
+	query := `
+		SELECT id, app_id, version, spec, created_by, created_at
+		FROM application_specs
+		WHERE app_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	spec, err := scanApplicationSpec(r.db.QueryRowContext(ctx, query, appID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+func (r *applicationSpecRepository) GetApplicationSpecByVersion(ctx context.Context, appID uuid.UUID, version int) (*domain.ApplicationSpec, error) {
+	query := `
+		SELECT id, app_id, version, spec, created_by, created_at
+		FROM application_specs
+		WHERE app_id = $1 AND version = $2
+	`
+
+	spec, err := scanApplicationSpec(r.db.QueryRowContext(ctx, query, appID, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// scanApplicationSpec scans an application spec row, decoding its JSONB spec
+func scanApplicationSpec(row *sql.Row) (*domain.ApplicationSpec, error) {
+	var spec domain.ApplicationSpec
+	var specJSON []byte
+
+	err := row.Scan(
+		&spec.ID,
+		&spec.AppID,
+		&spec.Version,
+		&specJSON,
+		&spec.CreatedBy,
+		&spec.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(specJSON, &spec.Spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
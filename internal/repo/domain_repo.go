@@ -38,8 +38,8 @@ func (r *domainRepo) CreateDomain(ctx context.Context, d *domain.Domain) (*domai
 	}
 
 	query := `
-		INSERT INTO domains (app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO domains (app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at`
 
 	var id uuid.UUID
@@ -47,6 +47,7 @@ func (r *domainRepo) CreateDomain(ctx context.Context, d *domain.Domain) (*domai
 
 	err = r.db.QueryRowContext(ctx, query,
 		d.AppID,
+		d.EnvironmentID,
 		d.Domain,
 		d.Provider,
 		configBytes,
@@ -72,7 +73,7 @@ func (r *domainRepo) CreateDomain(ctx context.Context, d *domain.Domain) (*domai
 
 func (r *domainRepo) GetDomainByID(ctx context.Context, id uuid.UUID) (*domain.Domain, error) {
 	query := `
-		SELECT id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
+		SELECT id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
 		FROM domains
 		WHERE id = $1`
 
@@ -84,6 +85,7 @@ func (r *domainRepo) GetDomainByID(ctx context.Context, id uuid.UUID) (*domain.D
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&d.ID,
 		&d.AppID,
+		&d.EnvironmentID,
 		&d.Domain,
 		&d.Provider,
 		&configBytes,
@@ -120,7 +122,7 @@ func (r *domainRepo) GetDomainByID(ctx context.Context, id uuid.UUID) (*domain.D
 
 func (r *domainRepo) GetDomainsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Domain, error) {
 	query := `
-		SELECT id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
+		SELECT id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
 		FROM domains
 		WHERE app_id = $1
 		ORDER BY created_at DESC`
@@ -141,6 +143,7 @@ func (r *domainRepo) GetDomainsByAppID(ctx context.Context, appID uuid.UUID) ([]
 		err := rows.Scan(
 			&d.ID,
 			&d.AppID,
+			&d.EnvironmentID,
 			&d.Domain,
 			&d.Provider,
 			&configBytes,
@@ -176,7 +179,7 @@ func (r *domainRepo) GetDomainsByAppID(ctx context.Context, appID uuid.UUID) ([]
 
 func (r *domainRepo) GetDomainByDomainInApp(ctx context.Context, appID uuid.UUID, domainName string) (*domain.Domain, error) {
 	query := `
-		SELECT id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
+		SELECT id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at
 		FROM domains
 		WHERE app_id = $1 AND domain = $2`
 
@@ -188,6 +191,7 @@ func (r *domainRepo) GetDomainByDomainInApp(ctx context.Context, appID uuid.UUID
 	err := r.db.QueryRowContext(ctx, query, appID, domainName).Scan(
 		&d.ID,
 		&d.AppID,
+		&d.EnvironmentID,
 		&d.Domain,
 		&d.Provider,
 		&configBytes,
@@ -227,7 +231,7 @@ func (r *domainRepo) UpdateDomainCertStatus(ctx context.Context, id uuid.UUID, s
 		UPDATE domains
 		SET cert_status = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
+		RETURNING id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
 
 	var d domain.Domain
 	var configBytes []byte
@@ -237,6 +241,7 @@ func (r *domainRepo) UpdateDomainCertStatus(ctx context.Context, id uuid.UUID, s
 	err := r.db.QueryRowContext(ctx, query, id, status).Scan(
 		&d.ID,
 		&d.AppID,
+		&d.EnvironmentID,
 		&d.Domain,
 		&d.Provider,
 		&configBytes,
@@ -273,7 +278,7 @@ func (r *domainRepo) UpdateDomainCertSecret(ctx context.Context, id uuid.UUID, s
 		UPDATE domains
 		SET cert_secret_name = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
+		RETURNING id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
 
 	var d domain.Domain
 	var configBytes []byte
@@ -283,6 +288,7 @@ func (r *domainRepo) UpdateDomainCertSecret(ctx context.Context, id uuid.UUID, s
 	err := r.db.QueryRowContext(ctx, query, id, secretName).Scan(
 		&d.ID,
 		&d.AppID,
+		&d.EnvironmentID,
 		&d.Domain,
 		&d.Provider,
 		&configBytes,
@@ -324,7 +330,7 @@ func (r *domainRepo) UpdateDomainProviderConfig(ctx context.Context, id uuid.UUI
 		UPDATE domains
 		SET provider_config = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, app_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
+		RETURNING id, app_id, environment_id, domain, provider, provider_config, cert_status, cert_secret_name, challenge_type, created_at, updated_at`
 
 	var d domain.Domain
 	var certSecretName sql.NullString
@@ -333,6 +339,7 @@ func (r *domainRepo) UpdateDomainProviderConfig(ctx context.Context, id uuid.UUI
 	err = r.db.QueryRowContext(ctx, query, id, configBytes).Scan(
 		&d.ID,
 		&d.AppID,
+		&d.EnvironmentID,
 		&d.Domain,
 		&d.Provider,
 		&configBytes,
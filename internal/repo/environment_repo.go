@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type EnvironmentRepository interface {
+	CreateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error)
+	GetEnvironmentByID(ctx context.Context, id uuid.UUID) (*domain.Environment, error)
+	GetEnvironmentByName(ctx context.Context, appID uuid.UUID, name string) (*domain.Environment, error)
+	GetEnvironmentsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Environment, error)
+	GetEnvironmentByNamespace(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Environment, error)
+	UpdateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error)
+	DeleteEnvironment(ctx context.Context, id uuid.UUID) error
+}
+
+type environmentRepository struct {
+	db *sql.DB
+}
+
+func NewEnvironmentRepository(db *sql.DB) EnvironmentRepository {
+	return &environmentRepository{db: db}
+}
+
+func (r *environmentRepository) CreateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	query := `
+		INSERT INTO app_environments (app_id, name, cluster_id, namespace, position, replicas, env_vars)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+	`
+
+	envVarsJSON, err := marshalEnvVars(env.EnvVars)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEnvironment(r.db.QueryRowContext(ctx, query,
+		env.AppID,
+		env.Name,
+		env.ClusterID,
+		env.Namespace,
+		env.Position,
+		env.Replicas,
+		envVarsJSON,
+	))
+}
+
+func (r *environmentRepository) GetEnvironmentByID(ctx context.Context, id uuid.UUID) (*domain.Environment, error) {
+	query := `
+		SELECT id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+		FROM app_environments
+		WHERE id = $1
+	`
+
+	env, err := scanEnvironment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (r *environmentRepository) GetEnvironmentByName(ctx context.Context, appID uuid.UUID, name string) (*domain.Environment, error) {
+	query := `
+		SELECT id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+		FROM app_environments
+		WHERE app_id = $1 AND name = $2
+	`
+
+	env, err := scanEnvironment(r.db.QueryRowContext(ctx, query, appID, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// GetEnvironmentsByAppID returns an application's environments in promotion order
+func (r *environmentRepository) GetEnvironmentsByAppID(ctx context.Context, appID uuid.UUID) ([]domain.Environment, error) {
+	query := `
+		SELECT id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+		FROM app_environments
+		WHERE app_id = $1
+		ORDER BY position ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var envs []domain.Environment
+	for rows.Next() {
+		env, err := scanEnvironment(rows)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, *env)
+	}
+
+	return envs, rows.Err()
+}
+
+// GetEnvironmentByNamespace returns the environment, of any application, that deploys to a
+// namespace of a cluster
+func (r *environmentRepository) GetEnvironmentByNamespace(ctx context.Context, clusterID uuid.UUID, namespace string) (*domain.Environment, error) {
+	query := `
+		SELECT id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+		FROM app_environments
+		WHERE cluster_id = $1 AND namespace = $2
+	`
+
+	env, err := scanEnvironment(r.db.QueryRowContext(ctx, query, clusterID, namespace))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// UpdateEnvironment replaces an environment's position, replicas and environment variables
+func (r *environmentRepository) UpdateEnvironment(ctx context.Context, env *domain.Environment) (*domain.Environment, error) {
+	query := `
+		UPDATE app_environments
+		SET position = $2, replicas = $3, env_vars = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, app_id, name, cluster_id, namespace, position, replicas, env_vars, created_at, updated_at
+	`
+
+	envVarsJSON, err := marshalEnvVars(env.EnvVars)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanEnvironment(r.db.QueryRowContext(ctx, query, env.ID, env.Position, env.Replicas, envVarsJSON))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (r *environmentRepository) DeleteEnvironment(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM app_environments WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// rowScanner is a single row of a query result, read with *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEnvironment scans an environment row, decoding its JSONB environment variables
+func scanEnvironment(row rowScanner) (*domain.Environment, error) {
+	var env domain.Environment
+	var replicas sql.NullInt32
+	var envVarsJSON []byte
+
+	err := row.Scan(
+		&env.ID,
+		&env.AppID,
+		&env.Name,
+		&env.ClusterID,
+		&env.Namespace,
+		&env.Position,
+		&replicas,
+		&envVarsJSON,
+		&env.CreatedAt,
+		&env.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if replicas.Valid {
+		env.Replicas = &replicas.Int32
+	}
+	if err := json.Unmarshal(envVarsJSON, &env.EnvVars); err != nil {
+		return nil, err
+	}
+
+	return &env, nil
+}
+
+// marshalEnvVars encodes environment variables for a JSONB column, storing none as an empty object
+func marshalEnvVars(envVars map[string]string) ([]byte, error) {
+	if envVars == nil {
+		envVars = map[string]string{}
+	}
+	return json.Marshal(envVars)
+}
@@ -38,10 +38,12 @@ type JobRepository interface {
 	GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, error)
 	GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.Job, error)
 	GetPendingJobs(ctx context.Context) ([]domain.Job, error)
+	GetPendingJobsByType(ctx context.Context, jobType domain.JobType) ([]domain.Job, error)
 	UpdateJobStatus(ctx context.Context, id uuid.UUID, status domain.JobStatus) (*domain.Job, error)
 	StartJob(ctx context.Context, id uuid.UUID) (*domain.Job, error)
 	CompleteJob(ctx context.Context, id uuid.UUID) (*domain.Job, error)
 	FailJob(ctx context.Context, id uuid.UUID, errorMessage string) (*domain.Job, error)
+	UpdateJobProgress(ctx context.Context, id uuid.UUID, progress []domain.JobStep) error
 	DeleteJob(ctx context.Context, id uuid.UUID) error
 }
 
@@ -630,9 +632,18 @@ func (r *jobRepo) CreateJob(ctx context.Context, job *domain.Job) (*domain.Job,
 		return nil, err
 	}
 
+	progress := job.Progress
+	if progress == nil {
+		progress = []domain.JobStep{}
+	}
+	progressBytes, err := json.Marshal(progress)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO job_queue (org_id, type, status, payload)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO job_queue (org_id, type, status, payload, progress)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at`
 
 	var id uuid.UUID
@@ -643,6 +654,7 @@ func (r *jobRepo) CreateJob(ctx context.Context, job *domain.Job) (*domain.Job,
 		job.Type,
 		job.Status,
 		payloadBytes,
+		progressBytes,
 	).Scan(&id, &createdAt)
 
 	if err != nil {
@@ -659,12 +671,13 @@ func (r *jobRepo) CreateJob(ctx context.Context, job *domain.Job) (*domain.Job,
 
 func (r *jobRepo) GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
 	query := `
-		SELECT id, org_id, type, status, payload, error_message, created_at, started_at, completed_at
+		SELECT id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at
 		FROM job_queue
 		WHERE id = $1`
 
 	var job domain.Job
 	var payloadBytes []byte
+	var progressBytes []byte
 	var errorMessage sql.NullString
 	var createdAt, startedAt, completedAt sql.NullTime
 
@@ -675,6 +688,7 @@ func (r *jobRepo) GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, er
 		&job.Status,
 		&payloadBytes,
 		&errorMessage,
+		&progressBytes,
 		&createdAt,
 		&startedAt,
 		&completedAt,
@@ -690,6 +704,9 @@ func (r *jobRepo) GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, er
 	if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+		return nil, err
+	}
 
 	if errorMessage.Valid {
 		job.ErrorMessage = errorMessage.String
@@ -709,7 +726,7 @@ func (r *jobRepo) GetJobByID(ctx context.Context, id uuid.UUID) (*domain.Job, er
 
 func (r *jobRepo) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.Job, error) {
 	query := `
-		SELECT id, org_id, type, status, payload, error_message, created_at, started_at, completed_at
+		SELECT id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at
 		FROM job_queue
 		WHERE org_id = $1
 		ORDER BY created_at DESC`
@@ -724,6 +741,7 @@ func (r *jobRepo) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain
 	for rows.Next() {
 		var job domain.Job
 		var payloadBytes []byte
+		var progressBytes []byte
 		var errorMessage sql.NullString
 		var createdAt, startedAt, completedAt sql.NullTime
 
@@ -734,6 +752,7 @@ func (r *jobRepo) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain
 			&job.Status,
 			&payloadBytes,
 			&errorMessage,
+			&progressBytes,
 			&createdAt,
 			&startedAt,
 			&completedAt,
@@ -745,6 +764,9 @@ func (r *jobRepo) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain
 		if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+			return nil, err
+		}
 
 		if errorMessage.Valid {
 			job.ErrorMessage = errorMessage.String
@@ -767,7 +789,7 @@ func (r *jobRepo) GetJobsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain
 
 func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 	query := `
-		SELECT id, org_id, type, status, payload, error_message, created_at, started_at, completed_at
+		SELECT id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at
 		FROM job_queue
 		WHERE status = 'pending'
 		ORDER BY created_at ASC`
@@ -782,6 +804,7 @@ func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 	for rows.Next() {
 		var job domain.Job
 		var payloadBytes []byte
+		var progressBytes []byte
 		var errorMessage sql.NullString
 		var createdAt, startedAt, completedAt sql.NullTime
 
@@ -792,6 +815,7 @@ func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 			&job.Status,
 			&payloadBytes,
 			&errorMessage,
+			&progressBytes,
 			&createdAt,
 			&startedAt,
 			&completedAt,
@@ -803,6 +827,72 @@ func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 		if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+			return nil, err
+		}
+
+		if errorMessage.Valid {
+			job.ErrorMessage = errorMessage.String
+		}
+		if createdAt.Valid {
+			job.CreatedAt = createdAt.Time
+		}
+		if startedAt.Valid {
+			job.StartedAt = &startedAt.Time
+		}
+		if completedAt.Valid {
+			job.CompletedAt = &completedAt.Time
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (r *jobRepo) GetPendingJobsByType(ctx context.Context, jobType domain.JobType) ([]domain.Job, error) {
+	query := `
+		SELECT id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at
+		FROM job_queue
+		WHERE status = 'pending' AND type = $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, jobType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.Job
+	for rows.Next() {
+		var job domain.Job
+		var payloadBytes []byte
+		var progressBytes []byte
+		var errorMessage sql.NullString
+		var createdAt, startedAt, completedAt sql.NullTime
+
+		err := rows.Scan(
+			&job.ID,
+			&job.OrgID,
+			&job.Type,
+			&job.Status,
+			&payloadBytes,
+			&errorMessage,
+			&progressBytes,
+			&createdAt,
+			&startedAt,
+			&completedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+			return nil, err
+		}
 
 		if errorMessage.Valid {
 			job.ErrorMessage = errorMessage.String
@@ -828,10 +918,11 @@ func (r *jobRepo) UpdateJobStatus(ctx context.Context, id uuid.UUID, status doma
 		UPDATE job_queue
 		SET status = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, org_id, type, status, payload, error_message, created_at, started_at, completed_at`
+		RETURNING id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at`
 
 	var job domain.Job
 	var payloadBytes []byte
+	var progressBytes []byte
 	var errorMessage sql.NullString
 	var createdAt, startedAt, completedAt sql.NullTime
 
@@ -842,6 +933,7 @@ func (r *jobRepo) UpdateJobStatus(ctx context.Context, id uuid.UUID, status doma
 		&job.Status,
 		&payloadBytes,
 		&errorMessage,
+		&progressBytes,
 		&createdAt,
 		&startedAt,
 		&completedAt,
@@ -854,6 +946,9 @@ func (r *jobRepo) UpdateJobStatus(ctx context.Context, id uuid.UUID, status doma
 	if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+		return nil, err
+	}
 
 	if errorMessage.Valid {
 		job.ErrorMessage = errorMessage.String
@@ -876,10 +971,11 @@ func (r *jobRepo) StartJob(ctx context.Context, id uuid.UUID) (*domain.Job, erro
 		UPDATE job_queue
 		SET status = 'processing', started_at = NOW(), updated_at = NOW()
 		WHERE id = $1 AND status = 'pending'
-		RETURNING id, org_id, type, status, payload, error_message, created_at, started_at, completed_at`
+		RETURNING id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at`
 
 	var job domain.Job
 	var payloadBytes []byte
+	var progressBytes []byte
 	var errorMessage sql.NullString
 	var createdAt, startedAt, completedAt sql.NullTime
 
@@ -890,6 +986,7 @@ func (r *jobRepo) StartJob(ctx context.Context, id uuid.UUID) (*domain.Job, erro
 		&job.Status,
 		&payloadBytes,
 		&errorMessage,
+		&progressBytes,
 		&createdAt,
 		&startedAt,
 		&completedAt,
@@ -902,6 +999,9 @@ func (r *jobRepo) StartJob(ctx context.Context, id uuid.UUID) (*domain.Job, erro
 	if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+		return nil, err
+	}
 
 	if errorMessage.Valid {
 		job.ErrorMessage = errorMessage.String
@@ -924,10 +1024,11 @@ func (r *jobRepo) CompleteJob(ctx context.Context, id uuid.UUID) (*domain.Job, e
 		UPDATE job_queue
 		SET status = 'completed', completed_at = NOW(), updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, org_id, type, status, payload, error_message, created_at, started_at, completed_at`
+		RETURNING id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at`
 
 	var job domain.Job
 	var payloadBytes []byte
+	var progressBytes []byte
 	var errorMessage sql.NullString
 	var createdAt, startedAt, completedAt sql.NullTime
 
@@ -938,6 +1039,7 @@ func (r *jobRepo) CompleteJob(ctx context.Context, id uuid.UUID) (*domain.Job, e
 		&job.Status,
 		&payloadBytes,
 		&errorMessage,
+		&progressBytes,
 		&createdAt,
 		&startedAt,
 		&completedAt,
@@ -950,6 +1052,9 @@ func (r *jobRepo) CompleteJob(ctx context.Context, id uuid.UUID) (*domain.Job, e
 	if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+		return nil, err
+	}
 
 	if errorMessage.Valid {
 		job.ErrorMessage = errorMessage.String
@@ -972,10 +1077,11 @@ func (r *jobRepo) FailJob(ctx context.Context, id uuid.UUID, errorMessage string
 		UPDATE job_queue
 		SET status = 'failed', error_message = $2, completed_at = NOW(), updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, org_id, type, status, payload, error_message, created_at, started_at, completed_at`
+		RETURNING id, org_id, type, status, payload, error_message, progress, created_at, started_at, completed_at`
 
 	var job domain.Job
 	var payloadBytes []byte
+	var progressBytes []byte
 	var errorMsg sql.NullString
 	var createdAt, startedAt, completedAt sql.NullTime
 
@@ -986,6 +1092,7 @@ func (r *jobRepo) FailJob(ctx context.Context, id uuid.UUID, errorMessage string
 		&job.Status,
 		&payloadBytes,
 		&errorMsg,
+		&progressBytes,
 		&createdAt,
 		&startedAt,
 		&completedAt,
@@ -998,6 +1105,9 @@ func (r *jobRepo) FailJob(ctx context.Context, id uuid.UUID, errorMessage string
 	if err := json.Unmarshal(payloadBytes, &job.Payload); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(progressBytes, &job.Progress); err != nil {
+		return nil, err
+	}
 
 	if errorMsg.Valid {
 		job.ErrorMessage = errorMsg.String
@@ -1015,6 +1125,34 @@ func (r *jobRepo) FailJob(ctx context.Context, id uuid.UUID, errorMessage string
 	return &job, nil
 }
 
+// UpdateJobProgress records the progress of a job's steps
+func (r *jobRepo) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress []domain.JobStep) error {
+	progressBytes, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE job_queue
+		SET progress = $2
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, progressBytes)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (r *jobRepo) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM job_queue WHERE id = $1`
 	result, err := r.db.ExecContext(ctx, query, id)
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type NamespacePolicyRepository interface {
+	GetNamespacePolicy(ctx context.Context, orgID uuid.UUID) (*domain.NamespacePolicy, error)
+	UpsertNamespacePolicy(ctx context.Context, policy *domain.NamespacePolicy) (*domain.NamespacePolicy, error)
+}
+
+type namespacePolicyRepository struct {
+	db *sql.DB
+}
+
+func NewNamespacePolicyRepository(db *sql.DB) NamespacePolicyRepository {
+	return &namespacePolicyRepository{db: db}
+}
+
+// GetNamespacePolicy returns an organization's namespace policy, or nil if it has not configured one
+func (r *namespacePolicyRepository) GetNamespacePolicy(ctx context.Context, orgID uuid.UUID) (*domain.NamespacePolicy, error) {
+	query := `
+		SELECT org_id, resource_quota, limit_range, ingress_namespaces, created_at, updated_at
+		FROM namespace_policies
+		WHERE org_id = $1
+	`
+
+	policy, err := scanNamespacePolicy(r.db.QueryRowContext(ctx, query, orgID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpsertNamespacePolicy creates or replaces an organization's namespace policy
+func (r *namespacePolicyRepository) UpsertNamespacePolicy(ctx context.Context, policy *domain.NamespacePolicy) (*domain.NamespacePolicy, error) {
+	query := `
+		INSERT INTO namespace_policies (org_id, resource_quota, limit_range, ingress_namespaces)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (org_id) DO UPDATE
+		SET resource_quota = EXCLUDED.resource_quota,
+			limit_range = EXCLUDED.limit_range,
+			ingress_namespaces = EXCLUDED.ingress_namespaces,
+			updated_at = NOW()
+		RETURNING org_id, resource_quota, limit_range, ingress_namespaces, created_at, updated_at
+	`
+
+	resourceQuota := policy.ResourceQuota
+	if resourceQuota == nil {
+		resourceQuota = map[string]string{}
+	}
+	quotaJSON, err := json.Marshal(resourceQuota)
+	if err != nil {
+		return nil, err
+	}
+
+	var limitRangeJSON interface{} // NULL without a LimitRange
+	if policy.LimitRange != nil {
+		limitRange, err := json.Marshal(policy.LimitRange)
+		if err != nil {
+			return nil, err
+		}
+		limitRangeJSON = limitRange
+	}
+
+	ingressNamespaces := policy.IngressNamespaces
+	if ingressNamespaces == nil {
+		ingressNamespaces = []string{}
+	}
+	ingressJSON, err := json.Marshal(ingressNamespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNamespacePolicy(r.db.QueryRowContext(ctx, query, policy.OrgID, quotaJSON, limitRangeJSON, ingressJSON))
+}
+
+func scanNamespacePolicy(row rowScanner) (*domain.NamespacePolicy, error) {
+	var policy domain.NamespacePolicy
+	var quotaJSON, limitRangeJSON, ingressJSON []byte
+	err := row.Scan(
+		&policy.OrgID,
+		&quotaJSON,
+		&limitRangeJSON,
+		&ingressJSON,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(quotaJSON, &policy.ResourceQuota); err != nil {
+		return nil, err
+	}
+	if limitRangeJSON != nil {
+		if err := json.Unmarshal(limitRangeJSON, &policy.LimitRange); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(ingressJSON, &policy.IngressNamespaces); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
@@ -948,6 +948,23 @@ WHERE
     status = 'pending'
 ORDER BY created_at ASC;
 
+-- name: GetPendingJobsByType :many
+SELECT
+    id,
+    org_id,
+    type,
+    status,
+    payload,
+    error_message,
+    created_at,
+    started_at,
+    completed_at
+FROM job_queue
+WHERE
+    status = 'pending'
+    AND type = $1
+ORDER BY created_at ASC;
+
 -- name: UpdateJobStatus :one
 UPDATE job_queue
 SET
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type RegistryCredentialRepository interface {
+	CreateRegistryCredential(ctx context.Context, cred *domain.RegistryCredential) (*domain.RegistryCredential, error)
+	GetRegistryCredentialByID(ctx context.Context, id uuid.UUID) (*domain.RegistryCredential, error)
+	GetRegistryCredentialByRegistry(ctx context.Context, orgID uuid.UUID, registry string) (*domain.RegistryCredential, error)
+	GetRegistryCredentialsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RegistryCredential, error)
+	UpdateRegistryCredential(ctx context.Context, id uuid.UUID, username string, passwordEncrypted []byte) (*domain.RegistryCredential, error)
+	DeleteRegistryCredential(ctx context.Context, id uuid.UUID) error
+}
+
+type registryCredentialRepository struct {
+	db *sql.DB
+}
+
+func NewRegistryCredentialRepository(db *sql.DB) RegistryCredentialRepository {
+	return &registryCredentialRepository{db: db}
+}
+
+func (r *registryCredentialRepository) CreateRegistryCredential(ctx context.Context, cred *domain.RegistryCredential) (*domain.RegistryCredential, error) {
+	query := `
+		INSERT INTO registry_credentials (org_id, provider, registry, username, password_encrypted)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, org_id, provider, registry, username, password_encrypted, created_at, updated_at
+	`
+
+	return scanRegistryCredential(r.db.QueryRowContext(ctx, query, cred.OrgID, cred.Provider, cred.Registry, cred.Username, cred.PasswordEncrypted))
+}
+
+func (r *registryCredentialRepository) GetRegistryCredentialByID(ctx context.Context, id uuid.UUID) (*domain.RegistryCredential, error) {
+	query := `
+		SELECT id, org_id, provider, registry, username, password_encrypted, created_at, updated_at
+		FROM registry_credentials
+		WHERE id = $1
+	`
+
+	cred, err := scanRegistryCredential(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// GetRegistryCredentialByRegistry returns an organization's credentials for a registry host
+func (r *registryCredentialRepository) GetRegistryCredentialByRegistry(ctx context.Context, orgID uuid.UUID, registry string) (*domain.RegistryCredential, error) {
+	query := `
+		SELECT id, org_id, provider, registry, username, password_encrypted, created_at, updated_at
+		FROM registry_credentials
+		WHERE org_id = $1 AND registry = $2
+	`
+
+	cred, err := scanRegistryCredential(r.db.QueryRowContext(ctx, query, orgID, registry))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+func (r *registryCredentialRepository) GetRegistryCredentialsByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RegistryCredential, error) {
+	query := `
+		SELECT id, org_id, provider, registry, username, password_encrypted, created_at, updated_at
+		FROM registry_credentials
+		WHERE org_id = $1
+		ORDER BY registry ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []domain.RegistryCredential
+	for rows.Next() {
+		cred, err := scanRegistryCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, *cred)
+	}
+
+	return creds, rows.Err()
+}
+
+func (r *registryCredentialRepository) UpdateRegistryCredential(ctx context.Context, id uuid.UUID, username string, passwordEncrypted []byte) (*domain.RegistryCredential, error) {
+	query := `
+		UPDATE registry_credentials
+		SET username = $2, password_encrypted = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, org_id, provider, registry, username, password_encrypted, created_at, updated_at
+	`
+
+	cred, err := scanRegistryCredential(r.db.QueryRowContext(ctx, query, id, username, passwordEncrypted))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+func (r *registryCredentialRepository) DeleteRegistryCredential(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM registry_credentials WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanRegistryCredential(row rowScanner) (*domain.RegistryCredential, error) {
+	var cred domain.RegistryCredential
+	err := row.Scan(
+		&cred.ID,
+		&cred.OrgID,
+		&cred.Provider,
+		&cred.Registry,
+		&cred.Username,
+		&cred.PasswordEncrypted,
+		&cred.CreatedAt,
+		&cred.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
@@ -0,0 +1,136 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type ReleaseDriftRepository interface {
+	UpsertReleaseDrift(ctx context.Context, drift *domain.ReleaseDrift) error
+	GetReleaseDriftByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseDrift, error)
+}
+
+type releaseDriftRepository struct {
+	db *sql.DB
+}
+
+func NewReleaseDriftRepository(db *sql.DB) ReleaseDriftRepository {
+	return &releaseDriftRepository{db: db}
+}
+
+// UpsertReleaseDrift records the result of a drift check of a release. Drift that persists keeps
+// the time it was first detected, and the last healed drift is kept until drift is healed again.
+func (r *releaseDriftRepository) UpsertReleaseDrift(ctx context.Context, drift *domain.ReleaseDrift) error {
+	query := `
+		INSERT INTO release_drift (release_id, app_id, status, objects, error, drifted_since, healed_objects, healed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (release_id) DO UPDATE
+		SET status = EXCLUDED.status,
+			objects = EXCLUDED.objects,
+			error = EXCLUDED.error,
+			drifted_since = CASE
+				WHEN EXCLUDED.status = 'in_sync' THEN NULL
+				ELSE COALESCE(release_drift.drifted_since, EXCLUDED.drifted_since)
+			END,
+			healed_objects = CASE
+				WHEN EXCLUDED.healed_at IS NULL THEN release_drift.healed_objects
+				ELSE EXCLUDED.healed_objects
+			END,
+			healed_at = COALESCE(EXCLUDED.healed_at, release_drift.healed_at),
+			checked_at = NOW()
+	`
+
+	objects := drift.Objects
+	if objects == nil {
+		objects = []domain.ObjectDrift{}
+	}
+	objectsJSON, err := json.Marshal(objects)
+	if err != nil {
+		return err
+	}
+
+	healed := drift.Healed
+	if healed == nil {
+		healed = []domain.ObjectDrift{}
+	}
+	healedJSON, err := json.Marshal(healed)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		drift.ReleaseID,
+		drift.AppID,
+		drift.Status,
+		objectsJSON,
+		drift.Error,
+		drift.DriftedSince,
+		healedJSON,
+		drift.HealedAt,
+	)
+	return err
+}
+
+// GetReleaseDriftByAppID returns the drift of the release each environment of an application, or
+// the application itself, is running. Environments whose latest release was not checked yet are
+// left out.
+func (r *releaseDriftRepository) GetReleaseDriftByAppID(ctx context.Context, appID uuid.UUID) ([]domain.ReleaseDrift, error) {
+	query := `
+		SELECT d.release_id, d.app_id, rel.environment_id, COALESCE(e.name, ''), d.status, d.objects, d.error,
+			d.drifted_since, d.healed_objects, d.healed_at, d.checked_at
+		FROM release_drift d
+		JOIN releases rel ON rel.id = d.release_id
+		LEFT JOIN app_environments e ON e.id = rel.environment_id
+		WHERE d.app_id = $1
+			AND rel.id = (
+				SELECT latest.id
+				FROM releases latest
+				WHERE latest.app_id = rel.app_id AND latest.environment_id IS NOT DISTINCT FROM rel.environment_id
+				ORDER BY latest.created_at DESC
+				LIMIT 1
+			)
+		ORDER BY e.position NULLS FIRST
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drifts []domain.ReleaseDrift
+	for rows.Next() {
+		var drift domain.ReleaseDrift
+		var objectsJSON, healedJSON []byte
+		err := rows.Scan(
+			&drift.ReleaseID,
+			&drift.AppID,
+			&drift.EnvironmentID,
+			&drift.Environment,
+			&drift.Status,
+			&objectsJSON,
+			&drift.Error,
+			&drift.DriftedSince,
+			&healedJSON,
+			&drift.HealedAt,
+			&drift.CheckedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(objectsJSON, &drift.Objects); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(healedJSON, &drift.Healed); err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, rows.Err()
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type ReleaseSnapshotRepository interface {
+	CreateReleaseSnapshot(ctx context.Context, appID uuid.UUID, dataEncrypted []byte) (*domain.ReleaseSnapshot, error)
+	GetReleaseSnapshotByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseSnapshot, error)
+}
+
+type releaseSnapshotRepository struct {
+	db *sql.DB
+}
+
+func NewReleaseSnapshotRepository(db *sql.DB) ReleaseSnapshotRepository {
+	return &releaseSnapshotRepository{db: db}
+}
+
+func (r *releaseSnapshotRepository) CreateReleaseSnapshot(ctx context.Context, appID uuid.UUID, dataEncrypted []byte) (*domain.ReleaseSnapshot, error) {
+	query := `
+		INSERT INTO release_snapshots (app_id, data_encrypted)
+		VALUES ($1, $2)
+		RETURNING id, app_id, data_encrypted, created_at
+	`
+
+	var snapshot domain.ReleaseSnapshot
+	err := r.db.QueryRowContext(ctx, query, appID, dataEncrypted).Scan(
+		&snapshot.ID,
+		&snapshot.AppID,
+		&snapshot.DataEncrypted,
+		&snapshot.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (r *releaseSnapshotRepository) GetReleaseSnapshotByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseSnapshot, error) {
+	query := `
+		SELECT id, app_id, data_encrypted, created_at
+		FROM release_snapshots
+		WHERE id = $1
+	`
+
+	var snapshot domain.ReleaseSnapshot
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&snapshot.ID,
+		&snapshot.AppID,
+		&snapshot.DataEncrypted,
+		&snapshot.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
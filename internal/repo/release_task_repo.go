@@ -0,0 +1,180 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type ReleaseTaskRepository interface {
+	CreateReleaseTask(ctx context.Context, task *domain.ReleaseTask) (*domain.ReleaseTask, error)
+	GetReleaseTaskByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseTask, error)
+	GetReleaseTasksByReleaseID(ctx context.Context, releaseID uuid.UUID) ([]domain.ReleaseTask, error)
+	StartReleaseTask(ctx context.Context, id uuid.UUID, jobName string) (*domain.ReleaseTask, error)
+	UpdateReleaseTaskLogs(ctx context.Context, id uuid.UUID, logs string) error
+	FinishReleaseTask(ctx context.Context, id uuid.UUID, status domain.ReleaseTaskStatus, exitCode *int32, failureReason, logs string) (*domain.ReleaseTask, error)
+}
+
+type releaseTaskRepository struct {
+	db *sql.DB
+}
+
+func NewReleaseTaskRepository(db *sql.DB) ReleaseTaskRepository {
+	return &releaseTaskRepository{db: db}
+}
+
+func (r *releaseTaskRepository) CreateReleaseTask(ctx context.Context, task *domain.ReleaseTask) (*domain.ReleaseTask, error) {
+	query := `
+		INSERT INTO release_tasks (app_id, release_id, kind, command, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, app_id, release_id, kind, command, status, job_name, exit_code, failure_reason, logs, created_by, started_at, finished_at, created_at, updated_at
+	`
+
+	commandJSON, err := json.Marshal(task.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReleaseTask(r.db.QueryRowContext(ctx, query,
+		task.AppID,
+		task.ReleaseID,
+		task.Kind,
+		commandJSON,
+		task.Status,
+		task.CreatedBy,
+	))
+}
+
+func (r *releaseTaskRepository) GetReleaseTaskByID(ctx context.Context, id uuid.UUID) (*domain.ReleaseTask, error) {
+	query := `
+		SELECT id, app_id, release_id, kind, command, status, job_name, exit_code, failure_reason, logs, created_by, started_at, finished_at, created_at, updated_at
+		FROM release_tasks
+		WHERE id = $1
+	`
+
+	task, err := scanReleaseTask(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// GetReleaseTasksByReleaseID returns the tasks run with a release, oldest first
+func (r *releaseTaskRepository) GetReleaseTasksByReleaseID(ctx context.Context, releaseID uuid.UUID) ([]domain.ReleaseTask, error) {
+	query := `
+		SELECT id, app_id, release_id, kind, command, status, job_name, exit_code, failure_reason, logs, created_by, started_at, finished_at, created_at, updated_at
+		FROM release_tasks
+		WHERE release_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []domain.ReleaseTask
+	for rows.Next() {
+		task, err := scanReleaseTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// StartReleaseTask marks a task running in the Job of the given name
+func (r *releaseTaskRepository) StartReleaseTask(ctx context.Context, id uuid.UUID, jobName string) (*domain.ReleaseTask, error) {
+	query := `
+		UPDATE release_tasks
+		SET status = 'running', job_name = $2, started_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, app_id, release_id, kind, command, status, job_name, exit_code, failure_reason, logs, created_by, started_at, finished_at, created_at, updated_at
+	`
+
+	task, err := scanReleaseTask(r.db.QueryRowContext(ctx, query, id, jobName))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// UpdateReleaseTaskLogs replaces the output captured from a running task
+func (r *releaseTaskRepository) UpdateReleaseTaskLogs(ctx context.Context, id uuid.UUID, logs string) error {
+	query := `UPDATE release_tasks SET logs = $2, updated_at = NOW() WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, logs)
+	return err
+}
+
+// FinishReleaseTask records the outcome and final output of a task
+func (r *releaseTaskRepository) FinishReleaseTask(ctx context.Context, id uuid.UUID, status domain.ReleaseTaskStatus, exitCode *int32, failureReason, logs string) (*domain.ReleaseTask, error) {
+	query := `
+		UPDATE release_tasks
+		SET status = $2, exit_code = $3, failure_reason = $4, logs = $5, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, app_id, release_id, kind, command, status, job_name, exit_code, failure_reason, logs, created_by, started_at, finished_at, created_at, updated_at
+	`
+
+	task, err := scanReleaseTask(r.db.QueryRowContext(ctx, query, id, status, exitCode, failureReason, logs))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// scanReleaseTask scans a release task row, decoding its JSONB command
+func scanReleaseTask(row rowScanner) (*domain.ReleaseTask, error) {
+	var task domain.ReleaseTask
+	var commandJSON []byte
+	var exitCode sql.NullInt32
+
+	err := row.Scan(
+		&task.ID,
+		&task.AppID,
+		&task.ReleaseID,
+		&task.Kind,
+		&commandJSON,
+		&task.Status,
+		&task.JobName,
+		&exitCode,
+		&task.FailureReason,
+		&task.Logs,
+		&task.CreatedBy,
+		&task.StartedAt,
+		&task.FinishedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if exitCode.Valid {
+		task.ExitCode = &exitCode.Int32
+	}
+	if err := json.Unmarshal(commandJSON, &task.Command); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
@@ -14,6 +14,7 @@ type RepositoryRepository interface {
 	GetRepositoryByID(ctx context.Context, id uuid.UUID) (*domain.Repository, error)
 	GetRepositoriesByOrgID(ctx context.Context, orgID uuid.UUID) ([]domain.RepositorySummary, error)
 	GetRepositoryByURL(ctx context.Context, orgID uuid.UUID, url string) (*domain.Repository, error)
+	GetRepositoriesByNormalizedURL(ctx context.Context, normalizedURL string) ([]domain.Repository, error)
 	UpdateRepositoryConfig(ctx context.Context, id uuid.UUID, config []byte) (*domain.Repository, error)
 	DeleteRepository(ctx context.Context, id uuid.UUID) error
 }
@@ -28,8 +29,8 @@ func NewRepositoryRepository(db *sql.DB) RepositoryRepository {
 
 func (r *repositoryRepository) CreateRepository(ctx context.Context, repo *domain.Repository) (*domain.Repository, error) {
 	query := `
-		INSERT INTO repositories (org_id, type, url, default_branch, config)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO repositories (org_id, type, url, default_branch, config, normalized_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, org_id, type, url, default_branch, config, created_at, updated_at
 	`
 
@@ -40,6 +41,7 @@ func (r *repositoryRepository) CreateRepository(ctx context.Context, repo *domai
 		repo.URL,
 		repo.DefaultBranch,
 		repo.Config,
+		domain.NormalizeRepositoryURL(repo.URL),
 	).Scan(
 		&createdRepo.ID,
 		&createdRepo.OrgID,
@@ -154,6 +156,44 @@ func (r *repositoryRepository) GetRepositoryByURL(ctx context.Context, orgID uui
 	return &repo, nil
 }
 
+func (r *repositoryRepository) GetRepositoriesByNormalizedURL(ctx context.Context, normalizedURL string) ([]domain.Repository, error) {
+	query := `
+		SELECT id, org_id, type, url, default_branch, config, created_at, updated_at
+		FROM repositories
+		WHERE normalized_url = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, normalizedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []domain.Repository
+	for rows.Next() {
+		var repo domain.Repository
+
+		err := rows.Scan(
+			&repo.ID,
+			&repo.OrgID,
+			&repo.Type,
+			&repo.URL,
+			&repo.DefaultBranch,
+			&repo.Config,
+			&repo.CreatedAt,
+			&repo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		repos = append(repos, repo)
+	}
+
+	return repos, nil
+}
+
 func (r *repositoryRepository) UpdateRepositoryConfig(ctx context.Context, id uuid.UUID, config []byte) (*domain.Repository, error) {
 	query := `
 		UPDATE repositories
@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/PouryDev/oneclick/internal/domain"
+)
+
+type WebhookDeliveryRepository interface {
+	CreateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error)
+	GetWebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+	GetWebhookDeliveriesByRepoID(ctx context.Context, repoID uuid.UUID, limit, offset int) ([]domain.WebhookDeliverySummary, error)
+}
+
+type webhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookDeliveryRepository(db *sql.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+func (r *webhookDeliveryRepository) CreateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries (repo_id, provider, event, delivery_id, headers, payload, verified, status, message, pipeline_ids, redelivery_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, repo_id, provider, event, delivery_id, headers, payload, verified, status, message, pipeline_ids, redelivery_of, created_at
+	`
+
+	headers, err := json.Marshal(delivery.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineIDs := delivery.PipelineIDs
+	if pipelineIDs == nil {
+		pipelineIDs = []uuid.UUID{}
+	}
+	pipelineIDsJSON, err := json.Marshal(pipelineIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRowContext(ctx, query,
+		delivery.RepoID,
+		delivery.Provider,
+		delivery.Event,
+		delivery.DeliveryID,
+		headers,
+		delivery.Payload,
+		delivery.Verified,
+		delivery.Status,
+		delivery.Message,
+		pipelineIDsJSON,
+		delivery.RedeliveryOf,
+	)
+
+	return scanWebhookDelivery(row)
+}
+
+func (r *webhookDeliveryRepository) GetWebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	query := `
+		SELECT id, repo_id, provider, event, delivery_id, headers, payload, verified, status, message, pipeline_ids, redelivery_of, created_at
+		FROM webhook_deliveries
+		WHERE id = $1
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookDeliveryRepository) GetWebhookDeliveriesByRepoID(ctx context.Context, repoID uuid.UUID, limit, offset int) ([]domain.WebhookDeliverySummary, error) {
+	query := `
+		SELECT id, provider, event, delivery_id, verified, status, message, pipeline_ids, redelivery_of, created_at
+		FROM webhook_deliveries
+		WHERE repo_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, repoID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDeliverySummary
+	for rows.Next() {
+		var delivery domain.WebhookDeliverySummary
+		var pipelineIDs []byte
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.Provider,
+			&delivery.Event,
+			&delivery.DeliveryID,
+			&delivery.Verified,
+			&delivery.Status,
+			&delivery.Message,
+			&pipelineIDs,
+			&delivery.RedeliveryOf,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(pipelineIDs, &delivery.PipelineIDs); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhookDelivery scans a full webhook delivery row, decoding its JSONB columns
+func scanWebhookDelivery(row *sql.Row) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var headers, pipelineIDs []byte
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.RepoID,
+		&delivery.Provider,
+		&delivery.Event,
+		&delivery.DeliveryID,
+		&headers,
+		&delivery.Payload,
+		&delivery.Verified,
+		&delivery.Status,
+		&delivery.Message,
+		&pipelineIDs,
+		&delivery.RedeliveryOf,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(headers, &delivery.Headers); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(pipelineIDs, &delivery.PipelineIDs); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,5 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_repositories_normalized_url;
+
+-- Drop column
+ALTER TABLE repositories DROP COLUMN IF EXISTS normalized_url;
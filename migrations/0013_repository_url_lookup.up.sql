@@ -0,0 +1,26 @@
+-- Migration: 0013_repository_url_lookup.up.sql
+-- Description: Add normalized repository URL used to route inbound Git webhooks
+
+ALTER TABLE repositories ADD COLUMN normalized_url TEXT;
+
+-- Backfill existing rows using the same rules as domain.NormalizeRepositoryURL:
+-- drop scheme, credentials and port, turn SCP-style "host:path" into "host/path",
+-- and strip a trailing ".git" or slash.
+UPDATE repositories
+SET normalized_url = regexp_replace(
+    regexp_replace(
+        regexp_replace(
+            regexp_replace(
+                regexp_replace(lower(url), '^[a-z][a-z0-9+.-]*://', ''),
+                '^[^@/]*@', ''
+            ),
+            '^([^/:]+):[0-9]+/', '\1/'
+        ),
+        '^([^/:]+):', '\1/'
+    ),
+    '(\.git)?/*$', ''
+);
+
+ALTER TABLE repositories ALTER COLUMN normalized_url SET NOT NULL;
+
+CREATE INDEX idx_repositories_normalized_url ON repositories (normalized_url);
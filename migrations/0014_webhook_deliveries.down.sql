@@ -0,0 +1,4 @@
+-- Migration: 0014_webhook_deliveries.down.sql
+-- Description: Drop webhook delivery log
+
+DROP TABLE IF EXISTS webhook_deliveries;
@@ -0,0 +1,22 @@
+-- Migration: 0014_webhook_deliveries.up.sql
+-- Description: Record inbound Git webhook deliveries for inspection and replay
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    repo_id UUID REFERENCES repositories(id) ON DELETE CASCADE, -- NULL when no repository matched
+    provider TEXT NOT NULL,
+    event TEXT NOT NULL DEFAULT '', -- X-GitHub-Event / X-Gitlab-Event / X-Gitea-Event
+    delivery_id TEXT NOT NULL DEFAULT '', -- Provider-assigned delivery identifier
+    headers JSONB NOT NULL DEFAULT '{}',
+    payload BYTEA NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL CHECK (status IN ('processed', 'ignored', 'rejected', 'failed')),
+    message TEXT NOT NULL DEFAULT '', -- Why the delivery was ignored, rejected or failed
+    pipeline_ids JSONB NOT NULL DEFAULT '[]',
+    redelivery_of UUID REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_deliveries_repo_id ON webhook_deliveries (repo_id, created_at DESC);
+
+CREATE INDEX idx_webhook_deliveries_delivery_id ON webhook_deliveries (delivery_id);
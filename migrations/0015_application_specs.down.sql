@@ -0,0 +1,4 @@
+-- Migration: 0015_application_specs.down.sql
+-- Description: Drop versioned application deployment specs
+
+DROP TABLE IF EXISTS application_specs;
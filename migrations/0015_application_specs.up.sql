@@ -0,0 +1,14 @@
+-- Migration: 0015_application_specs.up.sql
+-- Description: Versioned per-application deployment specs (replicas, ports, probes, resources)
+
+CREATE TABLE application_specs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version > 0),
+    spec JSONB NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (app_id, version)
+);
+
+CREATE INDEX idx_application_specs_app_id ON application_specs (app_id, version DESC);
@@ -0,0 +1,6 @@
+-- Migration: 0016_app_secrets.down.sql
+-- Description: Drop per-application secrets
+
+DROP TRIGGER IF EXISTS update_app_secrets_updated_at ON app_secrets;
+
+DROP TABLE IF EXISTS app_secrets;
@@ -0,0 +1,19 @@
+-- Migration: 0016_app_secrets.up.sql
+-- Description: Encrypted per-application secret environment variables
+
+CREATE TABLE app_secrets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value_encrypted BYTEA NOT NULL, -- AES-GCM with the master key
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (app_id, name)
+);
+
+CREATE INDEX idx_app_secrets_app_id ON app_secrets (app_id);
+
+CREATE TRIGGER update_app_secrets_updated_at
+    BEFORE UPDATE ON app_secrets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
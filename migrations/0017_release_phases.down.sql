@@ -0,0 +1,6 @@
+-- Migration: 0017_release_phases.down.sql
+-- Description: Drop release phases
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_phase;
+
+ALTER TABLE releases DROP COLUMN IF EXISTS phase;
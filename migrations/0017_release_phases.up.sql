@@ -0,0 +1,30 @@
+-- Migration: 0017_release_phases.up.sql
+-- Description: Track the deployment strategy phase of each release
+
+ALTER TABLE releases
+ADD COLUMN phase TEXT NOT NULL DEFAULT 'pending';
+
+-- Backfill existing releases from their status
+UPDATE releases
+SET phase = CASE status
+    WHEN 'running' THEN 'rolling_out'
+    WHEN 'succeeded' THEN 'completed'
+    WHEN 'failed' THEN 'failed'
+    ELSE 'pending'
+END;
+
+-- Add constraint for valid release phases
+ALTER TABLE releases
+ADD CONSTRAINT check_release_phase CHECK (
+    phase IN (
+        'pending',
+        'rolling_out',
+        'switching',
+        'canary',
+        'promoting',
+        'aborting',
+        'completed',
+        'aborted',
+        'failed'
+    )
+);
@@ -0,0 +1,16 @@
+-- Migration: 0018_release_rolled_back.down.sql
+-- Description: Disallow the rolled_back release status
+
+UPDATE releases SET status = 'failed' WHERE status = 'rolled_back';
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_status;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_status CHECK (
+    status IN (
+        'pending',
+        'running',
+        'succeeded',
+        'failed'
+    )
+);
@@ -0,0 +1,15 @@
+-- Migration: 0018_release_rolled_back.up.sql
+-- Description: Allow releases whose failed rollout was automatically rolled back
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_status;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_status CHECK (
+    status IN (
+        'pending',
+        'running',
+        'succeeded',
+        'failed',
+        'rolled_back'
+    )
+);
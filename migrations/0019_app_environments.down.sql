@@ -0,0 +1,12 @@
+-- Migration: 0019_app_environments.down.sql
+-- Description: Drop application environments along with their releases and domains
+
+DELETE FROM releases WHERE environment_id IS NOT NULL;
+
+DELETE FROM domains WHERE environment_id IS NOT NULL;
+
+ALTER TABLE domains DROP COLUMN IF EXISTS environment_id;
+
+ALTER TABLE releases DROP COLUMN IF EXISTS environment_id;
+
+DROP TABLE IF EXISTS app_environments;
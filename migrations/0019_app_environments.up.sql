@@ -0,0 +1,34 @@
+-- Migration: 0019_app_environments.up.sql
+-- Description: Per-application environments (e.g. dev, staging, production) that releases are deployed to and promoted through
+
+CREATE TABLE app_environments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    namespace TEXT NOT NULL,
+    position INTEGER NOT NULL CHECK (position >= 0), -- Order in the promotion chain, lowest first
+    replicas INTEGER CHECK (replicas >= 0), -- Overrides the deployment spec's replicas when set
+    env_vars JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (app_id, name),
+    UNIQUE (app_id, position),
+    UNIQUE (cluster_id, namespace)
+);
+
+CREATE INDEX idx_app_environments_app_id ON app_environments (app_id, position);
+
+CREATE TRIGGER update_app_environments_updated_at
+    BEFORE UPDATE ON app_environments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Releases and domains without an environment belong to the application's own cluster and namespace
+ALTER TABLE releases
+ADD COLUMN environment_id UUID REFERENCES app_environments(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_releases_environment_id ON releases (environment_id, created_at DESC);
+
+ALTER TABLE domains
+ADD COLUMN environment_id UUID REFERENCES app_environments(id) ON DELETE CASCADE;
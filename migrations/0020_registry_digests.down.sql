@@ -0,0 +1,6 @@
+-- Migration: 0020_registry_digests.down.sql
+-- Description: Drop registry credentials and release image digests
+
+DROP TABLE IF EXISTS registry_credentials;
+
+ALTER TABLE releases DROP COLUMN IF EXISTS image_digest;
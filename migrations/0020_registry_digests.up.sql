@@ -0,0 +1,23 @@
+-- Migration: 0020_registry_digests.up.sql
+-- Description: Pin releases to the image digest their tag resolved to, with per-organization registry credentials for the lookup
+
+ALTER TABLE releases
+ADD COLUMN image_digest TEXT NOT NULL DEFAULT ''; -- e.g. sha256:..., resolved from the tag when the release is first rolled out
+
+CREATE TABLE registry_credentials (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    registry TEXT NOT NULL, -- Registry host, with port if any, e.g. ghcr.io or registry.example.com:5000
+    username TEXT NOT NULL,
+    password_encrypted BYTEA NOT NULL, -- AES-GCM with the master key
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (org_id, registry)
+);
+
+CREATE INDEX idx_registry_credentials_org_id ON registry_credentials (org_id);
+
+CREATE TRIGGER update_registry_credentials_updated_at
+    BEFORE UPDATE ON registry_credentials
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+-- Migration: 0021_registry_credential_providers.down.sql
+-- Description: Drop the provider of registry credentials
+
+ALTER TABLE registry_credentials DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,10 @@
+-- Migration: 0021_registry_credential_providers.up.sql
+-- Description: Record the kind of registry credentials are for, rendered into image pull Secrets
+
+ALTER TABLE registry_credentials
+ADD COLUMN provider TEXT NOT NULL DEFAULT 'generic'
+    CHECK (provider IN ('dockerhub', 'ghcr', 'gitlab', 'generic'));
+
+UPDATE registry_credentials SET provider = 'dockerhub' WHERE registry = 'docker.io';
+UPDATE registry_credentials SET provider = 'ghcr' WHERE registry = 'ghcr.io';
+UPDATE registry_credentials SET provider = 'gitlab' WHERE registry = 'registry.gitlab.com';
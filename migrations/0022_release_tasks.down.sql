@@ -0,0 +1,23 @@
+-- Migration: 0022_release_tasks.down.sql
+-- Description: Drop release tasks and their release phases
+
+DROP TABLE IF EXISTS release_tasks;
+
+UPDATE releases SET phase = 'rolling_out' WHERE phase IN ('pre_deploy', 'post_deploy');
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_phase;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_phase CHECK (
+    phase IN (
+        'pending',
+        'rolling_out',
+        'switching',
+        'canary',
+        'promoting',
+        'aborting',
+        'completed',
+        'aborted',
+        'failed'
+    )
+);
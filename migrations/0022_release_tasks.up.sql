@@ -0,0 +1,48 @@
+-- Migration: 0022_release_tasks.up.sql
+-- Description: One-off commands run as Kubernetes Jobs with a release's image: pre- and post-deploy tasks, and ad-hoc runs
+
+CREATE TABLE release_tasks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    release_id UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE, -- Release whose image and environment the command runs with
+    kind TEXT NOT NULL CHECK (kind IN ('pre_deploy', 'post_deploy', 'run')),
+    command JSONB NOT NULL, -- Command and arguments
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    job_name TEXT NOT NULL DEFAULT '',
+    exit_code INTEGER,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    logs TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_release_tasks_release_id ON release_tasks (release_id, created_at);
+CREATE INDEX idx_release_tasks_app_id ON release_tasks (app_id, created_at DESC);
+
+CREATE TRIGGER update_release_tasks_updated_at
+    BEFORE UPDATE ON release_tasks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Releases report the release task they are running
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_phase;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_phase CHECK (
+    phase IN (
+        'pending',
+        'pre_deploy',
+        'rolling_out',
+        'switching',
+        'canary',
+        'promoting',
+        'aborting',
+        'post_deploy',
+        'completed',
+        'aborted',
+        'failed'
+    )
+);
@@ -0,0 +1,4 @@
+-- Migration: 0023_job_progress.down.sql
+-- Description: Drop job progress
+
+ALTER TABLE job_queue DROP COLUMN IF EXISTS progress;
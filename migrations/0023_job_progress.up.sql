@@ -0,0 +1,4 @@
+-- Migration: 0023_job_progress.up.sql
+-- Description: Step-by-step progress of jobs that report it, such as application deletion
+
+ALTER TABLE job_queue ADD COLUMN progress JSONB NOT NULL DEFAULT '[]';
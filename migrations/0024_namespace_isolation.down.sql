@@ -0,0 +1,10 @@
+-- Migration: 0024_namespace_isolation.down.sql
+-- Description: Drop namespace policies and application namespaces
+
+DROP TRIGGER IF EXISTS update_namespace_policies_updated_at ON namespace_policies;
+
+DROP TABLE IF EXISTS namespace_policies;
+
+DROP INDEX IF EXISTS idx_applications_cluster_namespace;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS namespace;
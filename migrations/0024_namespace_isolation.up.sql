@@ -0,0 +1,23 @@
+-- Migration: 0024_namespace_isolation.up.sql
+-- Description: Per-application namespaces encoding the organization, and per-organization namespace policies
+
+-- Applications deployed so far keep the namespace named after them
+ALTER TABLE applications ADD COLUMN namespace TEXT;
+UPDATE applications SET namespace = name;
+ALTER TABLE applications ALTER COLUMN namespace SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_applications_cluster_namespace ON applications (cluster_id, namespace);
+
+CREATE TABLE namespace_policies (
+    org_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    resource_quota JSONB NOT NULL DEFAULT '{}', -- Hard limits by resource name
+    limit_range JSONB, -- Container defaults and bounds, NULL for none
+    ingress_namespaces JSONB NOT NULL DEFAULT '["ingress-nginx"]',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TRIGGER update_namespace_policies_updated_at
+    BEFORE UPDATE ON namespace_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
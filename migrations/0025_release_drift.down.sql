@@ -0,0 +1,4 @@
+-- Migration: 0025_release_drift.down.sql
+-- Description: Drop release drift
+
+DROP TABLE IF EXISTS release_drift;
@@ -0,0 +1,16 @@
+-- Migration: 0025_release_drift.up.sql
+-- Description: Drift between what a release deployed and the live objects in its cluster, recorded by the deployment worker's reconciler
+
+CREATE TABLE release_drift (
+    release_id UUID PRIMARY KEY REFERENCES releases(id) ON DELETE CASCADE,
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('in_sync', 'drifted', 'unknown')),
+    objects JSONB NOT NULL DEFAULT '[]', -- Objects that differ from the release, with their differing fields
+    error TEXT NOT NULL DEFAULT '', -- Why the last check failed when the status is unknown
+    drifted_since TIMESTAMPTZ, -- When the drift was first detected; NULL while in sync
+    healed_objects JSONB NOT NULL DEFAULT '[]', -- Objects whose drift was last reverted
+    healed_at TIMESTAMPTZ, -- When drift was last reverted by re-applying the release
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_release_drift_app_id ON release_drift (app_id);
@@ -0,0 +1,23 @@
+-- Migration: 0026_gitops_export.down.sql
+-- Description: Drop the release phase of GitOps commits
+
+UPDATE releases SET phase = 'completed' WHERE phase = 'publishing';
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_phase;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_phase CHECK (
+    phase IN (
+        'pending',
+        'pre_deploy',
+        'rolling_out',
+        'switching',
+        'canary',
+        'promoting',
+        'aborting',
+        'post_deploy',
+        'completed',
+        'aborted',
+        'failed'
+    )
+);
@@ -0,0 +1,22 @@
+-- Migration: 0026_gitops_export.up.sql
+-- Description: Releases report when their manifests are being committed to a GitOps repository
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS check_release_phase;
+
+ALTER TABLE releases
+ADD CONSTRAINT check_release_phase CHECK (
+    phase IN (
+        'pending',
+        'pre_deploy',
+        'rolling_out',
+        'switching',
+        'canary',
+        'promoting',
+        'aborting',
+        'post_deploy',
+        'publishing',
+        'completed',
+        'aborted',
+        'failed'
+    )
+);
@@ -0,0 +1,4 @@
+-- Migration: 0027_release_snapshots.down.sql
+-- Description: Drop release snapshots
+
+DROP TABLE IF EXISTS release_snapshots;
@@ -0,0 +1,11 @@
+-- Migration: 0027_release_snapshots.up.sql
+-- Description: Encrypted snapshots of the secrets, registry credentials, domains and namespace isolation releases were rolled out with
+
+CREATE TABLE release_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    data_encrypted BYTEA NOT NULL, -- JSON snapshot, AES-GCM with the master key
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_release_snapshots_app_id ON release_snapshots (app_id);